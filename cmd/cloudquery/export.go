@@ -0,0 +1,86 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Uptycs/cloudquery/extension"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// runExport writes the rows of the registered tables to files without osquery, and returns the exit code
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export [flags]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	homeDir := flags.String("home", getHomeDirectory(), "Home directory holding the configurations")
+	configFile := flags.String("config", "", "Path to the extension configuration, defaults to config/extension_config.json under the home directory")
+	output := flags.String("output", "", "Local directory, or s3://bucket/prefix, gs://bucket/prefix or azblob://account/container/prefix URL to write the files to")
+	format := flags.String("format", extension.ExportFormatNDJSON, "Output format: ndjson, csv or parquet")
+	tables := flags.String("tables", "", "Comma separated tables to export, defaults to all the configured tables")
+	concurrency := flags.Int("concurrency", 4, "Number of tables exported at the same time")
+	verbose := flags.Bool("verbose", false, "Log debug messages")
+	flags.Parse(args)
+	if *output == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	logger := utilities.CreateLogger(*verbose, 0, 0, 0)
+	logger.SetOutput(os.Stderr)
+	if !*verbose {
+		logger.SetLevel(log.WarnLevel)
+	}
+
+	extConfigFile := *configFile
+	if extConfigFile == "" {
+		extConfigFile = getExtConfigFile(*homeDir)
+	}
+	if err := extension.LoadExtensionConfiguration(extConfigFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	extension.ReadTableConfigurations(*homeDir)
+	extension.DiscoverAccounts(context.Background())
+
+	options := extension.ExportOptions{
+		Destination: *output,
+		Format:      *format,
+		Concurrency: *concurrency,
+	}
+	for _, tableName := range strings.Split(*tables, ",") {
+		if tableName = strings.TrimSpace(tableName); tableName != "" {
+			options.Tables = append(options.Tables, tableName)
+		}
+	}
+	manifest, err := extension.ExportTables(context.Background(), options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, result := range manifest.Tables {
+		if result.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", result.Table, result.Error)
+		}
+	}
+	fmt.Printf("exported %d table(s) to %s, %d failed\n", len(manifest.Tables), *output, manifest.ErrorCount())
+	if manifest.ErrorCount() > 0 {
+		return 1
+	}
+	return 0
+}
@@ -30,18 +30,40 @@ var (
 	verbose  = flag.Bool("verbose", false, "Enable verbose logging")
 	timeout  = flag.Int("timeout", 10, "Seconds to wait for autoloaded extensions")
 	interval = flag.Int("interval", 10, "Seconds delay between connectivity checks")
+	reload   = flag.Int("reload_interval", 30, "Seconds delay between checks for configuration file changes, 0 to only reload on SIGHUP")
+	metrics  = flag.String("metrics_address", "", "Address to serve Prometheus metrics on, like :9100. Metrics are not served if empty")
 )
 
+func getHomeDirectory() string {
+	homeDirectory := os.Getenv("CLOUDQUERY_EXT_HOME")
+	if homeDirectory == "" {
+		homeDirectory = "/opt/cloudquery"
+	}
+	return homeDirectory
+}
+
+func getExtConfigFile(homeDirectory string) string {
+	return homeDirectory + string(os.PathSeparator) + "config" + string(os.PathSeparator) + "extension_config.json"
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "query":
+			os.Exit(runQuery(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 	if *socket == "" {
 		log.Fatalln("Missing required --socket argument")
 	}
 
-	homeDirectory := os.Getenv("CLOUDQUERY_EXT_HOME")
-	if homeDirectory == "" {
-		homeDirectory = "/opt/cloudquery"
-	}
+	homeDirectory := getHomeDirectory()
 
 	server, err := osquery.NewExtensionManagerServer(
 		"cloudquery_extension",
@@ -55,13 +77,20 @@ func main() {
 		log.Fatalf("Error creating extension: %s\n", err)
 	}
 
-	extension.ReadExtensionConfigurations(homeDirectory+string(os.PathSeparator)+"config"+string(os.PathSeparator)+"extension_config.json", *verbose)
+	extConfigFile := getExtConfigFile(homeDirectory)
+	extension.ReadExtensionConfigurations(extConfigFile, *verbose)
 	extension.ReadTableConfigurations(homeDirectory)
+	if *metrics != "" {
+		if err := extension.StartMetrics(*metrics); err != nil {
+			log.Fatalf("Error starting metrics server: %s\n", err)
+		}
+	}
 	extension.RegisterPlugins(server)
 
 	// Set up cancellation context and waitgroup
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
+	extension.SetShutdownContext(ctx)
 
 	// Wait for interrupt signal to gracefully shutdown the server with waitgroup
 	quit := make(chan os.Signal, 1)
@@ -69,6 +98,9 @@ func main() {
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// Reload configuration on SIGHUP, registered before the server starts so that an early SIGHUP does not kill the process
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
 
 	// Start server
 	go func() {
@@ -78,6 +110,14 @@ func main() {
 		syscall.Kill(syscall.Getpid(), syscall.SIGINT)
 	}()
 
+	// Reload configuration on SIGHUP and file changes
+	wg.Add(1)
+	go extension.WatchConfigurations(ctx, wg, hangup, homeDirectory, extConfigFile, time.Second*time.Duration(*reload))
+
+	// Discover the accounts of the organizations
+	wg.Add(1)
+	go extension.WatchAccounts(ctx, wg)
+
 	// Start event tables
 	for _, eventTable := range extension.GetEventTables() {
 		go eventTable.Start(ctx, wg, *socket, time.Second*time.Duration(*timeout))
@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Uptycs/cloudquery/extension"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// constraintFlags collects the column=value constraints given with --where
+type constraintFlags map[string][]string
+
+func (constraints constraintFlags) String() string {
+	values := make([]string, 0, len(constraints))
+	for column, columnValues := range constraints {
+		for _, value := range columnValues {
+			values = append(values, column+"="+value)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func (constraints constraintFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("expected column=value, got %s", value)
+	}
+	constraints[parts[0]] = append(constraints[parts[0]], parts[1])
+	return nil
+}
+
+// runQuery prints the rows of a table without osquery, and returns the exit code
+func runQuery(args []string) int {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s query [flags] <table>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	homeDir := flags.String("home", getHomeDirectory(), "Home directory holding the configurations")
+	configFile := flags.String("config", "", "Path to the extension configuration, defaults to config/extension_config.json under the home directory")
+	columns := flags.String("columns", "", "Comma separated columns to print, defaults to all the columns which are not hidden")
+	format := flags.String("format", extension.QueryFormatTable, "Output format: table, json or csv")
+	verbose := flags.Bool("verbose", false, "Log debug messages")
+	constraints := constraintFlags{}
+	flags.Var(constraints, "where", "Only return rows where column=value, repeat for more columns or values")
+
+	// Flags are accepted before and after the table name
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	tableName := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// Rows are printed to stdout, logs go to stderr
+	logger := utilities.CreateLogger(*verbose, 0, 0, 0)
+	logger.SetOutput(os.Stderr)
+	if !*verbose {
+		logger.SetLevel(log.WarnLevel)
+	}
+
+	extConfigFile := *configFile
+	if extConfigFile == "" {
+		extConfigFile = getExtConfigFile(*homeDir)
+	}
+	if err := extension.LoadExtensionConfiguration(extConfigFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	extension.ReadTableConfigurations(*homeDir)
+	extension.DiscoverAccounts(context.Background())
+
+	var selectedColumns []string
+	for _, column := range strings.Split(*columns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			selectedColumns = append(selectedColumns, column)
+		}
+	}
+	err := extension.QueryTable(context.Background(), os.Stdout, tableName, selectedColumns, constraints, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/Uptycs/cloudquery/extension"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// runValidate checks the configurations, prints the issues found and returns the exit code:
+// 0 if no error was found, 1 otherwise
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	homeDir := flags.String("home", getHomeDirectory(), "Home directory holding the configurations")
+	configFile := flags.String("config", "", "Path to the extension configuration, defaults to config/extension_config.json under the home directory")
+	verbose := flags.Bool("verbose", false, "Log the configuration files read")
+	flags.Parse(args)
+
+	// Only the report is printed, unless verbose
+	logger := utilities.CreateLogger(*verbose, 0, 0, 0)
+	if !*verbose {
+		logger.SetLevel(log.PanicLevel)
+	}
+
+	extConfigFile := *configFile
+	if extConfigFile == "" {
+		extConfigFile = getExtConfigFile(*homeDir)
+	}
+	report := extension.ValidateConfigurations(*homeDir, extConfigFile)
+	report.Print(os.Stdout)
+	if report.ErrorCount() > 0 {
+		return 1
+	}
+	return 0
+}
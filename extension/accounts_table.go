@@ -0,0 +1,193 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	extaws "github.com/Uptycs/cloudquery/extension/aws"
+	"github.com/Uptycs/cloudquery/extension/azure"
+	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+const (
+	accountsTableName   = "cloudquery_accounts"
+	accountCheckTimeout = 30 * time.Second
+)
+
+// accountCheck resolves the identity of a configured account
+type accountCheck struct {
+	provider string
+	account  string
+	check    func(ctx context.Context) (utilities.AccountIdentity, error)
+}
+
+func getAccountsColumns() []table.ColumnDefinition {
+	return []table.ColumnDefinition{
+		table.TextColumn("provider"),
+		table.TextColumn("account"),
+		table.TextColumn("identity"),
+		table.TextColumn("credential_source"),
+		table.BigIntColumn("credential_expiry"),
+		table.TextColumn("status"),
+		table.TextColumn("error"),
+		table.TextColumn("table_name"),
+		table.BigIntColumn("last_success"),
+		table.BigIntColumn("last_failure"),
+		table.TextColumn("last_error"),
+	}
+}
+
+// getAccountChecks returns the checks of the configured and discovered accounts, or of the default account of each provider
+// which has default credentials and no configured account
+func getAccountChecks() []accountCheck {
+	checks := make([]accountCheck, 0)
+
+	awsAccounts := utilities.GetAwsAccounts()
+	for idx := range awsAccounts {
+		account := &awsAccounts[idx]
+		checks = append(checks, accountCheck{provider: utilities.ProviderAws, account: account.ID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return extaws.GetAccountIdentity(ctx, account)
+		}})
+	}
+	if len(awsAccounts) == 0 && utilities.AwsAccountID != "" {
+		checks = append(checks, accountCheck{provider: utilities.ProviderAws, account: utilities.AwsAccountID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return extaws.GetAccountIdentity(ctx, nil)
+		}})
+	}
+
+	gcpAccounts := utilities.GetGcpAccounts()
+	for idx := range gcpAccounts {
+		account := &gcpAccounts[idx]
+		checks = append(checks, accountCheck{provider: utilities.ProviderGcp, account: account.ProjectID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return extgcp.GetAccountIdentity(ctx, account)
+		}})
+	}
+	if len(gcpAccounts) == 0 && utilities.DefaultGcpProjectID != "" {
+		checks = append(checks, accountCheck{provider: utilities.ProviderGcp, account: utilities.DefaultGcpProjectID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return extgcp.GetAccountIdentity(ctx, nil)
+		}})
+	}
+
+	azureAccounts := utilities.GetAzureAccounts()
+	for idx := range azureAccounts {
+		account := &azureAccounts[idx]
+		checks = append(checks, accountCheck{provider: utilities.ProviderAzure, account: account.SubscriptionID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return azure.GetAccountIdentity(ctx, account)
+		}})
+	}
+	if len(azureAccounts) == 0 && os.Getenv("AZURE_AUTH_LOCATION") != "" {
+		// The subscription of the default account is read from its auth file
+		subscriptionID := ""
+		if session, err := azure.GetAuthSession(nil); err == nil {
+			subscriptionID = session.SubscriptionId
+		}
+		checks = append(checks, accountCheck{provider: utilities.ProviderAzure, account: subscriptionID, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+			return azure.GetAccountIdentity(ctx, nil)
+		}})
+	}
+	return checks
+}
+
+// getAccountCheckConcurrency returns the number of accounts of given provider checked in parallel,
+// as many as the accounts processed in parallel by the tables of the provider
+func getAccountCheckConcurrency(provider string) int {
+	switch provider {
+	case utilities.ProviderAws:
+		return extaws.GetMaxConcurrency()
+	case utilities.ProviderAzure:
+		return azure.GetMaxConcurrency()
+	}
+	// The projects of GCP are processed one at a time
+	return 1
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// runAccountChecks runs the checks in parallel, with at most limit(provider) checks of each provider running at a time
+func runAccountChecks(ctx context.Context, checks []accountCheck, limit func(provider string) int) ([]utilities.AccountIdentity, []error) {
+	identities := make([]utilities.AccountIdentity, len(checks))
+	checkErrors := make([]error, len(checks))
+	providerChecks := make(map[string][]int)
+	for idx := range checks {
+		providerChecks[checks[idx].provider] = append(providerChecks[checks[idx].provider], idx)
+	}
+	var wg sync.WaitGroup
+	for provider, indices := range providerChecks {
+		wg.Add(1)
+		go func(workers chan struct{}, indices []int) {
+			defer wg.Done()
+			for _, idx := range indices {
+				workers <- struct{}{}
+				wg.Add(1)
+				go func(idx int) {
+					defer wg.Done()
+					defer func() { <-workers }()
+					checkCtx, cancel := context.WithTimeout(ctx, accountCheckTimeout)
+					defer cancel()
+					identities[idx], checkErrors[idx] = checks[idx].check(utilities.WithTableName(checkCtx, accountsTableName))
+				}(idx)
+			}
+		}(make(chan struct{}, limit(provider)), indices)
+	}
+	wg.Wait()
+	return identities, checkErrors
+}
+
+// generateAccounts checks the credentials of the accounts in parallel, and returns a row for each table collected
+// for an account, or a single row for an account without collection
+func generateAccounts(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	checks := getAccountChecks()
+	identities, checkErrors := runAccountChecks(ctx, checks, getAccountCheckConcurrency)
+
+	resultMap := make([]map[string]string, 0)
+	for idx, check := range checks {
+		row := map[string]string{
+			"provider":          check.provider,
+			"account":           check.account,
+			"identity":          identities[idx].Identity,
+			"credential_source": identities[idx].CredentialSource,
+			"credential_expiry": formatTime(identities[idx].Expiry),
+			"status":            "ok",
+		}
+		if checkErrors[idx] != nil {
+			row["status"] = "error"
+			row["error"] = checkErrors[idx].Error()
+		}
+		statuses := utilities.GetCollectionStatuses(check.provider, check.account)
+		if len(statuses) == 0 {
+			resultMap = append(resultMap, row)
+			continue
+		}
+		for _, status := range statuses {
+			statusRow := make(map[string]string, len(row)+4)
+			for key, value := range row {
+				statusRow[key] = value
+			}
+			statusRow["table_name"] = status.Table
+			statusRow["last_success"] = formatTime(status.LastSuccess)
+			statusRow["last_failure"] = formatTime(status.LastFailure)
+			statusRow["last_error"] = status.LastError
+			resultMap = append(resultMap, statusRow)
+		}
+	}
+	return resultMap, nil
+}
@@ -0,0 +1,94 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAccounts(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := utilities.ExtensionConfiguration{}
+	extConfig.ExtConfGcp.Accounts = []utilities.ExtensionConfigurationGcpAccount{
+		{ProjectID: "accounts-project", KeyFile: filepath.Join(t.TempDir(), "missing.json")},
+	}
+	utilities.SetExtConfiguration(&extConfig)
+
+	rows, err := generateAccounts(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, "accounts-project", rows[0]["account"])
+	assert.Equal(t, "key_file", rows[0]["credential_source"])
+	assert.Equal(t, "error", rows[0]["status"])
+	assert.Contains(t, rows[0]["error"], "missing.json")
+	assert.Equal(t, "", rows[0]["table_name"])
+
+	startTime := time.Now()
+	utilities.ReportCollectionError(utilities.CollectionError{Table: "gcp_compute_disk", Account: "accounts-project", Message: "forbidden"})
+	utilities.RecordCollectionSuccess("gcp_compute_network", "accounts-project", startTime)
+	rows, err = generateAccounts(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "gcp_compute_disk", rows[0]["table_name"])
+	assert.Equal(t, "", rows[0]["last_success"])
+	assert.NotEqual(t, "", rows[0]["last_failure"])
+	assert.Equal(t, "forbidden", rows[0]["last_error"])
+	assert.Equal(t, "gcp_compute_network", rows[1]["table_name"])
+	assert.NotEqual(t, "", rows[1]["last_success"])
+	assert.Equal(t, "error", rows[1]["status"])
+}
+
+func TestRunAccountChecks(t *testing.T) {
+	running := make(map[string]int32)
+	maxRunning := make(map[string]int32)
+	var mutex sync.Mutex
+	checks := make([]accountCheck, 0)
+	for idx := 0; idx < 10; idx++ {
+		for _, provider := range []string{utilities.ProviderAws, utilities.ProviderGcp} {
+			provider, account := provider, fmt.Sprint(idx)
+			checks = append(checks, accountCheck{provider: provider, account: account, check: func(ctx context.Context) (utilities.AccountIdentity, error) {
+				mutex.Lock()
+				running[provider]++
+				if running[provider] > maxRunning[provider] {
+					maxRunning[provider] = running[provider]
+				}
+				mutex.Unlock()
+				time.Sleep(2 * time.Millisecond)
+				mutex.Lock()
+				running[provider]--
+				mutex.Unlock()
+				return utilities.AccountIdentity{Identity: provider + "-" + account}, nil
+			}})
+		}
+	}
+
+	identities, checkErrors := runAccountChecks(context.Background(), checks, func(provider string) int {
+		if provider == utilities.ProviderAws {
+			return 3
+		}
+		return 1
+	})
+	for idx, check := range checks {
+		assert.Nil(t, checkErrors[idx])
+		assert.Equal(t, check.provider+"-"+check.account, identities[idx].Identity)
+	}
+	assert.LessOrEqual(t, maxRunning[utilities.ProviderAws], int32(3))
+	assert.Equal(t, int32(1), maxRunning[utilities.ProviderGcp])
+}
@@ -0,0 +1,22 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package acm
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_acm_certificate": {Type: reflect.TypeOf((*acm.ListCertificatesOutput)(nil)).Elem()},
+}
@@ -110,17 +110,11 @@ func (ct *CloudTrailEventTable) GetGenFunction() table.GenerateFunc {
 }
 
 func (ct *CloudTrailEventTable) initialize(ctx context.Context, socket string, timeout time.Duration) {
-	ct.ctx = ctx
+	ct.ctx = utilities.WithTableName(ctx, TABLE_NAME)
 	ct.markerDelayMinutes = MARKER_DELAY_MINUTES
 	ct.objectCache = cache.New(time.Duration(CACHE_TIMEOUT_MINUTES)*time.Minute, time.Duration(CACHE_TIMEOUT_MINUTES)*time.Minute)
 	ct.markerMap = make(map[string]*ObjectMarker)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) > 0 {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			for _, bucket := range account.CtS3Buckets {
-				ct.markerMap[bucket.Name] = nil
-			}
-		}
-	}
+	ct.syncBuckets(utilities.GetExtConfiguration().ExtConfAws.Accounts)
 	ct.client, _ = osquery.NewClient(socket, timeout)
 }
 
@@ -151,11 +145,38 @@ func (ct *CloudTrailEventTable) LookupEventsGenerate(osqCtx context.Context, que
 	return nil, nil
 }
 
+// syncBuckets tracks the buckets of given accounts, and forgets the markers of buckets no longer configured.
+// Accounts and buckets may change when the configuration is reloaded.
+func (ct *CloudTrailEventTable) syncBuckets(accounts []utilities.ExtensionConfigurationAwsAccount) {
+	configured := make(map[string]bool)
+	for _, account := range accounts {
+		for _, bucket := range account.CtS3Buckets {
+			configured[bucket.Name] = true
+			if _, found := ct.markerMap[bucket.Name]; !found {
+				utilities.GetLogger().WithFields(log.Fields{
+					"tableName": TABLE_NAME,
+					"account":   account.ID,
+					"bucket":    bucket.Name,
+				}).Info("tracking bucket")
+				ct.markerMap[bucket.Name] = nil
+			}
+		}
+	}
+	for bucketName := range ct.markerMap {
+		if !configured[bucketName] {
+			delete(ct.markerMap, bucketName)
+		}
+	}
+}
+
 func (ct *CloudTrailEventTable) runEventLoop() {
 	utilities.GetLogger().Info("Collecting events")
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) > 0 {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_acm_certificate", account.ID) {
+	// Use the same configuration for the whole loop
+	accounts := utilities.GetExtConfiguration().ExtConfAws.Accounts
+	ct.syncBuckets(accounts)
+	if len(accounts) > 0 {
+		for _, account := range accounts {
+			if !extaws.ShouldProcessAccount(table.QueryContext{}, TABLE_NAME, account.ID) {
 				continue
 			}
 			utilities.GetLogger().WithFields(log.Fields{
@@ -210,7 +231,23 @@ func (ct *CloudTrailEventTable) processRecords(account *utilities.ExtensionConfi
 		"prefix":    bucket.Prefix,
 		"key":       key,
 	}).Debug("Added events ", len(events))
-	ct.client.StreamEvents(TABLE_NAME, events)
+	status, err := ct.client.StreamEvents(TABLE_NAME, events)
+	if err == nil && status.Code != 0 {
+		err = fmt.Errorf("%s", status.Message)
+	}
+	if err != nil {
+		utilities.RecordStreamEventsFailure(TABLE_NAME)
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": TABLE_NAME,
+			"account":   account.ID,
+			"region":    bucket.Region,
+			"task":      "LookupEvents",
+			"bucket":    bucket.Name,
+			"key":       key,
+			"errString": err.Error(),
+		}).Error("failed to stream events")
+		return err
+	}
 	return nil
 }
 
@@ -274,6 +311,7 @@ func (ct *CloudTrailEventTable) processSingleObject(svc *s3.Client, account *uti
 			"key":       obj.Key,
 			"errString": err.Error(),
 		}).Error("failed to process S3 object")
+		extaws.ReportError(TABLE_NAME, account.ID, bucket.Region, err)
 		return err
 	}
 	reader, err := ct.getObjectReader(account, bucket, obj, output)
@@ -329,13 +367,16 @@ func (ct *CloudTrailEventTable) processObjects(svc *s3.Client, account *utilitie
 	sort.Slice(objs, func(p, q int) bool {
 		return objs[p].LastModified.Before(*objs[q].LastModified)
 	})
-	for _, obj := range objs {
+	for idx, obj := range objs {
+		utilities.SetEventBacklog(TABLE_NAME, account.ID, bucket.Name, len(objs)-idx)
 		if currentMarker == nil && obj.LastModified.Before(currentTime.Add(-time.Duration(time.Duration(LOOKBACK_MINUTES)*time.Minute))) {
 			// we dont have a marker set, and current file is not within latest 1 hour. Ignore
 			continue
 		}
 		// Process object
-		ct.processSingleObject(svc, account, tableConfig, bucket, obj)
+		if ct.processSingleObject(svc, account, tableConfig, bucket, obj) == nil {
+			utilities.SetEventLastProcessed(TABLE_NAME, account.ID, bucket.Name, *obj.LastModified)
+		}
 		// if object is not within latest ct.markerDelayMinutes
 		// and if it is modified after current marker, update the marker
 		if currentTime.Sub(*obj.LastModified) >= time.Duration(time.Duration(ct.markerDelayMinutes)*time.Minute) {
@@ -350,6 +391,7 @@ func (ct *CloudTrailEventTable) processObjects(svc *s3.Client, account *utilitie
 			}
 		}
 	}
+	utilities.SetEventBacklog(TABLE_NAME, account.ID, bucket.Name, 0)
 	if currentMarker != nil {
 		ct.markerMap[bucket.Name] = currentMarker
 	}
@@ -379,6 +421,7 @@ func (ct *CloudTrailEventTable) getS3Objects(svc *s3.Client, accountId string, b
 				"task":      "LookupEvents",
 				"errString": err.Error(),
 			}).Error("failed to process region")
+			extaws.ReportError(TABLE_NAME, accountId, bucket.Region, err)
 			return s3Objects
 		}
 		s3Objects = append(s3Objects, page.Contents...)
@@ -391,7 +434,7 @@ func (ct *CloudTrailEventTable) getS3Objects(svc *s3.Client, accountId string, b
 
 func (ct *CloudTrailEventTable) processBucket(account *utilities.ExtensionConfigurationAwsAccount, tableConfig *utilities.TableConfig, bucket utilities.CtS3Bucket) {
 	utilities.GetLogger().Info("Processing bucket ", account.ID, ":", bucket.Name)
-	sess, err := extaws.GetAwsConfig(account, bucket.Region)
+	sess, err := extaws.GetAwsConfig(ct.ctx, account, bucket.Region)
 	if err != nil {
 		return
 	}
@@ -418,7 +461,7 @@ func (ct *CloudTrailEventTable) processAccountLookupEvents(account *utilities.Ex
 	if account == nil || len(account.CtS3Buckets) == 0 {
 		return
 	}
-	tableConfig, ok := utilities.TableConfigurationMap[TABLE_NAME]
+	tableConfig, ok := utilities.GetTableConfig(TABLE_NAME)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": TABLE_NAME,
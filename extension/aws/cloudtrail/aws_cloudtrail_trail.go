@@ -24,66 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeTrailsColumns returns the list of columns in the table
-func DescribeTrailsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("cloud_watch_logs_log_group_arn"),
-		table.TextColumn("cloud_watch_logs_role_arn"),
-		table.TextColumn("has_custom_event_selectors"),
-		table.TextColumn("has_insight_selectors"),
-		table.TextColumn("home_region"),
-		table.TextColumn("include_global_service_events"),
-		table.TextColumn("is_multi_region_trail"),
-		table.TextColumn("is_organization_trail"),
-		table.TextColumn("kms_key_id"),
-		table.TextColumn("log_file_validation_enabled"),
-		table.TextColumn("name"),
-		table.TextColumn("s3_bucket_name"),
-		table.TextColumn("s3_key_prefix"),
-		table.TextColumn("sns_topic_arn"),
-		table.TextColumn("sns_topic_name"),
-		table.TextColumn("trail_arn"),
-	}
-}
-
 // DescribeTrailsGenerate returns the rows in the table for all configured accounts
 func DescribeTrailsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_cloudtrail_trail", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_cloudtrail_trail",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeTrails(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_cloudtrail_trail", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_cloudtrail_trail",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeTrails(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_cloudtrail_trail", processAccountDescribeTrails)
 }
 
 func processRegionDescribeTrails(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -124,7 +72,7 @@ func processRegionDescribeTrails(osqCtx context.Context, queryContext table.Quer
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_cloudtrail_trail", accountId, *region.RegionName, row) {
 			continue
@@ -137,7 +85,7 @@ func processRegionDescribeTrails(osqCtx context.Context, queryContext table.Quer
 
 func processAccountDescribeTrails(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -145,26 +93,14 @@ func processAccountDescribeTrails(osqCtx context.Context, queryContext table.Que
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_cloudtrail_trail"]
+	tableConfig, ok := utilities.GetTableConfig("aws_cloudtrail_trail")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_cloudtrail_trail",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_cloudtrail_trail", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeTrails(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_cloudtrail_trail", tableConfig, account, regions, processRegionDescribeTrails)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
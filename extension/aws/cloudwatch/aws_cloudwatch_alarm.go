@@ -24,132 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeAlarmsColumns returns the list of columns in the table
-func DescribeAlarmsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("actions_enabled"),
-		table.TextColumn("alarm_actions"),
-		table.TextColumn("alarm_arn"),
-		table.TextColumn("alarm_configuration_updated_timestamp"),
-		//table.BigIntColumn("alarm_configuration_updated_timestamp_ext"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc"),
-		//table.BigIntColumn("alarm_configuration_updated_timestamp_loc_cache_end"),
-		//table.BigIntColumn("alarm_configuration_updated_timestamp_loc_cache_start"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_cache_zone"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_cache_zone_is_dst"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_cache_zone_name"),
-		//table.IntegerColumn("alarm_configuration_updated_timestamp_loc_cache_zone_offset"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_name"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_tx"),
-		//table.IntegerColumn("alarm_configuration_updated_timestamp_loc_tx_index"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_tx_isstd"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_tx_isutc"),
-		//table.BigIntColumn("alarm_configuration_updated_timestamp_loc_tx_when"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_zone"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_zone_is_dst"),
-		//table.TextColumn("alarm_configuration_updated_timestamp_loc_zone_name"),
-		//table.IntegerColumn("alarm_configuration_updated_timestamp_loc_zone_offset"),
-		//table.BigIntColumn("alarm_configuration_updated_timestamp_wall"),
-		table.TextColumn("alarm_description"),
-		table.TextColumn("alarm_name"),
-		table.TextColumn("comparison_operator"),
-		table.BigIntColumn("datapoints_to_alarm"),
-		table.TextColumn("dimensions"),
-		table.TextColumn("dimensions_name"),
-		table.TextColumn("dimensions_value"),
-		table.TextColumn("evaluate_low_sample_count_percentile"),
-		table.BigIntColumn("evaluation_periods"),
-		table.TextColumn("extended_statistic"),
-		table.TextColumn("insufficient_data_actions"),
-		table.TextColumn("metric_name"),
-		table.TextColumn("metrics"),
-		//table.TextColumn("metrics_expression"),
-		//table.TextColumn("metrics_id"),
-		table.TextColumn("metrics_label"),
-		table.TextColumn("metrics_metric_stat"),
-		//table.TextColumn("metrics_metric_stat_metric"),
-		//table.TextColumn("metrics_metric_stat_metric_dimensions"),
-		//table.TextColumn("metrics_metric_stat_metric_dimensions_name"),
-		//table.TextColumn("metrics_metric_stat_metric_dimensions_value"),
-		//table.TextColumn("metrics_metric_stat_metric_metric_name"),
-		//table.TextColumn("metrics_metric_stat_metric_namespace"),
-		table.BigIntColumn("metrics_metric_stat_period"),
-		table.TextColumn("metrics_metric_stat_stat"),
-		table.TextColumn("metrics_metric_stat_unit"),
-		table.BigIntColumn("metrics_period"),
-		table.TextColumn("metrics_return_data"),
-		table.TextColumn("namespace"),
-		table.TextColumn("ok_actions"),
-		table.BigIntColumn("period"),
-		table.TextColumn("state_reason"),
-		table.TextColumn("state_reason_data"),
-		table.TextColumn("state_updated_timestamp"),
-		//table.BigIntColumn("state_updated_timestamp_ext"),
-		//table.TextColumn("state_updated_timestamp_loc"),
-		//table.BigIntColumn("state_updated_timestamp_loc_cache_end"),
-		//table.BigIntColumn("state_updated_timestamp_loc_cache_start"),
-		//table.TextColumn("state_updated_timestamp_loc_cache_zone"),
-		//table.TextColumn("state_updated_timestamp_loc_cache_zone_is_dst"),
-		//table.TextColumn("state_updated_timestamp_loc_cache_zone_name"),
-		//table.IntegerColumn("state_updated_timestamp_loc_cache_zone_offset"),
-		//table.TextColumn("state_updated_timestamp_loc_name"),
-		//table.TextColumn("state_updated_timestamp_loc_tx"),
-		//table.IntegerColumn("state_updated_timestamp_loc_tx_index"),
-		//table.TextColumn("state_updated_timestamp_loc_tx_isstd"),
-		//table.TextColumn("state_updated_timestamp_loc_tx_isutc"),
-		//table.BigIntColumn("state_updated_timestamp_loc_tx_when"),
-		//table.TextColumn("state_updated_timestamp_loc_zone"),
-		//table.TextColumn("state_updated_timestamp_loc_zone_is_dst"),
-		//table.TextColumn("state_updated_timestamp_loc_zone_name"),
-		//table.IntegerColumn("state_updated_timestamp_loc_zone_offset"),
-		table.BigIntColumn("state_updated_timestamp_wall"),
-		table.TextColumn("state_value"),
-		table.TextColumn("statistic"),
-		table.DoubleColumn("threshold"),
-		table.TextColumn("threshold_metric_id"),
-		table.TextColumn("treat_missing_data"),
-		table.TextColumn("unit"),
-	}
-}
-
 // DescribeAlarmsGenerate returns the rows in the table for all configured accounts
 func DescribeAlarmsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_cloudwatch_alarm", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_cloudwatch_alarm",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeAlarms(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_cloudwatch_alarm", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_cloudwatch_alarm",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeAlarms(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_cloudwatch_alarm", processAccountDescribeAlarms)
 }
 
 func processRegionDescribeAlarms(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -193,7 +75,7 @@ func processRegionDescribeAlarms(osqCtx context.Context, queryContext table.Quer
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_cloudwatch_alarm", accountId, *region.RegionName, row) {
 				continue
@@ -210,7 +92,7 @@ func processRegionDescribeAlarms(osqCtx context.Context, queryContext table.Quer
 
 func processAccountDescribeAlarms(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -218,26 +100,14 @@ func processAccountDescribeAlarms(osqCtx context.Context, queryContext table.Que
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_cloudwatch_alarm"]
+	tableConfig, ok := utilities.GetTableConfig("aws_cloudwatch_alarm")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_cloudwatch_alarm",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_cloudwatch_alarm", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeAlarms(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_cloudwatch_alarm", tableConfig, account, regions, processRegionDescribeAlarms)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -24,53 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// ListEventBusesColumns returns the list of columns in the table
-func ListEventBusesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("arn"),
-		table.TextColumn("name"),
-		table.TextColumn("policy"),
-	}
-}
-
 // ListEventBusesGenerate returns the rows in the table for all configured accounts
 func ListEventBusesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_cloudwatch_event_bus", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_cloudwatch_event_bus",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListEventBuses(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_cloudwatch_event_bus", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_cloudwatch_event_bus",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListEventBuses(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_cloudwatch_event_bus", processAccountListEventBuses)
 }
 
 func processRegionListEventBuses(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -111,7 +72,7 @@ func processRegionListEventBuses(osqCtx context.Context, queryContext table.Quer
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_cloudwatch_event_bus", accountId, *region.RegionName, row) {
 			continue
@@ -124,7 +85,7 @@ func processRegionListEventBuses(osqCtx context.Context, queryContext table.Quer
 
 func processAccountListEventBuses(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -132,26 +93,14 @@ func processAccountListEventBuses(osqCtx context.Context, queryContext table.Que
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_cloudwatch_event_bus"]
+	tableConfig, ok := utilities.GetTableConfig("aws_cloudwatch_event_bus")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_cloudwatch_event_bus",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_cloudwatch_event_bus", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListEventBuses(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_cloudwatch_event_bus", tableConfig, account, regions, processRegionListEventBuses)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
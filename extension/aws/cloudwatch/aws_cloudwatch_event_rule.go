@@ -24,59 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// ListRulesColumns returns the list of columns in the table
-func ListRulesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("arn"),
-		table.TextColumn("description"),
-		table.TextColumn("event_bus_name"),
-		table.TextColumn("event_pattern"),
-		table.TextColumn("managed_by"),
-		table.TextColumn("name"),
-		table.TextColumn("role_arn"),
-		table.TextColumn("schedule_expression"),
-		table.TextColumn("state"),
-	}
-}
-
 // ListRulesGenerate returns the rows in the table for all configured accounts
 func ListRulesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_cloudwatch_event_rule", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_cloudwatch_event_rule",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListRules(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_cloudwatch_event_rule", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_cloudwatch_event_rule",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListRules(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_cloudwatch_event_rule", processAccountListRules)
 }
 
 func processRegionListRules(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -117,7 +72,7 @@ func processRegionListRules(osqCtx context.Context, queryContext table.QueryCont
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_cloudwatch_event_rule", accountId, *region.RegionName, row) {
 			continue
@@ -130,7 +85,7 @@ func processRegionListRules(osqCtx context.Context, queryContext table.QueryCont
 
 func processAccountListRules(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -138,26 +93,14 @@ func processAccountListRules(osqCtx context.Context, queryContext table.QueryCon
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_cloudwatch_event_rule"]
+	tableConfig, ok := utilities.GetTableConfig("aws_cloudwatch_event_rule")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_cloudwatch_event_rule",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_cloudwatch_event_rule", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListRules(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_cloudwatch_event_rule", tableConfig, account, regions, processRegionListRules)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
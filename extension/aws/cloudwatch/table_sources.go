@@ -0,0 +1,25 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cloudwatch
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_cloudwatch_alarm":      {Type: reflect.TypeOf((*cloudwatch.DescribeAlarmsOutput)(nil)).Elem()},
+	"aws_cloudwatch_event_bus":  {Type: reflect.TypeOf((*cloudwatchevents.ListEventBusesOutput)(nil)).Elem()},
+	"aws_cloudwatch_event_rule": {Type: reflect.TypeOf((*cloudwatchevents.ListRulesOutput)(nil)).Elem()},
+}
@@ -24,51 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// ListRepositoriesColumns returns the list of columns in the table
-func ListRepositoriesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("repository_id"),
-		table.TextColumn("repository_name"),
-		//table.TextColumn("values"),
-
-	}
-}
-
 // ListRepositoriesGenerate returns the rows in the table for all configured accounts
 func ListRepositoriesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_codecommit_repository",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListRepositories(nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_codecommit_repository",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListRepositories(&account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_codecommit_repository", processAccountListRepositories)
 }
 
-func processRegionListRepositories(tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+func processRegionListRepositories(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -90,7 +53,7 @@ func processRegionListRepositories(tableConfig *utilities.TableConfig, account *
 	paginator := codecommit.NewListRepositoriesPaginator(svc, params)
 
 	for {
-		page, err := paginator.NextPage(context.TODO())
+		page, err := paginator.NextPage(osqCtx)
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": "aws_codecommit_repository",
@@ -112,8 +75,11 @@ func processRegionListRepositories(tableConfig *utilities.TableConfig, account *
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_codecommit_repository", accountId, *region.RegionName, row) {
+				continue
+			}
 			result := extaws.RowToMap(row, accountId, *region.RegionName, tableConfig)
 			resultMap = append(resultMap, result)
 		}
@@ -124,29 +90,24 @@ func processRegionListRepositories(tableConfig *utilities.TableConfig, account *
 	return resultMap, nil
 }
 
-func processAccountListRepositories(account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
+func processAccountListRepositories(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
-	regions, err := extaws.FetchRegions(context.TODO(), awsSession)
+	regions, err := extaws.FetchRegions(osqCtx, awsSession)
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_codecommit_repository"]
+	tableConfig, ok := utilities.GetTableConfig("aws_codecommit_repository")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_codecommit_repository",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		result, err := processRegionListRepositories(tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_codecommit_repository", tableConfig, account, regions, processRegionListRepositories)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
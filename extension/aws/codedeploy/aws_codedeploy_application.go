@@ -24,52 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// ListApplicationsColumns returns the list of columns in the table
-func ListApplicationsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("applications"),
-		//table.TextColumn("next_token"),
-		//table.TextColumn("result_metadata"),
-		//table.TextColumn("result_metadata_values"),
-
-	}
-}
-
 // ListApplicationsGenerate returns the rows in the table for all configured accounts
 func ListApplicationsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_codedeploy_application",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListApplications(nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_codedeploy_application",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListApplications(&account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_codedeploy_application", processAccountListApplications)
 }
 
-func processRegionListApplications(tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+func processRegionListApplications(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -91,7 +53,7 @@ func processRegionListApplications(tableConfig *utilities.TableConfig, account *
 	paginator := codedeploy.NewListApplicationsPaginator(svc, params)
 
 	for {
-		page, err := paginator.NextPage(context.TODO())
+		page, err := paginator.NextPage(osqCtx)
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": "aws_codedeploy_application",
@@ -113,8 +75,11 @@ func processRegionListApplications(tableConfig *utilities.TableConfig, account *
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_codedeploy_application", accountId, *region.RegionName, row) {
+				continue
+			}
 			result := extaws.RowToMap(row, accountId, *region.RegionName, tableConfig)
 			resultMap = append(resultMap, result)
 		}
@@ -125,29 +90,24 @@ func processRegionListApplications(tableConfig *utilities.TableConfig, account *
 	return resultMap, nil
 }
 
-func processAccountListApplications(account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
+func processAccountListApplications(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
-	regions, err := extaws.FetchRegions(context.TODO(), awsSession)
+	regions, err := extaws.FetchRegions(osqCtx, awsSession)
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_codedeploy_application"]
+	tableConfig, ok := utilities.GetTableConfig("aws_codedeploy_application")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_codedeploy_application",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		result, err := processRegionListApplications(tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_codedeploy_application", tableConfig, account, regions, processRegionListApplications)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -0,0 +1,23 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package config
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/configservice"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_config_recorder":         {Type: reflect.TypeOf((*configservice.DescribeConfigurationRecordersOutput)(nil)).Elem()},
+	"aws_config_delivery_channel": {Type: reflect.TypeOf((*configservice.DescribeDeliveryChannelsOutput)(nil)).Elem()},
+}
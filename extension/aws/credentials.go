@@ -0,0 +1,176 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSessionName     = "cloudquery"
+	defaultSessionDuration = time.Hour
+	// credentialsExpiryWindow is how long before their expiry the credentials of the roles are refreshed
+	credentialsExpiryWindow = 5 * time.Minute
+)
+
+// cachedCredentials is the credentials provider of an account, created with the credential options identified by key
+type cachedCredentials struct {
+	key      string
+	provider aws.CredentialsProvider
+}
+
+// credentialsCache holds the credentials providers shared by the tables, by account
+var credentialsCache = struct {
+	sync.Mutex
+	providers map[string]cachedCredentials
+}{providers: make(map[string]cachedCredentials)}
+
+// getCachedCredentials returns the credentials provider cached for given account.
+// It is created with newProvider if missing, or if it was created with other credential options than key.
+func getCachedCredentials(accountId string, key string, newProvider func() aws.CredentialsProvider) aws.CredentialsProvider {
+	credentialsCache.Lock()
+	defer credentialsCache.Unlock()
+	if cached, found := credentialsCache.providers[accountId]; found && cached.key == key {
+		return cached.provider
+	}
+	provider := newProvider()
+	if provider != nil {
+		credentialsCache.providers[accountId] = cachedCredentials{key: key, provider: provider}
+	}
+	return provider
+}
+
+// getCredentialsKey returns the key of the credential options of given account.
+// The key changes with the credential and config files, so that the credentials are read again once they are updated.
+func getCredentialsKey(account *utilities.ExtensionConfigurationAwsAccount) string {
+	options := *account
+	options.ID = ""
+	options.CtS3Buckets = nil
+	key, _ := json.Marshal(options)
+	for _, file := range []string{account.CredentialFile, account.ConfigFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			key = append(key, fmt.Sprintf("|%d", info.ModTime().UnixNano())...)
+		}
+	}
+	return string(key)
+}
+
+// newAccountCredentials returns the credentials provider of given account, from the base credentials of cfg.
+// The role of the web identity, and the roles of the chain are assumed in order, their credentials are refreshed before they expire.
+func newAccountCredentials(cfg aws.Config, account *utilities.ExtensionConfigurationAwsAccount) aws.CredentialsProvider {
+	provider := cfg.Credentials
+	if account.WebIdentityTokenFile != "" {
+		webIdentity := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(cfg), account.WebIdentityRoleArn,
+			stscreds.IdentityTokenFile(account.WebIdentityTokenFile), func(options *stscreds.WebIdentityRoleOptions) {
+				options.RoleSessionName = getSessionName(account.SessionName)
+			})
+		provider = newRefreshingCredentials(webIdentity)
+	}
+	roles := append([]utilities.ExtensionConfigurationAwsRole{}, account.RoleChain...)
+	if account.RoleArn != "" {
+		roles = append(roles, utilities.ExtensionConfigurationAwsRole{
+			RoleArn:         account.RoleArn,
+			ExternalID:      account.ExternalID,
+			SessionName:     account.SessionName,
+			SessionTags:     account.SessionTags,
+			SessionDuration: account.SessionDuration,
+		})
+	}
+	for _, role := range roles {
+		cfg.Credentials = provider
+		provider = newRefreshingCredentials(&assumeRoleProvider{client: sts.NewFromConfig(cfg), role: role})
+	}
+	return provider
+}
+
+// newRefreshingCredentials caches the credentials of given provider, and refreshes them before they expire
+func newRefreshingCredentials(provider aws.CredentialsProvider) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(provider, func(options *aws.CredentialsCacheOptions) {
+		options.ExpiryWindow = credentialsExpiryWindow
+		options.ExpiryWindowJitterFrac = 0.5
+	})
+}
+
+// assumeRoleProvider retrieves the credentials of a role, with the session options of its configuration
+type assumeRoleProvider struct {
+	client stscreds.AssumeRoleAPIClient
+	role   utilities.ExtensionConfigurationAwsRole
+}
+
+// Retrieve assumes the role
+func (provider *assumeRoleProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	role := provider.role
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(role.RoleArn),
+		RoleSessionName: aws.String(getSessionName(role.SessionName)),
+		DurationSeconds: aws.Int32(int32(getSessionDuration(role.SessionDuration) / time.Second)),
+	}
+	if role.ExternalID != "" {
+		input.ExternalId = aws.String(role.ExternalID)
+	}
+	keys := make([]string, 0, len(role.SessionTags))
+	for key := range role.SessionTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(role.SessionTags[key])})
+	}
+
+	utilities.GetLogger().WithFields(log.Fields{
+		"role":        role.RoleArn,
+		"sessionName": aws.ToString(input.RoleSessionName),
+	}).Debug("assuming role")
+	output, err := provider.client.AssumeRole(ctx, input)
+	if err != nil {
+		return aws.Credentials{Source: stscreds.ProviderName}, err
+	}
+	if output.Credentials == nil {
+		return aws.Credentials{Source: stscreds.ProviderName}, fmt.Errorf("no credentials returned for role %s", role.RoleArn)
+	}
+	return aws.Credentials{
+		AccessKeyID:     aws.ToString(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.Credentials.SessionToken),
+		Source:          stscreds.ProviderName,
+		CanExpire:       true,
+		Expires:         aws.ToTime(output.Credentials.Expiration),
+	}, nil
+}
+
+func getSessionName(sessionName string) string {
+	if sessionName == "" {
+		return defaultSessionName
+	}
+	return sessionName
+}
+
+func getSessionDuration(sessionDuration int) time.Duration {
+	if sessionDuration == 0 {
+		return defaultSessionDuration
+	}
+	return time.Duration(sessionDuration) * time.Second
+}
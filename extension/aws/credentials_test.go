@@ -0,0 +1,104 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSts returns credentials expiring in an hour, and records the calls
+type fakeSts struct {
+	inputs []*sts.AssumeRoleInput
+}
+
+func (svc *fakeSts) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	svc.inputs = append(svc.inputs, params)
+	return &sts.AssumeRoleOutput{Credentials: &types.Credentials{
+		AccessKeyId:     aws.String("key-" + aws.ToString(params.RoleArn)),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestAssumeRoleProvider(t *testing.T) {
+	svc := &fakeSts{}
+	provider := newRefreshingCredentials(&assumeRoleProvider{client: svc, role: utilities.ExtensionConfigurationAwsRole{
+		RoleArn:     "arn:aws:iam::111:role/spoke",
+		SessionTags: map[string]string{"team": "security", "app": "cloudquery"},
+	}})
+	credentials, err := provider.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "key-arn:aws:iam::111:role/spoke", credentials.AccessKeyID)
+	assert.True(t, credentials.CanExpire)
+
+	assert.Equal(t, 1, len(svc.inputs))
+	input := svc.inputs[0]
+	assert.Equal(t, defaultSessionName, aws.ToString(input.RoleSessionName))
+	assert.Equal(t, int32(3600), aws.ToInt32(input.DurationSeconds))
+	assert.Nil(t, input.ExternalId)
+	assert.Equal(t, "app", aws.ToString(input.Tags[0].Key))
+	assert.Equal(t, "team", aws.ToString(input.Tags[1].Key))
+
+	// The credentials are cached until they are about to expire
+	_, err = provider.Retrieve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(svc.inputs))
+}
+
+func TestGetCachedCredentials(t *testing.T) {
+	account := utilities.ExtensionConfigurationAwsAccount{ID: "111", RoleArn: "arn:aws:iam::111:role/spoke", CtS3Buckets: []utilities.CtS3Bucket{{Name: "trail"}}}
+	other := account
+	other.ID = "alias"
+	other.CtS3Buckets = nil
+	assert.Equal(t, getCredentialsKey(&account), getCredentialsKey(&other))
+	other.RoleChain = []utilities.ExtensionConfigurationAwsRole{{RoleArn: "arn:aws:iam::222:role/hub"}}
+	assert.NotEqual(t, getCredentialsKey(&account), getCredentialsKey(&other))
+
+	created := 0
+	newProvider := func() aws.CredentialsProvider {
+		created++
+		return newRefreshingCredentials(&assumeRoleProvider{client: &fakeSts{}})
+	}
+	provider := getCachedCredentials("test-111", getCredentialsKey(&account), newProvider)
+	assert.True(t, provider == getCachedCredentials("test-111", getCredentialsKey(&account), newProvider))
+	assert.Equal(t, 1, created)
+
+	// The provider of the account is replaced when its credential options change
+	updated := getCachedCredentials("test-111", getCredentialsKey(&other), newProvider)
+	assert.False(t, provider == updated)
+	assert.Equal(t, 2, created)
+	assert.True(t, updated == getCachedCredentials("test-111", getCredentialsKey(&other), newProvider))
+	credentialsCache.Lock()
+	assert.Equal(t, getCredentialsKey(&other), credentialsCache.providers["test-111"].key)
+	credentialsCache.Unlock()
+}
+
+func TestGetCredentialsKeyFileUpdate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+	assert.Nil(t, os.WriteFile(file, []byte("[default]"), 0600))
+	account := utilities.ExtensionConfigurationAwsAccount{ID: "111", CredentialFile: file}
+	key := getCredentialsKey(&account)
+	assert.Equal(t, key, getCredentialsKey(&account))
+
+	modified := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file, modified, modified))
+	assert.NotEqual(t, key, getCredentialsKey(&account))
+}
@@ -24,67 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeAddressesColumns returns the list of columns in the table
-func DescribeAddressesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("allocation_id"),
-		table.TextColumn("association_id"),
-		table.TextColumn("carrier_ip"),
-		table.TextColumn("customer_owned_ip"),
-		table.TextColumn("customer_owned_ipv4_pool"),
-		table.TextColumn("domain"),
-		table.TextColumn("instance_id"),
-		table.TextColumn("network_border_group"),
-		table.TextColumn("network_interface_id"),
-		table.TextColumn("network_interface_owner_id"),
-		table.TextColumn("private_ip_address"),
-		table.TextColumn("public_ip"),
-		table.TextColumn("public_ipv4_pool"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-
-	}
-}
-
 // DescribeAddressesGenerate returns the rows in the table for all configured accounts
 func DescribeAddressesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_address", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_address",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeAddresses(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_address", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_address",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeAddresses(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_address", processAccountDescribeAddresses)
 }
 
 func processRegionDescribeAddresses(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -125,7 +72,7 @@ func processRegionDescribeAddresses(osqCtx context.Context, queryContext table.Q
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_address", accountId, *region.RegionName, row) {
 			continue
@@ -138,7 +85,7 @@ func processRegionDescribeAddresses(osqCtx context.Context, queryContext table.Q
 
 func processAccountDescribeAddresses(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -146,26 +93,14 @@ func processAccountDescribeAddresses(osqCtx context.Context, queryContext table.
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_address"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_address")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_address",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_address", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeAddresses(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_address", tableConfig, account, regions, processRegionDescribeAddresses)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -24,58 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeEgressOnlyInternetGatewaysColumns returns the list of columns in the table
-func DescribeEgressOnlyInternetGatewaysColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("attachments"),
-		//table.TextColumn("attachments_state"),
-		//table.TextColumn("attachments_vpc_id"),
-		table.TextColumn("egress_only_internet_gateway_id"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-
-	}
-}
-
 // DescribeEgressOnlyInternetGatewaysGenerate returns the rows in the table for all configured accounts
 func DescribeEgressOnlyInternetGatewaysGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_egress_only_internet_gateway", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_egress_only_internet_gateway",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeEgressOnlyInternetGateways(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_egress_only_internet_gateway", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_egress_only_internet_gateway",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeEgressOnlyInternetGateways(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_egress_only_internet_gateway", processAccountDescribeEgressOnlyInternetGateways)
 }
 
 func processRegionDescribeEgressOnlyInternetGateways(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -119,7 +75,7 @@ func processRegionDescribeEgressOnlyInternetGateways(osqCtx context.Context, que
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_egress_only_internet_gateway", accountId, *region.RegionName, row) {
 				continue
@@ -136,7 +92,7 @@ func processRegionDescribeEgressOnlyInternetGateways(osqCtx context.Context, que
 
 func processAccountDescribeEgressOnlyInternetGateways(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -144,26 +100,14 @@ func processAccountDescribeEgressOnlyInternetGateways(osqCtx context.Context, qu
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_egress_only_internet_gateway"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_egress_only_internet_gateway")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_egress_only_internet_gateway",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_egress_only_internet_gateway", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeEgressOnlyInternetGateways(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_egress_only_internet_gateway", tableConfig, account, regions, processRegionDescribeEgressOnlyInternetGateways)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
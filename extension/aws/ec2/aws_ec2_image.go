@@ -23,89 +23,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeImagesColumns returns the list of columns in the table
-func DescribeImagesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("architecture"),
-		table.TextColumn("owner_id"),
-		table.TextColumn("platform"),
-		table.TextColumn("usage_operation"),
-		table.TextColumn("block_device_mappings"),
-		//table.TextColumn("block_device_mappings_ebs"),
-		//table.IntegerColumn("block_device_mappings_ebs_volume_size"),
-		//table.TextColumn("block_device_mappings_ebs_volume_type"),
-		//table.TextColumn("block_device_mappings_ebs_kms_key_id"),
-		//table.IntegerColumn("block_device_mappings_ebs_throughput"),
-		//table.TextColumn("block_device_mappings_ebs_encrypted"),
-		//table.TextColumn("block_device_mappings_ebs_delete_on_termination"),
-		//table.IntegerColumn("block_device_mappings_ebs_iops"),
-		//table.TextColumn("block_device_mappings_ebs_snapshot_id"),
-		//table.TextColumn("block_device_mappings_no_device"),
-		//table.TextColumn("block_device_mappings_device_name"),
-		//table.TextColumn("block_device_mappings_virtual_name"),
-		table.TextColumn("root_device_type"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-		table.TextColumn("image_id"),
-		table.TextColumn("image_type"),
-		table.TextColumn("product_codes"),
-		//table.TextColumn("product_codes_product_code_id"),
-		//table.TextColumn("product_codes_product_code_type"),
-		table.TextColumn("ramdisk_id"),
-		table.TextColumn("root_device_name"),
-		table.TextColumn("creation_date"),
-		table.TextColumn("image_location"),
-		table.TextColumn("kernel_id"),
-		table.TextColumn("state"),
-		table.TextColumn("hypervisor"),
-		table.TextColumn("image_owner_alias"),
-		table.TextColumn("name"),
-		table.TextColumn("sriov_net_support"),
-		table.TextColumn("state_reason"),
-		//table.TextColumn("state_reason_code"),
-		//table.TextColumn("state_reason_message"),
-		table.TextColumn("public"),
-		table.TextColumn("platform_details"),
-		table.TextColumn("description"),
-		table.TextColumn("ena_support"),
-		table.TextColumn("virtualization_type"),
-	}
-}
-
 // DescribeImagesGenerate returns the rows in the table for all configured accounts
 func DescribeImagesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_image", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_image",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeImages(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_image", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_image",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeImages(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_image", processAccountDescribeImages)
 }
 
 func updateFilters(page *ec2.DescribeInstancesOutput, filters map[*string]bool) {
@@ -138,7 +58,7 @@ func processDescribeImages(osqCtx context.Context, queryContext table.QueryConte
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_image", accountId, *region.RegionName, row) {
 			continue
@@ -176,7 +96,7 @@ func getImages(osqCtx context.Context, queryContext table.QueryContext, tableCon
 
 func processRegionDescribeImages(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -215,7 +135,7 @@ func processRegionDescribeImages(osqCtx context.Context, queryContext table.Quer
 
 func processAccountDescribeImages(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -223,26 +143,14 @@ func processAccountDescribeImages(osqCtx context.Context, queryContext table.Que
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_image"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_image")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_image",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_image", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeImages(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_image", tableConfig, account, regions, processRegionDescribeImages)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
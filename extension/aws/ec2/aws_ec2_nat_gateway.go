@@ -24,148 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeNatGatewaysColumns returns the list of columns in the table
-func DescribeNatGatewaysColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("create_time"),
-		//table.BigIntColumn("create_time_ext"),
-		//table.TextColumn("create_time_loc"),
-		//table.BigIntColumn("create_time_loc_cache_end"),
-		//table.BigIntColumn("create_time_loc_cache_start"),
-		//table.TextColumn("create_time_loc_cache_zone"),
-		//table.TextColumn("create_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("create_time_loc_cache_zone_name"),
-		//table.IntegerColumn("create_time_loc_cache_zone_offset"),
-		//table.TextColumn("create_time_loc_name"),
-		//table.TextColumn("create_time_loc_tx"),
-		//table.IntegerColumn("create_time_loc_tx_index"),
-		//table.TextColumn("create_time_loc_tx_isstd"),
-		//table.TextColumn("create_time_loc_tx_isutc"),
-		//table.BigIntColumn("create_time_loc_tx_when"),
-		//table.TextColumn("create_time_loc_zone"),
-		//table.TextColumn("create_time_loc_zone_is_dst"),
-		//table.TextColumn("create_time_loc_zone_name"),
-		//table.IntegerColumn("create_time_loc_zone_offset"),
-		//table.BigIntColumn("create_time_wall"),
-		table.TextColumn("delete_time"),
-		//table.BigIntColumn("delete_time_ext"),
-		//table.TextColumn("delete_time_loc"),
-		//table.BigIntColumn("delete_time_loc_cache_end"),
-		//table.BigIntColumn("delete_time_loc_cache_start"),
-		//table.TextColumn("delete_time_loc_cache_zone"),
-		//table.TextColumn("delete_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("delete_time_loc_cache_zone_name"),
-		//table.IntegerColumn("delete_time_loc_cache_zone_offset"),
-		//table.TextColumn("delete_time_loc_name"),
-		//table.TextColumn("delete_time_loc_tx"),
-		//table.IntegerColumn("delete_time_loc_tx_index"),
-		//table.TextColumn("delete_time_loc_tx_isstd"),
-		//table.TextColumn("delete_time_loc_tx_isutc"),
-		//table.BigIntColumn("delete_time_loc_tx_when"),
-		//table.TextColumn("delete_time_loc_zone"),
-		//table.TextColumn("delete_time_loc_zone_is_dst"),
-		//table.TextColumn("delete_time_loc_zone_name"),
-		//table.IntegerColumn("delete_time_loc_zone_offset"),
-		//table.BigIntColumn("delete_time_wall"),
-		table.TextColumn("failure_code"),
-		table.TextColumn("failure_message"),
-		table.TextColumn("nat_gateway_addresses"),
-		//table.TextColumn("nat_gateway_addresses_allocation_id"),
-		//table.TextColumn("nat_gateway_addresses_network_interface_id"),
-		//table.TextColumn("nat_gateway_addresses_private_ip"),
-		//table.TextColumn("nat_gateway_addresses_public_ip"),
-		table.TextColumn("nat_gateway_id"),
-		table.TextColumn("provisioned_bandwidth"),
-		//table.TextColumn("provisioned_bandwidth_provision_time"),
-		//table.BigIntColumn("provisioned_bandwidth_provision_time_ext"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc"),
-		//table.BigIntColumn("provisioned_bandwidth_provision_time_loc_cache_end"),
-		//table.BigIntColumn("provisioned_bandwidth_provision_time_loc_cache_start"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_cache_zone"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_cache_zone_name"),
-		//table.IntegerColumn("provisioned_bandwidth_provision_time_loc_cache_zone_offset"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_name"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_tx"),
-		//table.IntegerColumn("provisioned_bandwidth_provision_time_loc_tx_index"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_tx_isstd"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_tx_isutc"),
-		//table.BigIntColumn("provisioned_bandwidth_provision_time_loc_tx_when"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_zone"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_zone_is_dst"),
-		//table.TextColumn("provisioned_bandwidth_provision_time_loc_zone_name"),
-		//table.IntegerColumn("provisioned_bandwidth_provision_time_loc_zone_offset"),
-		//table.BigIntColumn("provisioned_bandwidth_provision_time_wall"),
-		//table.TextColumn("provisioned_bandwidth_provisioned"),
-		//table.TextColumn("provisioned_bandwidth_request_time"),
-		//table.BigIntColumn("provisioned_bandwidth_request_time_ext"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc"),
-		//table.BigIntColumn("provisioned_bandwidth_request_time_loc_cache_end"),
-		//table.BigIntColumn("provisioned_bandwidth_request_time_loc_cache_start"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_cache_zone"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_cache_zone_name"),
-		//table.IntegerColumn("provisioned_bandwidth_request_time_loc_cache_zone_offset"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_name"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_tx"),
-		//table.IntegerColumn("provisioned_bandwidth_request_time_loc_tx_index"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_tx_isstd"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_tx_isutc"),
-		//table.BigIntColumn("provisioned_bandwidth_request_time_loc_tx_when"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_zone"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_zone_is_dst"),
-		//table.TextColumn("provisioned_bandwidth_request_time_loc_zone_name"),
-		//table.IntegerColumn("provisioned_bandwidth_request_time_loc_zone_offset"),
-		//table.BigIntColumn("provisioned_bandwidth_request_time_wall"),
-		//table.TextColumn("provisioned_bandwidth_requested"),
-		//table.TextColumn("provisioned_bandwidth_status"),
-		table.TextColumn("state"),
-		table.TextColumn("subnet_id"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-		table.TextColumn("vpc_id"),
-	}
-}
-
 // DescribeNatGatewaysGenerate returns the rows in the table for all configured accounts
 func DescribeNatGatewaysGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_nat_gateway", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_nat_gateway",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeNatGateways(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_nat_gateway", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_nat_gateway",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeNatGateways(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_nat_gateway", processAccountDescribeNatGateways)
 }
 
 func processRegionDescribeNatGateways(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -209,7 +75,7 @@ func processRegionDescribeNatGateways(osqCtx context.Context, queryContext table
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_nat_gateway", accountId, *region.RegionName, row) {
 				continue
@@ -226,7 +92,7 @@ func processRegionDescribeNatGateways(osqCtx context.Context, queryContext table
 
 func processAccountDescribeNatGateways(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -234,26 +100,14 @@ func processAccountDescribeNatGateways(osqCtx context.Context, queryContext tabl
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_nat_gateway"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_nat_gateway")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_nat_gateway",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_nat_gateway", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeNatGateways(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_nat_gateway", tableConfig, account, regions, processRegionDescribeNatGateways)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
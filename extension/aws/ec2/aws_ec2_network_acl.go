@@ -24,74 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeNetworkAclsColumns returns the list of columns in the table
-func DescribeNetworkAclsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("associations"),
-		//table.TextColumn("associations_network_acl_association_id"),
-		//table.TextColumn("associations_network_acl_id"),
-		//table.TextColumn("associations_subnet_id"),
-		table.TextColumn("entries"),
-		//table.TextColumn("entries_cidr_block"),
-		//table.TextColumn("entries_egress"),
-		//table.TextColumn("entries_icmp_type_code"),
-		//table.BigIntColumn("entries_icmp_type_code_code"),
-		//table.BigIntColumn("entries_icmp_type_code_type"),
-		//table.TextColumn("entries_ipv6_cidr_block"),
-		//table.TextColumn("entries_port_range"),
-		//table.BigIntColumn("entries_port_range_from"),
-		//table.BigIntColumn("entries_port_range_to"),
-		//table.TextColumn("entries_protocol"),
-		//table.TextColumn("entries_rule_action"),
-		//table.BigIntColumn("entries_rule_number"),
-		table.TextColumn("is_default"),
-		table.TextColumn("network_acl_id"),
-		table.TextColumn("owner_id"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-		table.TextColumn("vpc_id"),
-	}
-}
-
 // DescribeNetworkAclsGenerate returns the rows in the table for all configured accounts
 func DescribeNetworkAclsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_network_acl", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_network_acl",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeNetworkAcls(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_network_acl", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_network_acl",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeNetworkAcls(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_network_acl", processAccountDescribeNetworkAcls)
 }
 
 func processRegionDescribeNetworkAcls(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -135,7 +75,7 @@ func processRegionDescribeNetworkAcls(osqCtx context.Context, queryContext table
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_network_acl", accountId, *region.RegionName, row) {
 				continue
@@ -152,7 +92,7 @@ func processRegionDescribeNetworkAcls(osqCtx context.Context, queryContext table
 
 func processAccountDescribeNetworkAcls(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -160,26 +100,14 @@ func processAccountDescribeNetworkAcls(osqCtx context.Context, queryContext tabl
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_network_acl"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_network_acl")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_network_acl",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_network_acl", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeNetworkAcls(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_network_acl", tableConfig, account, regions, processRegionDescribeNetworkAcls)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
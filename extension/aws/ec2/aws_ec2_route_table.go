@@ -24,83 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeRouteTablesColumns returns the list of columns in the table
-func DescribeRouteTablesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("associations"),
-		//table.TextColumn("associations_association_state"),
-		//table.TextColumn("associations_association_state_state"),
-		//table.TextColumn("associations_association_state_status_message"),
-		//table.TextColumn("associations_gateway_id"),
-		//table.TextColumn("associations_main"),
-		//table.TextColumn("associations_route_table_association_id"),
-		//table.TextColumn("associations_route_table_id"),
-		//table.TextColumn("associations_subnet_id"),
-		table.TextColumn("owner_id"),
-		table.TextColumn("propagating_vgws"),
-		//table.TextColumn("propagating_vgws_gateway_id"),
-		table.TextColumn("route_table_id"),
-		table.TextColumn("routes"),
-		//table.TextColumn("routes_carrier_gateway_id"),
-		//table.TextColumn("routes_destination_cidr_block"),
-		//table.TextColumn("routes_destination_ipv6_cidr_block"),
-		//table.TextColumn("routes_destination_prefix_list_id"),
-		//table.TextColumn("routes_egress_only_internet_gateway_id"),
-		//table.TextColumn("routes_gateway_id"),
-		//table.TextColumn("routes_instance_id"),
-		//table.TextColumn("routes_instance_owner_id"),
-		//table.TextColumn("routes_local_gateway_id"),
-		//table.TextColumn("routes_nat_gateway_id"),
-		//table.TextColumn("routes_network_interface_id"),
-		//table.TextColumn("routes_origin"),
-		//table.TextColumn("routes_state"),
-		//table.TextColumn("routes_transit_gateway_id"),
-		//table.TextColumn("routes_vpc_peering_connection_id"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-		table.TextColumn("vpc_id"),
-	}
-}
-
 // DescribeRouteTablesGenerate returns the rows in the table for all configured accounts
 func DescribeRouteTablesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_route_table", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_route_table",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeRouteTables(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_route_table", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_route_table",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeRouteTables(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_route_table", processAccountDescribeRouteTables)
 }
 
 func processRegionDescribeRouteTables(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -144,7 +75,7 @@ func processRegionDescribeRouteTables(osqCtx context.Context, queryContext table
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_route_table", accountId, *region.RegionName, row) {
 				continue
@@ -161,7 +92,7 @@ func processRegionDescribeRouteTables(osqCtx context.Context, queryContext table
 
 func processAccountDescribeRouteTables(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -169,26 +100,14 @@ func processAccountDescribeRouteTables(osqCtx context.Context, queryContext tabl
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_route_table"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_route_table")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_route_table",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_route_table", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeRouteTables(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_route_table", tableConfig, account, regions, processRegionDescribeRouteTables)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
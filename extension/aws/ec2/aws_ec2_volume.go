@@ -24,112 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-// DescribeVolumesColumns returns the list of columns in the table
-func DescribeVolumesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("attachments"),
-		//table.TextColumn("attachments_attach_time"),
-		//table.BigIntColumn("attachments_attach_time_ext"),
-		//table.TextColumn("attachments_attach_time_loc"),
-		//table.BigIntColumn("attachments_attach_time_loc_cache_end"),
-		//table.BigIntColumn("attachments_attach_time_loc_cache_start"),
-		//table.TextColumn("attachments_attach_time_loc_cache_zone"),
-		//table.TextColumn("attachments_attach_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("attachments_attach_time_loc_cache_zone_name"),
-		//table.IntegerColumn("attachments_attach_time_loc_cache_zone_offset"),
-		//table.TextColumn("attachments_attach_time_loc_name"),
-		//table.TextColumn("attachments_attach_time_loc_tx"),
-		//table.IntegerColumn("attachments_attach_time_loc_tx_index"),
-		//table.TextColumn("attachments_attach_time_loc_tx_isstd"),
-		//table.TextColumn("attachments_attach_time_loc_tx_isutc"),
-		//table.BigIntColumn("attachments_attach_time_loc_tx_when"),
-		//table.TextColumn("attachments_attach_time_loc_zone"),
-		//table.TextColumn("attachments_attach_time_loc_zone_is_dst"),
-		//table.TextColumn("attachments_attach_time_loc_zone_name"),
-		//table.IntegerColumn("attachments_attach_time_loc_zone_offset"),
-		//table.BigIntColumn("attachments_attach_time_wall"),
-		//table.TextColumn("attachments_delete_on_termination"),
-		//table.TextColumn("attachments_device"),
-		//table.TextColumn("attachments_instance_id"),
-		//table.TextColumn("attachments_state"),
-		//table.TextColumn("attachments_volume_id"),
-		table.TextColumn("availability_zone"),
-		table.TextColumn("create_time"),
-		//table.BigIntColumn("create_time_ext"),
-		//table.TextColumn("create_time_loc"),
-		//table.BigIntColumn("create_time_loc_cache_end"),
-		//table.BigIntColumn("create_time_loc_cache_start"),
-		//table.TextColumn("create_time_loc_cache_zone"),
-		//table.TextColumn("create_time_loc_cache_zone_is_dst"),
-		//table.TextColumn("create_time_loc_cache_zone_name"),
-		//table.IntegerColumn("create_time_loc_cache_zone_offset"),
-		//table.TextColumn("create_time_loc_name"),
-		//table.TextColumn("create_time_loc_tx"),
-		//table.IntegerColumn("create_time_loc_tx_index"),
-		//table.TextColumn("create_time_loc_tx_isstd"),
-		//table.TextColumn("create_time_loc_tx_isutc"),
-		//table.BigIntColumn("create_time_loc_tx_when"),
-		//table.TextColumn("create_time_loc_zone"),
-		//table.TextColumn("create_time_loc_zone_is_dst"),
-		//table.TextColumn("create_time_loc_zone_name"),
-		//table.IntegerColumn("create_time_loc_zone_offset"),
-		//table.BigIntColumn("create_time_wall"),
-		table.TextColumn("encrypted"),
-		table.TextColumn("fast_restored"),
-		table.BigIntColumn("iops"),
-		table.TextColumn("kms_key_id"),
-		table.TextColumn("multi_attach_enabled"),
-		table.TextColumn("outpost_arn"),
-		table.BigIntColumn("size"),
-		table.TextColumn("snapshot_id"),
-		table.TextColumn("state"),
-		table.TextColumn("tags"),
-		//table.TextColumn("tags_key"),
-		//table.TextColumn("tags_value"),
-		table.BigIntColumn("throughput"),
-		table.TextColumn("volume_id"),
-		table.TextColumn("volume_type"),
-	}
-}
-
 // DescribeVolumesGenerate returns the rows in the table for all configured accounts
 func DescribeVolumesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ec2_volume", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ec2_volume",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountDescribeVolumes(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ec2_volume", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ec2_volume",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountDescribeVolumes(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ec2_volume", processAccountDescribeVolumes)
 }
 
 func processRegionDescribeVolumes(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -173,7 +75,7 @@ func processRegionDescribeVolumes(osqCtx context.Context, queryContext table.Que
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ec2_volume", accountId, *region.RegionName, row) {
 				continue
@@ -190,7 +92,7 @@ func processRegionDescribeVolumes(osqCtx context.Context, queryContext table.Que
 
 func processAccountDescribeVolumes(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -198,26 +100,14 @@ func processAccountDescribeVolumes(osqCtx context.Context, queryContext table.Qu
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ec2_volume"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ec2_volume")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ec2_volume",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ec2_volume", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionDescribeVolumes(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ec2_volume", tableConfig, account, regions, processRegionDescribeVolumes)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
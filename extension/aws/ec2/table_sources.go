@@ -0,0 +1,39 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package ec2
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_ec2_instance":                     {Type: reflect.TypeOf((*ec2.DescribeInstancesOutput)(nil)).Elem()},
+	"aws_ec2_instance_network_interface":   {Type: reflect.TypeOf((*types.InstanceNetworkInterface)(nil)).Elem()},
+	"aws_ec2_vpc":                          {Type: reflect.TypeOf((*ec2.DescribeVpcsOutput)(nil)).Elem()},
+	"aws_ec2_subnet":                       {Type: reflect.TypeOf((*ec2.DescribeSubnetsOutput)(nil)).Elem()},
+	"aws_ec2_image":                        {Type: reflect.TypeOf((*ec2.DescribeImagesOutput)(nil)).Elem()},
+	"aws_ec2_egress_only_internet_gateway": {Type: reflect.TypeOf((*ec2.DescribeEgressOnlyInternetGatewaysOutput)(nil)).Elem()},
+	"aws_ec2_internet_gateway":             {Type: reflect.TypeOf((*ec2.DescribeInternetGatewaysOutput)(nil)).Elem()},
+	"aws_ec2_nat_gateway":                  {Type: reflect.TypeOf((*ec2.DescribeNatGatewaysOutput)(nil)).Elem()},
+	"aws_ec2_network_acl":                  {Type: reflect.TypeOf((*ec2.DescribeNetworkAclsOutput)(nil)).Elem()},
+	"aws_ec2_route_table":                  {Type: reflect.TypeOf((*ec2.DescribeRouteTablesOutput)(nil)).Elem()},
+	"aws_ec2_security_group":               {Type: reflect.TypeOf((*ec2.DescribeSecurityGroupsOutput)(nil)).Elem()},
+	"aws_ec2_tag":                          {Type: reflect.TypeOf((*ec2.DescribeTagsOutput)(nil)).Elem()},
+	"aws_ec2_address":                      {Type: reflect.TypeOf((*ec2.DescribeAddressesOutput)(nil)).Elem()},
+	"aws_ec2_flowlog":                      {Type: reflect.TypeOf((*ec2.DescribeFlowLogsOutput)(nil)).Elem()},
+	"aws_ec2_keypair":                      {Type: reflect.TypeOf((*ec2.DescribeKeyPairsOutput)(nil)).Elem()},
+	"aws_ec2_snapshot":                     {Type: reflect.TypeOf((*ec2.DescribeSnapshotsOutput)(nil)).Elem()},
+	"aws_ec2_volume":                       {Type: reflect.TypeOf((*ec2.DescribeVolumesOutput)(nil)).Elem()},
+}
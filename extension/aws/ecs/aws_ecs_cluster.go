@@ -24,51 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 )
 
-// ListClustersColumns returns the list of columns in the table
-func ListClustersColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("cluster_arns"),
-	}
-}
-
 // ListClustersGenerate returns the rows in the table for all configured accounts
 func ListClustersGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_ecs_cluster", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_ecs_cluster",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListClusters(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_ecs_cluster", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_ecs_cluster",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListClusters(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_ecs_cluster", processAccountListClusters)
 }
 
 func processRegionListClusters(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -112,7 +75,7 @@ func processRegionListClusters(osqCtx context.Context, queryContext table.QueryC
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_ecs_cluster", accountId, *region.RegionName, row) {
 				continue
@@ -129,7 +92,7 @@ func processRegionListClusters(osqCtx context.Context, queryContext table.QueryC
 
 func processAccountListClusters(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -137,26 +100,14 @@ func processAccountListClusters(osqCtx context.Context, queryContext table.Query
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_ecs_cluster"]
+	tableConfig, ok := utilities.GetTableConfig("aws_ecs_cluster")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_ecs_cluster",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_ecs_cluster", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListClusters(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_ecs_cluster", tableConfig, account, regions, processRegionListClusters)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -0,0 +1,38 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"errors"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/smithy-go"
+)
+
+// ReportError records a failure to collect the rows of given table for an account, or one of its regions.
+// The API and the error code are read from the error returned by the SDK.
+func ReportError(tableName string, account string, region string, err error) {
+	collectionError := utilities.CollectionError{
+		Table:    tableName,
+		Provider: utilities.ProviderAws,
+		Account:  account,
+		Region:   region,
+		Message:  err.Error(),
+	}
+	var operationErr *smithy.OperationError
+	if errors.As(err, &operationErr) {
+		collectionError.API = operationErr.Service() + "." + operationErr.Operation()
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		collectionError.Code = apiErr.ErrorCode()
+	}
+	utilities.ReportCollectionError(collectionError)
+}
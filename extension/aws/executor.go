@@ -0,0 +1,239 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxConcurrency           = 32
+	defaultMaxConcurrencyPerAccount = 8
+)
+
+// AccountTask collects the rows of a table for given account.
+// account is nil for the default account
+type AccountTask func(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error)
+
+// RegionTask collects the rows of a table for given account and region
+type RegionTask func(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error)
+
+// RegionErrors holds the errors of the regions which failed, keyed by region
+type RegionErrors map[string]error
+
+func (e RegionErrors) Error() string {
+	regions := make([]string, 0, len(e))
+	for region := range e {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	messages := make([]string, 0, len(regions))
+	for _, region := range regions {
+		messages = append(messages, fmt.Sprintf("%s: %s", region, e[region].Error()))
+	}
+	return fmt.Sprintf("failed to process %d region(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// globalSemaphore is shared by all region tasks of all tables, and created again when maxConcurrency changes
+var globalSemaphore = struct {
+	sync.Mutex
+	size  int
+	slots chan struct{}
+}{}
+
+// GetMaxConcurrency returns the number of accounts processed in parallel
+func GetMaxConcurrency() int {
+	if utilities.GetExtConfiguration().ExtConfAws.MaxConcurrency > 0 {
+		return utilities.GetExtConfiguration().ExtConfAws.MaxConcurrency
+	}
+	return defaultMaxConcurrency
+}
+
+func getMaxConcurrencyPerAccount() int {
+	if utilities.GetExtConfiguration().ExtConfAws.MaxConcurrencyPerAccount > 0 {
+		return utilities.GetExtConfiguration().ExtConfAws.MaxConcurrencyPerAccount
+	}
+	return defaultMaxConcurrencyPerAccount
+}
+
+// getGlobalSemaphore returns the semaphore shared by all region tasks of all tables.
+// Tasks holding a slot of a previous semaphore release it there.
+func getGlobalSemaphore() chan struct{} {
+	size := GetMaxConcurrency()
+	globalSemaphore.Lock()
+	defer globalSemaphore.Unlock()
+	if globalSemaphore.slots == nil || globalSemaphore.size != size {
+		globalSemaphore.size = size
+		globalSemaphore.slots = make(chan struct{}, size)
+	}
+	return globalSemaphore.slots
+}
+
+// runTasks runs count tasks with at most limit of them in parallel.
+// If global is set, each task also holds a slot of the global semaphore while running.
+// Tasks which are not run because ctx is done are passed to skipped, with the error of ctx.
+// Results are merged in task order, so the output does not depend on scheduling.
+func runTasks(ctx context.Context, limit int, global bool, count int, task func(index int) []map[string]string, skipped func(index int, err error)) []map[string]string {
+	results := make([][]map[string]string, count)
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, limit)
+	for index := 0; index < count; index++ {
+		if ctx.Err() != nil {
+			skipped(index, ctx.Err())
+			continue
+		}
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			skipped(index, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			if ctx.Err() != nil {
+				skipped(index, ctx.Err())
+				return
+			}
+			if global {
+				semaphore := getGlobalSemaphore()
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-ctx.Done():
+					skipped(index, ctx.Err())
+					return
+				}
+			}
+			results[index] = task(index)
+		}(index)
+	}
+	wg.Wait()
+	return mergeResults(results)
+}
+
+func mergeResults(results [][]map[string]string) []map[string]string {
+	resultMap := make([]map[string]string, 0)
+	for _, result := range results {
+		resultMap = append(resultMap, result...)
+	}
+	return resultMap
+}
+
+// ProcessAccounts runs given task for the default account, or for all configured and discovered accounts in parallel.
+// Accounts which are not supposed to be processed for given table are skipped.
+// Failure of one of the configured accounts does not fail the query, it is reported as a collection error.
+// The success of an account is only recorded when none of its regions failed, the rows of the other regions are kept.
+func ProcessAccounts(osqCtx context.Context, queryContext table.QueryContext, tableName string, task AccountTask) ([]map[string]string, error) {
+	accounts := utilities.GetAwsAccounts()
+	if len(accounts) == 0 {
+		resultMap := make([]map[string]string, 0)
+		if !ShouldProcessAccount(queryContext, tableName, utilities.AwsAccountID) {
+			return resultMap, nil
+		}
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": tableName,
+			"account":   "default",
+		}).Info("processing account")
+		startTime := time.Now()
+		results, err := task(osqCtx, queryContext, nil)
+		var regionErrors RegionErrors
+		if errors.As(err, &regionErrors) {
+			// The regions which failed are reported by ProcessRegions
+			return append(resultMap, results...), nil
+		}
+		if err != nil {
+			ReportError(tableName, utilities.AwsAccountID, "", err)
+			return resultMap, err
+		}
+		utilities.RecordCollectionSuccess(tableName, utilities.AwsAccountID, startTime)
+		return append(resultMap, results...), nil
+	}
+
+	selected := make([]*utilities.ExtensionConfigurationAwsAccount, 0)
+	for index := range accounts {
+		if !ShouldProcessAccount(queryContext, tableName, accounts[index].ID) {
+			continue
+		}
+		selected = append(selected, &accounts[index])
+	}
+	resultMap := runTasks(osqCtx, GetMaxConcurrency(), false, len(selected), func(index int) []map[string]string {
+		account := selected[index]
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": tableName,
+			"account":   account.ID,
+		}).Info("processing account")
+		startTime := time.Now()
+		results, err := task(osqCtx, queryContext, account)
+		var regionErrors RegionErrors
+		if errors.As(err, &regionErrors) {
+			return results
+		}
+		if err != nil {
+			ReportError(tableName, account.ID, "", err)
+			return nil
+		}
+		utilities.RecordCollectionSuccess(tableName, account.ID, startTime)
+		return results
+	}, func(index int, err error) {
+		ReportError(tableName, selected[index].ID, "", err)
+	})
+	return resultMap, nil
+}
+
+// ProcessRegions runs given task for each of the regions of given account in parallel.
+// Regions which are not supposed to be processed for given table are skipped, as are the regions which fail.
+// Regions which are not processed because osqCtx is done fail with its error.
+// Failures are reported as collection errors, and returned as RegionErrors along with the rows of the other regions.
+func ProcessRegions(osqCtx context.Context, queryContext table.QueryContext, tableName string, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, regions []types.Region, task RegionTask) ([]map[string]string, error) {
+	accountId := utilities.AwsAccountID
+	if account != nil {
+		accountId = account.ID
+	}
+	selected := make([]types.Region, 0)
+	for _, region := range regions {
+		if !ShouldProcessRegion(queryContext, tableName, accountId, *region.RegionName) {
+			continue
+		}
+		selected = append(selected, region)
+	}
+	regionErrors := make(RegionErrors)
+	var mutex sync.Mutex
+	addError := func(index int, err error) {
+		ReportError(tableName, accountId, *selected[index].RegionName, err)
+		mutex.Lock()
+		regionErrors[*selected[index].RegionName] = err
+		mutex.Unlock()
+	}
+	resultMap := runTasks(osqCtx, getMaxConcurrencyPerAccount(), true, len(selected), func(index int) []map[string]string {
+		results, err := task(osqCtx, queryContext, tableConfig, account, selected[index])
+		if err != nil {
+			addError(index, err)
+			return nil
+		}
+		return results
+	}, addError)
+	if len(regionErrors) > 0 {
+		return resultMap, regionErrors
+	}
+	return resultMap, nil
+}
@@ -0,0 +1,184 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTasks(t *testing.T) {
+	var running, maxRunning int32
+	result := runTasks(context.Background(), 3, true, 10, func(index int) []map[string]string {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return []map[string]string{{"index": fmt.Sprint(index)}}
+	}, func(index int, err error) {
+		t.Errorf("task %d skipped: %s", index, err)
+	})
+
+	assert.Equal(t, 10, len(result))
+	for index, row := range result {
+		assert.Equal(t, fmt.Sprint(index), row["index"])
+	}
+	assert.LessOrEqual(t, maxRunning, int32(3))
+}
+
+func TestProcessRegions(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(tableConfigJSON))
+	assert.Nil(t, err)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"region_code": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorLike, Expression: "us-%"},
+				},
+			},
+		},
+	}
+	regions := []types.Region{
+		{RegionName: aws.String("us-east-1")},
+		{RegionName: aws.String("eu-west-1")},
+		{RegionName: aws.String("us-west-2")},
+	}
+	account := &utilities.ExtensionConfigurationAwsAccount{ID: "test-account"}
+	result, err := ProcessRegions(context.Background(), queryContext, "test_table_1", nil, account, regions,
+		func(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+			if *region.RegionName == "us-west-2" {
+				return nil, fmt.Errorf("throttled")
+			}
+			return []map[string]string{{"account_id": account.ID, "region_code": *region.RegionName}}, nil
+		})
+
+	assert.Equal(t, []map[string]string{{"account_id": "test-account", "region_code": "us-east-1"}}, result)
+	regionErrors, ok := err.(RegionErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(regionErrors))
+	assert.NotNil(t, regionErrors["us-west-2"])
+
+	// Regions which are not processed once the query is cancelled fail, so that the account is not recorded as successful
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = ProcessRegions(ctx, queryContext, "test_table_1", nil, account, regions,
+		func(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+			return []map[string]string{{"account_id": account.ID, "region_code": *region.RegionName}}, nil
+		})
+	assert.Equal(t, 0, len(result))
+	regionErrors, ok = err.(RegionErrors)
+	assert.True(t, ok)
+	assert.Equal(t, RegionErrors{"us-east-1": context.Canceled, "us-west-2": context.Canceled}, regionErrors)
+
+	// Regions waiting for the global semaphore when the query times out fail too
+	semaphore := getGlobalSemaphore()
+	for len(semaphore) < cap(semaphore) {
+		semaphore <- struct{}{}
+	}
+	defer func() {
+		for len(semaphore) > 0 {
+			<-semaphore
+		}
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ProcessRegions(ctx, queryContext, "test_table_1", nil, account, regions,
+		func(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+			return []map[string]string{{"account_id": account.ID, "region_code": *region.RegionName}}, nil
+		})
+	assert.Equal(t, RegionErrors{"us-east-1": context.DeadlineExceeded, "us-west-2": context.DeadlineExceeded}, err)
+}
+
+func TestProcessAccounts(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(tableConfigJSON))
+	assert.Nil(t, err)
+
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.Accounts = []utilities.ExtensionConfigurationAwsAccount{
+		{ID: "111"}, {ID: "222"}, {ID: "333"},
+	}
+	utilities.SetExtConfiguration(&extConfig)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"account_id": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "111"},
+					{Operator: table.OperatorEquals, Expression: "333"},
+				},
+			},
+		},
+	}
+	result, err := ProcessAccounts(context.Background(), queryContext, "test_table_1",
+		func(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
+			return []map[string]string{{"account_id": account.ID}}, nil
+		})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"account_id": "111"}, {"account_id": "333"}}, result)
+}
+
+func TestProcessAccountsRegionErrors(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.Accounts = []utilities.ExtensionConfigurationAwsAccount{{ID: "444"}, {ID: "555"}}
+	utilities.SetExtConfiguration(&extConfig)
+
+	result, err := ProcessAccounts(context.Background(), table.QueryContext{}, "aws_test_region_errors",
+		func(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
+			if account.ID == "555" {
+				return []map[string]string{{"account_id": account.ID}}, RegionErrors{"us-west-2": fmt.Errorf("access denied")}
+			}
+			return []map[string]string{{"account_id": account.ID}}, nil
+		})
+	assert.Nil(t, err)
+	// The rows of the regions which did not fail are kept
+	assert.Equal(t, []map[string]string{{"account_id": "444"}, {"account_id": "555"}}, result)
+
+	// Only the accounts without failed regions are successful
+	for _, status := range utilities.GetCollectionStatuses(utilities.ProviderAws, "444") {
+		assert.False(t, status.LastSuccess.IsZero())
+	}
+	for _, status := range utilities.GetCollectionStatuses(utilities.ProviderAws, "555") {
+		assert.True(t, status.LastSuccess.IsZero())
+	}
+}
+
+func TestGetGlobalSemaphore(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.MaxConcurrency = 2
+	utilities.SetExtConfiguration(&extConfig)
+	assert.Equal(t, 2, cap(getGlobalSemaphore()))
+
+	// A reloaded limit replaces the semaphore
+	extConfig.ExtConfAws.MaxConcurrency = 5
+	utilities.SetExtConfiguration(&extConfig)
+	assert.Equal(t, 5, cap(getGlobalSemaphore()))
+}
@@ -12,28 +12,44 @@ package aws
 import (
 	"context"
 	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
 )
 
-// ShouldProcessAccount returns false if given account is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
-func ShouldProcessAccount(tableName string, accountId string) bool {
-	return true
+// ShouldProcessAccount returns false if given account is not supposed to be processed for given table.
+// Accounts excluded by the filter rules, or which can not satisfy the account id constraints of the query are skipped.
+func ShouldProcessAccount(queryContext table.QueryContext, tableName string, accountId string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAws.Filters, utilities.FilterLevelAccount, tableName, accountId, "", nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Aws.AccountIDAttribute, accountId)
 }
 
-// ShouldProcessRegion returns false if given region for given account is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
-func ShouldProcessRegion(tableName string, accountId string, region string) bool {
-	return true
+// ShouldProcessRegion returns false if given region for given account is not supposed to be processed for given table.
+// Regions excluded by the filter rules, or which can not satisfy the region constraints of the query are skipped.
+func ShouldProcessRegion(queryContext table.QueryContext, tableName string, accountId string, region string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAws.Filters, utilities.FilterLevelRegion, tableName, accountId, region, nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	if !utilities.MatchConstraints(queryContext, tableConfig.Aws.RegionCodeAttribute, region) {
+		return false
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Aws.RegionAttribute, region)
 }
 
-// ShouldProcessRow returns false if given row is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
+// ShouldProcessRow returns false if given row is excluded by the filter rules for given table
 func ShouldProcessRow(osqCtx context.Context, queryContext table.QueryContext, tableName string, accountId string, region string, row map[string]interface{}) bool {
-	return true
+	return utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAws.Filters, utilities.FilterLevelRow, tableName, accountId, region, utilities.InterfaceRow(row))
 }
 
-// ShouldProcessEvent returns false if given event is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
+// ShouldProcessEvent returns false if given event is excluded by the filter rules for given table
 func ShouldProcessEvent(tableName string, accountId string, region string, row map[string]string) bool {
-	return true
+	return utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAws.Filters, utilities.FilterLevelRow, tableName, accountId, region, utilities.StringRow(row))
 }
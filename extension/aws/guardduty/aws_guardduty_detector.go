@@ -24,52 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/guardduty"
 )
 
-// ListDetectorsColumns returns the list of columns in the table
-func ListDetectorsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("detector_ids"),
-		//table.TextColumn("next_token"),
-		//table.TextColumn("result_metadata"),
-		//table.TextColumn("result_metadata_values"),
-
-	}
-}
-
 // ListDetectorsGenerate returns the rows in the table for all configured accounts
 func ListDetectorsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_guardduty_detector",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListDetectors(nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_guardduty_detector",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListDetectors(&account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_guardduty_detector", processAccountListDetectors)
 }
 
-func processRegionListDetectors(tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
+func processRegionListDetectors(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -91,7 +53,7 @@ func processRegionListDetectors(tableConfig *utilities.TableConfig, account *uti
 	paginator := guardduty.NewListDetectorsPaginator(svc, params)
 
 	for {
-		page, err := paginator.NextPage(context.TODO())
+		page, err := paginator.NextPage(osqCtx)
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": "aws_guardduty_detector",
@@ -113,8 +75,11 @@ func processRegionListDetectors(tableConfig *utilities.TableConfig, account *uti
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_guardduty_detector", accountId, *region.RegionName, row) {
+				continue
+			}
 			result := extaws.RowToMap(row, accountId, *region.RegionName, tableConfig)
 			resultMap = append(resultMap, result)
 		}
@@ -125,29 +90,24 @@ func processRegionListDetectors(tableConfig *utilities.TableConfig, account *uti
 	return resultMap, nil
 }
 
-func processAccountListDetectors(account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
+func processAccountListDetectors(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
-	regions, err := extaws.FetchRegions(context.TODO(), awsSession)
+	regions, err := extaws.FetchRegions(osqCtx, awsSession)
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_guardduty_detector"]
+	tableConfig, ok := utilities.GetTableConfig("aws_guardduty_detector")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_guardduty_detector",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		result, err := processRegionListDetectors(tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_guardduty_detector", tableConfig, account, regions, processRegionListDetectors)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
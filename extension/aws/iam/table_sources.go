@@ -0,0 +1,26 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package iam
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_iam_user":                    {Type: reflect.TypeOf((*iam.ListUsersOutput)(nil)).Elem()},
+	"aws_iam_role":                    {Type: reflect.TypeOf((*iam.ListRolesOutput)(nil)).Elem()},
+	"aws_iam_group":                   {Type: reflect.TypeOf((*iam.ListGroupsOutput)(nil)).Elem()},
+	"aws_iam_policy":                  {Type: reflect.TypeOf((*iam.ListPoliciesOutput)(nil)).Elem()},
+	"aws_iam_account_password_policy": {Type: reflect.TypeOf((*iam.GetAccountPasswordPolicyOutput)(nil)).Elem()},
+}
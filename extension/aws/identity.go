@@ -0,0 +1,59 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// GetAccountIdentity returns the ARN the credentials of given account resolve to, using STS GetCallerIdentity.
+// account is nil for the default account.
+func GetAccountIdentity(ctx context.Context, account *utilities.ExtensionConfigurationAwsAccount) (utilities.AccountIdentity, error) {
+	identity := utilities.AccountIdentity{CredentialSource: getCredentialSource(account)}
+	cfg, err := GetAwsConfig(ctx, account, "us-east-1")
+	if err != nil {
+		return identity, err
+	}
+	if cfg.Credentials == nil {
+		return identity, fmt.Errorf("no credentials found")
+	}
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return identity, err
+	}
+	if credentials.CanExpire {
+		identity.Expiry = credentials.Expires
+	}
+	output, err := sts.NewFromConfig(*cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return identity, err
+	}
+	identity.Identity = aws.ToString(output.Arn)
+	return identity, nil
+}
+
+// getCredentialSource returns how the credentials of given account are created, following GetAwsConfig
+func getCredentialSource(account *utilities.ExtensionConfigurationAwsAccount) string {
+	if account == nil {
+		return "default"
+	} else if len(account.RoleArn) != 0 || len(account.RoleChain) != 0 {
+		return "role"
+	} else if len(account.WebIdentityTokenFile) != 0 {
+		return "web_identity"
+	} else if len(account.ProfileName) != 0 {
+		return "profile"
+	}
+	return "default"
+}
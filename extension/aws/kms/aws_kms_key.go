@@ -24,53 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/kms"
 )
 
-// ListKeysColumns returns the list of columns in the table
-func ListKeysColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("region"),
-		table.TextColumn("key_arn"),
-		table.TextColumn("key_id"),
-	}
-}
-
 // ListKeysGenerate returns the rows in the table for all configured accounts
 func ListKeysGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_kms_key", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_kms_key",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListKeys(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_kms_key", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_kms_key",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListKeys(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_kms_key", processAccountListKeys)
 }
 
 func processRegionListKeys(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -114,7 +75,7 @@ func processRegionListKeys(osqCtx context.Context, queryContext table.QueryConte
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_kms_key", accountId, *region.RegionName, row) {
 				continue
@@ -131,7 +92,7 @@ func processRegionListKeys(osqCtx context.Context, queryContext table.QueryConte
 
 func processAccountListKeys(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -139,26 +100,14 @@ func processAccountListKeys(osqCtx context.Context, queryContext table.QueryCont
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_kms_key"]
+	tableConfig, ok := utilities.GetTableConfig("aws_kms_key")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_kms_key",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_kms_key", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListKeys(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_kms_key", tableConfig, account, regions, processRegionListKeys)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
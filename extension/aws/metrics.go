@@ -0,0 +1,59 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// throttleErrorCodes are the error codes of the AWS APIs rejecting a request because of its rate
+var throttleErrorCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"BandwidthLimitExceeded":                 true,
+	"LimitExceededException":                 true,
+	"RequestThrottled":                       true,
+	"SlowDown":                               true,
+	"EC2ThrottledException":                  true,
+}
+
+func isThrottleError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && throttleErrorCodes[apiErr.ErrorCode()]
+}
+
+// addMetricsMiddleware counts the API calls made with given config.
+// The middleware runs after the retry middleware, so that every attempt is counted.
+func addMetricsMiddleware(cfg *aws.Config, accountId string) {
+	configRegion := cfg.Region
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CloudqueryMetrics", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			region := awsmiddleware.GetRegion(ctx)
+			if region == "" {
+				region = configRegion
+			}
+			utilities.RecordAPICall(utilities.GetTableName(ctx), utilities.ProviderAws, accountId, region, err != nil, isThrottleError(err))
+			return out, metadata, err
+		}), middleware.After)
+	})
+}
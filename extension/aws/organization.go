@@ -0,0 +1,253 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultOrganizationRefreshInterval is the interval at which the accounts of the organization are listed again
+const defaultOrganizationRefreshInterval = time.Hour
+
+// organizationsAPI is the part of the Organizations API used to discover the accounts of an organization
+type organizationsAPI interface {
+	organizations.ListAccountsAPIClient
+	organizations.ListAccountsForParentAPIClient
+	organizations.ListOrganizationalUnitsForParentAPIClient
+	organizations.ListTagsForResourceAPIClient
+	DescribeOrganization(ctx context.Context, params *organizations.DescribeOrganizationInput, optFns ...func(*organizations.Options)) (*organizations.DescribeOrganizationOutput, error)
+}
+
+// discoveryError is a failure to list the accounts of the organization or of an organizational unit, with its id
+type discoveryError struct {
+	id  string
+	err error
+}
+
+func (e *discoveryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *discoveryError) Unwrap() error {
+	return e.err
+}
+
+// GetOrganizationRefreshInterval returns the interval at which the accounts of given organization are listed again
+func GetOrganizationRefreshInterval(org *utilities.ExtensionConfigurationAwsOrganization) time.Duration {
+	if org == nil || org.RefreshInterval == 0 {
+		return defaultOrganizationRefreshInterval
+	}
+	return time.Duration(org.RefreshInterval) * time.Second
+}
+
+// DiscoverOrganizationAccounts returns the active accounts of given organization, selected by its organizational units and tags.
+// The member accounts are accessed by assuming the configured role, the management account with its own credentials.
+func DiscoverOrganizationAccounts(ctx context.Context, org *utilities.ExtensionConfigurationAwsOrganization) ([]utilities.ExtensionConfigurationAwsAccount, error) {
+	cfg, err := GetAwsConfig(ctx, getOrganizationCredentials(org), "aws-global")
+	if err != nil {
+		return nil, err
+	}
+	return discoverAccounts(ctx, organizations.NewFromConfig(*cfg), org)
+}
+
+func discoverAccounts(ctx context.Context, svc organizationsAPI, org *utilities.ExtensionConfigurationAwsOrganization) ([]utilities.ExtensionConfigurationAwsAccount, error) {
+	output, err := svc.DescribeOrganization(ctx, &organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return nil, err
+	}
+	managementAccountID := ""
+	organizationID := ""
+	if output.Organization != nil {
+		managementAccountID = aws.ToString(output.Organization.MasterAccountId)
+		organizationID = aws.ToString(output.Organization.Id)
+	}
+
+	members := make(map[string]types.Account)
+	if len(org.OrganizationalUnits) == 0 {
+		paginator := organizations.NewListAccountsPaginator(svc, &organizations.ListAccountsInput{})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, &discoveryError{id: organizationID, err: err}
+			}
+			for _, member := range page.Accounts {
+				members[aws.ToString(member.Id)] = member
+			}
+		}
+	}
+	for _, parentID := range org.OrganizationalUnits {
+		if err := listAccountsForParent(ctx, svc, parentID, members); err != nil {
+			return nil, err
+		}
+	}
+
+	accounts := make([]utilities.ExtensionConfigurationAwsAccount, 0, len(members))
+	for id, member := range members {
+		if member.Status != types.AccountStatusActive {
+			continue
+		}
+		if len(org.Tags) > 0 {
+			matched, err := matchAccountTags(ctx, svc, id, org.Tags)
+			if err != nil {
+				return nil, &discoveryError{id: organizationID, err: err}
+			}
+			if !matched {
+				continue
+			}
+		}
+		account := getManagementAccount(org)
+		account.ID = id
+		if id != managementAccountID {
+			account.RoleArn = getMemberRoleArn(member, org.RoleName)
+			account.ExternalID = org.ExternalID
+			account.SessionName = org.SessionName
+			account.SessionTags = org.SessionTags
+			account.SessionDuration = org.SessionDuration
+		}
+		accounts = append(accounts, *account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts, nil
+}
+
+// getOrganizationCredentials returns the account the organization is accessed with, or nil to use the default credentials
+func getOrganizationCredentials(org *utilities.ExtensionConfigurationAwsOrganization) *utilities.ExtensionConfigurationAwsAccount {
+	if org.CredentialFile == "" && org.ConfigFile == "" && org.ProfileName == "" && org.WebIdentityTokenFile == "" && len(org.RoleChain) == 0 {
+		return nil
+	}
+	management := getManagementAccount(org)
+	management.ID = "organization"
+	return management
+}
+
+// getManagementAccount returns the credential options of the management account of given organization
+func getManagementAccount(org *utilities.ExtensionConfigurationAwsOrganization) *utilities.ExtensionConfigurationAwsAccount {
+	return &utilities.ExtensionConfigurationAwsAccount{
+		CredentialFile:       org.CredentialFile,
+		ConfigFile:           org.ConfigFile,
+		ProfileName:          org.ProfileName,
+		WebIdentityTokenFile: org.WebIdentityTokenFile,
+		WebIdentityRoleArn:   org.WebIdentityRoleArn,
+		RoleChain:            org.RoleChain,
+	}
+}
+
+// listAccountsForParent adds the accounts of given organizational unit, and of its children, to members
+func listAccountsForParent(ctx context.Context, svc organizationsAPI, parentID string, members map[string]types.Account) error {
+	accountPaginator := organizations.NewListAccountsForParentPaginator(svc, &organizations.ListAccountsForParentInput{ParentId: aws.String(parentID)})
+	for accountPaginator.HasMorePages() {
+		page, err := accountPaginator.NextPage(ctx)
+		if err != nil {
+			return &discoveryError{id: parentID, err: err}
+		}
+		for _, member := range page.Accounts {
+			members[aws.ToString(member.Id)] = member
+		}
+	}
+	unitPaginator := organizations.NewListOrganizationalUnitsForParentPaginator(svc, &organizations.ListOrganizationalUnitsForParentInput{ParentId: aws.String(parentID)})
+	for unitPaginator.HasMorePages() {
+		page, err := unitPaginator.NextPage(ctx)
+		if err != nil {
+			return &discoveryError{id: parentID, err: err}
+		}
+		for _, unit := range page.OrganizationalUnits {
+			if err := listAccountsForParent(ctx, svc, aws.ToString(unit.Id), members); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchAccountTags returns true if given account has all of the tags, with a value matching one of their patterns
+func matchAccountTags(ctx context.Context, svc organizationsAPI, accountID string, tags map[string][]string) (bool, error) {
+	accountTags := make(map[string]string)
+	paginator := organizations.NewListTagsForResourcePaginator(svc, &organizations.ListTagsForResourceInput{ResourceId: aws.String(accountID)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, tag := range page.Tags {
+			accountTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	for key, patterns := range tags {
+		value, found := accountTags[key]
+		if !found {
+			return false, nil
+		}
+		matched := false
+		for _, pattern := range patterns {
+			if utilities.MatchGlob(pattern, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getMemberRoleArn returns the ARN of the role to assume in given member account, in the partition of the organization
+func getMemberRoleArn(member types.Account, roleName string) string {
+	partition := "aws"
+	if parts := strings.Split(aws.ToString(member.Arn), ":"); len(parts) > 1 && parts[1] != "" {
+		partition = parts[1]
+	}
+	accountID := aws.ToString(member.Id)
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, strings.ReplaceAll(roleName, "{accountId}", accountID))
+}
+
+// RefreshOrganizationAccounts lists the accounts of the configured organization again, and replaces the discovered accounts.
+// The accounts discovered before are kept if the listing fails, and dropped if no organization is configured.
+func RefreshOrganizationAccounts(ctx context.Context) {
+	org := utilities.GetExtConfiguration().ExtConfAws.Organization
+	if org == nil {
+		utilities.SetDiscoveredAwsAccounts(nil)
+		return
+	}
+	accounts, err := DiscoverOrganizationAccounts(ctx, org)
+	if err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"errString": err.Error(),
+		}).Error("failed to discover organization accounts")
+		ReportError(utilities.DiscoveryTable, getDiscoveryErrorID(err), "", err)
+		return
+	}
+	utilities.GetLogger().WithFields(log.Fields{
+		"totalAccounts": len(accounts),
+	}).Info("discovered organization accounts")
+	utilities.SetDiscoveredAwsAccounts(accounts)
+}
+
+// getDiscoveryErrorID returns the id of the organization or organizational unit whose accounts failed to be listed, if known
+func getDiscoveryErrorID(err error) string {
+	var discoveryErr *discoveryError
+	if errors.As(err, &discoveryErr) {
+		return discoveryErr.id
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOrganization is an organization whose root r-1 holds the management account and ou-1,
+// ou-1 holds an account and ou-2, which holds a suspended account and a tagged account
+type fakeOrganization struct{}
+
+func newFakeAccount(id string, status types.AccountStatus) types.Account {
+	return types.Account{Id: aws.String(id), Arn: aws.String("arn:aws-us-gov:organizations::111:account/o-1/" + id), Status: status}
+}
+
+var fakeAccounts = map[string][]types.Account{
+	"r-1":  {newFakeAccount("111", types.AccountStatusActive)},
+	"ou-1": {newFakeAccount("222", types.AccountStatusActive)},
+	"ou-2": {newFakeAccount("333", types.AccountStatusSuspended), newFakeAccount("444", types.AccountStatusActive)},
+}
+
+var fakeUnits = map[string][]types.OrganizationalUnit{
+	"r-1":  {{Id: aws.String("ou-1")}},
+	"ou-1": {{Id: aws.String("ou-2")}},
+}
+
+func (fakeOrganization) DescribeOrganization(ctx context.Context, params *organizations.DescribeOrganizationInput, optFns ...func(*organizations.Options)) (*organizations.DescribeOrganizationOutput, error) {
+	return &organizations.DescribeOrganizationOutput{Organization: &types.Organization{Id: aws.String("o-1"), MasterAccountId: aws.String("111")}}, nil
+}
+
+func (fakeOrganization) ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	accounts := make([]types.Account, 0)
+	for _, parentID := range []string{"r-1", "ou-1", "ou-2"} {
+		accounts = append(accounts, fakeAccounts[parentID]...)
+	}
+	return &organizations.ListAccountsOutput{Accounts: accounts}, nil
+}
+
+func (fakeOrganization) ListAccountsForParent(ctx context.Context, params *organizations.ListAccountsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsForParentOutput, error) {
+	if *params.ParentId == "ou-denied" {
+		return nil, errors.New("access denied")
+	}
+	return &organizations.ListAccountsForParentOutput{Accounts: fakeAccounts[*params.ParentId]}, nil
+}
+
+func (fakeOrganization) ListOrganizationalUnitsForParent(ctx context.Context, params *organizations.ListOrganizationalUnitsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListOrganizationalUnitsForParentOutput, error) {
+	return &organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: fakeUnits[*params.ParentId]}, nil
+}
+
+func (fakeOrganization) ListTagsForResource(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error) {
+	if *params.ResourceId == "444" {
+		return &organizations.ListTagsForResourceOutput{Tags: []types.Tag{{Key: aws.String("env"), Value: aws.String("prod-1")}}}, nil
+	}
+	return &organizations.ListTagsForResourceOutput{}, nil
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	org := &utilities.ExtensionConfigurationAwsOrganization{ProfileName: "management", RoleName: "audit-{accountId}", ExternalID: "external"}
+	accounts, err := discoverAccounts(context.Background(), fakeOrganization{}, org)
+	assert.Nil(t, err)
+	assert.Equal(t, []utilities.ExtensionConfigurationAwsAccount{
+		{ID: "111", ProfileName: "management"},
+		{ID: "222", ProfileName: "management", RoleArn: "arn:aws-us-gov:iam::222:role/audit-222", ExternalID: "external"},
+		{ID: "444", ProfileName: "management", RoleArn: "arn:aws-us-gov:iam::444:role/audit-444", ExternalID: "external"},
+	}, accounts)
+
+	// Accounts of the organizational units and their children
+	org.OrganizationalUnits = []string{"ou-1"}
+	accounts, err = discoverAccounts(context.Background(), fakeOrganization{}, org)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(accounts))
+	assert.Equal(t, "222", accounts[0].ID)
+
+	org.Tags = map[string][]string{"env": {"prod-*"}}
+	accounts, err = discoverAccounts(context.Background(), fakeOrganization{}, org)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "444", accounts[0].ID)
+
+	// Failures are reported with the id of the organizational unit
+	org.OrganizationalUnits = []string{"ou-1", "ou-denied"}
+	_, err = discoverAccounts(context.Background(), fakeOrganization{}, org)
+	assert.NotNil(t, err)
+	assert.Equal(t, "ou-denied", getDiscoveryErrorID(err))
+	assert.Equal(t, "", getDiscoveryErrorID(errors.New("access denied")))
+}
+
+func TestGetOrganizationCredentials(t *testing.T) {
+	assert.Nil(t, getOrganizationCredentials(&utilities.ExtensionConfigurationAwsOrganization{RoleName: "audit"}))
+
+	// Credential and config files without a profile use their default profile
+	for _, org := range []*utilities.ExtensionConfigurationAwsOrganization{
+		{CredentialFile: "/etc/cloudquery/credentials"},
+		{ConfigFile: "/etc/cloudquery/config"},
+		{ProfileName: "management"},
+		{WebIdentityTokenFile: "/var/run/token", WebIdentityRoleArn: "arn:aws:iam::111:role/audit"},
+		{RoleChain: []utilities.ExtensionConfigurationAwsRole{{RoleArn: "arn:aws:iam::111:role/audit"}}},
+	} {
+		management := getOrganizationCredentials(org)
+		if assert.NotNil(t, management) {
+			assert.Equal(t, "organization", management.ID)
+			assert.Equal(t, org.CredentialFile, management.CredentialFile)
+			assert.Equal(t, org.ConfigFile, management.ConfigFile)
+		}
+	}
+}
@@ -23,97 +23,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 )
 
-// ListDelegatedAdministratorsColumns returns the list of columns in the table
-func ListDelegatedAdministratorsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("arn"),
-		table.TextColumn("delegation_enabled_date"),
-		//table.BigIntColumn("delegation_enabled_date_ext"),
-		//table.TextColumn("delegation_enabled_date_loc"),
-		//table.BigIntColumn("delegation_enabled_date_loc_cache_end"),
-		//table.BigIntColumn("delegation_enabled_date_loc_cache_start"),
-		//table.TextColumn("delegation_enabled_date_loc_cache_zone"),
-		//table.TextColumn("delegation_enabled_date_loc_cache_zone_is_dst"),
-		//table.TextColumn("delegation_enabled_date_loc_cache_zone_name"),
-		//table.IntegerColumn("delegation_enabled_date_loc_cache_zone_offset"),
-		//table.TextColumn("delegation_enabled_date_loc_name"),
-		//table.TextColumn("delegation_enabled_date_loc_tx"),
-		//table.IntegerColumn("delegation_enabled_date_loc_tx_index"),
-		//table.TextColumn("delegation_enabled_date_loc_tx_isstd"),
-		//table.TextColumn("delegation_enabled_date_loc_tx_isutc"),
-		//table.BigIntColumn("delegation_enabled_date_loc_tx_when"),
-		//table.TextColumn("delegation_enabled_date_loc_zone"),
-		//table.TextColumn("delegation_enabled_date_loc_zone_is_dst"),
-		//table.TextColumn("delegation_enabled_date_loc_zone_name"),
-		//table.IntegerColumn("delegation_enabled_date_loc_zone_offset"),
-		//table.BigIntColumn("delegation_enabled_date_wall"),
-		table.TextColumn("email"),
-		table.TextColumn("id"),
-		table.TextColumn("joined_method"),
-		table.TextColumn("joined_timestamp"),
-		//table.BigIntColumn("joined_timestamp_ext"),
-		//table.TextColumn("joined_timestamp_loc"),
-		//table.BigIntColumn("joined_timestamp_loc_cache_end"),
-		//table.BigIntColumn("joined_timestamp_loc_cache_start"),
-		//table.TextColumn("joined_timestamp_loc_cache_zone"),
-		//table.TextColumn("joined_timestamp_loc_cache_zone_is_dst"),
-		//table.TextColumn("joined_timestamp_loc_cache_zone_name"),
-		//table.IntegerColumn("joined_timestamp_loc_cache_zone_offset"),
-		//table.TextColumn("joined_timestamp_loc_name"),
-		//table.TextColumn("joined_timestamp_loc_tx"),
-		//table.IntegerColumn("joined_timestamp_loc_tx_index"),
-		//table.TextColumn("joined_timestamp_loc_tx_isstd"),
-		//table.TextColumn("joined_timestamp_loc_tx_isutc"),
-		//table.BigIntColumn("joined_timestamp_loc_tx_when"),
-		//table.TextColumn("joined_timestamp_loc_zone"),
-		//table.TextColumn("joined_timestamp_loc_zone_is_dst"),
-		//table.TextColumn("joined_timestamp_loc_zone_name"),
-		//table.IntegerColumn("joined_timestamp_loc_zone_offset"),
-		//table.BigIntColumn("joined_timestamp_wall"),
-		table.TextColumn("name"),
-		table.TextColumn("status"),
-		//table.TextColumn("values"),
-
-	}
-}
-
 // ListDelegatedAdministratorsGenerate returns the rows in the table for all configured accounts
 func ListDelegatedAdministratorsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_organizations_delegated_administrator", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_organizations_delegated_administrator",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListDelegatedAdministrators(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_organizations_delegated_administrator", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_organizations_delegated_administrator",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListDelegatedAdministrators(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_organizations_delegated_administrator", processAccountListDelegatedAdministrators)
 }
 
 func processGlobalListDelegatedAdministrators(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, "aws-global")
+	sess, err := extaws.GetAwsConfig(osqCtx, account, "aws-global")
 	if err != nil {
 		return resultMap, err
 	}
@@ -157,7 +74,7 @@ func processGlobalListDelegatedAdministrators(osqCtx context.Context, queryConte
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_organizations_delegated_administrator", accountId, "aws-global", row) {
 				continue
@@ -174,13 +91,20 @@ func processGlobalListDelegatedAdministrators(osqCtx context.Context, queryConte
 
 func processAccountListDelegatedAdministrators(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	tableConfig, ok := utilities.TableConfigurationMap["aws_organizations_delegated_administrator"]
+	tableConfig, ok := utilities.GetTableConfig("aws_organizations_delegated_administrator")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_organizations_delegated_administrator",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
+	accountId := utilities.AwsAccountID
+	if account != nil {
+		accountId = account.ID
+	}
+	if !extaws.ShouldProcessRegion(queryContext, "aws_organizations_delegated_administrator", accountId, "aws-global") {
+		return resultMap, nil
+	}
 	result, err := processGlobalListDelegatedAdministrators(osqCtx, queryContext, tableConfig, account)
 	if err != nil {
 		return resultMap, err
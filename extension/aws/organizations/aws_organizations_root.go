@@ -23,57 +23,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 )
 
-// ListRootsColumns returns the list of columns in the table
-func ListRootsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		//table.TextColumn("values"),
-		table.TextColumn("arn"),
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		table.TextColumn("policy_types"),
-		//table.TextColumn("policy_types_status"),
-		//table.TextColumn("policy_types_type"),
-
-	}
-}
-
 // ListRootsGenerate returns the rows in the table for all configured accounts
 func ListRootsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_organizations_root", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_organizations_root",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListRoots(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_organizations_root", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_organizations_root",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListRoots(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_organizations_root", processAccountListRoots)
 }
 
 func processGlobalListRoots(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, "aws-global")
+	sess, err := extaws.GetAwsConfig(osqCtx, account, "aws-global")
 	if err != nil {
 		return resultMap, err
 	}
@@ -117,7 +74,7 @@ func processGlobalListRoots(osqCtx context.Context, queryContext table.QueryCont
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_organizations_root", accountId, "aws-global", row) {
 				continue
@@ -134,13 +91,20 @@ func processGlobalListRoots(osqCtx context.Context, queryContext table.QueryCont
 
 func processAccountListRoots(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	tableConfig, ok := utilities.TableConfigurationMap["aws_organizations_root"]
+	tableConfig, ok := utilities.GetTableConfig("aws_organizations_root")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_organizations_root",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
+	accountId := utilities.AwsAccountID
+	if account != nil {
+		accountId = account.ID
+	}
+	if !extaws.ShouldProcessRegion(queryContext, "aws_organizations_root", accountId, "aws-global") {
+		return resultMap, nil
+	}
 	result, err := processGlobalListRoots(osqCtx, queryContext, tableConfig, account)
 	if err != nil {
 		return resultMap, err
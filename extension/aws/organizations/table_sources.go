@@ -0,0 +1,25 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package organizations
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_organizations_organization":            {Type: reflect.TypeOf((*organizations.DescribeOrganizationOutput)(nil)).Elem()},
+	"aws_organizations_account":                 {Type: reflect.TypeOf((*organizations.ListAccountsOutput)(nil)).Elem()},
+	"aws_organizations_root":                    {Type: reflect.TypeOf((*organizations.ListRootsOutput)(nil)).Elem()},
+	"aws_organizations_delegated_administrator": {Type: reflect.TypeOf((*organizations.ListDelegatedAdministratorsOutput)(nil)).Elem()},
+}
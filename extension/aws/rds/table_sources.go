@@ -0,0 +1,24 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package rds
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"aws_rds_snapshot": {Type: reflect.TypeOf((*rds.DescribeDBClusterSnapshotsOutput)(nil)).Elem()},
+	"aws_rds_instance": {Type: reflect.TypeOf((*rds.DescribeDBInstancesOutput)(nil)).Elem()},
+	"aws_rds_cluster":  {Type: reflect.TypeOf((*rds.DescribeDBClustersOutput)(nil)).Elem()},
+}
@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+type retryerKey struct {
+	accountId string
+	service   string
+}
+
+type cachedRetryer struct {
+	policy  utilities.RetryPolicy
+	retryer aws.Retryer
+}
+
+// retryers holds the retryers of the services of each account, so that the calls of a service share its retry quota
+var retryers = struct {
+	sync.Mutex
+	retryers map[retryerKey]cachedRetryer
+}{retryers: make(map[retryerKey]cachedRetryer)}
+
+// getRetryer returns the retryer of given service of an account, created again when its retry policy changes
+func getRetryer(accountId string, service string, policy utilities.RetryPolicy) aws.Retryer {
+	retryers.Lock()
+	defer retryers.Unlock()
+	key := retryerKey{accountId: accountId, service: service}
+	if cached, found := retryers.retryers[key]; found && cached.policy == policy {
+		return cached.retryer
+	}
+	retryer := retry.NewStandard(func(options *retry.StandardOptions) {
+		options.MaxAttempts = policy.MaxAttempts
+		options.MaxBackoff = policy.GetMaxBackoff()
+	})
+	retryers.retryers[key] = cachedRetryer{policy: policy, retryer: retryer}
+	return retryer
+}
+
+// retryMiddleware replaces the retry middleware of the SDK, to retry with the policy of the service of each call
+type retryMiddleware struct {
+	accountId string
+}
+
+func (retryMiddleware) ID() string {
+	return "Retry"
+}
+
+func (m retryMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	service := awsmiddleware.GetServiceID(ctx)
+	retryer := getRetryer(m.accountId, service, utilities.GetRetryPolicy(utilities.ProviderAws, service))
+	out, metadata, err := retry.NewAttemptMiddleware(retryer, smithyhttp.RequestCloner).HandleFinalize(ctx, in, next)
+
+	retries, throttles := 0, 0
+	if results, ok := retry.GetAttemptResults(metadata); ok {
+		for _, result := range results.Results {
+			if result.Retried {
+				retries++
+			}
+			if isThrottleError(result.Err) {
+				throttles++
+			}
+		}
+	}
+	utilities.LogRetries(ctx, utilities.ProviderAws, service, m.accountId, retries, throttles, err)
+	return out, metadata, err
+}
+
+// rateLimitMiddleware limits the rate of the attempts of the API calls
+type rateLimitMiddleware struct {
+	accountId string
+}
+
+func (rateLimitMiddleware) ID() string {
+	return "CloudqueryRateLimit"
+}
+
+func (m rateLimitMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	if err := utilities.WaitForRateLimit(ctx, utilities.ProviderAws, awsmiddleware.GetServiceID(ctx), m.accountId); err != nil {
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+	}
+	return next.HandleFinalize(ctx, in)
+}
+
+// addRetryMiddlewares applies the retry policy of the AWS services to the API calls made with given config
+func addRetryMiddlewares(cfg *aws.Config, accountId string) {
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		if _, ok := stack.Finalize.Get("Retry"); !ok {
+			return nil
+		}
+		if _, err := stack.Finalize.Swap("Retry", retryMiddleware{accountId: accountId}); err != nil {
+			return err
+		}
+		return stack.Finalize.Insert(rateLimitMiddleware{accountId: accountId}, "Retry", middleware.After)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/Uptycs/cloudquery/utilities"
 
@@ -20,6 +21,8 @@ import (
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extaws "github.com/Uptycs/cloudquery/extension/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
@@ -49,69 +52,9 @@ type s3BucketInfoList struct {
 	buckets []s3BucketInfo
 }
 
-var (
-	// Map of region to buckets
-	regionBuckets map[string]s3BucketInfoList
-)
-
-// ListBucketsColumns returns the list of columns in the table
-func ListBucketsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("region"),
-		table.TextColumn("name"),
-		table.TextColumn("creation_time"),
-		table.TextColumn("server_side_encryption_configuration"),
-		table.TextColumn("mfa_delete"),
-		table.TextColumn("versioning_status"),
-		table.TextColumn("acl_owner"),
-		table.TextColumn("acl_grants"),
-		table.TextColumn("website_enabled"),
-		table.TextColumn("website_redirection"),
-		table.TextColumn("public_access_block_config"),
-		table.TextColumn("policy_status"),
-		table.TextColumn("accelerate_configuration_status"),
-		table.TextColumn("object_lock_configuration_enabled"),
-		table.TextColumn("lifecycle_configuration_enabled"),
-		table.TextColumn("notification_enabled"),
-		table.TextColumn("cors_enabled"),
-		table.TextColumn("policy"),
-		table.TextColumn("tags"),
-	}
-}
-
 // ListBucketsGenerate returns the rows in the table for all configured accounts
 func ListBucketsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_s3_bucket", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_s3_bucket",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListBuckets(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_s3_bucket", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_s3_bucket",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListBuckets(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_s3_bucket", processAccountListBuckets)
 }
 
 func getBucketLocation(osqCtx context.Context, queryContext table.QueryContext, svc *s3.Client, bucketName *string) (string, error) {
@@ -135,7 +78,7 @@ func getBucketLocation(osqCtx context.Context, queryContext table.QueryContext,
 	}
 }
 
-func addBucketToRegionBucketList(osqCtx context.Context, queryContext table.QueryContext, svc *s3.Client, bucket types.Bucket) error {
+func addBucketToRegionBucketList(osqCtx context.Context, queryContext table.QueryContext, svc *s3.Client, regionBuckets map[string]s3BucketInfoList, bucket types.Bucket) error {
 	bucketRegion, err := getBucketLocation(osqCtx, queryContext, svc, bucket.Name)
 	if err != nil {
 		return err
@@ -289,9 +232,28 @@ func (bucket *s3BucketInfo) getBucketPolicy(osqCtx context.Context, queryContext
 	bucket.Policy = output.Policy
 }
 
+// getEnrichments returns the calls filling the attributes of the bucket, each of them writes distinct attributes
+func (bucket *s3BucketInfo) getEnrichments(queryContext table.QueryContext, svc *s3.Client) []utilities.Enrichment {
+	return []utilities.Enrichment{
+		{Attributes: []string{"AccelerateConfigurationStatus"}, Enrich: func(ctx context.Context) { bucket.getBucketAccelerateConfiguration(ctx, queryContext, svc) }},
+		{Attributes: []string{"AclOwner", "AclGrants"}, Enrich: func(ctx context.Context) { bucket.getBucketAcl(ctx, queryContext, svc) }},
+		{Attributes: []string{"CorsEnabled"}, Enrich: func(ctx context.Context) { bucket.getBucketCorsConfiguration(ctx, queryContext, svc) }},
+		{Attributes: []string{"ServerSideEncryptionConfiguration"}, Enrich: func(ctx context.Context) { bucket.getBucketEncryption(ctx, queryContext, svc) }},
+		{Attributes: []string{"LifecycleConfigurationEnabled"}, Enrich: func(ctx context.Context) { bucket.getBucketLifecycleConfiguration(ctx, queryContext, svc) }},
+		{Attributes: []string{"NotificationEnabled"}, Enrich: func(ctx context.Context) { bucket.getBucketNotificationConfiguration(ctx, queryContext, svc) }},
+		{Attributes: []string{"Policy"}, Enrich: func(ctx context.Context) { bucket.getBucketPolicy(ctx, queryContext, svc) }},
+		{Attributes: []string{"PolicyStatus"}, Enrich: func(ctx context.Context) { bucket.getBucketPolicyStatus(ctx, queryContext, svc) }},
+		{Attributes: []string{"PublicAccessBlockConfig"}, Enrich: func(ctx context.Context) { bucket.getBucketPublicAccessBlock(ctx, queryContext, svc) }},
+		{Attributes: []string{"Tags"}, Enrich: func(ctx context.Context) { bucket.getBucketTags(ctx, queryContext, svc) }},
+		{Attributes: []string{"MfaDelete", "VersioningStatus"}, Enrich: func(ctx context.Context) { bucket.getBucketVersioning(ctx, queryContext, svc) }},
+		{Attributes: []string{"WebsiteEnabled", "WebsiteRedirection"}, Enrich: func(ctx context.Context) { bucket.getBucketWebsite(ctx, queryContext, svc) }},
+		{Attributes: []string{"ObjectLockConfigurationEnabled"}, Enrich: func(ctx context.Context) { bucket.getObjectLockConfiguration(ctx, queryContext, svc) }},
+	}
+}
+
 func processBucket(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region string, bucket *s3BucketInfo) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, region)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, region)
 	if err != nil {
 		return resultMap, err
 	}
@@ -304,19 +266,7 @@ func processBucket(osqCtx context.Context, queryContext table.QueryContext, tabl
 		"tableName": "aws_s3_bucket",
 		"bucket":    bucket.Name,
 	}).Debug("processing bucket")
-	bucket.getBucketAccelerateConfiguration(osqCtx, queryContext, svc)
-	bucket.getBucketAcl(osqCtx, queryContext, svc)
-	bucket.getBucketCorsConfiguration(osqCtx, queryContext, svc)
-	bucket.getBucketEncryption(osqCtx, queryContext, svc)
-	bucket.getBucketLifecycleConfiguration(osqCtx, queryContext, svc)
-	bucket.getBucketNotificationConfiguration(osqCtx, queryContext, svc)
-	bucket.getBucketPolicy(osqCtx, queryContext, svc)
-	bucket.getBucketPolicyStatus(osqCtx, queryContext, svc)
-	bucket.getBucketPublicAccessBlock(osqCtx, queryContext, svc)
-	bucket.getBucketTags(osqCtx, queryContext, svc)
-	bucket.getBucketVersioning(osqCtx, queryContext, svc)
-	bucket.getBucketWebsite(osqCtx, queryContext, svc)
-	bucket.getObjectLockConfiguration(osqCtx, queryContext, svc)
+	utilities.RunEnrichments(osqCtx, "aws_s3_bucket", bucket.getEnrichments(queryContext, svc))
 	byteArr, err := json.Marshal(bucket)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -328,7 +278,7 @@ func processBucket(osqCtx context.Context, queryContext table.QueryContext, tabl
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	table := utilities.NewTable(byteArr, tableConfig)
+	table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 	for _, row := range table.Rows {
 		if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_s3_bucket", accountId, region, row) {
 			continue
@@ -341,7 +291,7 @@ func processBucket(osqCtx context.Context, queryContext table.QueryContext, tabl
 
 func processListBuckets(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, "us-west-1")
+	sess, err := extaws.GetAwsConfig(osqCtx, account, "us-west-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -364,33 +314,39 @@ func processListBuckets(osqCtx context.Context, queryContext table.QueryContext,
 		}).Error("failed to get bucket list")
 		return resultMap, err
 	}
-	regionBuckets = make(map[string]s3BucketInfoList)
+	regionBuckets := make(map[string]s3BucketInfoList)
 	// Get bucket region and put that bucket in that bucketList
 	for _, bucket := range output.Buckets {
-		addBucketToRegionBucketList(osqCtx, queryContext, svc, bucket)
-	}
-	// Process all buckets
-	for region, regionBucketList := range regionBuckets {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_s3_bucket", accountId, region) {
-			continue
-		}
-		for _, regionBucket := range regionBucketList.buckets {
-			result, err := processBucket(osqCtx, queryContext, tableConfig, account, region, &regionBucket)
+		addBucketToRegionBucketList(osqCtx, queryContext, svc, regionBuckets, bucket)
+	}
+	regionNames := make([]string, 0, len(regionBuckets))
+	for region := range regionBuckets {
+		regionNames = append(regionNames, region)
+	}
+	sort.Strings(regionNames)
+	regions := make([]ec2types.Region, 0, len(regionNames))
+	for _, region := range regionNames {
+		regions = append(regions, ec2types.Region{RegionName: aws.String(region)})
+	}
+	// Process all buckets, one task per region
+	processRegionBuckets := func(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region ec2types.Region) ([]map[string]string, error) {
+		regionResultMap := make([]map[string]string, 0)
+		for _, regionBucket := range regionBuckets[*region.RegionName].buckets {
+			result, err := processBucket(osqCtx, queryContext, tableConfig, account, *region.RegionName, &regionBucket)
 			if err == nil {
-				resultMap = append(resultMap, result...)
+				regionResultMap = append(regionResultMap, result...)
 			}
 		}
+		return regionResultMap, nil
 	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_s3_bucket", tableConfig, account, regions, processRegionBuckets)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
 
 func processAccountListBuckets(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	tableConfig, ok := utilities.TableConfigurationMap["aws_s3_bucket"]
+	tableConfig, ok := utilities.GetTableConfig("aws_s3_bucket")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_s3_bucket",
@@ -24,56 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/glacier"
 )
 
-// ListVaultsColumns returns the list of columns in the table
-func ListVaultsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("creation_date"),
-		table.TextColumn("last_inventory_date"),
-		table.BigIntColumn("number_of_archives"),
-		table.BigIntColumn("size_in_bytes"),
-		table.TextColumn("vault_arn"),
-		table.TextColumn("vault_name"),
-	}
-}
-
 // ListVaultsGenerate returns the rows in the table for all configured accounts
 func ListVaultsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_s3_glacier_vault", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_s3_glacier_vault",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListVaults(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_s3_glacier_vault", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_s3_glacier_vault",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListVaults(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_s3_glacier_vault", processAccountListVaults)
 }
 
 func processRegionListVaults(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -117,7 +75,7 @@ func processRegionListVaults(osqCtx context.Context, queryContext table.QueryCon
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_s3_glacier_vault", accountId, *region.RegionName, row) {
 				continue
@@ -134,7 +92,7 @@ func processRegionListVaults(osqCtx context.Context, queryContext table.QueryCon
 
 func processAccountListVaults(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -142,26 +100,14 @@ func processAccountListVaults(osqCtx context.Context, queryContext table.QueryCo
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_s3_glacier_vault"]
+	tableConfig, ok := utilities.GetTableConfig("aws_s3_glacier_vault")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_s3_glacier_vault",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_s3_glacier_vault", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListVaults(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_s3_glacier_vault", tableConfig, account, regions, processRegionListVaults)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -24,52 +24,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 )
 
-// ListTopicsColumns returns the list of columns in the table
-func ListTopicsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_id"),
-		table.TextColumn("region_code"),
-		table.TextColumn("region"),
-		table.TextColumn("topic"),
-	}
-}
-
 // ListTopicsGenerate returns the rows in the table for all configured accounts
 func ListTopicsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
-	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAws.Accounts) == 0 && extaws.ShouldProcessAccount("aws_sns_topic", utilities.AwsAccountID) {
-		utilities.GetLogger().WithFields(log.Fields{
-			"tableName": "aws_sns_topic",
-			"account":   "default",
-		}).Info("processing account")
-		results, err := processAccountListTopics(osqCtx, queryContext, nil)
-		if err != nil {
-			return resultMap, err
-		}
-		resultMap = append(resultMap, results...)
-	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAws.Accounts {
-			if !extaws.ShouldProcessAccount("aws_sns_topic", account.ID) {
-				continue
-			}
-			utilities.GetLogger().WithFields(log.Fields{
-				"tableName": "aws_sns_topic",
-				"account":   account.ID,
-			}).Info("processing account")
-			results, err := processAccountListTopics(osqCtx, queryContext, &account)
-			if err != nil {
-				continue
-			}
-			resultMap = append(resultMap, results...)
-		}
-	}
-
-	return resultMap, nil
+	return extaws.ProcessAccounts(osqCtx, queryContext, "aws_sns_topic", processAccountListTopics)
 }
 
 func processRegionListTopics(osqCtx context.Context, queryContext table.QueryContext, tableConfig *utilities.TableConfig, account *utilities.ExtensionConfigurationAwsAccount, region types.Region) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	sess, err := extaws.GetAwsConfig(account, *region.RegionName)
+	sess, err := extaws.GetAwsConfig(osqCtx, account, *region.RegionName)
 	if err != nil {
 		return resultMap, err
 	}
@@ -113,7 +75,7 @@ func processRegionListTopics(osqCtx context.Context, queryContext table.QueryCon
 			}).Error("failed to marshal response")
 			return nil, err
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(osqCtx, byteArr, tableConfig)
 		for _, row := range table.Rows {
 			if !extaws.ShouldProcessRow(osqCtx, queryContext, "aws_sns_topic", accountId, *region.RegionName, row) {
 				continue
@@ -130,7 +92,7 @@ func processRegionListTopics(osqCtx context.Context, queryContext table.QueryCon
 
 func processAccountListTopics(osqCtx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAwsAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	awsSession, err := extaws.GetAwsConfig(account, "us-east-1")
+	awsSession, err := extaws.GetAwsConfig(osqCtx, account, "us-east-1")
 	if err != nil {
 		return resultMap, err
 	}
@@ -138,26 +100,14 @@ func processAccountListTopics(osqCtx context.Context, queryContext table.QueryCo
 	if err != nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["aws_sns_topic"]
+	tableConfig, ok := utilities.GetTableConfig("aws_sns_topic")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "aws_sns_topic",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
-	for _, region := range regions {
-		accountId := utilities.AwsAccountID
-		if account != nil {
-			accountId = account.ID
-		}
-		if !extaws.ShouldProcessRegion("aws_sns_topic", accountId, *region.RegionName) {
-			continue
-		}
-		result, err := processRegionListTopics(osqCtx, queryContext, tableConfig, account, region)
-		if err != nil {
-			continue
-		}
-		resultMap = append(resultMap, result...)
-	}
-	return resultMap, nil
+	rows, err := extaws.ProcessRegions(osqCtx, queryContext, "aws_sns_topic", tableConfig, account, regions, processRegionListTopics)
+	resultMap = append(resultMap, rows...)
+	return resultMap, err
 }
@@ -11,97 +11,83 @@ package aws
 
 import (
 	"context"
-	"time"
 
 	"github.com/Uptycs/cloudquery/utilities"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	log "github.com/sirupsen/logrus"
 )
 
 // GetAwsConfig creates an AWS Config for given account.
-// If account is nil, it creates a default config
-func GetAwsConfig(account *utilities.ExtensionConfigurationAwsAccount, regionCode string) (*aws.Config, error) {
+// If account is nil, it creates a default config.
+// The credentials of an account are shared by the configs created for it, and refreshed before they expire.
+// The API calls made with the config are retried, and their rate limited, as configured for their service.
+func GetAwsConfig(ctx context.Context, account *utilities.ExtensionConfigurationAwsAccount, regionCode string) (*aws.Config, error) {
+	var cfg *aws.Config
+	var err error
+	accountId := utilities.AwsAccountID
 	if account == nil {
 		utilities.GetLogger().Debug("creating default session")
-		return getDefaultAwsConfig(regionCode)
-	}
-
-	if len(account.ProfileName) != 0 && len(account.RoleArn) == 0 {
-		utilities.GetLogger().Debug("creating session using profile")
-		return getAwsConfigForProfile(account, regionCode)
-	} else if len(account.RoleArn) != 0 {
-		utilities.GetLogger().Debug("creating session using roleArn")
-		return getAwsConfigForRole(account, regionCode)
+		cfg, err = getDefaultAwsConfig(ctx, regionCode)
+		if err == nil {
+			cfg.Credentials = getCachedCredentials("default", "", func() aws.CredentialsProvider {
+				return cfg.Credentials
+			})
+		}
 	} else {
-		utilities.GetLogger().Debug("creating default session")
-		return getDefaultAwsConfig(regionCode)
+		accountId = account.ID
+		utilities.GetLogger().Debug("creating session for account")
+		cfg, err = getAwsConfigForAccount(ctx, account, regionCode)
 	}
-}
-
-func getAwsConfigForProfile(account *utilities.ExtensionConfigurationAwsAccount, regionCode string) (*aws.Config, error) {
-	utilities.GetLogger().WithFields(log.Fields{
-		"account": account.ID,
-		"region":  regionCode,
-		"profile": account.ProfileName,
-	}).Debug("creating config")
-	credentialFiles := make([]string, 0)
-	credentialFiles = append(credentialFiles, account.CredentialFile)
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(regionCode),
-		config.WithSharedCredentialsFiles(credentialFiles),
-		config.WithSharedConfigProfile(account.ProfileName),
-	)
 	if err != nil {
-		utilities.GetLogger().WithFields(log.Fields{
-			"account":   account.ID,
-			"profile":   account.ProfileName,
-			"errString": err.Error(),
-		}).Error("failed to create config")
-		return nil, err
+		return cfg, err
 	}
-	return &cfg, nil
+	addRetryMiddlewares(cfg, accountId)
+	if utilities.MetricsEnabled() {
+		addMetricsMiddleware(cfg, accountId)
+	}
+	return cfg, err
 }
 
-func getAwsConfigForRole(account *utilities.ExtensionConfigurationAwsAccount, regionCode string) (*aws.Config, error) {
+// getAwsConfigForAccount creates the config of given account, with the credentials read from its files and profile,
+// or the default credentials, and the credentials of its web identity and roles
+func getAwsConfigForAccount(ctx context.Context, account *utilities.ExtensionConfigurationAwsAccount, regionCode string) (*aws.Config, error) {
 	utilities.GetLogger().WithFields(log.Fields{
 		"account": account.ID,
 		"region":  regionCode,
 		"profile": account.ProfileName,
 		"role":    account.RoleArn,
 	}).Debug("creating config")
-	credentialFiles := make([]string, 0)
-	credentialFiles = append(credentialFiles, account.CredentialFile)
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(regionCode),
-		config.WithSharedCredentialsFiles(credentialFiles),
-		config.WithSharedConfigProfile(account.ProfileName),
-	)
+	options := []func(*config.LoadOptions) error{config.WithRegion(regionCode)}
+	if account.CredentialFile != "" {
+		options = append(options, config.WithSharedCredentialsFiles([]string{account.CredentialFile}))
+	}
+	if account.ConfigFile != "" {
+		options = append(options, config.WithSharedConfigFiles([]string{account.ConfigFile}))
+	}
+	if account.ProfileName != "" {
+		options = append(options, config.WithSharedConfigProfile(account.ProfileName))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"account":   account.ID,
+			"profile":   account.ProfileName,
 			"role":      account.RoleArn,
 			"errString": err.Error(),
 		}).Error("failed to create config")
 		return nil, err
 	}
-	// Create the credentials from AssumeRoleProvider to assume the role
-	// referenced by the role ARN.
-	stsSvc := sts.NewFromConfig(cfg)
-	creds := stscreds.NewAssumeRoleProvider(stsSvc, account.RoleArn, func(options *stscreds.AssumeRoleOptions) {
-		options.Duration = time.Duration(60) * time.Minute
-		options.ExternalID = &account.ExternalID
+	cfg.Credentials = getCachedCredentials(account.ID, getCredentialsKey(account), func() aws.CredentialsProvider {
+		return newAccountCredentials(cfg, account)
 	})
-	cfg.Credentials = aws.NewCredentialsCache(creds)
 	return &cfg, nil
 }
 
-func getDefaultAwsConfig(regionCode string) (*aws.Config, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+func getDefaultAwsConfig(ctx context.Context, regionCode string) (*aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(regionCode),
 	)
 	if err != nil {
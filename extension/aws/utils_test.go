@@ -10,10 +10,18 @@
 package aws
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
+	"github.com/Uptycs/basequery-go/plugin/table"
 	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,9 +49,91 @@ func TestRowToMap(t *testing.T) {
 
 	acntID, region := "test-account", "us-east4"
 	inRow := make(map[string]interface{})
-	tabConfig := utilities.TableConfigurationMap["test_table_1"]
+	tabConfig, _ := utilities.GetTableConfig("test_table_1")
 	outRow := RowToMap(inRow, acntID, region, tabConfig)
 
 	assert.Equal(t, acntID, outRow["account_id"])
 	assert.Equal(t, region, outRow["region_code"])
 }
+
+func TestShouldProcessAccountAndRegion(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(tableConfigJSON))
+	assert.Nil(t, err)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"account_id": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "test-account"},
+				},
+			},
+			"region_code": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "us-east-1"},
+					{Operator: table.OperatorEquals, Expression: "us-west-2"},
+				},
+			},
+		},
+	}
+
+	assert.True(t, ShouldProcessAccount(queryContext, "test_table_1", "test-account"))
+	assert.False(t, ShouldProcessAccount(queryContext, "test_table_1", "other-account"))
+	assert.True(t, ShouldProcessRegion(queryContext, "test_table_1", "test-account", "us-west-2"))
+	assert.False(t, ShouldProcessRegion(queryContext, "test_table_1", "test-account", "eu-west-1"))
+	// Tables without configuration are never pruned
+	assert.True(t, ShouldProcessAccount(queryContext, "unknown_table", "other-account"))
+	assert.True(t, ShouldProcessAccount(table.QueryContext{}, "test_table_1", "other-account"))
+}
+
+func TestIsThrottleError(t *testing.T) {
+	assert.True(t, isThrottleError(&smithy.GenericAPIError{Code: "RequestLimitExceeded"}))
+	assert.True(t, isThrottleError(fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "ThrottlingException"})))
+	assert.False(t, isThrottleError(&smithy.GenericAPIError{Code: "AccessDenied"}))
+	assert.False(t, isThrottleError(fmt.Errorf("connection refused")))
+	assert.False(t, isThrottleError(nil))
+}
+
+func TestReportError(t *testing.T) {
+	ReportError("aws_test_errors", "123456789012", "us-east-1", &smithy.OperationError{
+		ServiceID:     "EC2",
+		OperationName: "DescribeInstances",
+		Err:           &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "not authorized"},
+	})
+	collectionErrors := utilities.GetCollectionErrors()
+	collectionError := collectionErrors[len(collectionErrors)-1]
+	assert.Equal(t, "aws_test_errors", collectionError.Table)
+	assert.Equal(t, utilities.ProviderAws, collectionError.Provider)
+	assert.Equal(t, "123456789012", collectionError.Account)
+	assert.Equal(t, "us-east-1", collectionError.Region)
+	assert.Equal(t, "EC2.DescribeInstances", collectionError.API)
+	assert.Equal(t, "UnauthorizedOperation", collectionError.Code)
+}
+
+func TestAddRetryMiddlewares(t *testing.T) {
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	stack.Finalize.Add(retry.NewAttemptMiddleware(retry.NewStandard(), smithyhttp.RequestCloner), middleware.After)
+	stack.Finalize.Add(&v4.SignHTTPRequestMiddleware{}, middleware.After)
+	cfg := aws.Config{}
+	addRetryMiddlewares(&cfg, "123456789012")
+	for _, apiOption := range cfg.APIOptions {
+		assert.Nil(t, apiOption(stack))
+	}
+	assert.Equal(t, []string{"Retry", "CloudqueryRateLimit", "Signing"}, stack.Finalize.List())
+	m, _ := stack.Finalize.Get("Retry")
+	assert.IsType(t, retryMiddleware{}, m)
+}
+
+func TestGetRetryer(t *testing.T) {
+	policy := utilities.RetryPolicy{MaxAttempts: 3, MaxBackoffSeconds: 10}
+	retryer := getRetryer("123456789012", "EC2", policy)
+	assert.Equal(t, 3, retryer.MaxAttempts())
+	assert.Same(t, retryer, getRetryer("123456789012", "EC2", policy))
+	assert.NotSame(t, retryer, getRetryer("123456789012", "S3", policy))
+	assert.NotSame(t, retryer, getRetryer("210987654321", "EC2", policy))
+
+	// The retryer is replaced when the policy changes
+	policy.MaxAttempts = 5
+	updated := getRetryer("123456789012", "EC2", policy)
+	assert.Equal(t, 5, updated.MaxAttempts())
+	assert.Same(t, updated, getRetryer("123456789012", "EC2", policy))
+}
@@ -0,0 +1,216 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultMaxConcurrency = 8
+
+// ResourceGroupTask appends the rows of a table for given resource group to resultMap.
+// resultMap is owned by the task, it is never shared with tasks of other resource groups.
+// The task reports its failures, and returns an error if the rows of the resource group are incomplete.
+type ResourceGroupTask func(ctx context.Context, queryContext table.QueryContext, session *AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error
+
+// ResourceGroupErrors holds the errors of the resource groups which failed, keyed by resource group
+type ResourceGroupErrors map[string]error
+
+func (e ResourceGroupErrors) Error() string {
+	groups := make([]string, 0, len(e))
+	for group := range e {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	messages := make([]string, 0, len(groups))
+	for _, group := range groups {
+		messages = append(messages, fmt.Sprintf("%s: %s", group, e[group].Error()))
+	}
+	return fmt.Sprintf("failed to process %d resource group(s): %s", len(e), strings.Join(messages, "; "))
+}
+
+// RowCollector collects the rows produced by concurrent tasks.
+// Every task gets its own slot, and the slots are merged in task order,
+// so the rows do not depend on scheduling.
+type RowCollector struct {
+	slots  [][]map[string]string
+	errors ResourceGroupErrors
+	mutex  sync.Mutex
+}
+
+// NewRowCollector creates a collector with given number of slots
+func NewRowCollector(count int) *RowCollector {
+	return &RowCollector{
+		slots:  make([][]map[string]string, count),
+		errors: make(ResourceGroupErrors),
+	}
+}
+
+// Slot returns the slice owned by the task with given index
+func (c *RowCollector) Slot(index int) *[]map[string]string {
+	return &c.slots[index]
+}
+
+// AddError records the failure of given resource group
+func (c *RowCollector) AddError(group string, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.errors[group] = err
+}
+
+// Rows returns the rows of all slots, in slot order
+func (c *RowCollector) Rows() []map[string]string {
+	resultMap := make([]map[string]string, 0)
+	for _, slot := range c.slots {
+		resultMap = append(resultMap, slot...)
+	}
+	return resultMap
+}
+
+// Errors returns the errors recorded so far, or nil if there are none
+func (c *RowCollector) Errors() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.errors) == 0 {
+		return nil
+	}
+	return c.errors
+}
+
+// GetMaxConcurrency returns the number of resource groups, or resources, processed in parallel
+func GetMaxConcurrency() int {
+	if utilities.GetExtConfiguration().ExtConfAzure.MaxConcurrency > 0 {
+		return utilities.GetExtConfiguration().ExtConfAzure.MaxConcurrency
+	}
+	return defaultMaxConcurrency
+}
+
+// ProcessResourceGroups runs given task for each of the resource groups in parallel,
+// with at most maxConcurrency tasks running at a time.
+// A task which returns an error or panics fails only its own resource group. Failures are logged and reported, and
+// the aggregated error is returned only if all of the resource groups failed.
+// The collection is recorded as successful only if none of the resource groups failed.
+// Once ctx is done, the resource groups not started yet are not processed.
+func ProcessResourceGroups(ctx context.Context, queryContext table.QueryContext, tableName string, session *AzureSession, groups []string, tableConfig *utilities.TableConfig, task ResourceGroupTask) ([]map[string]string, error) {
+	startTime := time.Now()
+	collector := NewRowCollector(len(groups))
+	session = session.forTable(tableName)
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, GetMaxConcurrency())
+	for index := range groups {
+		if ctx.Err() != nil {
+			collector.AddError(groups[index], ctx.Err())
+			continue
+		}
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			collector.AddError(groups[index], ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			group := groups[index]
+			defer func() {
+				if r := recover(); r != nil {
+					// Rows appended before the failure are incomplete
+					*collector.Slot(index) = nil
+					collector.AddError(group, fmt.Errorf("%v", r))
+					utilities.GetLogger().WithFields(log.Fields{
+						"tableName":     tableName,
+						"resourceGroup": group,
+						"errString":     fmt.Sprintf("%v", r),
+					}).Error("failed to process resource group")
+					ReportError(tableName, session.SubscriptionId, group, fmt.Errorf("%v", r))
+				}
+			}()
+			if err := task(ctx, queryContext, session, group, collector.Slot(index), tableConfig); err != nil {
+				collector.AddError(group, err)
+			}
+		}(index)
+	}
+	wg.Wait()
+
+	err := collector.Errors()
+	if err != nil && len(collector.errors) == len(groups) {
+		return collector.Rows(), err
+	}
+	if err != nil || ctx.Err() != nil {
+		return collector.Rows(), nil
+	}
+	utilities.RecordCollectionSuccess(tableName, session.SubscriptionId, startTime)
+	return collector.Rows(), nil
+}
+
+// ResourceTask appends the rows of a table for given resource of a resource group to resultMap.
+// resultMap is owned by the task, it is never shared with tasks of other resources.
+// The task reports its failures, and returns an error if the rows of the resource are incomplete.
+type ResourceTask func(ctx context.Context, name string, resultMap *[]map[string]string) error
+
+// ProcessResources runs given task for each of the named resources of a resource group in parallel,
+// with at most maxConcurrency tasks running at a time, and appends their rows to resultMap in resource order.
+// A task which returns an error or panics fails only its own resource. Panics are reported.
+// Once ctx is done, the resources not started yet are not processed.
+// An error is returned if any of the resources failed or was not processed.
+func ProcessResources(ctx context.Context, tableName string, session *AzureSession, rg string, names []string, resultMap *[]map[string]string, task ResourceTask) error {
+	collector := NewRowCollector(len(names))
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, GetMaxConcurrency())
+	for index := range names {
+		if ctx.Err() != nil {
+			collector.AddError(names[index], ctx.Err())
+			continue
+		}
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			collector.AddError(names[index], ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			defer func() {
+				if r := recover(); r != nil {
+					*collector.Slot(index) = nil
+					collector.AddError(names[index], fmt.Errorf("%v", r))
+					utilities.GetLogger().WithFields(log.Fields{
+						"tableName":     tableName,
+						"resourceGroup": rg,
+						"resource":      names[index],
+						"errString":     fmt.Sprintf("%v", r),
+					}).Error("failed to process resource")
+					ReportError(tableName, session.SubscriptionId, rg, fmt.Errorf("%v", r))
+				}
+			}()
+			if err := task(ctx, names[index], collector.Slot(index)); err != nil {
+				collector.AddError(names[index], err)
+			}
+		}(index)
+	}
+	wg.Wait()
+	*resultMap = append(*resultMap, collector.Rows()...)
+	if len(collector.errors) > 0 {
+		return fmt.Errorf("failed to process %d resource(s) of resource group %s", len(collector.errors), rg)
+	}
+	return nil
+}
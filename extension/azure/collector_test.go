@@ -0,0 +1,140 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessResourceGroups(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAzure.MaxConcurrency = 3
+	utilities.SetExtConfiguration(&extConfig)
+
+	groups := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		groups = append(groups, fmt.Sprintf("rg-%02d", i))
+	}
+	session := &AzureSession{SubscriptionId: "test-subscription"}
+
+	var running, maxRunning int32
+	task := func(ctx context.Context, queryContext table.QueryContext, session *AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if rg == "rg-05" {
+			*resultMap = append(*resultMap, map[string]string{"name": "partial"})
+			panic("failed")
+		}
+		for i := 0; i < 2; i++ {
+			*resultMap = append(*resultMap, map[string]string{"name": fmt.Sprintf("%s-%d", rg, i)})
+		}
+		return nil
+	}
+
+	rows, err := ProcessResourceGroups(context.Background(), table.QueryContext{}, "test_table_1", session, groups, nil, task)
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	// Rows of the failed group are dropped, the rest are in resource group order
+	assert.Equal(t, 38, len(rows))
+	assert.Equal(t, "rg-00-0", rows[0]["name"])
+	assert.Equal(t, "rg-04-1", rows[9]["name"])
+	assert.Equal(t, "rg-06-0", rows[10]["name"])
+	assert.Equal(t, "rg-19-1", rows[37]["name"])
+
+	failing := func(ctx context.Context, queryContext table.QueryContext, session *AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+		panic("failed")
+	}
+	rows, err = ProcessResourceGroups(context.Background(), table.QueryContext{}, "test_table_1", session, groups[:2], nil, failing)
+	assert.Equal(t, 0, len(rows))
+	assert.EqualError(t, err, "failed to process 2 resource group(s): rg-00: failed; rg-01: failed")
+
+	// Resource groups are not processed once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rows, err = ProcessResourceGroups(ctx, table.QueryContext{}, "test_table_1", session, groups[:2], nil, task)
+	assert.Equal(t, 0, len(rows))
+	assert.EqualError(t, err, "failed to process 2 resource group(s): rg-00: context canceled; rg-01: context canceled")
+}
+
+func TestProcessResources(t *testing.T) {
+	session := &AzureSession{SubscriptionId: "test-subscription"}
+	names := []string{"account-0", "account-1", "account-2", "account-3"}
+	task := func(ctx context.Context, name string, resultMap *[]map[string]string) error {
+		if name == "account-2" {
+			*resultMap = append(*resultMap, map[string]string{"name": "partial"})
+			panic("failed")
+		}
+		*resultMap = append(*resultMap, map[string]string{"name": name})
+		return nil
+	}
+
+	resultMap := []map[string]string{{"name": "existing"}}
+	err := ProcessResources(context.Background(), "test_table_1", session, "rg-00", names, &resultMap, task)
+	assert.EqualError(t, err, "failed to process 1 resource(s) of resource group rg-00")
+	// Rows of the failed resource are dropped, the rest are appended in resource order
+	assert.Equal(t, []map[string]string{{"name": "existing"}, {"name": "account-0"}, {"name": "account-1"}, {"name": "account-3"}}, resultMap)
+
+	// A resource whose task returns an error fails the resource group
+	failing := func(ctx context.Context, name string, resultMap *[]map[string]string) error {
+		if name == "account-1" {
+			return fmt.Errorf("access denied")
+		}
+		*resultMap = append(*resultMap, map[string]string{"name": name})
+		return nil
+	}
+	resultMap = make([]map[string]string, 0)
+	err = ProcessResources(context.Background(), "test_table_1", session, "rg-00", names, &resultMap, failing)
+	assert.EqualError(t, err, "failed to process 1 resource(s) of resource group rg-00")
+	assert.Equal(t, []map[string]string{{"name": "account-0"}, {"name": "account-2"}, {"name": "account-3"}}, resultMap)
+}
+
+func TestProcessResourceGroupsErrors(t *testing.T) {
+	groups := []string{"rg-00", "rg-01"}
+	task := func(ctx context.Context, queryContext table.QueryContext, session *AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+		*resultMap = append(*resultMap, map[string]string{"name": rg})
+		if rg == "rg-01" && session.SubscriptionId == "failing-subscription" {
+			return fmt.Errorf("access denied")
+		}
+		return nil
+	}
+
+	rows, err := ProcessResourceGroups(context.Background(), table.QueryContext{}, "azure_test_group_errors", &AzureSession{SubscriptionId: "test-subscription"}, groups, nil, task)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rows))
+
+	// The rows of the failed group are kept, but the collection is not successful
+	rows, err = ProcessResourceGroups(context.Background(), table.QueryContext{}, "azure_test_group_errors", &AzureSession{SubscriptionId: "failing-subscription"}, groups, nil, task)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "rg-00"}, {"name": "rg-01"}}, rows)
+
+	successful := false
+	for _, status := range utilities.GetCollectionStatuses(utilities.ProviderAzure, "test-subscription") {
+		successful = successful || (status.Table == "azure_test_group_errors" && !status.LastSuccess.IsZero())
+	}
+	assert.True(t, successful)
+	assert.Equal(t, 0, len(utilities.GetCollectionStatuses(utilities.ProviderAzure, "failing-subscription")))
+}
@@ -13,7 +13,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -22,112 +21,34 @@ import (
 	"github.com/Uptycs/basequery-go/plugin/table"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-06-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-04-01/compute"
 	"github.com/fatih/structs"
 )
 
 var azureComputeDisk = "azure_compute_disk"
 
-// DiskColumns returns the list of columns in the table
-func DiskColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("extended_location"),
-		//table.TextColumn("extended_location_name"),
-		//table.TextColumn("extended_location_type"),
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("managed_by"),
-		//table.TextColumn("managed_by_extended"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("bursting_enabled"),
-		table.TextColumn("creation_data"),
-		// table.TextColumn("creation_data_create_option"),
-		// table.TextColumn("creation_data_gallery_image_reference"),
-		// table.TextColumn("creation_data_gallery_image_reference_id"),
-		// table.TextColumn("creation_data_gallery_image_reference_lun"),
-		// table.TextColumn("creation_data_image_reference"),
-		// table.TextColumn("creation_data_image_reference_id"),
-		// table.TextColumn("creation_data_image_reference_lun"),
-		// table.TextColumn("creation_data_logical_sector_size"),
-		// table.TextColumn("creation_data_source_resource_id"),
-		// table.TextColumn("creation_data_source_unique_id"),
-		// table.TextColumn("creation_data_source_uri"),
-		// table.TextColumn("creation_data_storage_account_id"),
-		// table.BigIntColumn("creation_data_upload_size_bytes"),
-		table.TextColumn("disk_access_id"),
-		table.BigIntColumn("disk_iops_read_only"),
-		table.BigIntColumn("disk_iops_read_write"),
-		table.BigIntColumn("disk_m_bps_read_only"),
-		table.BigIntColumn("disk_m_bps_read_write"),
-		table.BigIntColumn("disk_size_bytes"),
-		table.TextColumn("disk_size_gb"),
-		table.TextColumn("disk_state"),
-		table.TextColumn("encryption"),
-		//table.TextColumn("encryption_settings_collection"),
-		// table.TextColumn("encryption_settings_collection_enabled"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_version"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_disk_encryption_key"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_disk_encryption_key_secret_url"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_disk_encryption_key_source_vault"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_disk_encryption_key_source_vault_id"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_key_encryption_key"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_key_encryption_key_key_url"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_key_encryption_key_source_vault"),
-		// table.TextColumn("encryption_settings_collection_encryption_settings_key_encryption_key_source_vault_id"),
-		table.TextColumn("encryption_disk_encryption_set_id"),
-		table.TextColumn("encryption_type"),
-		table.TextColumn("hyper_v_generation"),
-		table.TextColumn("max_shares"),
-		table.TextColumn("network_access_policy"),
-		table.TextColumn("os_type"),
-		table.TextColumn("property_updates_in_progress"),
-		// table.TextColumn("property_updates_in_progress_target_tier"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("purchase_plan"),
-		// table.TextColumn("purchase_plan_name"),
-		// table.TextColumn("purchase_plan_product"),
-		// table.TextColumn("purchase_plan_promotion_code"),
-		// table.TextColumn("purchase_plan_publisher"),
-		table.TextColumn("security_profile"),
-		// table.TextColumn("security_profile_security_type"),
-		table.TextColumn("share_info"),
-		// table.TextColumn("share_info_vm_uri"),
-		table.TextColumn("supports_hibernation"),
-		table.TextColumn("tier"),
-		table.TextColumn("time_created"),
-		table.TextColumn("unique_id"),
-		table.TextColumn("sku"),
-		// table.TextColumn("sku_name"),
-		// table.TextColumn("sku_tier"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-		table.TextColumn("zones"),
-	}
-}
-
 // DiskGenerate returns the rows in the table for all configured accounts
 func DiskGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeDisk,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountDisk(nil)
+		results, err := processAccountDisk(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureComputeDisk,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountDisk(&account)
+			results, err := processAccountDisk(osqCtx, queryContext, &account)
 			if err != nil {
+				extazure.ReportError(azureComputeDisk, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -137,22 +58,20 @@ func DiskGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]ma
 	return resultMap, nil
 }
 
-func processAccountDisk(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountDisk(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := extazure.GetAuthSession(account)
-	if err != nil {
+	session, err := extazure.GetTableSession(queryContext, azureComputeDisk, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := extazure.GetGroups(session)
+	groups, err := extazure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = extazure.FilterGroups(queryContext, azureComputeDisk, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureComputeDisk]
+	tableConfig, ok := utilities.GetTableConfig(azureComputeDisk)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeDisk,
@@ -160,31 +79,25 @@ func processAccountDisk(account *utilities.ExtensionConfigurationAzureAccount) (
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getDisk(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return extazure.ProcessResourceGroups(ctx, queryContext, azureComputeDisk, session, groups, tableConfig, getDisk)
 }
 
-func getDisk(session *extazure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
+func getDisk(ctx context.Context, queryContext table.QueryContext, session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
 	svcClient := compute.NewDisksClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	extazure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	for resourceItr, err := svcClient.ListByResourceGroupComplete(context.Background(), rg); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := svcClient.ListByResourceGroupComplete(ctx, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     azureComputeDisk,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			extazure.ReportError(azureComputeDisk, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		utilities.GetLogger().Error(resMap)
 		byteArr, err := json.Marshal(resMap)
@@ -196,10 +109,14 @@ func getDisk(session *extazure.AzureSession, rg string, wg *sync.WaitGroup, resu
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extazure.ShouldProcessRow(ctx, queryContext, azureComputeDisk, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := extazure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
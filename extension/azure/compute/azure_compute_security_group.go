@@ -13,7 +13,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -26,74 +25,28 @@ import (
 
 var azureComputeSecurityGroup = "azure_compute_security_group"
 
-// SecurityGroupsColumns returns the list of columns in the table
-func SecurityGroupsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("default_security_rules"),
-		// table.TextColumn("default_security_rules_etag"),
-		// table.TextColumn("default_security_rules_id"),
-		// table.TextColumn("default_security_rules_name"),
-		// table.TextColumn("default_security_rules_type"),
-		table.TextColumn("flow_logs"),
-		// table.TextColumn("flow_logs_etag"),
-		// table.TextColumn("flow_logs_id"),
-		// table.TextColumn("flow_logs_location"),
-		// table.TextColumn("flow_logs_name"),
-		// table.TextColumn("flow_logs_tags"),
-		// table.TextColumn("flow_logs_type"),
-		table.TextColumn("network_interfaces"),
-		// table.TextColumn("network_interfaces_etag"),
-		// table.TextColumn("network_interfaces_extended_location"),
-		// table.TextColumn("network_interfaces_extended_location_name"),
-		// table.TextColumn("network_interfaces_extended_location_type"),
-		// table.TextColumn("network_interfaces_id"),
-		// table.TextColumn("network_interfaces_location"),
-		// table.TextColumn("network_interfaces_name"),
-		// table.TextColumn("network_interfaces_tags"),
-		// table.TextColumn("network_interfaces_type"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("resource_guid"),
-		table.TextColumn("security_rules"),
-		// table.TextColumn("security_rules_etag"),
-		// table.TextColumn("security_rules_id"),
-		// table.TextColumn("security_rules_name"),
-		// table.TextColumn("security_rules_type"),
-		table.TextColumn("subnets"),
-		// table.TextColumn("subnets_etag"),
-		// table.TextColumn("subnets_id"),
-		// table.TextColumn("subnets_name"),
-		// table.TextColumn("subnets_type"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // SecurityGroupsGenerate returns the rows in the table for all configured accounts
 func SecurityGroupsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeSecurityGroup,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSecurityGroups(nil)
+		results, err := processAccountSecurityGroups(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureComputeSecurityGroup,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSecurityGroups(&account)
+			results, err := processAccountSecurityGroups(osqCtx, queryContext, &account)
 			if err != nil {
+				extazure.ReportError(azureComputeSecurityGroup, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -103,22 +56,20 @@ func SecurityGroupsGenerate(osqCtx context.Context, queryContext table.QueryCont
 	return resultMap, nil
 }
 
-func processAccountSecurityGroups(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSecurityGroups(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := extazure.GetAuthSession(account)
-	if err != nil {
+	session, err := extazure.GetTableSession(queryContext, azureComputeSecurityGroup, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := extazure.GetGroups(session)
+	groups, err := extazure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = extazure.FilterGroups(queryContext, azureComputeSecurityGroup, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureComputeSecurityGroup]
+	tableConfig, ok := utilities.GetTableConfig(azureComputeSecurityGroup)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeSecurityGroup,
@@ -126,31 +77,25 @@ func processAccountSecurityGroups(account *utilities.ExtensionConfigurationAzure
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getSecurityGroups(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return extazure.ProcessResourceGroups(ctx, queryContext, azureComputeSecurityGroup, session, groups, tableConfig, getSecurityGroups)
 }
 
-func getSecurityGroups(session *extazure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
+func getSecurityGroups(ctx context.Context, queryContext table.QueryContext, session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
 	svcClient := network.NewSecurityGroupsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	extazure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	for resourceItr, err := svcClient.ListComplete(context.Background(), rg); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := svcClient.ListComplete(ctx, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     azureComputeSecurityGroup,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			extazure.ReportError(azureComputeSecurityGroup, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		utilities.GetLogger().Error(resMap)
 
@@ -163,10 +108,14 @@ func getSecurityGroups(session *extazure.AzureSession, rg string, wg *sync.WaitG
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extazure.ShouldProcessRow(ctx, queryContext, azureComputeSecurityGroup, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := extazure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
@@ -13,7 +13,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -28,56 +27,28 @@ import (
 
 var azureComputeVirtualNetwork string = "azure_compute_virtual_network"
 
-// VirtualNetworkColumns returns the list of columns in the table
-func VirtualNetworkColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("address_space"),
-		// table.TextColumn("address_space_address_prefixes"),
-		table.TextColumn("dhcp_options"),
-		// table.TextColumn("dhcp_options_dns_servers"),
-		table.TextColumn("enable_ddos_protection"),
-		table.TextColumn("enable_vm_protection"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("resource_guid"),
-		table.TextColumn("subnets"),
-		// table.TextColumn("subnets_etag"),
-		// table.TextColumn("subnets_id"),
-		// table.TextColumn("subnets_name"),
-		table.TextColumn("virtual_network_peerings"),
-		// table.TextColumn("virtual_network_peerings_etag"),
-		// table.TextColumn("virtual_network_peerings_id"),
-		// table.TextColumn("virtual_network_peerings_name"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // VirtualNetworksGenerate returns the rows in the table for all configured accounts
 func VirtualNetworksGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeVirtualNetwork,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountVirtualNetworks(nil)
+		results, err := processAccountVirtualNetworks(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureComputeVirtualNetwork,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountVirtualNetworks(&account)
+			results, err := processAccountVirtualNetworks(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureComputeVirtualNetwork, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -87,22 +58,20 @@ func VirtualNetworksGenerate(osqCtx context.Context, queryContext table.QueryCon
 	return resultMap, nil
 }
 
-func processAccountVirtualNetworks(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountVirtualNetworks(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureComputeVirtualNetwork, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, azureComputeVirtualNetwork, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureComputeVirtualNetwork]
+	tableConfig, ok := utilities.GetTableConfig(azureComputeVirtualNetwork)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureComputeVirtualNetwork,
@@ -110,31 +79,25 @@ func processAccountVirtualNetworks(account *utilities.ExtensionConfigurationAzur
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getVirtualNetworks(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, azureComputeVirtualNetwork, session, groups, tableConfig, getVirtualNetworks)
 }
 
-func getVirtualNetworks(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	svcClient := network.NewInterfacesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+func getVirtualNetworks(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	svcClient := network.NewVirtualNetworksClient(session.SubscriptionId)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	for resourceItr, err := svcClient.ListComplete(context.Background(), rg); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := svcClient.ListComplete(ctx, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     azureComputeVirtualNetwork,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(azureComputeVirtualNetwork, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 
 		byteArr, err := json.Marshal(resMap)
@@ -146,10 +109,14 @@ func getVirtualNetworks(session *azure.AzureSession, rg string, wg *sync.WaitGro
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureComputeVirtualNetwork, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,31 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package compute
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-06-01/compute"
+	compute20210401 "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-04-01/compute"
+	network20180101 "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-01-01/network"
+	network20210301 "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
+	network20210501 "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-05-01/network"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_compute_vm":               {Type: reflect.TypeOf((*compute.VirtualMachine)(nil)).Elem()},
+	"azure_compute_networkinterface": {Type: reflect.TypeOf((*network20180101.Interface)(nil)).Elem()},
+	"azure_compute_virtual_network":  {Type: reflect.TypeOf((*network20210301.VirtualNetwork)(nil)).Elem(), FieldNames: true},
+	"azure_compute_subnet":           {Type: reflect.TypeOf((*network20210301.Subnet)(nil)).Elem(), FieldNames: true},
+	"azure_compute_disk":             {Type: reflect.TypeOf((*compute20210401.Disk)(nil)).Elem(), FieldNames: true},
+	"azure_compute_security_group":   {Type: reflect.TypeOf((*network20210501.SecurityGroup)(nil)).Elem(), FieldNames: true},
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,47 +18,28 @@ import (
 
 const cosmosdbMongodb string = "azure_cosmosdb_mongodb"
 
-// CosmosdbMongodbColumns returns the list of columns in the table
-func CosmosdbMongodbColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("options"),
-		// table.TextColumn("options_autoscale_settings"),
-		// table.IntegerColumn("options_autoscale_settings_max_throughput"),
-		// table.IntegerColumn("options_throughput"),
-		table.TextColumn("resource"),
-		// table.TextColumn("resource__etag"),
-		// table.TextColumn("resource__rid"),
-		// table.TextColumn("resource_id"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // CosmosdbMongodbGenerate returns the rows in the table for all configured accounts
 func CosmosdbMongodbGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": cosmosdbMongodb,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountCosmosdbMongodb(nil)
+		results, err := processAccountCosmosdbMongodb(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": cosmosdbMongodb,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountCosmosdbMongodb(&account)
+			results, err := processAccountCosmosdbMongodb(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(cosmosdbMongodb, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -69,22 +49,20 @@ func CosmosdbMongodbGenerate(osqCtx context.Context, queryContext table.QueryCon
 	return resultMap, nil
 }
 
-func processAccountCosmosdbMongodb(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountCosmosdbMongodb(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, cosmosdbMongodb, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, cosmosdbMongodb, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[cosmosdbMongodb]
+	tableConfig, ok := utilities.GetTableConfig(cosmosdbMongodb)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": cosmosdbMongodb,
@@ -92,41 +70,39 @@ func processAccountCosmosdbMongodb(account *utilities.ExtensionConfigurationAzur
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getCosmosdbAccountsForMongodb(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, cosmosdbMongodb, session, groups, tableConfig, getCosmosdbAccountsForMongodb)
 }
 
-func getCosmosdbAccountsForMongodb(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-	accoutnamelist, err := getCosmosdbAccountData(session, rg)
+func getCosmosdbAccountsForMongodb(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accoutnamelist, err := getCosmosdbAccountData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      cosmosdbMongodb,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get cosmosdb account list from api")
+		azure.ReportError(cosmosdbMongodb, session.SubscriptionId, rg, err)
+		return err
 	}
 	for _, accountnameinfo := range *accoutnamelist.Value {
-		setCosmosdbMongodbToTable(session, rg, wg, resultMap, tableConfig, *accountnameinfo.Name)
+		setCosmosdbMongodbToTable(ctx, queryContext, session, rg, resultMap, tableConfig, *accountnameinfo.Name)
 	}
+	return nil
 
 }
 
-func setCosmosdbMongodbToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
-	mongodblist, err := getCosmosdbMongodbData(session, rg, accountName)
+func setCosmosdbMongodbToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+	mongodblist, err := getCosmosdbMongodbData(ctx, session, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     cosmosdbMongodb,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get sql database list")
+		azure.ReportError(cosmosdbMongodb, session.SubscriptionId, rg, err)
 	}
 
 	for _, mongodb := range *mongodblist.Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(mongodb)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -137,17 +113,20 @@ func setCosmosdbMongodbToTable(session *azure.AzureSession, rg string, wg *sync.
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, cosmosdbMongodb, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
 
-func getCosmosdbMongodbData(session *azure.AzureSession, rg string, accountName string) (result documentdb.MongoDBDatabaseListResult, err error) {
+func getCosmosdbMongodbData(ctx context.Context, session *azure.AzureSession, rg string, accountName string) (result documentdb.MongoDBDatabaseListResult, err error) {
 	svcClient := documentdb.NewMongoDBResourcesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListMongoDBDatabases(context.Background(), rg, accountName)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListMongoDBDatabases(ctx, rg, accountName)
 }
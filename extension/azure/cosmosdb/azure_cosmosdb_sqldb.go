@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,50 +18,28 @@ import (
 
 const cosmosdbSqldb string = "azure_cosmosdb_sqldb"
 
-// CosmosdbSqldbsColumns returns the list of columns in the table
-func CosmosdbSqldbsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("options"),
-		// table.TextColumn("options_autoscale_settings"),
-		// table.IntegerColumn("options_autoscale_settings_max_throughput"),
-		// table.IntegerColumn("options_throughput"),
-		table.TextColumn("resource"),
-		// table.TextColumn("resource__colls"),
-		// table.TextColumn("resource__etag"),
-		// table.TextColumn("resource__rid"),
-		// table.DoubleColumn("resource__ts"),
-		// table.TextColumn("resource__users"),
-		// table.TextColumn("resource_id"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // CosmosdbSqldbsGenerate returns the rows in the table for all configured accounts
 func CosmosdbSqldbsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": cosmosdbSqldb,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountCosmosdbSqldbs(nil)
+		results, err := processAccountCosmosdbSqldbs(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": cosmosdbSqldb,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountCosmosdbSqldbs(&account)
+			results, err := processAccountCosmosdbSqldbs(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(cosmosdbSqldb, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -72,22 +49,20 @@ func CosmosdbSqldbsGenerate(osqCtx context.Context, queryContext table.QueryCont
 	return resultMap, nil
 }
 
-func processAccountCosmosdbSqldbs(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountCosmosdbSqldbs(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, cosmosdbSqldb, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, cosmosdbSqldb, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[cosmosdbSqldb]
+	tableConfig, ok := utilities.GetTableConfig(cosmosdbSqldb)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": cosmosdbSqldb,
@@ -95,40 +70,38 @@ func processAccountCosmosdbSqldbs(account *utilities.ExtensionConfigurationAzure
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getCosmosdbAccountforsqldb(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, cosmosdbSqldb, session, groups, tableConfig, getCosmosdbAccountforsqldb)
 }
 
-func getCosmosdbAccountforsqldb(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-	accoutnamelist, err := getCosmosdbAccountData(session, rg)
+func getCosmosdbAccountforsqldb(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accoutnamelist, err := getCosmosdbAccountData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      cosmosdbSqldb,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get cosmosdb account list from api")
+		azure.ReportError(cosmosdbSqldb, session.SubscriptionId, rg, err)
+		return err
 	}
 	for _, accountnameinfo := range *accoutnamelist.Value {
-		setCosmosdbSqldbDataToTable(session, rg, wg, resultMap, tableConfig, *accountnameinfo.Name)
+		setCosmosdbSqldbDataToTable(ctx, queryContext, session, rg, resultMap, tableConfig, *accountnameinfo.Name)
 	}
+	return nil
 
 }
-func setCosmosdbSqldbDataToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
-	sqldblist, err := getCosmosdbSqldbData(session, rg, accountName)
+func setCosmosdbSqldbDataToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+	sqldblist, err := getCosmosdbSqldbData(ctx, session, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     cosmosdbSqldb,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get sql database list")
+		azure.ReportError(cosmosdbSqldb, session.SubscriptionId, rg, err)
 	}
 
 	for _, sqldb := range *sqldblist.Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(sqldb)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -139,16 +112,19 @@ func setCosmosdbSqldbDataToTable(session *azure.AzureSession, rg string, wg *syn
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, cosmosdbSqldb, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getCosmosdbSqldbData(session *azure.AzureSession, rg string, accountName string) (result documentdb.SQLDatabaseListResult, err error) {
+func getCosmosdbSqldbData(ctx context.Context, session *azure.AzureSession, rg string, accountName string) (result documentdb.SQLDatabaseListResult, err error) {
 	svcClient := documentdb.NewSQLResourcesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListSQLDatabases(context.Background(), rg, accountName)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListSQLDatabases(ctx, rg, accountName)
 }
@@ -0,0 +1,24 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package cosmosdb
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2021-10-15/documentdb"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_cosmosdb_account": {Type: reflect.TypeOf((*documentdb.DatabaseAccountGetResults)(nil)).Elem(), FieldNames: true},
+	"azure_cosmosdb_mongodb": {Type: reflect.TypeOf((*documentdb.MongoDBDatabaseGetResults)(nil)).Elem(), FieldNames: true},
+	"azure_cosmosdb_sqldb":   {Type: reflect.TypeOf((*documentdb.SQLDatabaseGetResults)(nil)).Elem(), FieldNames: true},
+}
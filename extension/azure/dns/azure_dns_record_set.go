@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -13,82 +12,34 @@ import (
 	"github.com/Uptycs/basequery-go/plugin/table"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"github.com/Azure/azure-sdk-for-go/services/preview/dns/mgmt/2018-03-01-preview/dns"
+	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2018-05-01/dns"
 	"github.com/fatih/structs"
 )
 
 const azureDnsRecordSet string = "azure_dns_record_set"
 
-// DnsRecordSetColumns returns the list of columns in the table
-func DnsRecordSetColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("aaaa_records"),
-		// table.TextColumn("aaaa_records_ipv6_address"),
-		table.TextColumn("a_records"),
-		// table.TextColumn("a_records_ipv4_address"),
-		table.TextColumn("cname_record"),
-		// table.TextColumn("cname_record_cname"),
-		table.TextColumn("mx_records"),
-		// table.TextColumn("mx_records_exchange"),
-		// table.IntegerColumn("mx_records_preference"),
-		table.TextColumn("ns_records"),
-		// table.TextColumn("ns_records_nsdname"),
-		table.TextColumn("ptr_records"),
-		// table.TextColumn("ptr_records_ptrdname"),
-		table.TextColumn("soa_record"),
-		// table.TextColumn("soa_record_email"),
-		// table.BigIntColumn("soa_record_expire_time"),
-		// table.TextColumn("soa_record_host"),
-		// table.BigIntColumn("soa_record_minimum_ttl"),
-		// table.BigIntColumn("soa_record_refresh_time"),
-		// table.BigIntColumn("soa_record_retry_time"),
-		// table.BigIntColumn("soa_record_serial_number"),
-		table.TextColumn("srv_records"),
-		// table.IntegerColumn("srv_records_port"),
-		// table.IntegerColumn("srv_records_priority"),
-		// table.TextColumn("srv_records_target"),
-		// table.IntegerColumn("srv_records_weight"),
-		table.BigIntColumn("ttl"),
-		table.TextColumn("txt_records"),
-		// table.TextColumn("txt_records_value"),
-		table.TextColumn("caa_records"),
-		// table.IntegerColumn("caa_records_flags"),
-		// table.TextColumn("caa_records_tag"),
-		// table.TextColumn("caa_records_value"),
-		table.TextColumn("fqdn"),
-		table.TextColumn("metadata"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("target_resource"),
-		// table.TextColumn("target_resource_id"),
-		table.TextColumn("type"),
-	}
-}
-
 // DnsRecordSetGenerate returns the rows in the table for all configured accounts
 func DnsRecordSetGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureDnsRecordSet,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processDnsRecordSet(nil)
+		results, err := processDnsRecordSet(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureDnsRecordSet,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processDnsRecordSet(&account)
+			results, err := processDnsRecordSet(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureDnsRecordSet, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -98,22 +49,20 @@ func DnsRecordSetGenerate(osqCtx context.Context, queryContext table.QueryContex
 	return resultMap, nil
 }
 
-func processDnsRecordSet(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processDnsRecordSet(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureDnsRecordSet, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, azureDnsRecordSet, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureDnsRecordSet]
+	tableConfig, ok := utilities.GetTableConfig(azureDnsRecordSet)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureDnsRecordSet,
@@ -121,45 +70,42 @@ func processDnsRecordSet(account *utilities.ExtensionConfigurationAzureAccount)
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go collectDnsZonetoTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, azureDnsRecordSet, session, groups, tableConfig, collectDnsZonetoTable)
 }
 
-func collectDnsZonetoTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourcesItr, err := getDnsZoneData(session, rg); resourcesItr.NotDone(); resourcesItr.Next() {
+func collectDnsZonetoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	for resourcesItr, err := getDnsZoneData(ctx, session, rg); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":      azureDnsRecordSet,
 				"rescourceGroup": rg,
 				"errString":      err.Error(),
 			}).Error("failed to get DNS zones")
+			azure.ReportError(azureDnsRecordSet, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourcesItr.Value()
 
-		setDnsRecordSettoTable(session, rg, *resource.Name, resultMap, tableConfig)
+		setDnsRecordSettoTable(ctx, queryContext, session, rg, *resource.Name, resultMap, tableConfig)
 	}
+	return nil
 }
 
-func setDnsRecordSettoTable(session *azure.AzureSession, rg string, zone string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setDnsRecordSettoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, zone string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	for resourcesItr, err := getDnsRecordSetData(session, rg, zone); resourcesItr.NotDone(); resourcesItr.Next() {
+	for resourcesItr, err := getDnsRecordSetData(ctx, session, rg, zone); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":      azureDnsRecordSet,
 				"rescourceGroup": rg,
 				"errString":      err.Error(),
 			}).Error("failed to get DNS zones")
+			azure.ReportError(azureDnsRecordSet, session.SubscriptionId, rg, err)
 		}
 
 		resource := resourcesItr.Value()
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -170,15 +116,18 @@ func setDnsRecordSettoTable(session *azure.AzureSession, rg string, zone string,
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureDnsRecordSet, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getDnsRecordSetData(session *azure.AzureSession, rg string, zone string) (result dns.RecordSetListResultIterator, err error) {
+func getDnsRecordSetData(ctx context.Context, session *azure.AzureSession, rg string, zone string) (result dns.RecordSetListResultIterator, err error) {
 	svcClient := dns.NewRecordSetsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListAllByDNSZoneComplete(context.Background(), rg, zone, nil, "")
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListAllByDNSZoneComplete(ctx, rg, zone, nil, "")
 }
@@ -0,0 +1,23 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package dns
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2018-05-01/dns"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_dns_record_set": {Type: reflect.TypeOf((*dns.RecordSet)(nil)).Elem(), FieldNames: true},
+	"azure_dns_zone":       {Type: reflect.TypeOf((*dns.Zone)(nil)).Elem(), FieldNames: true},
+}
@@ -0,0 +1,43 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// ReportError records a failure to collect the rows of given table for a subscription, or one of its resource groups.
+// The API and the error code are read from the error returned by the SDK.
+func ReportError(tableName string, subscriptionID string, resourceGroup string, err error) {
+	collectionError := utilities.CollectionError{
+		Table:    tableName,
+		Provider: utilities.ProviderAzure,
+		Account:  subscriptionID,
+		Region:   resourceGroup,
+		Message:  err.Error(),
+	}
+	var detailedErr autorest.DetailedError
+	if errors.As(err, &detailedErr) {
+		collectionError.API = detailedErr.PackageType + "." + detailedErr.Method
+		if detailedErr.StatusCode != nil && detailedErr.StatusCode != 0 {
+			collectionError.Code = fmt.Sprintf("%v", detailedErr.StatusCode)
+		}
+	}
+	var requestErr *azure.RequestError
+	if errors.As(err, &requestErr) && requestErr.ServiceError != nil && requestErr.ServiceError.Code != "" {
+		collectionError.Code = requestErr.ServiceError.Code
+	}
+	utilities.ReportCollectionError(collectionError)
+}
@@ -0,0 +1,77 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// ShouldProcessSubscription returns false if given subscription is not supposed to be processed for given table.
+// Subscriptions excluded by the filter rules, or which can not satisfy the subscription id constraints of the query are skipped.
+func ShouldProcessSubscription(queryContext table.QueryContext, tableName string, subscriptionId string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAzure.Filters, utilities.FilterLevelAccount, tableName, subscriptionId, "", nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Azure.SubscriptionIDAttribute, subscriptionId)
+}
+
+// GetTableSession returns the session of given account, or nil if its subscription is not supposed to be processed for given table.
+// The subscription of a configured account is checked before the credentials of the account are used,
+// the subscription of the default account is only known once its credentials are read.
+func GetTableSession(queryContext table.QueryContext, tableName string, account *utilities.ExtensionConfigurationAzureAccount) (*AzureSession, error) {
+	if account != nil && account.SubscriptionID != "" && !ShouldProcessSubscription(queryContext, tableName, account.SubscriptionID) {
+		return nil, nil
+	}
+	session, err := GetAuthSession(account)
+	if err != nil {
+		return nil, err
+	}
+	if !ShouldProcessSubscription(queryContext, tableName, session.SubscriptionId) {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// ShouldProcessResourceGroup returns false if given resource group is not supposed to be processed for given table.
+// Resource groups excluded by the filter rules, or which can not satisfy the resource group constraints of the query are skipped.
+func ShouldProcessResourceGroup(queryContext table.QueryContext, tableName string, subscriptionId string, resourceGroup string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAzure.Filters, utilities.FilterLevelRegion, tableName, subscriptionId, resourceGroup, nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Azure.ResourceGroupAttribute, resourceGroup)
+}
+
+// FilterGroups returns the resource groups from given list which are supposed to be processed for given table
+func FilterGroups(queryContext table.QueryContext, tableName string, subscriptionId string, groups []string) []string {
+	filtered := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if ShouldProcessResourceGroup(queryContext, tableName, subscriptionId, group) {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
+// ShouldProcessRow returns false if given row is excluded by the filter rules for given table.
+// resourceGroup is empty for the tables which are not per resource group.
+func ShouldProcessRow(ctx context.Context, queryContext table.QueryContext, tableName string, subscriptionId string, resourceGroup string, row map[string]interface{}) bool {
+	return utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfAzure.Filters, utilities.FilterLevelRow, tableName, subscriptionId, resourceGroup, utilities.InterfaceRow(row))
+}
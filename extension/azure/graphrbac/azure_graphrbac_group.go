@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,43 +19,32 @@ import (
 
 const azureGraphrbacGroup string = "azure_graphrbac_group"
 
-// GraphrbacGroupColunmns returns the list of columns in the table
-func GraphrbacGroupColunmns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("deletion_timestamp"),
-		table.TextColumn("display_name"),
-		table.TextColumn("mail"),
-		table.TextColumn("mail_enabled"),
-		table.TextColumn("mail_nickname"),
-		table.TextColumn("object_id"),
-		table.TextColumn("object_type"),
-		table.TextColumn("security_enabled"),
-	}
-}
-
 // GraphrbacGroupGenerate returns the rows in the table for all configured accounts
 func GraphrbacGroupGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacGroup,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountGraphrbacGroup(nil)
+		results, err := processAccountGraphrbacGroup(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacGroup,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountGraphrbacGroup(&account)
+			startTime := time.Now()
+			results, err := processAccountGraphrbacGroup(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureGraphrbacGroup, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(azureGraphrbacGroup, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -62,15 +52,15 @@ func GraphrbacGroupGenerate(osqCtx context.Context, queryContext table.QueryCont
 	return resultMap, nil
 }
 
-func processAccountGraphrbacGroup(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountGraphrbacGroup(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
 
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureGraphrbacGroup, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
 
-	tableConfig, ok := utilities.TableConfigurationMap[azureGraphrbacGroup]
+	tableConfig, ok := utilities.GetTableConfig(azureGraphrbacGroup)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacGroup,
@@ -78,25 +68,25 @@ func processAccountGraphrbacGroup(account *utilities.ExtensionConfigurationAzure
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setGraphrbacGrouptoTable(account.TenantID, session, &resultMap, tableConfig)
+	setGraphrbacGrouptoTable(ctx, queryContext, account.TenantID, session, &resultMap, tableConfig)
 
 	return resultMap, nil
 }
 
-func setGraphrbacGrouptoTable(tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setGraphrbacGrouptoTable(ctx context.Context, queryContext table.QueryContext, tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	for resourcesItr, err := getGraphrbacGroupData(session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
+	for resourcesItr, err := getGraphrbacGroupData(ctx, session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacGroup,
 				"TenantId":  tenantId,
 				"errString": err.Error(),
 			}).Error("failed to get group list iterator zones")
+			azure.ReportError(azureGraphrbacGroup, session.SubscriptionId, "", err)
 		}
 
 		resource := resourcesItr.Value()
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -107,16 +97,19 @@ func setGraphrbacGrouptoTable(tenantId string, session *azure.AzureSession, resu
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureGraphrbacGroup, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 
 }
-func getGraphrbacGroupData(session *azure.AzureSession, tenantId string) (result graphrbac.GroupListResultIterator, err error) {
+func getGraphrbacGroupData(ctx context.Context, session *azure.AzureSession, tenantId string) (result graphrbac.GroupListResultIterator, err error) {
 	svcClient := graphrbac.NewGroupsClient(tenantId)
-	svcClient.Authorizer = session.GraphAuthorizer
-	return svcClient.ListComplete(context.Background(), "")
+	azure.ConfigureClient(&svcClient.Client, session.GraphAuthorizer)
+	return svcClient.ListComplete(ctx, "")
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,85 +19,32 @@ import (
 
 const azureGraphrbacServicePrincipal string = "azure_graphrbac_service_principal"
 
-// GraphrbacServicePrincipalColumns returns the list of columns in the table
-func GraphrbacServicePrincipalColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_enabled"),
-		table.TextColumn("alternative_names"),
-		table.TextColumn("app_display_name"),
-		table.TextColumn("app_id"),
-		table.TextColumn("app_owner_tenant_id"),
-		table.TextColumn("app_role_assignment_required"),
-		table.TextColumn("app_roles"),
-		table.TextColumn("app_roles_allowed_member_types"),
-		table.TextColumn("app_roles_description"),
-		table.TextColumn("app_roles_display_name"),
-		table.TextColumn("app_roles_id"),
-		table.TextColumn("app_roles_is_enabled"),
-		table.TextColumn("app_roles_value"),
-		table.TextColumn("deletion_timestamp"),
-		table.TextColumn("display_name"),
-		table.TextColumn("error_url"),
-		table.TextColumn("homepage"),
-		table.TextColumn("key_credentials"),
-		table.TextColumn("key_credentials_custom_key_identifier"),
-		table.TextColumn("key_credentials_end_date"),
-		table.TextColumn("key_credentials_key_id"),
-		table.TextColumn("key_credentials_start_date"),
-		table.TextColumn("key_credentials_type"),
-		table.TextColumn("key_credentials_usage"),
-		table.TextColumn("key_credentials_value"),
-		table.TextColumn("logout_url"),
-		table.TextColumn("oauth2_permissions"),
-		table.TextColumn("oauth2_permissions_admin_consent_description"),
-		table.TextColumn("oauth2_permissions_admin_consent_display_name"),
-		table.TextColumn("oauth2_permissions_id"),
-		table.TextColumn("oauth2_permissions_is_enabled"),
-		table.TextColumn("oauth2_permissions_type"),
-		table.TextColumn("oauth2_permissions_user_consent_description"),
-		table.TextColumn("oauth2_permissions_user_consent_display_name"),
-		table.TextColumn("oauth2_permissions_value"),
-		table.TextColumn("object_id"),
-		table.TextColumn("object_type"),
-		table.TextColumn("password_credentials"),
-		table.TextColumn("password_credentials_custom_key_identifier"),
-		table.TextColumn("password_credentials_end_date"),
-		table.TextColumn("password_credentials_key_id"),
-		table.TextColumn("password_credentials_start_date"),
-		table.TextColumn("password_credentials_value"),
-		table.TextColumn("preferred_token_signing_key_thumbprint"),
-		table.TextColumn("publisher_name"),
-		table.TextColumn("reply_urls"),
-		table.TextColumn("saml_metadata_url"),
-		table.TextColumn("service_principal_names"),
-		table.TextColumn("service_principal_type"),
-		table.TextColumn("tags"),
-	}
-}
-
 // GraphrbacServicePrincipalGenerate returns the rows in the table for all configured accounts
 func GraphrbacServicePrincipalGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacServicePrincipal,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processGraphrbacServicePrincipal(nil)
+		results, err := processGraphrbacServicePrincipal(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacServicePrincipal,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processGraphrbacServicePrincipal(&account)
+			startTime := time.Now()
+			results, err := processGraphrbacServicePrincipal(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureGraphrbacServicePrincipal, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(azureGraphrbacServicePrincipal, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -104,15 +52,15 @@ func GraphrbacServicePrincipalGenerate(osqCtx context.Context, queryContext tabl
 	return resultMap, nil
 }
 
-func processGraphrbacServicePrincipal(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processGraphrbacServicePrincipal(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
 
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureGraphrbacServicePrincipal, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
 
-	tableConfig, ok := utilities.TableConfigurationMap[azureGraphrbacServicePrincipal]
+	tableConfig, ok := utilities.GetTableConfig(azureGraphrbacServicePrincipal)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacServicePrincipal,
@@ -120,25 +68,25 @@ func processGraphrbacServicePrincipal(account *utilities.ExtensionConfigurationA
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setGraphrbacServicePrincipaltoTable(account.TenantID, session, &resultMap, tableConfig)
+	setGraphrbacServicePrincipaltoTable(ctx, queryContext, account.TenantID, session, &resultMap, tableConfig)
 
 	return resultMap, nil
 }
 
-func setGraphrbacServicePrincipaltoTable(tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setGraphrbacServicePrincipaltoTable(ctx context.Context, queryContext table.QueryContext, tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	for resourcesItr, err := getGraphrbacServicePrincipalData(session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
+	for resourcesItr, err := getGraphrbacServicePrincipalData(ctx, session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacServicePrincipal,
 				"TenantId":  tenantId,
 				"errString": err.Error(),
 			}).Error("failed to get DNS zones")
+			azure.ReportError(azureGraphrbacServicePrincipal, session.SubscriptionId, "", err)
 		}
 
 		resource := resourcesItr.Value()
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -149,15 +97,18 @@ func setGraphrbacServicePrincipaltoTable(tenantId string, session *azure.AzureSe
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureGraphrbacServicePrincipal, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getGraphrbacServicePrincipalData(session *azure.AzureSession, tenantId string) (result graphrbac.ServicePrincipalListResultIterator, err error) {
+func getGraphrbacServicePrincipalData(ctx context.Context, session *azure.AzureSession, tenantId string) (result graphrbac.ServicePrincipalListResultIterator, err error) {
 	svcClient := graphrbac.NewServicePrincipalsClient(tenantId)
-	svcClient.Authorizer = session.GraphAuthorizer
-	return svcClient.ListComplete(context.Background(), "")
+	azure.ConfigureClient(&svcClient.Client, session.GraphAuthorizer)
+	return svcClient.ListComplete(ctx, "")
 }
@@ -13,6 +13,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -28,66 +29,47 @@ import (
 
 var azureGraphrbacUser string = "azure_graphrbac_user"
 
-// GraphrbacUserColumns returns the list of columns in the table
-func GraphrbacUserColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("account_enabled"),
-		table.TextColumn("deletion_timestamp"),
-		table.TextColumn("display_name"),
-		table.TextColumn("given_name"),
-		table.TextColumn("immutable_id"),
-		table.TextColumn("mail"),
-		table.TextColumn("mail_nickname"),
-		table.TextColumn("object_id"),
-		table.TextColumn("object_type"),
-		table.TextColumn("sign_in_names"),
-		table.TextColumn("sign_in_names_type"),
-		table.TextColumn("sign_in_names_value"),
-		table.TextColumn("surname"),
-		table.TextColumn("usage_location"),
-		table.TextColumn("user_principal_name"),
-		table.TextColumn("user_type"),
-	}
-}
-
 // GraphrbacUsersGenerate returns the rows in the table for all configured accounts
 func GraphrbacUsersGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacUser,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountGraphrbacUsers(nil)
+		results, err := processAccountGraphrbacUsers(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacUser,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountGraphrbacUsers(&account)
+			startTime := time.Now()
+			results, err := processAccountGraphrbacUsers(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureGraphrbacUser, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(azureGraphrbacUser, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
 
 	return resultMap, nil
 }
-func processAccountGraphrbacUsers(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountGraphrbacUsers(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
 
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureGraphrbacUser, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
 
-	tableConfig, ok := utilities.TableConfigurationMap[azureGraphrbacUser]
+	tableConfig, ok := utilities.GetTableConfig(azureGraphrbacUser)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureGraphrbacUser,
@@ -95,25 +77,25 @@ func processAccountGraphrbacUsers(account *utilities.ExtensionConfigurationAzure
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setGraphrbacUserstoTable(account.TenantID, session, &resultMap, tableConfig)
+	setGraphrbacUserstoTable(ctx, queryContext, account.TenantID, session, &resultMap, tableConfig)
 
 	return resultMap, nil
 }
 
-func setGraphrbacUserstoTable(tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setGraphrbacUserstoTable(ctx context.Context, queryContext table.QueryContext, tenantId string, session *azure.AzureSession, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	for resourcesItr, err := getGraphrbacUsersData(session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
+	for resourcesItr, err := getGraphrbacUsersData(ctx, session, tenantId); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureGraphrbacUser,
 				"TenantId":  tenantId,
 				"errString": err.Error(),
 			}).Error("failed to get DNS zones")
+			azure.ReportError(azureGraphrbacUser, session.SubscriptionId, "", err)
 		}
 
 		resource := resourcesItr.Value()
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -124,15 +106,18 @@ func setGraphrbacUserstoTable(tenantId string, session *azure.AzureSession, resu
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureGraphrbacUser, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getGraphrbacUsersData(session *azure.AzureSession, tenantId string) (result graphrbac.UserListResultIterator, err error) {
+func getGraphrbacUsersData(ctx context.Context, session *azure.AzureSession, tenantId string) (result graphrbac.UserListResultIterator, err error) {
 	svcClient := graphrbac.NewUsersClient(tenantId)
-	svcClient.Authorizer = session.GraphAuthorizer
-	return svcClient.ListComplete(context.Background(), "", "")
+	azure.ConfigureClient(&svcClient.Client, session.GraphAuthorizer)
+	return svcClient.ListComplete(ctx, "", "")
 }
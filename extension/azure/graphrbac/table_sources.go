@@ -0,0 +1,24 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package graphrbac
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_graphrbac_user":              {Type: reflect.TypeOf((*graphrbac.User)(nil)).Elem(), FieldNames: true},
+	"azure_graphrbac_service_principal": {Type: reflect.TypeOf((*graphrbac.ServicePrincipal)(nil)).Elem(), FieldNames: true},
+	"azure_graphrbac_group":             {Type: reflect.TypeOf((*graphrbac.ADGroup)(nil)).Elem(), FieldNames: true},
+}
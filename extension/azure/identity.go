@@ -0,0 +1,57 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// GetAccountIdentity returns the tenant of given account, and the expiry of its management API token.
+// The token is refreshed, so credentials which are not valid anymore fail the check.
+func GetAccountIdentity(ctx context.Context, account *utilities.ExtensionConfigurationAzureAccount) (utilities.AccountIdentity, error) {
+	identity := utilities.AccountIdentity{CredentialSource: getCredentialSource(account)}
+	session, err := GetAuthSession(account)
+	if err != nil {
+		return identity, err
+	}
+	identity.Identity = session.TenantId
+	if bearer, ok := session.Authorizer.(*autorest.BearerAuthorizer); ok {
+		if token, ok := bearer.TokenProvider().(*adal.ServicePrincipalToken); ok {
+			if err := token.EnsureFreshWithContext(ctx); err != nil {
+				return identity, err
+			}
+			identity.Expiry = token.Token().Expires()
+		}
+	}
+	return identity, nil
+}
+
+// credentialSources are the credential sources reported for the authentication types
+var credentialSources = map[string]string{
+	utilities.AzureAuthFile:              "auth_file",
+	utilities.AzureAuthClientSecret:      "client_secret",
+	utilities.AzureAuthClientCertificate: "client_certificate",
+	utilities.AzureAuthManagedIdentity:   "managed_identity",
+	utilities.AzureAuthWorkloadIdentity:  "workload_identity",
+	utilities.AzureAuthEnvironment:       "environment",
+	utilities.AzureAuthCli:               "cli",
+}
+
+// getCredentialSource returns how the credentials of given account are created, following GetAuthSession
+func getCredentialSource(account *utilities.ExtensionConfigurationAzureAccount) string {
+	if account == nil {
+		return credentialSources[utilities.AzureAuthFile]
+	}
+	return credentialSources[account.GetAuthType()]
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,52 +17,28 @@ import (
 
 const keyvaultKey string = "azure_keyvault_key"
 
-// KeyvaultKeyColumns returns the list of columns in the table
-func KeyvaultKeyColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("attributes"),
-		// table.BigIntColumn("attributes_created"),
-		// table.TextColumn("attributes_enabled"),
-		// table.BigIntColumn("attributes_exp"),
-		// table.BigIntColumn("attributes_nbf"),
-		// table.TextColumn("attributes_recovery_level"),
-		// table.BigIntColumn("attributes_updated"),
-		table.TextColumn("curve_name"),
-		table.TextColumn("key_ops"),
-		table.IntegerColumn("key_size"),
-		table.TextColumn("key_uri"),
-		table.TextColumn("key_uri_with_version"),
-		table.TextColumn("kty"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // KeyvaultKeysGenerate returns the rows in the table for all configured accounts
 func KeyvaultKeysGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultKey,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountKeyvaultKeys(nil)
+		results, err := processAccountKeyvaultKeys(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": keyvaultKey,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountKeyvaultKeys(&account)
+			results, err := processAccountKeyvaultKeys(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(keyvaultKey, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -73,22 +48,20 @@ func KeyvaultKeysGenerate(osqCtx context.Context, queryContext table.QueryContex
 	return resultMap, nil
 }
 
-func processAccountKeyvaultKeys(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountKeyvaultKeys(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, keyvaultKey, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, keyvaultKey, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[keyvaultKey]
+	tableConfig, ok := utilities.GetTableConfig(keyvaultKey)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultKey,
@@ -96,47 +69,46 @@ func processAccountKeyvaultKeys(account *utilities.ExtensionConfigurationAzureAc
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go setKeyvaultKeyToTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, keyvaultKey, session, groups, tableConfig, setKeyvaultKeyToTable)
 }
 
-func setKeyvaultKeyToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := getKeyvaultVaultData(session, rg)
+func setKeyvaultKeyToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := getKeyvaultVaultData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      keyvaultKey,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get keyvault vault list from api")
+		azure.ReportError(keyvaultKey, session.SubscriptionId, rg, err)
+		return err
 	}
 
+	vaultNames := make([]string, 0)
 	for _, vault := range *resources.Response().Value {
-		structs.DefaultTagName = "json"
-		setKeyvaultKeyToTableHelper(session, rg, wg, resultMap, tableConfig, *vault.Name)
+		vaultNames = append(vaultNames, *vault.Name)
 	}
+	return azure.ProcessResources(ctx, keyvaultKey, session, rg, vaultNames, resultMap, func(ctx context.Context, vaultName string, resultMap *[]map[string]string) error {
+		return setKeyvaultKeyToTableHelper(ctx, queryContext, session, rg, resultMap, tableConfig, vaultName)
+	})
 }
-func setKeyvaultKeyToTableHelper(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, vaultName string) {
+func setKeyvaultKeyToTableHelper(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, vaultName string) error {
 
 	KeysList := make([]keyvault.Key, 0)
-	resourceItr, err := getKeyvaultKeyHelperData(session, rg, vaultName)
+	resourceItr, err := getKeyvaultKeyHelperData(ctx, session, rg, vaultName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     keyvaultKey,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get list from api")
-
+		azure.ReportError(keyvaultKey, session.SubscriptionId, rg, err)
+		return err
 	}
 	resource := resourceItr.Response().Value
 	KeysList = append(KeysList, *resource...)
 	for _, KeyList := range KeysList {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(KeyList)
 		byteArr, err := json.Marshal(resMap)
 
@@ -149,17 +121,21 @@ func setKeyvaultKeyToTableHelper(session *azure.AzureSession, rg string, wg *syn
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, keyvaultKey, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
-func getKeyvaultKeyHelperData(session *azure.AzureSession, rg string, vaultName string) (result keyvault.KeyListResultPage, err error) {
+func getKeyvaultKeyHelperData(ctx context.Context, session *azure.AzureSession, rg string, vaultName string) (result keyvault.KeyListResultPage, err error) {
 
 	svcClient := keyvault.NewKeysClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background(), rg, vaultName)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx, rg, vaultName)
 
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,126 +17,28 @@ import (
 
 const keyvaultSecret string = "azure_keyvault_secret"
 
-// KeyvaultSecretColumns returns the list of columns in the table
-func KeyvaultSecretColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		// table.TextColumn("attributes"),
-		table.TextColumn("attributes_created"),
-		// table.BigIntColumn("attributes_created_ext"),
-		// table.TextColumn("attributes_created_loc"),
-		// table.BigIntColumn("attributes_created_loc_cache_end"),
-		// table.BigIntColumn("attributes_created_loc_cache_start"),
-		// table.TextColumn("attributes_created_loc_cache_zone"),
-		// table.TextColumn("attributes_created_loc_cache_zone_is_dst"),
-		// table.TextColumn("attributes_created_loc_cache_zone_name"),
-		// table.IntegerColumn("attributes_created_loc_cache_zone_offset"),
-		// table.TextColumn("attributes_created_loc_extend"),
-		// table.TextColumn("attributes_created_loc_name"),
-		// table.TextColumn("attributes_created_loc_tx"),
-		// table.IntegerColumn("attributes_created_loc_tx_index"),
-		// table.TextColumn("attributes_created_loc_tx_isstd"),
-		// table.TextColumn("attributes_created_loc_tx_isutc"),
-		// table.BigIntColumn("attributes_created_loc_tx_when"),
-		// table.TextColumn("attributes_created_loc_zone"),
-		// table.TextColumn("attributes_created_loc_zone_is_dst"),
-		// table.TextColumn("attributes_created_loc_zone_name"),
-		// table.IntegerColumn("attributes_created_loc_zone_offset"),
-		// table.BigIntColumn("attributes_created_wall"),
-		table.TextColumn("attributes_enabled"),
-		table.TextColumn("attributes_exp"),
-		// table.BigIntColumn("attributes_exp_ext"),
-		// table.TextColumn("attributes_exp_loc"),
-		// table.BigIntColumn("attributes_exp_loc_cache_end"),
-		// table.BigIntColumn("attributes_exp_loc_cache_start"),
-		// table.TextColumn("attributes_exp_loc_cache_zone"),
-		// table.TextColumn("attributes_exp_loc_cache_zone_is_dst"),
-		// table.TextColumn("attributes_exp_loc_cache_zone_name"),
-		// table.IntegerColumn("attributes_exp_loc_cache_zone_offset"),
-		// table.TextColumn("attributes_exp_loc_extend"),
-		// table.TextColumn("attributes_exp_loc_name"),
-		// table.TextColumn("attributes_exp_loc_tx"),
-		// table.IntegerColumn("attributes_exp_loc_tx_index"),
-		// table.TextColumn("attributes_exp_loc_tx_isstd"),
-		// table.TextColumn("attributes_exp_loc_tx_isutc"),
-		// table.BigIntColumn("attributes_exp_loc_tx_when"),
-		// table.TextColumn("attributes_exp_loc_zone"),
-		// table.TextColumn("attributes_exp_loc_zone_is_dst"),
-		// table.TextColumn("attributes_exp_loc_zone_name"),
-		// table.IntegerColumn("attributes_exp_loc_zone_offset"),
-		// table.BigIntColumn("attributes_exp_wall"),
-		table.TextColumn("attributes_nbf"),
-		// table.BigIntColumn("attributes_nbf_ext"),
-		// table.TextColumn("attributes_nbf_loc"),
-		// table.BigIntColumn("attributes_nbf_loc_cache_end"),
-		// table.BigIntColumn("attributes_nbf_loc_cache_start"),
-		// table.TextColumn("attributes_nbf_loc_cache_zone"),
-		// table.TextColumn("attributes_nbf_loc_cache_zone_is_dst"),
-		// table.TextColumn("attributes_nbf_loc_cache_zone_name"),
-		// table.IntegerColumn("attributes_nbf_loc_cache_zone_offset"),
-		// table.TextColumn("attributes_nbf_loc_extend"),
-		// table.TextColumn("attributes_nbf_loc_name"),
-		// table.TextColumn("attributes_nbf_loc_tx"),
-		// table.IntegerColumn("attributes_nbf_loc_tx_index"),
-		// table.TextColumn("attributes_nbf_loc_tx_isstd"),
-		// table.TextColumn("attributes_nbf_loc_tx_isutc"),
-		// table.BigIntColumn("attributes_nbf_loc_tx_when"),
-		// table.TextColumn("attributes_nbf_loc_zone"),
-		// table.TextColumn("attributes_nbf_loc_zone_is_dst"),
-		// table.TextColumn("attributes_nbf_loc_zone_name"),
-		// table.IntegerColumn("attributes_nbf_loc_zone_offset"),
-		// table.BigIntColumn("attributes_nbf_wall"),
-		table.IntegerColumn("attributes_recoverable_days"),
-		table.TextColumn("attributes_recovery_level"),
-		table.TextColumn("attributes_updated"),
-		// table.BigIntColumn("attributes_updated_ext"),
-		// table.TextColumn("attributes_updated_loc"),
-		// table.BigIntColumn("attributes_updated_loc_cache_end"),
-		// table.BigIntColumn("attributes_updated_loc_cache_start"),
-		// table.TextColumn("attributes_updated_loc_cache_zone"),
-		// table.TextColumn("attributes_updated_loc_cache_zone_is_dst"),
-		// table.TextColumn("attributes_updated_loc_cache_zone_name"),
-		// table.IntegerColumn("attributes_updated_loc_cache_zone_offset"),
-		// table.TextColumn("attributes_updated_loc_extend"),
-		// table.TextColumn("attributes_updated_loc_name"),
-		// table.TextColumn("attributes_updated_loc_tx"),
-		// table.IntegerColumn("attributes_updated_loc_tx_index"),
-		// table.TextColumn("attributes_updated_loc_tx_isstd"),
-		// table.TextColumn("attributes_updated_loc_tx_isutc"),
-		// table.BigIntColumn("attributes_updated_loc_tx_when"),
-		// table.TextColumn("attributes_updated_loc_zone"),
-		// table.TextColumn("attributes_updated_loc_zone_is_dst"),
-		// table.TextColumn("attributes_updated_loc_zone_name"),
-		// table.IntegerColumn("attributes_updated_loc_zone_offset"),
-		// table.BigIntColumn("attributes_updated_wall"),
-		table.TextColumn("content_type"),
-		table.TextColumn("id"),
-		table.TextColumn("kid"),
-		table.TextColumn("managed"),
-		table.TextColumn("tags"),
-	}
-}
-
 // KeyvaultSecretsGenerate returns the rows in the table for all configured accounts
 func KeyvaultSecretsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultSecret,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountKeyvaultSecrets(nil)
+		results, err := processAccountKeyvaultSecrets(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": keyvaultSecret,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountKeyvaultSecrets(&account)
+			results, err := processAccountKeyvaultSecrets(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(keyvaultSecret, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -147,21 +48,19 @@ func KeyvaultSecretsGenerate(osqCtx context.Context, queryContext table.QueryCon
 	return resultMap, nil
 }
 
-func processAccountKeyvaultSecrets(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountKeyvaultSecrets(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, keyvaultSecret, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, keyvaultSecret, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[keyvaultSecret]
+	tableConfig, ok := utilities.GetTableConfig(keyvaultSecret)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultSecret,
@@ -169,46 +68,45 @@ func processAccountKeyvaultSecrets(account *utilities.ExtensionConfigurationAzur
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go setKeyvaultSecretToTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, keyvaultSecret, session, groups, tableConfig, setKeyvaultSecretToTable)
 }
 
-func setKeyvaultSecretToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := getKeyvaultVaultData(session, rg)
+func setKeyvaultSecretToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := getKeyvaultVaultData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      keyvaultSecret,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get keyvault vault list from api")
+		azure.ReportError(keyvaultSecret, session.SubscriptionId, rg, err)
+		return err
 	}
 
+	vaultNames := make([]string, 0)
 	for _, vault := range *resources.Response().Value {
-		structs.DefaultTagName = "json"
-		setKeyvaultSecretToTableHelper(session, rg, wg, resultMap, tableConfig, *vault.Name)
+		vaultNames = append(vaultNames, *vault.Name)
 	}
+	return azure.ProcessResources(ctx, keyvaultSecret, session, rg, vaultNames, resultMap, func(ctx context.Context, vaultName string, resultMap *[]map[string]string) error {
+		return setKeyvaultSecretToTableHelper(ctx, queryContext, session, rg, resultMap, tableConfig, vaultName)
+	})
 }
-func setKeyvaultSecretToTableHelper(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, vaultName string) {
+func setKeyvaultSecretToTableHelper(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, vaultName string) error {
 
 	vaultBaseURL := "https://" + vaultName + ".vault.azure.net"
-	resourceItr, err := getKeyvaultSecretHelperData(session, rg, vaultBaseURL)
+	resourceItr, err := getKeyvaultSecretHelperData(ctx, session, rg, vaultBaseURL)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     keyvaultSecret,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get list from api")
-		return
+		azure.ReportError(keyvaultSecret, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, secret := range *resourceItr.Response().Value {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(secret)
 		byteArr, err := json.Marshal(resMap)
 
@@ -221,17 +119,21 @@ func setKeyvaultSecretToTableHelper(session *azure.AzureSession, rg string, wg *
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, keyvaultSecret, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
-func getKeyvaultSecretHelperData(session *azure.AzureSession, rg string, vaultBaseURL string) (result keyvault.SecretListResultPage, err error) {
+func getKeyvaultSecretHelperData(ctx context.Context, session *azure.AzureSession, rg string, vaultBaseURL string) (result keyvault.SecretListResultPage, err error) {
 
 	var top int32 = 25
 	svcClient := keyvault.New()
-	svcClient.Authorizer = session.VaultAuthorizer
-	return svcClient.GetSecrets(context.Background(), vaultBaseURL, &top)
+	azure.ConfigureClient(&svcClient.Client, session.VaultAuthorizer)
+	return svcClient.GetSecrets(ctx, vaultBaseURL, &top)
 }
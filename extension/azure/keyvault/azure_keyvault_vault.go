@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,69 +18,28 @@ import (
 
 const keyvaultVault string = "azure_keyvault_vault"
 
-// KeyvaultVaultColumns returns the list of columns in the table
-func KeyvaultVaultColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("properties_access_policies"),
-		// table.TextColumn("properties_access_policies_application_id"),
-		// table.TextColumn("properties_access_policies_object_id"),
-		// table.TextColumn("properties_access_policies_permissions"),
-		// table.TextColumn("properties_access_policies_permissions_certificates"),
-		// table.TextColumn("properties_access_policies_permissions_keys"),
-		// table.TextColumn("properties_access_policies_permissions_secrets"),
-		// table.TextColumn("properties_access_policies_permissions_storage"),
-		// table.TextColumn("properties_access_policies_tenant_id"),
-		table.TextColumn("properties_create_mode"),
-		table.TextColumn("properties_enable_purge_protection"),
-		table.TextColumn("properties_enable_rbac_authorization"),
-		table.TextColumn("properties_enable_soft_delete"),
-		table.TextColumn("properties_enabled_for_deployment"),
-		table.TextColumn("properties_enabled_for_disk_encryption"),
-		table.TextColumn("properties_enabled_for_template_deployment"),
-		table.TextColumn("properties_network_acls"),
-		// table.TextColumn("properties_network_acls_bypass"),
-		// table.TextColumn("properties_network_acls_default_action"),
-		// table.TextColumn("properties_network_acls_ip_rules"),
-		// table.TextColumn("properties_network_acls_ip_rules_value"),
-		// table.TextColumn("properties_network_acls_virtual_network_rules"),
-		// table.TextColumn("properties_network_acls_virtual_network_rules_id"),
-		table.TextColumn("properties_private_endpoint_connections"),
-		table.TextColumn("properties_sku"),
-		// table.TextColumn("properties_sku_family"),
-		// table.TextColumn("properties_sku_name"),
-		table.IntegerColumn("properties_soft_delete_retention_in_days"),
-		table.TextColumn("properties_tenant_id"),
-		table.TextColumn("properties_vault_uri"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // KeyvaultVaultsGenerate returns the rows in the table for all configured accounts
 func KeyvaultVaultsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultVault,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountKeyvaultVaults(nil)
+		results, err := processAccountKeyvaultVaults(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": keyvaultVault,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountKeyvaultVaults(&account)
+			results, err := processAccountKeyvaultVaults(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(keyvaultVault, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -91,22 +49,20 @@ func KeyvaultVaultsGenerate(osqCtx context.Context, queryContext table.QueryCont
 	return resultMap, nil
 }
 
-func processAccountKeyvaultVaults(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountKeyvaultVaults(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, keyvaultVault, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, keyvaultVault, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[keyvaultVault]
+	tableConfig, ok := utilities.GetTableConfig(keyvaultVault)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": keyvaultVault,
@@ -114,27 +70,22 @@ func processAccountKeyvaultVaults(account *utilities.ExtensionConfigurationAzure
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go setKeyvaultVaultToTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, keyvaultVault, session, groups, tableConfig, setKeyvaultVaultToTable)
 }
 
-func setKeyvaultVaultToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := getKeyvaultVaultData(session, rg)
+func setKeyvaultVaultToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := getKeyvaultVaultData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      keyvaultVault,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get keyvault vault list from api")
+		azure.ReportError(keyvaultVault, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, vault := range *resources.Response().Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(vault)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -145,18 +96,22 @@ func setKeyvaultVaultToTable(session *azure.AzureSession, rg string, wg *sync.Wa
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, keyvaultVault, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
-func getKeyvaultVaultData(session *azure.AzureSession, rg string) (result keyvault.VaultListResultPage, err error) {
+func getKeyvaultVaultData(ctx context.Context, session *azure.AzureSession, rg string) (result keyvault.VaultListResultPage, err error) {
 
 	var top int32 = 1
 	svcClient := keyvault.NewVaultsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListByResourceGroup(context.Background(), rg, &top)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListByResourceGroup(ctx, rg, &top)
 
 }
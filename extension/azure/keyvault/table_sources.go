@@ -0,0 +1,25 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package keyvault
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/2016-10-01/keyvault"
+	keyvaultmgmt "github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2019-09-01/keyvault"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_keyvault_vault":  {Type: reflect.TypeOf((*keyvaultmgmt.Vault)(nil)).Elem(), FieldNames: true},
+	"azure_keyvault_key":    {Type: reflect.TypeOf((*keyvaultmgmt.Key)(nil)).Elem(), FieldNames: true},
+	"azure_keyvault_secret": {Type: reflect.TypeOf((*keyvault.SecretItem)(nil)).Elem(), FieldNames: true},
+}
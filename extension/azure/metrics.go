@@ -0,0 +1,92 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/tracing"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// metricsTracer instruments the transport of the Azure SDK clients.
+// The SDK only builds its transport once, so it must be registered before the first request.
+type metricsTracer struct{}
+
+func (metricsTracer) NewTransport(base *http.Transport) http.RoundTripper {
+	return utilities.NewMetricsTransport(base, utilities.ProviderAzure, getRequestAccount)
+}
+
+func (metricsTracer) StartSpan(ctx context.Context, name string) context.Context {
+	return ctx
+}
+
+func (metricsTracer) EndSpan(ctx context.Context, httpStatusCode int, err error) {
+}
+
+// EnableMetrics counts the requests sent by the Azure SDK clients
+func EnableMetrics() {
+	tracing.Register(metricsTracer{})
+}
+
+// getRequestAccount returns the subscription of a management API request,
+// the tenant of a graph API request, or the host of other requests
+func getRequestAccount(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for idx := 0; idx+1 < len(segments); idx++ {
+		if strings.EqualFold(segments[idx], "subscriptions") {
+			return segments[idx+1]
+		}
+	}
+	if strings.HasPrefix(req.URL.Host, "graph.") && segments[0] != "" {
+		return segments[0]
+	}
+	return req.URL.Host
+}
+
+// tableAuthorizer attributes the requests it authorizes to a table
+type tableAuthorizer struct {
+	authorizer autorest.Authorizer
+	tableName  string
+}
+
+func (a tableAuthorizer) WithAuthorization() autorest.PrepareDecorator {
+	authorization := a.authorizer.WithAuthorization()
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := authorization(p).Prepare(r)
+			if err != nil {
+				return r, err
+			}
+			return r.WithContext(utilities.WithTableName(r.Context(), a.tableName)), nil
+		})
+	}
+}
+
+// forTable returns a copy of the session whose requests are attributed to given table in the metrics
+func (session *AzureSession) forTable(tableName string) *AzureSession {
+	if !utilities.MetricsEnabled() {
+		return session
+	}
+	withTable := func(authorizer autorest.Authorizer) autorest.Authorizer {
+		if authorizer == nil {
+			return nil
+		}
+		return tableAuthorizer{authorizer: authorizer, tableName: tableName}
+	}
+	tableSession := *session
+	tableSession.Authorizer = withTable(session.Authorizer)
+	tableSession.GraphAuthorizer = withTable(session.GraphAuthorizer)
+	tableSession.VaultAuthorizer = withTable(session.VaultAuthorizer)
+	return &tableSession
+}
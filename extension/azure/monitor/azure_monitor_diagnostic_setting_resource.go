@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	extazure "github.com/Uptycs/cloudquery/extension/azure"
 	"github.com/fatih/structs"
@@ -18,56 +17,28 @@ import (
 
 const azureMonitorDiagnosticSettingsResource = "azure_monitor_diagnostic_settings_resource"
 
-// DiagnosticSettingsResourceColumns returns the list of columns in the table
-func DiagnosticSettingsResourceColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("event_hub_authorization_rule_id"),
-		table.TextColumn("event_hub_name"),
-		table.TextColumn("log_analytics_destination_type"),
-		table.TextColumn("logs"),
-		// table.TextColumn("logs_category"),
-		// table.TextColumn("logs_enabled"),
-		// table.TextColumn("logs_retention_policy"),
-		// table.IntegerColumn("logs_retention_policy_days"),
-		// table.TextColumn("logs_retention_policy_enabled"),
-		table.TextColumn("metrics"),
-		// table.TextColumn("metrics_category"),
-		// table.TextColumn("metrics_enabled"),
-		// table.TextColumn("metrics_retention_policy"),
-		// table.IntegerColumn("metrics_retention_policy_days"),
-		// table.TextColumn("metrics_retention_policy_enabled"),
-		// table.TextColumn("metrics_time_grain"),
-		table.TextColumn("service_bus_rule_id"),
-		table.TextColumn("storage_account_id"),
-		table.TextColumn("workspace_id"),
-		table.TextColumn("type"),
-	}
-}
-
 // DiagnosticSettingsResourceGenerate returns the rows in the table for all configured accounts
 func DiagnosticSettingsResourceGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureMonitorDiagnosticSettingsResource,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processDignosticSettingsResource(nil)
+		results, err := processDignosticSettingsResource(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureMonitorDiagnosticSettingsResource,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processDignosticSettingsResource(&account)
+			results, err := processDignosticSettingsResource(osqCtx, queryContext, &account)
 			if err != nil {
+				extazure.ReportError(azureMonitorDiagnosticSettingsResource, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -77,22 +48,20 @@ func DiagnosticSettingsResourceGenerate(osqCtx context.Context, queryContext tab
 	return resultMap, nil
 }
 
-func processDignosticSettingsResource(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processDignosticSettingsResource(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := extazure.GetAuthSession(account)
-	if err != nil {
+	session, err := extazure.GetTableSession(queryContext, azureMonitorDiagnosticSettingsResource, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := extazure.GetGroups(session)
+	groups, err := extazure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = extazure.FilterGroups(queryContext, azureMonitorDiagnosticSettingsResource, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureMonitorDiagnosticSettingsResource]
+	tableConfig, ok := utilities.GetTableConfig(azureMonitorDiagnosticSettingsResource)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureMonitorDiagnosticSettingsResource,
@@ -100,21 +69,15 @@ func processDignosticSettingsResource(account *utilities.ExtensionConfigurationA
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getDignosticSettingsResource(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return extazure.ProcessResourceGroups(ctx, queryContext, azureMonitorDiagnosticSettingsResource, session, groups, tableConfig, getDignosticSettingsResource)
 }
 
-func getDignosticSettingsResource(session *extazure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
+func getDignosticSettingsResource(ctx context.Context, queryContext table.QueryContext, session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
 	svcClient := azuremonitor.NewDiagnosticSettingsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	extazure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
 	resourceURI := "/subscriptions/" + session.SubscriptionId
-	resourceItr, err := svcClient.List(context.Background(), resourceURI)
+	resourceItr, err := svcClient.List(ctx, resourceURI)
 
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -122,12 +85,13 @@ func getDignosticSettingsResource(session *extazure.AzureSession, rg string, wg
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get resource list")
+		extazure.ReportError(azureMonitorDiagnosticSettingsResource, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	resource := resourceItr.Value
 
 	for _, diagnosticSetting := range *resource {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(diagnosticSetting)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -139,10 +103,14 @@ func getDignosticSettingsResource(session *extazure.AzureSession, rg string, wg
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extazure.ShouldProcessRow(ctx, queryContext, azureMonitorDiagnosticSettingsResource, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := extazure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
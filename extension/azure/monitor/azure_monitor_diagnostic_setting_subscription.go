@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	extazure "github.com/Uptycs/cloudquery/extension/azure"
 	"github.com/fatih/structs"
@@ -20,52 +19,28 @@ import (
 
 const azureMonitorDiagnosticSettingsSubscription = "azure_monitor_diagnostic_settings_resource"
 
-// DiagnosticSettingsSubscriptionColumns returns the list of columns in the table
-func DiagnosticSettingsSubscriptionColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("event_hub_authorization_rule_id"),
-		table.TextColumn("event_hub_name"),
-		table.TextColumn("log_analytics_destination_type"),
-		table.TextColumn("logs"),
-		table.TextColumn("logs_category"),
-		table.TextColumn("logs_enabled"),
-		table.TextColumn("logs_retention_policy"),
-		// table.IntegerColumn("logs_retention_policy_days"),
-		// table.TextColumn("logs_retention_policy_enabled"),
-		table.TextColumn("metrics"),
-		table.TextColumn("metrics_category"),
-		table.TextColumn("metrics_enabled"),
-		table.TextColumn("metrics_retention_policy"),
-		// table.IntegerColumn("metrics_retention_policy_days"),
-		// table.TextColumn("metrics_retention_policy_enabled"),
-		table.TextColumn("metrics_time_grain"),
-		table.TextColumn("service_bus_rule_id"),
-		table.TextColumn("storage_account_id"),
-		table.TextColumn("workspace_id"),
-	}
-}
-
 // DiagnosticSettingsSubscriptionGenerate returns the rows in the table for all configured accounts
 func DiagnosticSettingsSubscriptionGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureMonitorDiagnosticSettingsSubscription,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processDignosticSettingsSubscription(nil)
+		results, err := processDignosticSettingsSubscription(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureMonitorDiagnosticSettingsSubscription,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processDignosticSettingsSubscription(&account)
+			results, err := processDignosticSettingsSubscription(osqCtx, queryContext, &account)
 			if err != nil {
+				extazure.ReportError(azureMonitorDiagnosticSettingsSubscription, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -75,22 +50,20 @@ func DiagnosticSettingsSubscriptionGenerate(osqCtx context.Context, queryContext
 	return resultMap, nil
 }
 
-func processDignosticSettingsSubscription(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processDignosticSettingsSubscription(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := extazure.GetAuthSession(account)
-	if err != nil {
+	session, err := extazure.GetTableSession(queryContext, azureMonitorDiagnosticSettingsSubscription, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := extazure.GetGroups(session)
+	groups, err := extazure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = extazure.FilterGroups(queryContext, azureMonitorDiagnosticSettingsSubscription, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureMonitorDiagnosticSettingsSubscription]
+	tableConfig, ok := utilities.GetTableConfig(azureMonitorDiagnosticSettingsSubscription)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureMonitorDiagnosticSettingsSubscription,
@@ -98,40 +71,36 @@ func processDignosticSettingsSubscription(account *utilities.ExtensionConfigurat
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getStorageAccountIdForSubscription(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return extazure.ProcessResourceGroups(ctx, queryContext, azureMonitorDiagnosticSettingsSubscription, session, groups, tableConfig, getStorageAccountIdForSubscription)
 }
-func getStorageAccountIdForSubscription(session *extazure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
+func getStorageAccountIdForSubscription(ctx context.Context, queryContext table.QueryContext, session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
 	diagnosticSettings := make([]azuremonitor.DiagnosticSettingsResource, 0)
 
-	for resourceItr, err := azurestorage.GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := azurestorage.GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     azureMonitorDiagnosticSettingsSubscription,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			extazure.ReportError(azureMonitorDiagnosticSettingsSubscription, session.SubscriptionId, rg, err)
+			return err
 		}
 		resource := resourceItr.Value()
 
-		getDiagnosticSettingSubscription(session, rg, *resource.ID, &diagnosticSettings)
+		getDiagnosticSettingSubscription(ctx, session, rg, *resource.ID, &diagnosticSettings)
 	}
 
-	addDignosticSettingsSubscription(session, rg, resultMap, tableConfig, diagnosticSettings)
+	addDignosticSettingsSubscription(ctx, queryContext, session, rg, resultMap, tableConfig, diagnosticSettings)
+	return nil
 }
 
-func getDiagnosticSettingSubscription(session *extazure.AzureSession, rg string, resourceURI string, diagnosticSettings *[]azuremonitor.DiagnosticSettingsResource) {
+func getDiagnosticSettingSubscription(ctx context.Context, session *extazure.AzureSession, rg string, resourceURI string, diagnosticSettings *[]azuremonitor.DiagnosticSettingsResource) {
 
 	svcClient := azuremonitor.NewDiagnosticSettingsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	extazure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	resourceItr, err := svcClient.List(context.Background(), resourceURI)
+	resourceItr, err := svcClient.List(ctx, resourceURI)
 
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -139,6 +108,7 @@ func getDiagnosticSettingSubscription(session *extazure.AzureSession, rg string,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get resource list")
+		extazure.ReportError(azureMonitorDiagnosticSettingsSubscription, session.SubscriptionId, rg, err)
 	}
 
 	resource := resourceItr.Value
@@ -146,9 +116,8 @@ func getDiagnosticSettingSubscription(session *extazure.AzureSession, rg string,
 	*diagnosticSettings = append(*diagnosticSettings, *resource...)
 }
 
-func addDignosticSettingsSubscription(session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, diagnosticSettings []azuremonitor.DiagnosticSettingsResource) {
+func addDignosticSettingsSubscription(ctx context.Context, queryContext table.QueryContext, session *extazure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, diagnosticSettings []azuremonitor.DiagnosticSettingsResource) {
 	for _, diagnosticSetting := range diagnosticSettings {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(diagnosticSetting)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -160,8 +129,11 @@ func addDignosticSettingsSubscription(session *extazure.AzureSession, rg string,
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !extazure.ShouldProcessRow(ctx, queryContext, azureMonitorDiagnosticSettingsSubscription, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := extazure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
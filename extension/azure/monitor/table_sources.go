@@ -0,0 +1,24 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package monitor
+
+import (
+	"reflect"
+
+	azuremonitor "github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2021-07-01-preview/insights"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_monitor_diagnostic_settings_resource":     {Type: reflect.TypeOf((*azuremonitor.DiagnosticSettingsResource)(nil)).Elem(), FieldNames: true},
+	"azure_monitor_diagnostic_settings_subscription": {Type: reflect.TypeOf((*azuremonitor.DiagnosticSettingsResource)(nil)).Elem(), FieldNames: true},
+	"azure_monitor_activity_log_alert":               {Type: reflect.TypeOf((*azuremonitor.ActivityLogAlertResource)(nil)).Elem(), FieldNames: true},
+}
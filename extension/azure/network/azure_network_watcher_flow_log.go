@@ -13,7 +13,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -28,58 +27,28 @@ import (
 
 var azureNetworkWatcherFlowLog string = "azure_network_watcher_flow_log"
 
-// AzureNetworkWatcherFlowLogColumns returns the list of columns in the table
-func AzureNetworkWatcherFlowLogColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("enabled"),
-		table.TextColumn("flow_analytics_configuration"),
-		// table.TextColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration"),
-		// table.TextColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration_enabled"),
-		// table.IntegerColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration_traffic_analytics_interval"),
-		// table.TextColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration_workspace_id"),
-		// table.TextColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration_workspace_region"),
-		// table.TextColumn("flow_analytics_configuration_network_watcher_flow_analytics_configuration_workspace_resource_id"),
-		table.TextColumn("format"),
-		// table.TextColumn("format_type"),
-		// table.IntegerColumn("format_version"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("retention_policy"),
-		// table.IntegerColumn("retention_policy_days"),
-		// table.TextColumn("retention_policy_enabled"),
-		table.TextColumn("storage_id"),
-		table.TextColumn("target_resource_guid"),
-		table.TextColumn("target_resource_id"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // AzureNetworkWatcherFlowLogsGenerate returns the rows in the table for all configured accounts
 func AzureNetworkWatcherFlowLogsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureNetworkWatcherFlowLog,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountAzureNetworkWatcherFlowLogs(nil)
+		results, err := processAccountAzureNetworkWatcherFlowLogs(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureNetworkWatcherFlowLog,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountAzureNetworkWatcherFlowLogs(&account)
+			results, err := processAccountAzureNetworkWatcherFlowLogs(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureNetworkWatcherFlowLog, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -89,22 +58,20 @@ func AzureNetworkWatcherFlowLogsGenerate(osqCtx context.Context, queryContext ta
 	return resultMap, nil
 }
 
-func processAccountAzureNetworkWatcherFlowLogs(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountAzureNetworkWatcherFlowLogs(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureNetworkWatcherFlowLog, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, azureNetworkWatcherFlowLog, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureNetworkWatcherFlowLog]
+	tableConfig, ok := utilities.GetTableConfig(azureNetworkWatcherFlowLog)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureNetworkWatcherFlowLog,
@@ -112,43 +79,40 @@ func processAccountAzureNetworkWatcherFlowLogs(account *utilities.ExtensionConfi
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getWatcherNameForFlowLogs(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, azureNetworkWatcherFlowLog, session, groups, tableConfig, getWatcherNameForFlowLogs)
 }
 
-func getWatcherNameForFlowLogs(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := GetWatcherName(session, rg)
+func getWatcherNameForFlowLogs(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := GetWatcherName(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      azureNetworkWatcherFlowLog,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get list from api")
+		azure.ReportError(azureNetworkWatcherFlowLog, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, watcher := range *resources.Value {
-		setFlowLogToTableHelper(session, rg, wg, resultMap, tableConfig, *watcher.Name)
+		setFlowLogToTableHelper(ctx, queryContext, session, rg, resultMap, tableConfig, *watcher.Name)
 	}
+	return nil
 }
-func setFlowLogToTableHelper(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, watcherName string) {
+func setFlowLogToTableHelper(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, watcherName string) {
 
-	for resourceItr, err := getWatcherFlowLogHelperData(session, rg, watcherName); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := getWatcherFlowLogHelperData(ctx, session, rg, watcherName); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     azureNetworkWatcherFlowLog,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
+			azure.ReportError(azureNetworkWatcherFlowLog, session.SubscriptionId, rg, err)
 			continue
 		}
 
 		resource := resourceItr.Value()
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 
 		byteArr, err := json.Marshal(resMap)
@@ -160,23 +124,26 @@ func setFlowLogToTableHelper(session *azure.AzureSession, rg string, wg *sync.Wa
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureNetworkWatcherFlowLog, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getWatcherFlowLogHelperData(session *azure.AzureSession, rg string, watcherName string) (result network.FlowLogListResultIterator, err error) {
+func getWatcherFlowLogHelperData(ctx context.Context, session *azure.AzureSession, rg string, watcherName string) (result network.FlowLogListResultIterator, err error) {
 
 	svcClient := network.NewFlowLogsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListComplete(context.Background(), rg, watcherName)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListComplete(ctx, rg, watcherName)
 
 }
 
-func GetWatcherName(session *azure.AzureSession, rg string) (result network.WatcherListResult, err error) {
+func GetWatcherName(ctx context.Context, session *azure.AzureSession, rg string) (result network.WatcherListResult, err error) {
 	svcClient := network.NewWatchersClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background(), rg)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx, rg)
 }
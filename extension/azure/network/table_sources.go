@@ -0,0 +1,24 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package network
+
+import (
+	"reflect"
+
+	network20210301 "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-05-01/network"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_network_watcher_flow_log": {Type: reflect.TypeOf((*network.FlowLog)(nil)).Elem(), FieldNames: true},
+	"azure_network_load_balancer":    {Type: reflect.TypeOf((*network20210301.LoadBalancer)(nil)).Elem(), FieldNames: true},
+}
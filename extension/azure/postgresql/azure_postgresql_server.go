@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,77 +18,28 @@ import (
 
 const postgresqlServer string = "azure_postgresql_server"
 
-// PostgresqlServerColumns returns the list of columns in the table
-func PostgresqlServerColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("identity"),
-		// table.TextColumn("identity_principal_id"),
-		// table.TextColumn("identity_tenant_id"),
-		// table.TextColumn("identity_type"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("administrator_login"),
-		table.TextColumn("byok_enforcement"),
-		table.TextColumn("earliest_restore_date"),
-		table.TextColumn("fully_qualified_domain_name"),
-		table.TextColumn("infrastructure_encryption"),
-		table.TextColumn("master_server_id"),
-		table.TextColumn("minimal_tls_version"),
-		table.TextColumn("private_endpoint_connections"),
-		// table.TextColumn("private_endpoint_connections_id"),
-		// table.TextColumn("private_endpoint_connections_properties"),
-		// table.TextColumn("private_endpoint_connections_properties_private_endpoint"),
-		// table.TextColumn("private_endpoint_connections_properties_private_endpoint_id"),
-		// table.TextColumn("private_endpoint_connections_properties_private_link_service_connection_state"),
-		// table.TextColumn("private_endpoint_connections_properties_private_link_service_connection_state_actions_required"),
-		// table.TextColumn("private_endpoint_connections_properties_private_link_service_connection_state_description"),
-		// table.TextColumn("private_endpoint_connections_properties_private_link_service_connection_state_status"),
-		// table.TextColumn("private_endpoint_connections_properties_provisioning_state"),
-		table.TextColumn("public_network_access"),
-		table.IntegerColumn("replica_capacity"),
-		table.TextColumn("replication_role"),
-		table.TextColumn("ssl_enforcement"),
-		table.TextColumn("storage_profile"),
-		// table.TextColumn("storage_profile_backup_retention_days"),
-		// table.TextColumn("storage_profile_geo_redundant_backup"),
-		// table.TextColumn("storage_profile_storage_autogrow"),
-		// table.TextColumn("storage_profile_storage_mb"),
-		table.TextColumn("user_visible_state"),
-		table.TextColumn("version"),
-		table.TextColumn("sku"),
-		// table.TextColumn("sku_capacity"),
-		// table.TextColumn("sku_family"),
-		// table.TextColumn("sku_name"),
-		// table.TextColumn("sku_size"),
-		// table.TextColumn("sku_tier"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-	}
-}
-
 // PostgresqlServersGenerate returns the rows in the table for all configured accounts
 func PostgresqlServersGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": postgresqlServer,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountPostgresqlServers(nil)
+		results, err := processAccountPostgresqlServers(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": postgresqlServer,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountPostgresqlServers(&account)
+			results, err := processAccountPostgresqlServers(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(postgresqlServer, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -99,22 +49,20 @@ func PostgresqlServersGenerate(osqCtx context.Context, queryContext table.QueryC
 	return resultMap, nil
 }
 
-func processAccountPostgresqlServers(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountPostgresqlServers(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, postgresqlServer, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, postgresqlServer, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[postgresqlServer]
+	tableConfig, ok := utilities.GetTableConfig(postgresqlServer)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": postgresqlServer,
@@ -122,27 +70,22 @@ func processAccountPostgresqlServers(account *utilities.ExtensionConfigurationAz
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go setPostgresqlServertoTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, postgresqlServer, session, groups, tableConfig, setPostgresqlServertoTable)
 }
 
-func setPostgresqlServertoTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := getPostgresqlServerData(session, rg)
+func setPostgresqlServertoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := getPostgresqlServerData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      postgresqlServer,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get postgresql server list from api")
+		azure.ReportError(postgresqlServer, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, server := range *resources.Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(server)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -153,17 +96,21 @@ func setPostgresqlServertoTable(session *azure.AzureSession, rg string, wg *sync
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, postgresqlServer, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
-func getPostgresqlServerData(session *azure.AzureSession, rg string) (result postgresql.ServerListResult, err error) {
+func getPostgresqlServerData(ctx context.Context, session *azure.AzureSession, rg string) (result postgresql.ServerListResult, err error) {
 
 	svcClient := postgresql.NewServersClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListByResourceGroup(context.Background(), rg)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListByResourceGroup(ctx, rg)
 
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,73 +18,28 @@ import (
 
 const azureRedisCache string = "azure_redis_cache"
 
-// RedisCacheColumns returns the list of columns in the table
-func RedisCacheColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		table.TextColumn("type"),
-		// table.TextColumn("properties"),
-		table.TextColumn("access_keys"),
-		// table.TextColumn("access_keys_primary_key"),
-		// table.TextColumn("access_keys_secondary_key"),
-		table.TextColumn("enable_non_ssl_port"),
-		table.TextColumn("host_name"),
-		table.TextColumn("instances"),
-		// table.TextColumn("instances_is_master"),
-		// table.TextColumn("instances_is_primary"),
-		// table.IntegerColumn("instances_non_ssl_port"),
-		// table.IntegerColumn("instances_shard_id"),
-		// table.IntegerColumn("instances_ssl_port"),
-		// table.TextColumn("instances_zone"),
-		table.TextColumn("linked_servers"),
-		// table.TextColumn("linked_servers_id"),
-		table.TextColumn("minimum_tls_version"),
-		table.IntegerColumn("port"),
-		table.TextColumn("private_endpoint_connections"),
-		// table.TextColumn("private_endpoint_connections_id"),
-		// table.TextColumn("private_endpoint_connections_name"),
-		// table.TextColumn("private_endpoint_connections_type"),
-		table.TextColumn("provisioning_state"),
-		table.TextColumn("public_network_access"),
-		table.TextColumn("redis_configuration"),
-		table.TextColumn("redis_version"),
-		table.IntegerColumn("replicas_per_master"),
-		table.IntegerColumn("replicas_per_primary"),
-		table.IntegerColumn("shard_count"),
-		table.TextColumn("sku"),
-		// table.IntegerColumn("sku_capacity"),
-		// table.TextColumn("sku_family"),
-		// table.TextColumn("sku_name"),
-		table.IntegerColumn("ssl_port"),
-		table.TextColumn("static_ip"),
-		table.TextColumn("subnet_id"),
-		table.TextColumn("tenant_settings"),
-	}
-}
-
 // RedisCacheGenerate returns the rows in the table for all configured accounts
 func RedisCacheGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureRedisCache,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processRedisCache(nil)
+		results, err := processRedisCache(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": azureRedisCache,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processRedisCache(&account)
+			results, err := processRedisCache(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(azureRedisCache, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -95,22 +49,20 @@ func RedisCacheGenerate(osqCtx context.Context, queryContext table.QueryContext)
 	return resultMap, nil
 }
 
-func processRedisCache(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processRedisCache(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, azureRedisCache, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, azureRedisCache, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[azureRedisCache]
+	tableConfig, ok := utilities.GetTableConfig(azureRedisCache)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": azureRedisCache,
@@ -118,28 +70,23 @@ func processRedisCache(account *utilities.ExtensionConfigurationAzureAccount) ([
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go setRedisCachetoTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, azureRedisCache, session, groups, tableConfig, setRedisCachetoTable)
 }
 
-func setRedisCachetoTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourcesItr, err := getRedisCacheData(session, rg); resourcesItr.NotDone(); resourcesItr.Next() {
+func setRedisCachetoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	for resourcesItr, err := getRedisCacheData(ctx, session, rg); resourcesItr.NotDone(); resourcesItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":      azureRedisCache,
 				"rescourceGroup": rg,
 				"errString":      err.Error(),
 			}).Error("failed to get DNS zones")
+			azure.ReportError(azureRedisCache, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourcesItr.Value()
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -150,15 +97,19 @@ func setRedisCachetoTable(session *azure.AzureSession, rg string, wg *sync.WaitG
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, azureRedisCache, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
-func getRedisCacheData(session *azure.AzureSession, rg string) (result redis.ListResultIterator, err error) {
+func getRedisCacheData(ctx context.Context, session *azure.AzureSession, rg string) (result redis.ListResultIterator, err error) {
 	svcClient := redis.NewClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListByResourceGroupComplete(context.Background(), rg)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListByResourceGroupComplete(ctx, rg)
 }
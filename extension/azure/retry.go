@@ -0,0 +1,53 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// ConfigureClient sets the authorizer of an API client, and sends its requests with the retry policy of the service of each request.
+// The default decorator of the SDK still registers the missing resource providers of the subscription, and sends the request again.
+// Its own retries are limited to that attempt, the throttled and failed requests are retried by the policy.
+func ConfigureClient(client *autorest.Client, authorizer autorest.Authorizer) {
+	client.Authorizer = authorizer
+	registrationClient := *client
+	registrationClient.RetryAttempts = 2
+	client.SendDecorators = []autorest.SendDecorator{doRetryWithPolicy, azure.DoRetryWithRegistration(registrationClient)}
+}
+
+func doRetryWithPolicy(sender autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		return utilities.SendWithRetry(req, sender.Do, utilities.ProviderAzure, getRequestService(req), getRequestAccount(req))
+	})
+}
+
+// getRequestService returns the resource provider of a management API request, such as Microsoft.Compute,
+// graph for a graph API request, vault for a key vault request, or the host of other requests
+func getRequestService(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for idx := len(segments) - 2; idx >= 0; idx-- {
+		if strings.EqualFold(segments[idx], "providers") {
+			return segments[idx+1]
+		}
+	}
+	if strings.HasPrefix(req.URL.Host, "graph.") {
+		return "graph"
+	}
+	if strings.Contains(req.URL.Host, ".vault.") {
+		return "vault"
+	}
+	return req.URL.Host
+}
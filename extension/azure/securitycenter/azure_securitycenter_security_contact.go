@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,43 +19,32 @@ import (
 
 const SecuritycenterSecurityContact string = "azure_securitycenter_security_contact"
 
-// SecuritycenterSecurityContactColumns returns the list of columns in the table
-func SecuritycenterSecurityContactColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("alert_notifications"),
-		table.TextColumn("alerts_to_admins"),
-		table.TextColumn("email"),
-		table.TextColumn("phone"),
-		table.TextColumn("type"),
-	}
-}
-
 // SecuritycenterSecurityContactsGenerate returns the rows in the table for all configured accounts
 func SecuritycenterSecurityContactsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSecurityContact,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSecuritycenterSecurityContacts(nil)
+		results, err := processAccountSecuritycenterSecurityContacts(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": SecuritycenterSecurityContact,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSecuritycenterSecurityContacts(&account)
+			startTime := time.Now()
+			results, err := processAccountSecuritycenterSecurityContacts(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(SecuritycenterSecurityContact, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(SecuritycenterSecurityContact, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -62,13 +52,13 @@ func SecuritycenterSecurityContactsGenerate(osqCtx context.Context, queryContext
 	return resultMap, nil
 }
 
-func processAccountSecuritycenterSecurityContacts(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSecuritycenterSecurityContacts(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, SecuritycenterSecurityContact, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap[SecuritycenterSecurityContact]
+	tableConfig, ok := utilities.GetTableConfig(SecuritycenterSecurityContact)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSecurityContact,
@@ -76,23 +66,23 @@ func processAccountSecuritycenterSecurityContacts(account *utilities.ExtensionCo
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setSecuritycenterSecurityContacttoTable(session, "", &resultMap, tableConfig)
+	setSecuritycenterSecurityContacttoTable(ctx, queryContext, session, "", &resultMap, tableConfig)
 
 	return resultMap, nil
 }
-func setSecuritycenterSecurityContacttoTable(session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setSecuritycenterSecurityContacttoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	resources, err := getSecuritycenterSecurityContactData(session, rg)
+	resources, err := getSecuritycenterSecurityContactData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      SecuritycenterSecurityContact,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get contact list from api")
+		azure.ReportError(SecuritycenterSecurityContact, session.SubscriptionId, rg, err)
 	}
 
 	for _, contact := range resources.Values() {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(contact)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -103,17 +93,20 @@ func setSecuritycenterSecurityContacttoTable(session *azure.AzureSession, rg str
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, SecuritycenterSecurityContact, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getSecuritycenterSecurityContactData(session *azure.AzureSession, asclocation string) (result azuresecurity.ContactListPage, err error) {
+func getSecuritycenterSecurityContactData(ctx context.Context, session *azure.AzureSession, asclocation string) (result azuresecurity.ContactListPage, err error) {
 
 	svcClient := azuresecurity.NewContactsClient(session.SubscriptionId, asclocation)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background())
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx)
 
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,39 +19,32 @@ import (
 
 const SecuritycenterSetting string = "azure_securitycenter_setting"
 
-// SecuritycenterSettingColumns returns the list of columns in the table
-func SecuritycenterSettingColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		table.TextColumn("type"),
-	}
-}
-
 //SecuritycenterSettingGenerate returns the rows in the table for all configured accounts
 func SecuritycenterSettingGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSetting,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSecuritycenterSetting(nil)
+		results, err := processAccountSecuritycenterSetting(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": SecuritycenterSetting,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSecuritycenterSetting(&account)
+			startTime := time.Now()
+			results, err := processAccountSecuritycenterSetting(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(SecuritycenterSetting, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(SecuritycenterSetting, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -58,13 +52,13 @@ func SecuritycenterSettingGenerate(osqCtx context.Context, queryContext table.Qu
 	return resultMap, nil
 }
 
-func processAccountSecuritycenterSetting(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSecuritycenterSetting(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, SecuritycenterSetting, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap[SecuritycenterSetting]
+	tableConfig, ok := utilities.GetTableConfig(SecuritycenterSetting)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSetting,
@@ -72,23 +66,23 @@ func processAccountSecuritycenterSetting(account *utilities.ExtensionConfigurati
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setSecuritycenterSettingtoTable(session, "", &resultMap, tableConfig)
+	setSecuritycenterSettingtoTable(ctx, queryContext, session, "", &resultMap, tableConfig)
 
 	return resultMap, nil
 }
-func setSecuritycenterSettingtoTable(session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setSecuritycenterSettingtoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	resources, err := getSecuritycenterSettingData(session, rg)
+	resources, err := getSecuritycenterSettingData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      SecuritycenterSetting,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get setting list from api")
+		azure.ReportError(SecuritycenterSetting, session.SubscriptionId, rg, err)
 	}
 
 	for _, contact := range resources.Values() {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(contact)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -99,17 +93,20 @@ func setSecuritycenterSettingtoTable(session *azure.AzureSession, rg string, res
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, SecuritycenterSetting, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getSecuritycenterSettingData(session *azure.AzureSession, asclocation string) (result azuresecurity.SettingsListPage, err error) {
+func getSecuritycenterSettingData(ctx context.Context, session *azure.AzureSession, asclocation string) (result azuresecurity.SettingsListPage, err error) {
 
 	svcClient := azuresecurity.NewSettingsClient(session.SubscriptionId, asclocation)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background())
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx)
 
 }
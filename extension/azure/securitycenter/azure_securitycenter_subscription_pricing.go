@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,41 +19,32 @@ import (
 
 const SecuritycenterSubscriptionPricing string = "azure_securitycenter_subscription_pricing"
 
-// SecuritycenterSubscriptionPricingColumns returns the list of columns in the table
-func SecuritycenterSubscriptionPricingColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("free_trial_remaining_time"),
-		table.TextColumn("pricing_tier"),
-		table.TextColumn("type"),
-	}
-}
-
 //SecuritycenterSubscriptionPricingGenerate returns the rows in the table for all configured accounts
 func SecuritycenterSubscriptionPricingGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSubscriptionPricing,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSecuritycenterSubscriptionPricing(nil)
+		results, err := processAccountSecuritycenterSubscriptionPricing(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": SecuritycenterSubscriptionPricing,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSecuritycenterSubscriptionPricing(&account)
+			startTime := time.Now()
+			results, err := processAccountSecuritycenterSubscriptionPricing(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(SecuritycenterSubscriptionPricing, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(SecuritycenterSubscriptionPricing, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -60,13 +52,13 @@ func SecuritycenterSubscriptionPricingGenerate(osqCtx context.Context, queryCont
 	return resultMap, nil
 }
 
-func processAccountSecuritycenterSubscriptionPricing(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSecuritycenterSubscriptionPricing(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, SecuritycenterSubscriptionPricing, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap[SecuritycenterSubscriptionPricing]
+	tableConfig, ok := utilities.GetTableConfig(SecuritycenterSubscriptionPricing)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterSubscriptionPricing,
@@ -74,23 +66,23 @@ func processAccountSecuritycenterSubscriptionPricing(account *utilities.Extensio
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setSecuritycenterSubscriptionPricingtoTable(session, "", &resultMap, tableConfig)
+	setSecuritycenterSubscriptionPricingtoTable(ctx, queryContext, session, "", &resultMap, tableConfig)
 
 	return resultMap, nil
 }
-func setSecuritycenterSubscriptionPricingtoTable(session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setSecuritycenterSubscriptionPricingtoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	resources, err := getSecuritycenterSubscriptionPricingData(session, rg)
+	resources, err := getSecuritycenterSubscriptionPricingData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      SecuritycenterSubscriptionPricing,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get pricing list from api")
+		azure.ReportError(SecuritycenterSubscriptionPricing, session.SubscriptionId, rg, err)
 	}
 
 	for _, contact := range *resources.Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(contact)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -101,17 +93,20 @@ func setSecuritycenterSubscriptionPricingtoTable(session *azure.AzureSession, rg
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, SecuritycenterSubscriptionPricing, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getSecuritycenterSubscriptionPricingData(session *azure.AzureSession, asclocation string) (result azuresecurity.PricingList, err error) {
+func getSecuritycenterSubscriptionPricingData(ctx context.Context, session *azure.AzureSession, asclocation string) (result azuresecurity.PricingList, err error) {
 
 	svcClient := azuresecurity.NewPricingsClient(session.SubscriptionId, asclocation)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background())
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx)
 
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,40 +19,32 @@ import (
 
 const SecuritycenterAutoProvisioning string = "azure_securitycenter_auto_provisioning"
 
-// SecuritycenterAutoProvisioningColumns returns the list of columns in the table
-func SecuritycenterAutoProvisioningColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("auto_provision"),
-		table.TextColumn("type"),
-	}
-}
-
 //SecuritycenterAutoProvisioningGenerate returns the rows in the table for all configured accounts
 func SecuritycenterAutoProvisioningGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterAutoProvisioning,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSecuritycenterAutoProvisioning(nil)
+		results, err := processAccountSecuritycenterAutoProvisioning(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": SecuritycenterAutoProvisioning,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSecuritycenterAutoProvisioning(&account)
+			startTime := time.Now()
+			results, err := processAccountSecuritycenterAutoProvisioning(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(SecuritycenterAutoProvisioning, account.SubscriptionID, "", err)
 				continue
 			}
+			utilities.RecordCollectionSuccess(SecuritycenterAutoProvisioning, account.SubscriptionID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -59,13 +52,13 @@ func SecuritycenterAutoProvisioningGenerate(osqCtx context.Context, queryContext
 	return resultMap, nil
 }
 
-func processAccountSecuritycenterAutoProvisioning(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSecuritycenterAutoProvisioning(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, SecuritycenterAutoProvisioning, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap[SecuritycenterAutoProvisioning]
+	tableConfig, ok := utilities.GetTableConfig(SecuritycenterAutoProvisioning)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": SecuritycenterAutoProvisioning,
@@ -73,23 +66,23 @@ func processAccountSecuritycenterAutoProvisioning(account *utilities.ExtensionCo
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	setSecuritycenterAutoProvisioningtoTable(session, "", &resultMap, tableConfig)
+	setSecuritycenterAutoProvisioningtoTable(ctx, queryContext, session, "", &resultMap, tableConfig)
 
 	return resultMap, nil
 }
-func setSecuritycenterAutoProvisioningtoTable(session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
+func setSecuritycenterAutoProvisioningtoTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
 
-	resources, err := getSecuritycenterAutoProvisioningData(session, rg)
+	resources, err := getSecuritycenterAutoProvisioningData(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      SecuritycenterAutoProvisioning,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get setting list from api")
+		azure.ReportError(SecuritycenterAutoProvisioning, session.SubscriptionId, rg, err)
 	}
 
 	for _, contact := range resources.Values() {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(contact)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
@@ -100,17 +93,20 @@ func setSecuritycenterAutoProvisioningtoTable(session *azure.AzureSession, rg st
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, SecuritycenterAutoProvisioning, session.SubscriptionId, "", row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", "", tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
-func getSecuritycenterAutoProvisioningData(session *azure.AzureSession, asclocation string) (result azuresecurity.AutoProvisioningSettingListPage, err error) {
+func getSecuritycenterAutoProvisioningData(ctx context.Context, session *azure.AzureSession, asclocation string) (result azuresecurity.AutoProvisioningSettingListPage, err error) {
 
 	svcClient := azuresecurity.NewAutoProvisioningSettingsClient(session.SubscriptionId, asclocation)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background())
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx)
 
 }
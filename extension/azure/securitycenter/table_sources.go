@@ -0,0 +1,25 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package securitycenter
+
+import (
+	"reflect"
+
+	azuresecurity "github.com/Azure/azure-sdk-for-go/services/preview/security/mgmt/v3.0/security"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_securitycenter_security_contact":     {Type: reflect.TypeOf((*azuresecurity.Contact)(nil)).Elem(), FieldNames: true},
+	"azure_securitycenter_setting":              {Type: reflect.TypeOf((*azuresecurity.BasicSetting)(nil)).Elem(), FieldNames: true},
+	"azure_securitycenter_subscription_pricing": {Type: reflect.TypeOf((*azuresecurity.Pricing)(nil)).Elem(), FieldNames: true},
+	"azure_securitycenter_auto_provisioning":    {Type: reflect.TypeOf((*azuresecurity.AutoProvisioningSetting)(nil)).Elem(), FieldNames: true},
+}
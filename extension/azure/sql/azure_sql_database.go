@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	"github.com/fatih/structs"
 	log "github.com/sirupsen/logrus"
@@ -19,77 +18,28 @@ import (
 
 const sqlDatabase string = "azure_sql_database"
 
-// SqlDatabaseColumns returns the list of columns in the table
-func SqlDatabaseColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("collation"),
-		table.BigIntColumn("containment_state"),
-		table.TextColumn("create_mode"),
-		table.TextColumn("creation_date"),
-		table.TextColumn("current_service_objective_id"),
-		table.TextColumn("database_id"),
-		table.TextColumn("default_secondary_location"),
-		table.TextColumn("earliest_restore_date"),
-		table.TextColumn("edition"),
-		table.TextColumn("elastic_pool_name"),
-		table.TextColumn("failover_group_id"),
-		table.TextColumn("max_size_bytes"),
-		table.TextColumn("read_scale"),
-		table.TextColumn("recommended_index"),
-		// table.TextColumn("recommended_index_id"),
-		// table.TextColumn("recommended_index_name"),
-		// table.TextColumn("recommended_index_type"),
-		table.TextColumn("recovery_services_recovery_point_resource_id"),
-		table.TextColumn("requested_service_objective_id"),
-		table.TextColumn("requested_service_objective_name"),
-		table.TextColumn("restore_point_in_time"),
-		table.TextColumn("sample_name"),
-		table.TextColumn("service_level_objective"),
-		table.TextColumn("service_tier_advisors"),
-		// table.TextColumn("service_tier_advisors_id"),
-		// table.TextColumn("service_tier_advisors_name"),
-		// table.TextColumn("service_tier_advisors_type"),
-		table.TextColumn("source_database_deletion_date"),
-		table.TextColumn("source_database_id"),
-		table.TextColumn("status"),
-		table.TextColumn("transparent_data_encryption"),
-		// table.TextColumn("transparent_data_encryption_id"),
-		// table.TextColumn("transparent_data_encryption_location"),
-		// table.TextColumn("transparent_data_encryption_name"),
-		// table.TextColumn("transparent_data_encryption_type"),
-		table.TextColumn("zone_redundant"),
-		table.TextColumn("tags"),
-		table.TextColumn("type"),
-		table.TextColumn("server_name"),
-	}
-}
-
 // SqlDatabaseGenerate returns the rows in the table for all configured accounts
 func SqlDatabaseGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": sqlDatabase,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountSqlDatabase(nil)
+		results, err := processAccountSqlDatabase(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": sqlDatabase,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountSqlDatabase(&account)
+			results, err := processAccountSqlDatabase(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(sqlDatabase, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -99,22 +49,20 @@ func SqlDatabaseGenerate(osqCtx context.Context, queryContext table.QueryContext
 	return resultMap, nil
 }
 
-func processAccountSqlDatabase(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountSqlDatabase(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, sqlDatabase, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, sqlDatabase, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[sqlDatabase]
+	tableConfig, ok := utilities.GetTableConfig(sqlDatabase)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": sqlDatabase,
@@ -122,43 +70,39 @@ func processAccountSqlDatabase(account *utilities.ExtensionConfigurationAzureAcc
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getSqlServerNameForTable(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, sqlDatabase, session, groups, tableConfig, getSqlServerNameForTable)
 }
 
-func getSqlServerNameForTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resourceItr, err := getSqlServer(session, rg)
+func getSqlServerNameForTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resourceItr, err := getSqlServer(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     sqlDatabase,
 			"resourceGroup": rg,
 			"error":         err.Error(),
 		}).Error("failed to get server list")
+		azure.ReportError(sqlDatabase, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, server := range *resourceItr.Value {
-		setSqlDatabaseDataToTable(session, rg, wg, resultMap, tableConfig, *server.Name)
+		setSqlDatabaseDataToTable(ctx, queryContext, session, rg, resultMap, tableConfig, *server.Name)
 	}
+	return nil
 }
 
-func setSqlDatabaseDataToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, serverName string) {
-	resourceItr, err := getSqlDatabaseData(session, rg, serverName)
+func setSqlDatabaseDataToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, serverName string) {
+	resourceItr, err := getSqlDatabaseData(ctx, session, rg, serverName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     sqlDatabase,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get sql database list")
+		azure.ReportError(sqlDatabase, session.SubscriptionId, rg, err)
 	}
 
 	for _, resource := range *resourceItr.Value {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.MarshalIndent(resMap, "", "	")
 		if err != nil {
@@ -169,9 +113,12 @@ func setSqlDatabaseDataToTable(session *azure.AzureSession, rg string, wg *sync.
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, sqlDatabase, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			result["server_name"] = serverName
 			*resultMap = append(*resultMap, result)
@@ -179,8 +126,8 @@ func setSqlDatabaseDataToTable(session *azure.AzureSession, rg string, wg *sync.
 	}
 }
 
-func getSqlDatabaseData(session *azure.AzureSession, rg string, serverName string) (result sql.DatabaseListResult, err error) {
+func getSqlDatabaseData(ctx context.Context, session *azure.AzureSession, rg string, serverName string) (result sql.DatabaseListResult, err error) {
 	svcClient := sql.NewDatabasesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListByServer(context.Background(), rg, serverName, "", "")
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListByServer(ctx, rg, serverName, "", "")
 }
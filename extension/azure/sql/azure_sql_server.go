@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	"github.com/fatih/structs"
 	log "github.com/sirupsen/logrus"
@@ -41,20 +40,20 @@ func SqlServerCloumns() []table.ColumnDefinition {
 // SqlServerGenerate returns the row in the table for all configured sql server
 func SqlServerGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": sqlServer,
 			"account":   "default",
 		}).Info("processing sql server")
 
-		results, err := processSqlServer(nil)
+		results, err := processSqlServer(osqCtx, queryContext, nil)
 
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":      sqlServer,
 				"account":        account,
@@ -62,8 +61,9 @@ func SqlServerGenerate(osqCtx context.Context, queryContext table.QueryContext)
 			}).Info("processing accounts")
 
 			results, err :=
-				processSqlServer(&account)
+				processSqlServer(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(sqlServer, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -73,23 +73,21 @@ func SqlServerGenerate(osqCtx context.Context, queryContext table.QueryContext)
 	return resultMap, nil
 }
 
-func processSqlServer(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processSqlServer(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
 
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, sqlServer, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
 
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, sqlServer, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[sqlServer]
+	tableConfig, ok := utilities.GetTableConfig(sqlServer)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": sqlServer,
@@ -98,29 +96,23 @@ func processSqlServer(account *utilities.ExtensionConfigurationAzureAccount) ([]
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go addSqlServer(session, group, &wg, &resultMap, tableConfig)
-	}
-
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, sqlServer, session, groups, tableConfig, addSqlServer)
 }
 
-func addSqlServer(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	resources, err := getSqlServer(session, rg)
+func addSqlServer(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	resources, err := getSqlServer(ctx, session, rg)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":      sqlServer,
 			"rescourceGroup": rg,
 			"errString":      err.Error(),
 		}).Error("failed to get server list from api")
+		azure.ReportError(sqlServer, session.SubscriptionId, rg, err)
+		return err
 	}
 
-	for _, sqlServer := range *resources.Value {
-		structs.DefaultTagName = "json"
-		resMap := structs.Map(sqlServer)
+	for _, server := range *resources.Value {
+		resMap := structs.Map(server)
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
@@ -130,16 +122,20 @@ func addSqlServer(session *azure.AzureSession, rg string, wg *sync.WaitGroup, re
 			}).Error("failed to marshal response")
 			continue
 		}
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, sqlServer, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getSqlServer(session *azure.AzureSession, rg string) (result sql.ServerListResult, err error) {
+func getSqlServer(ctx context.Context, session *azure.AzureSession, rg string) (result sql.ServerListResult, err error) {
 	svcClient := sql.NewServersClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListByResourceGroup(context.Background(), rg)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListByResourceGroup(ctx, rg)
 }
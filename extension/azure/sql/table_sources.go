@@ -0,0 +1,23 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package sql
+
+import (
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/services/sql/mgmt/2014-04-01/sql"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_sql_server":   {Type: reflect.TypeOf((*sql.Server)(nil)).Elem(), FieldNames: true},
+	"azure_sql_database": {Type: reflect.TypeOf((*sql.Database)(nil)).Elem(), Extra: []string{"server_name"}, FieldNames: true},
+}
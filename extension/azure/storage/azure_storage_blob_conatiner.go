@@ -13,7 +13,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-04-01/storage"
 	log "github.com/sirupsen/logrus"
@@ -27,73 +26,28 @@ import (
 
 const storageBlobContainer string = "azure_storage_blob_container"
 
-// StorageBlobContainerColumns returns the list of columns in the table
-func StorageBlobContainerColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("etag"),
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		table.TextColumn("properties"),
-		table.TextColumn("default_encryption_scope"),
-		table.TextColumn("deleted"),
-		table.TextColumn("deleted_time"),
-		table.TextColumn("deny_encryption_scope_override"),
-		table.TextColumn("has_immutability_policy"),
-		table.TextColumn("has_legal_hold"),
-		table.TextColumn("immutability_policy"),
-		// table.TextColumn("immutability_policy_etag"),
-		// table.TextColumn("immutability_policy_update_history"),
-		// table.IntegerColumn("immutability_policy_update_history_immutability_period_since_creation_in_days"),
-		// table.TextColumn("immutability_policy_update_history_object_identifier"),
-		// table.TextColumn("immutability_policy_update_history_tenant_id"),
-		// table.TextColumn("immutability_policy_update_history_timestamp"),
-		// table.TextColumn("immutability_policy_update_history_update"),
-		// table.TextColumn("immutability_policy_update_history_upn"),
-		table.TextColumn("immutable_storage_with_versioning"),
-		// table.TextColumn("immutable_storage_with_versioning_enabled"),
-		// table.TextColumn("immutable_storage_with_versioning_migration_state"),
-		// table.TextColumn("immutable_storage_with_versioning_time_stamp"),
-		table.TextColumn("last_modified_time"),
-		table.TextColumn("lease_duration"),
-		table.TextColumn("lease_state"),
-		table.TextColumn("lease_status"),
-		table.TextColumn("legal_hold"),
-		// table.TextColumn("legal_hold_has_legal_hold"),
-		// table.TextColumn("legal_hold_tags"),
-		// table.TextColumn("legal_hold_tags_object_identifier"),
-		// table.TextColumn("legal_hold_tags_tag"),
-		// table.TextColumn("legal_hold_tags_tenant_id"),
-		// table.TextColumn("legal_hold_tags_timestamp"),
-		// table.TextColumn("legal_hold_tags_upn"),
-		table.TextColumn("metadata"),
-		table.TextColumn("public_access"),
-		table.IntegerColumn("remaining_retention_days"),
-		table.TextColumn("version"),
-		table.TextColumn("type"),
-	}
-}
-
 // StorageBlobContainerGenerate returns the rows in the table for all configured accounts
 func StorageBlobContainerGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageBlobContainer,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processStorageBlobContainer(nil)
+		results, err := processStorageBlobContainer(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageBlobContainer,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processStorageBlobContainer(&account)
+			results, err := processStorageBlobContainer(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageBlobContainer, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -103,22 +57,20 @@ func StorageBlobContainerGenerate(osqCtx context.Context, queryContext table.Que
 	return resultMap, nil
 }
 
-func processStorageBlobContainer(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processStorageBlobContainer(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageBlobContainer, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageBlobContainer, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageBlobContainer]
+	tableConfig, ok := utilities.GetTableConfig(storageBlobContainer)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageBlobContainer,
@@ -126,45 +78,44 @@ func processStorageBlobContainer(account *utilities.ExtensionConfigurationAzureA
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getStorageAccountsForBlobContainer(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageBlobContainer, session, groups, tableConfig, getStorageAccountsForBlobContainer)
 }
 
-func getStorageAccountsForBlobContainer(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getStorageAccountsForBlobContainer(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountNames := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageBlobContainer,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageBlobContainer, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		setStorageBlobContainerToTable(session, rg, wg, resultMap, tableConfig, *resource.Name)
+		accountNames = append(accountNames, *resource.Name)
 	}
+	return azure.ProcessResources(ctx, storageBlobContainer, session, rg, accountNames, resultMap, func(ctx context.Context, accountName string, resultMap *[]map[string]string) error {
+		return setStorageBlobContainerToTable(ctx, queryContext, session, rg, resultMap, tableConfig, accountName)
+	})
 }
 
-func setStorageBlobContainerToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+func setStorageBlobContainerToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) error {
 
-	for resourceItr, err := getStorageBlobContainerData(session, rg, accountName); resourceItr.NotDone(); err = resourceItr.Next() {
+	for resourceItr, err := getStorageBlobContainerData(ctx, session, rg, accountName); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageBlobContainer,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageBlobContainer, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(resource)
 		byteArr, err := json.Marshal(resMap)
 
@@ -177,16 +128,20 @@ func setStorageBlobContainerToTable(session *azure.AzureSession, rg string, wg *
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageBlobContainer, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getStorageBlobContainerData(session *azure.AzureSession, rg string, accountName string) (result storage.ListContainerItemsIterator, err error) {
+func getStorageBlobContainerData(ctx context.Context, session *azure.AzureSession, rg string, accountName string) (result storage.ListContainerItemsIterator, err error) {
 	svcClient := storage.NewBlobContainersClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.ListComplete(context.Background(), rg, accountName, "", "", storage.ListContainersIncludeDeleted)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.ListComplete(ctx, rg, accountName, "", "", storage.ListContainersIncludeDeleted)
 }
\ No newline at end of file
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,69 +17,28 @@ import (
 
 const storageBlobService string = "azure_storage_blob_service"
 
-// StorageBlobServiceColumns returns the list of columns in the table
-func StorageBlobServiceColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("automatic_snapshot_policy_enabled"),
-		table.TextColumn("change_feed"),
-		// table.TextColumn("change_feed_enabled"),
-		// table.IntegerColumn("change_feed_retention_in_days"),
-		table.TextColumn("container_delete_retention_policy"),
-		// table.IntegerColumn("container_delete_retention_policy_days"),
-		// table.TextColumn("container_delete_retention_policy_enabled"),
-		table.TextColumn("cors"),
-		// table.TextColumn("cors_cors_rules"),
-		// table.TextColumn("cors_cors_rules_allowed_headers"),
-		// table.TextColumn("cors_cors_rules_allowed_methods"),
-		// table.TextColumn("cors_cors_rules_allowed_origins"),
-		// table.TextColumn("cors_cors_rules_exposed_headers"),
-		// table.IntegerColumn("cors_cors_rules_max_age_in_seconds"),
-		table.TextColumn("default_service_version"),
-		table.TextColumn("delete_retention_policy"),
-		// table.IntegerColumn("delete_retention_policy_days"),
-		// table.TextColumn("delete_retention_policy_enabled"),
-		table.TextColumn("is_versioning_enabled"),
-		table.TextColumn("last_access_time_tracking_policy"),
-		// table.TextColumn("last_access_time_tracking_policy_blob_type"),
-		// table.TextColumn("last_access_time_tracking_policy_enable"),
-		// table.TextColumn("last_access_time_tracking_policy_name"),
-		// table.IntegerColumn("last_access_time_tracking_policy_tracking_granularity_in_days"),
-		table.TextColumn("restore_policy"),
-		// table.IntegerColumn("restore_policy_days"),
-		// table.TextColumn("restore_policy_enabled"),
-		// table.TextColumn("restore_policy_last_enabled_time"),
-		// table.TextColumn("restore_policy_min_restore_time"),
-		table.TextColumn("sku"),
-		table.TextColumn("sku_name"),
-		table.TextColumn("sku_tier"),
-		table.TextColumn("type"),
-	}
-}
-
 // StorageBlobServicesGenerate returns the rows in the table for all configured accounts
 func StorageBlobServicesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageBlobService,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountStorageBlobServices(nil)
+		results, err := processAccountStorageBlobServices(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageBlobService,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountStorageBlobServices(&account)
+			results, err := processAccountStorageBlobServices(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageBlobService, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -90,22 +48,20 @@ func StorageBlobServicesGenerate(osqCtx context.Context, queryContext table.Quer
 	return resultMap, nil
 }
 
-func processAccountStorageBlobServices(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountStorageBlobServices(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageBlobService, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageBlobService, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageBlobService]
+	tableConfig, ok := utilities.GetTableConfig(storageBlobService)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageBlobService,
@@ -113,38 +69,38 @@ func processAccountStorageBlobServices(account *utilities.ExtensionConfiguration
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getAccountsForStorageBlobServices(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageBlobService, session, groups, tableConfig, getAccountsForStorageBlobServices)
 }
-func getAccountsForStorageBlobServices(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getAccountsForStorageBlobServices(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountNames := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageBlobService,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageBlobService, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		setStorageBlobServicesToTable(session, rg, wg, resultMap, tableConfig, *resource.Name)
+		accountNames = append(accountNames, *resource.Name)
 	}
+	return azure.ProcessResources(ctx, storageBlobService, session, rg, accountNames, resultMap, func(ctx context.Context, accountName string, resultMap *[]map[string]string) error {
+		return setStorageBlobServicesToTable(ctx, queryContext, session, rg, resultMap, tableConfig, accountName)
+	})
 }
-func setStorageBlobServicesToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+func setStorageBlobServicesToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) error {
 
 	Blobservices := make([]storage.BlobServiceProperties, 0)
 
-	getStorageBlobServicesData(session, rg, accountName, &Blobservices)
+	if err := getStorageBlobServicesData(ctx, session, rg, accountName, &Blobservices); err != nil {
+		return err
+	}
 
 	for _, BlobService := range Blobservices {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(BlobService)
 		byteArr, err := json.Marshal(resMap)
 
@@ -157,29 +113,34 @@ func setStorageBlobServicesToTable(session *azure.AzureSession, rg string, wg *s
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageBlobService, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getStorageBlobServicesData(session *azure.AzureSession, rg string, accountName string, BlobService *[]storage.BlobServiceProperties) {
+func getStorageBlobServicesData(ctx context.Context, session *azure.AzureSession, rg string, accountName string, BlobService *[]storage.BlobServiceProperties) error {
 
 	svcClient := storage.NewBlobServicesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	resourceItr, err := svcClient.List(context.Background(), rg, accountName)
+	resourceItr, err := svcClient.List(ctx, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     storageBlobService,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get list from api")
-
+		azure.ReportError(storageBlobService, session.SubscriptionId, rg, err)
+		return err
 	}
 	resource := resourceItr.Value
 	*BlobService = append(*BlobService, *resource...)
-
+	return nil
 }
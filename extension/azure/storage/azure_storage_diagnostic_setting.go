@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	"github.com/fatih/structs"
 	log "github.com/sirupsen/logrus"
@@ -29,58 +28,28 @@ const (
 	TableService   ServiceName = "tableservices"
 )
 
-// StorageDiagnosticSettingsColumns returns the list of columns in the table
-func StorageDiagnosticSettingColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		table.TextColumn("storageAccountId"),
-		table.TextColumn("type"),
-		table.TextColumn("properties_log"),
-		table.TextColumn("properties_metrics"),
-		table.TextColumn("event_hub_authorization_rule_id"),
-		table.TextColumn("event_hub_name"),
-		table.TextColumn("log_analytics_destination_type"),
-		table.TextColumn("logs"),
-		// table.TextColumn("logs_category"),
-		// table.TextColumn("logs_enabled"),
-		// table.TextColumn("logs_retention_policy"),
-		// table.IntegerColumn("logs_retention_policy_days"),
-		// table.TextColumn("logs_retention_policy_enabled"),
-		table.TextColumn("metrics"),
-		// table.TextColumn("metrics_category"),
-		// table.TextColumn("metrics_enabled"),
-		// table.TextColumn("metrics_retention_policy"),
-		// table.IntegerColumn("metrics_retention_policy_days"),
-		// table.TextColumn("metrics_retention_policy_enabled"),
-		// table.TextColumn("metrics_time_grain"),
-		table.TextColumn("service_bus_rule_id"),
-		table.TextColumn("storage_account_id"),
-		table.TextColumn("workspace_id"),
-	}
-}
-
 // StorageDiagnosticSettingsGenerate returns the rows in the table for all configured diagnostic settings
 func StorageDiagnosticSettingsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageDiagnosticSetting,
 			"account":   "default",
 		}).Info("processing diagnostic setting")
-		results, err := processStorageDiagnosticSetting(nil)
+		results, err := processStorageDiagnosticSetting(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageDiagnosticSetting,
 				"account":   account.SubscriptionID,
 			}).Info("processing diagnostic setting")
-			results, err := processStorageDiagnosticSetting(&account)
+			results, err := processStorageDiagnosticSetting(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageDiagnosticSetting, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -90,22 +59,20 @@ func StorageDiagnosticSettingsGenerate(osqCtx context.Context, queryContext tabl
 	return resultMap, nil
 }
 
-func processStorageDiagnosticSetting(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processStorageDiagnosticSetting(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageDiagnosticSetting, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageDiagnosticSetting, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageDiagnosticSetting]
+	tableConfig, ok := utilities.GetTableConfig(storageDiagnosticSetting)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageDiagnosticSetting,
@@ -114,42 +81,49 @@ func processStorageDiagnosticSetting(account *utilities.ExtensionConfigurationAz
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getStorageAccountId(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageDiagnosticSetting, session, groups, tableConfig, getStorageAccountId)
 }
 
-func getStorageAccountId(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	diagnosticSettings := make([]diagnostic.DiagnosticSettingsResource, 0)
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getStorageAccountId(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountIds := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
-				"tableName":     storageAccount,
+				"tableName":     storageDiagnosticSetting,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageDiagnosticSetting, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		getStorageDiagnosticSetting(session, rg, *resource.ID, &diagnosticSettings, StorageService)
-		getStorageDiagnosticSetting(session, rg, *resource.ID, &diagnosticSettings, FileService)
-		getStorageDiagnosticSetting(session, rg, *resource.ID, &diagnosticSettings, BlobService)
-		getStorageDiagnosticSetting(session, rg, *resource.ID, &diagnosticSettings, QueueService)
-		getStorageDiagnosticSetting(session, rg, *resource.ID, &diagnosticSettings, TableService)
+		accountIds = append(accountIds, *resource.ID)
 	}
 
-	addStorageDiagnosticSetting(session, rg, resultMap, tableConfig, diagnosticSettings)
+	return azure.ProcessResources(ctx, storageDiagnosticSetting, session, rg, accountIds, resultMap, func(ctx context.Context, accountId string, resultMap *[]map[string]string) error {
+		diagnosticSettings := make([]diagnostic.DiagnosticSettingsResource, 0)
+		// The settings of the other services are still added
+		var settingErr error
+		for _, service := range []ServiceName{StorageService, FileService, BlobService, QueueService, TableService} {
+			if err := getStorageDiagnosticSetting(ctx, session, rg, accountId, &diagnosticSettings, service); err != nil {
+				settingErr = err
+			}
+		}
+		addStorageDiagnosticSetting(ctx, queryContext, session, rg, resultMap, tableConfig, accountId, diagnosticSettings)
+		return settingErr
+	})
 }
 
-func addStorageDiagnosticSetting(session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, diagnosticSettings []diagnostic.DiagnosticSettingsResource) {
+func addStorageDiagnosticSetting(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountId string, diagnosticSettings []diagnostic.DiagnosticSettingsResource) {
 	for _, diagnosticSetting := range diagnosticSettings {
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(diagnosticSetting)
+		resMap["storageAccountId"] = accountId
+		if diagnosticSetting.DiagnosticSettings != nil {
+			// Kept besides properties_logs and properties_metrics for compatibility
+			resMap["logs"] = diagnosticSetting.Logs
+			resMap["metrics"] = diagnosticSetting.Metrics
+		}
 		byteArr, err := json.Marshal(resMap)
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
@@ -160,17 +134,20 @@ func addStorageDiagnosticSetting(session *azure.AzureSession, rg string, resultM
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageDiagnosticSetting, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
 }
 
-func getStorageDiagnosticSetting(session *azure.AzureSession, rg string, resourceURI string, diagnosticSettings *[]diagnostic.DiagnosticSettingsResource, serviceNameString ServiceName) {
+func getStorageDiagnosticSetting(ctx context.Context, session *azure.AzureSession, rg string, resourceURI string, diagnosticSettings *[]diagnostic.DiagnosticSettingsResource, serviceNameString ServiceName) error {
 	svcClient := diagnostic.NewDiagnosticSettingsClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
 	if serviceNameString != StorageService {
 		resourceURI += "/" + string(serviceNameString) + "/deafult"
@@ -182,14 +159,15 @@ func getStorageDiagnosticSetting(session *azure.AzureSession, rg string, resourc
 		"resourceURI":   resourceURI,
 	}).Info("Getting data from")
 
-	returnObj, err := svcClient.List(context.Background(), resourceURI)
+	returnObj, err := svcClient.List(ctx, resourceURI)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     storageDiagnosticSetting,
 			"resourceGroup": rg,
 			"error":         err.Error(),
 		}).Error("failed to get List")
-		return
+		azure.ReportError(storageDiagnosticSetting, session.SubscriptionId, rg, err)
+		return err
 	}
 	resource := returnObj.Value
 
@@ -198,4 +176,5 @@ func getStorageDiagnosticSetting(session *azure.AzureSession, rg string, resourc
 	}
 
 	*diagnosticSettings = append(*diagnosticSettings, *resource...)
+	return nil
 }
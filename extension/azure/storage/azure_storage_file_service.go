@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,58 +18,28 @@ import (
 
 const storageFileService string = "azure_storage_file_service"
 
-// StorageFileServiceColumns returns the list of columns in the table
-func StorageFileServiceColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("cors"),
-		// table.TextColumn("cors_cors_rules"),
-		// table.TextColumn("cors_cors_rules_allowed_headers"),
-		// table.TextColumn("cors_cors_rules_allowed_methods"),
-		// table.TextColumn("cors_cors_rules_allowed_origins"),
-		// table.TextColumn("cors_cors_rules_exposed_headers"),
-		// table.IntegerColumn("cors_cors_rules_max_age_in_seconds"),
-		table.TextColumn("protocol_settings"),
-		// table.TextColumn("protocol_settings_smb"),
-		// table.TextColumn("protocol_settings_smb_authentication_methods"),
-		// table.TextColumn("protocol_settings_smb_channel_encryption"),
-		// table.TextColumn("protocol_settings_smb_kerberos_ticket_encryption"),
-		// table.TextColumn("protocol_settings_smb_multichannel"),
-		// table.TextColumn("protocol_settings_smb_multichannel_enabled"),
-		// table.TextColumn("protocol_settings_smb_versions"),
-		table.TextColumn("share_delete_retention_policy"),
-		// table.IntegerColumn("share_delete_retention_policy_days"),
-		// table.TextColumn("share_delete_retention_policy_enabled"),
-		table.TextColumn("sku"),
-		table.TextColumn("sku_name"),
-		table.TextColumn("sku_tier"),
-		table.TextColumn("type"),
-	}
-}
-
 // StorageFileServicesGenerate returns the rows in the table for all configured accounts
 func StorageFileServicesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageFileService,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountStorageFileServices(nil)
+		results, err := processAccountStorageFileServices(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageFileService,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountStorageFileServices(&account)
+			results, err := processAccountStorageFileServices(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageFileService, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -80,22 +49,20 @@ func StorageFileServicesGenerate(osqCtx context.Context, queryContext table.Quer
 	return resultMap, nil
 }
 
-func processAccountStorageFileServices(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountStorageFileServices(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageFileService, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageFileService, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageFileService]
+	tableConfig, ok := utilities.GetTableConfig(storageFileService)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageFileService,
@@ -103,39 +70,39 @@ func processAccountStorageFileServices(account *utilities.ExtensionConfiguration
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getAccountsForStorageFileServices(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageFileService, session, groups, tableConfig, getAccountsForStorageFileServices)
 }
 
-func getAccountsForStorageFileServices(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getAccountsForStorageFileServices(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountNames := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageFileService,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageFileService, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		setStorageFileServicesToTable(session, rg, wg, resultMap, tableConfig, *resource.Name)
+		accountNames = append(accountNames, *resource.Name)
 	}
+	return azure.ProcessResources(ctx, storageFileService, session, rg, accountNames, resultMap, func(ctx context.Context, accountName string, resultMap *[]map[string]string) error {
+		return setStorageFileServicesToTable(ctx, queryContext, session, rg, resultMap, tableConfig, accountName)
+	})
 }
-func setStorageFileServicesToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+func setStorageFileServicesToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) error {
 
 	Fileservices := make([]storage.FileServiceProperties, 0)
 
-	getStorageFileServicesData(session, rg, accountName, &Fileservices)
+	if err := getStorageFileServicesData(ctx, session, rg, accountName, &Fileservices); err != nil {
+		return err
+	}
 
 	for _, Fileservice := range Fileservices {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(Fileservice)
 		byteArr, err := json.Marshal(resMap)
 
@@ -148,29 +115,34 @@ func setStorageFileServicesToTable(session *azure.AzureSession, rg string, wg *s
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageFileService, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getStorageFileServicesData(session *azure.AzureSession, rg string, accountName string, Fileservice *[]storage.FileServiceProperties) {
+func getStorageFileServicesData(ctx context.Context, session *azure.AzureSession, rg string, accountName string, Fileservice *[]storage.FileServiceProperties) error {
 
 	svcClient := storage.NewFileServicesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	resourceItr, err := svcClient.List(context.Background(), rg, accountName)
+	resourceItr, err := svcClient.List(ctx, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     storageFileService,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get list from api")
-
+		azure.ReportError(storageFileService, session.SubscriptionId, rg, err)
+		return err
 	}
 	resource := resourceItr.Value
 	*Fileservice = append(*Fileservice, *resource...)
-
+	return nil
 }
\ No newline at end of file
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,44 +18,28 @@ import (
 
 const storageQueueService string = "azure_storage_queue_service"
 
-// StorageQueueServicesColumns returns the list of columns in the table
-func StorageQueueServicesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("cors"),
-		// table.TextColumn("cors_cors_rules"),
-		// table.TextColumn("cors_cors_rules_allowed_headers"),
-		// table.TextColumn("cors_cors_rules_allowed_methods"),
-		// table.TextColumn("cors_cors_rules_allowed_origins"),
-		// table.TextColumn("cors_cors_rules_exposed_headers"),
-		// table.TextColumn("cors_cors_rules_max_age_in_seconds"),
-		table.TextColumn("type"),
-	}
-}
-
 // StorageQueueServicesGenerate returns the rows in the table for all configured accounts
 func StorageQueueServicesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageQueueService,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountStorageQueueServices(nil)
+		results, err := processAccountStorageQueueServices(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageQueueService,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountStorageQueueServices(&account)
+			results, err := processAccountStorageQueueServices(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageQueueService, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -66,22 +49,20 @@ func StorageQueueServicesGenerate(osqCtx context.Context, queryContext table.Que
 	return resultMap, nil
 }
 
-func processAccountStorageQueueServices(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountStorageQueueServices(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageQueueService, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageQueueService, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageQueueService]
+	tableConfig, ok := utilities.GetTableConfig(storageQueueService)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageQueueService,
@@ -89,43 +70,43 @@ func processAccountStorageQueueServices(account *utilities.ExtensionConfiguratio
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getStorageAccountsForStorageQueueServices(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageQueueService, session, groups, tableConfig, getStorageAccountsForStorageQueueServices)
 }
-func getStorageAccountsForStorageQueueServices(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getStorageAccountsForStorageQueueServices(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountNames := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageQueueService,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageQueueService, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		setStorageQueueServicesToTable(session, rg, wg, resultMap, tableConfig, *resource.Name)
+		accountNames = append(accountNames, *resource.Name)
 	}
+	return azure.ProcessResources(ctx, storageQueueService, session, rg, accountNames, resultMap, func(ctx context.Context, accountName string, resultMap *[]map[string]string) error {
+		return setStorageQueueServicesToTable(ctx, queryContext, session, rg, resultMap, tableConfig, accountName)
+	})
 }
-func setStorageQueueServicesToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+func setStorageQueueServicesToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) error {
 
-	resource, err := getStorageQueueServicesData(session, rg, accountName)
+	resource, err := getStorageQueueServicesData(ctx, session, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     storageQueueService,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get Queueservice list")
+		azure.ReportError(storageQueueService, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, Queueservice := range *resource.Value {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(Queueservice)
 		byteArr, err := json.Marshal(resMap)
 
@@ -138,18 +119,22 @@ func setStorageQueueServicesToTable(session *azure.AzureSession, rg string, wg *
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageQueueService, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getStorageQueueServicesData(session *azure.AzureSession, rg string, accountName string) (result storage.ListQueueServices, err error) {
+func getStorageQueueServicesData(ctx context.Context, session *azure.AzureSession, rg string, accountName string) (result storage.ListQueueServices, err error) {
 
 	svcClient := storage.NewQueueServicesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
-	return svcClient.List(context.Background(), rg, accountName)
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
+	return svcClient.List(ctx, rg, accountName)
 
 }
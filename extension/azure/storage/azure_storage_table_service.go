@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,44 +18,28 @@ import (
 
 const storageTableService string = "azure_storage_table_service"
 
-// StorageTableServicesColumns returns the list of columns in the table
-func StorageTableServicesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("id"),
-		table.TextColumn("name"),
-		// table.TextColumn("properties"),
-		table.TextColumn("cors"),
-		// table.TextColumn("cors_cors_rules"),
-		// table.TextColumn("cors_cors_rules_allowed_headers"),
-		// table.TextColumn("cors_cors_rules_allowed_methods"),
-		// table.TextColumn("cors_cors_rules_allowed_origins"),
-		// table.TextColumn("cors_cors_rules_exposed_headers"),
-		// table.TextColumn("cors_cors_rules_max_age_in_seconds"),
-		table.TextColumn("type"),
-	}
-}
-
 // StorageTableServicesGenerate returns the rows in the table for all configured accounts
 func StorageTableServicesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	if len(utilities.ExtConfiguration.ExtConfAzure.Accounts) == 0 {
+	if len(utilities.GetAzureAccounts()) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageTableService,
 			"account":   "default",
 		}).Info("processing account")
-		results, err := processAccountStorageTableServices(nil)
+		results, err := processAccountStorageTableServices(osqCtx, queryContext, nil)
 		if err != nil {
 			return resultMap, err
 		}
 		resultMap = append(resultMap, results...)
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfAzure.Accounts {
+		for _, account := range utilities.GetAzureAccounts() {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName": storageTableService,
 				"account":   account.SubscriptionID,
 			}).Info("processing account")
-			results, err := processAccountStorageTableServices(&account)
+			results, err := processAccountStorageTableServices(osqCtx, queryContext, &account)
 			if err != nil {
+				azure.ReportError(storageTableService, account.SubscriptionID, "", err)
 				continue
 			}
 			resultMap = append(resultMap, results...)
@@ -66,22 +49,20 @@ func StorageTableServicesGenerate(osqCtx context.Context, queryContext table.Que
 	return resultMap, nil
 }
 
-func processAccountStorageTableServices(account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
+func processAccountStorageTableServices(ctx context.Context, queryContext table.QueryContext, account *utilities.ExtensionConfigurationAzureAccount) ([]map[string]string, error) {
 	resultMap := make([]map[string]string, 0)
-	var wg sync.WaitGroup
-	session, err := azure.GetAuthSession(account)
-	if err != nil {
+	session, err := azure.GetTableSession(queryContext, storageTableService, account)
+	if err != nil || session == nil {
 		return resultMap, err
 	}
-	groups, err := azure.GetGroups(session)
+	groups, err := azure.GetGroups(ctx, session)
 
 	if err != nil {
 		return resultMap, err
 	}
+	groups = azure.FilterGroups(queryContext, storageTableService, session.SubscriptionId, groups)
 
-	wg.Add(len(groups))
-
-	tableConfig, ok := utilities.TableConfigurationMap[storageTableService]
+	tableConfig, ok := utilities.GetTableConfig(storageTableService)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": storageTableService,
@@ -89,44 +70,44 @@ func processAccountStorageTableServices(account *utilities.ExtensionConfiguratio
 		return resultMap, fmt.Errorf("table configuration not found")
 	}
 
-	for _, group := range groups {
-		go getAccountsForStorageTableServices(session, group, &wg, &resultMap, tableConfig)
-	}
-	wg.Wait()
-	return resultMap, nil
+	return azure.ProcessResourceGroups(ctx, queryContext, storageTableService, session, groups, tableConfig, getAccountsForStorageTableServices)
 }
 
-func getAccountsForStorageTableServices(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) {
-	defer wg.Done()
-
-	for resourceItr, err := GetStorageAccounts(session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
+func getAccountsForStorageTableServices(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig) error {
+	accountNames := make([]string, 0)
+	for resourceItr, err := GetStorageAccounts(ctx, session, rg); resourceItr.NotDone(); err = resourceItr.Next() {
 		if err != nil {
 			utilities.GetLogger().WithFields(log.Fields{
 				"tableName":     storageTableService,
 				"resourceGroup": rg,
 				"errString":     err.Error(),
 			}).Error("failed to get resource list")
-			continue
+			azure.ReportError(storageTableService, session.SubscriptionId, rg, err)
+			return err
 		}
 
 		resource := resourceItr.Value()
-		setStorageTableServicesToTable(session, rg, wg, resultMap, tableConfig, *resource.Name)
+		accountNames = append(accountNames, *resource.Name)
 	}
+	return azure.ProcessResources(ctx, storageTableService, session, rg, accountNames, resultMap, func(ctx context.Context, accountName string, resultMap *[]map[string]string) error {
+		return setStorageTableServicesToTable(ctx, queryContext, session, rg, resultMap, tableConfig, accountName)
+	})
 }
-func setStorageTableServicesToTable(session *azure.AzureSession, rg string, wg *sync.WaitGroup, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) {
+func setStorageTableServicesToTable(ctx context.Context, queryContext table.QueryContext, session *azure.AzureSession, rg string, resultMap *[]map[string]string, tableConfig *utilities.TableConfig, accountName string) error {
 
-	resource, err := getStorageTableServicesData(session, rg, accountName)
+	resource, err := getStorageTableServicesData(ctx, session, rg, accountName)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName":     storageTableService,
 			"resourceGroup": rg,
 			"errString":     err.Error(),
 		}).Error("failed to get tale services list")
+		azure.ReportError(storageTableService, session.SubscriptionId, rg, err)
+		return err
 	}
 
 	for _, Tableservice := range *resource.Value {
 
-		structs.DefaultTagName = "json"
 		resMap := structs.Map(Tableservice)
 		byteArr, err := json.Marshal(resMap)
 
@@ -139,19 +120,23 @@ func setStorageTableServicesToTable(session *azure.AzureSession, rg string, wg *
 			continue
 		}
 
-		table := utilities.NewTable(byteArr, tableConfig)
+		table := utilities.NewTable(ctx, byteArr, tableConfig)
 		for _, row := range table.Rows {
+			if !azure.ShouldProcessRow(ctx, queryContext, storageTableService, session.SubscriptionId, rg, row) {
+				continue
+			}
 			result := azure.RowToMap(row, session.SubscriptionId, "", rg, tableConfig)
 			*resultMap = append(*resultMap, result)
 		}
 	}
+	return nil
 }
 
-func getStorageTableServicesData(session *azure.AzureSession, rg string, accountName string) (result storage.ListTableServices, err error) {
+func getStorageTableServicesData(ctx context.Context, session *azure.AzureSession, rg string, accountName string) (result storage.ListTableServices, err error) {
 
 	svcClient := storage.NewTableServicesClient(session.SubscriptionId)
-	svcClient.Authorizer = session.Authorizer
+	azure.ConfigureClient(&svcClient.Client, session.Authorizer)
 
-	return svcClient.List(context.Background(), rg, accountName)
+	return svcClient.List(ctx, rg, accountName)
 
 }
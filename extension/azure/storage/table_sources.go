@@ -0,0 +1,33 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package storage
+
+import (
+	"reflect"
+
+	storageprofile "github.com/Azure/azure-sdk-for-go/profiles/latest/storage/mgmt/storage"
+	diagnostic "github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2021-07-01-preview/insights"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-04-01/storage"
+	azureazblob "github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"azure_storage_account":            {Type: reflect.TypeOf((*storage.Account)(nil)).Elem(), FieldNames: true},
+	"azure_storage_blob_container":     {Type: reflect.TypeOf((*storage.ListContainerItem)(nil)).Elem(), FieldNames: true},
+	"azure_storage_diagnostic_setting": {Type: reflect.TypeOf((*diagnostic.DiagnosticSettingsResource)(nil)).Elem(), Extra: []string{"storageAccountId", "logs", "metrics"}, FieldNames: true},
+	"azure_storage_file_service":       {Type: reflect.TypeOf((*storage.FileServiceProperties)(nil)).Elem(), FieldNames: true},
+	"azure_storage_blob_service":       {Type: reflect.TypeOf((*storageprofile.BlobServiceProperties)(nil)).Elem(), FieldNames: true},
+	"azure_storage_queue_service":      {Type: reflect.TypeOf((*storage.QueueServiceProperties)(nil)).Elem(), FieldNames: true},
+	"azure_storage_table_service":      {Type: reflect.TypeOf((*storage.TableServiceProperties)(nil)).Elem(), FieldNames: true},
+	"azure_storage_blob": {Type: reflect.TypeOf((*azureazblob.BlobItemInternal)(nil)).Elem(), Extra: []string{"ContainerName", "StorageAccountName", "ContainerId", "ContainerType",
+		"StorageAccountId", "Location", "IsSnapshot"}, FieldNames: true},
+}
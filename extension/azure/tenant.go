@@ -0,0 +1,170 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2020-05-01/managementgroups"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2021-01-01/subscriptions"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultTenantRefreshInterval is the interval at which the subscriptions of the tenants are listed again
+const defaultTenantRefreshInterval = time.Hour
+
+// GetTenantRefreshInterval returns the shortest interval at which the subscriptions of given tenants are listed again
+func GetTenantRefreshInterval(tenants []utilities.ExtensionConfigurationAzureTenant) time.Duration {
+	interval := defaultTenantRefreshInterval
+	for idx, tenant := range tenants {
+		tenantInterval := defaultTenantRefreshInterval
+		if tenant.RefreshInterval > 0 {
+			tenantInterval = time.Duration(tenant.RefreshInterval) * time.Second
+		}
+		if idx == 0 || tenantInterval < interval {
+			interval = tenantInterval
+		}
+	}
+	return interval
+}
+
+// DiscoverTenantSubscriptions returns the enabled subscriptions of given tenant, selected by its management groups and tags.
+// The subscriptions are listed, and collected, with the credentials of the tenant.
+func DiscoverTenantSubscriptions(ctx context.Context, tenant *utilities.ExtensionConfigurationAzureTenant) ([]utilities.ExtensionConfigurationAzureAccount, error) {
+	session, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{
+		TenantID:                               tenant.TenantID,
+		AuthFile:                               tenant.AuthFile,
+		ExtensionConfigurationAzureCredentials: tenant.ExtensionConfigurationAzureCredentials,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tenantID := tenant.TenantID
+	if tenantID == "" {
+		tenantID = session.TenantId
+	}
+
+	client := subscriptions.NewClient()
+	ConfigureClient(&client.Client, session.Authorizer)
+	list := make([]subscriptions.Subscription, 0)
+	for itr, err := client.ListComplete(ctx); itr.NotDone(); err = itr.NextWithContext(ctx) {
+		if err != nil {
+			return nil, errors.Wrap(err, "error traversing subscription list")
+		}
+		list = append(list, itr.Value())
+	}
+
+	var selected map[string]bool
+	if len(tenant.ManagementGroups) > 0 {
+		selected = make(map[string]bool)
+		groupsClient := managementgroups.NewClient()
+		ConfigureClient(&groupsClient.Client, session.Authorizer)
+		for _, group := range tenant.ManagementGroups {
+			for itr, err := groupsClient.GetDescendantsComplete(ctx, group, "", nil); itr.NotDone(); err = itr.NextWithContext(ctx) {
+				if err != nil {
+					return nil, errors.Wrap(err, "error traversing management group descendants")
+				}
+				descendant := itr.Value()
+				if descendant.Type != nil && strings.HasSuffix(*descendant.Type, "/subscriptions") && descendant.Name != nil {
+					selected[*descendant.Name] = true
+				}
+			}
+		}
+	}
+	return selectSubscriptions(list, selected, tenantID, tenant), nil
+}
+
+// selectSubscriptions returns the accounts of the enabled subscriptions of the tenant, which are in selected if not nil
+// and have the tags of the tenant configuration
+func selectSubscriptions(list []subscriptions.Subscription, selected map[string]bool, tenantID string, tenant *utilities.ExtensionConfigurationAzureTenant) []utilities.ExtensionConfigurationAzureAccount {
+	accounts := make([]utilities.ExtensionConfigurationAzureAccount, 0, len(list))
+	for _, subscription := range list {
+		if subscription.SubscriptionID == nil || subscription.State != subscriptions.StateEnabled {
+			continue
+		}
+		if tenantID != "" && subscription.TenantID != nil && *subscription.TenantID != tenantID {
+			continue
+		}
+		if selected != nil && !selected[*subscription.SubscriptionID] {
+			continue
+		}
+		if !matchSubscriptionTags(subscription.Tags, tenant.Tags) {
+			continue
+		}
+		accounts = append(accounts, utilities.ExtensionConfigurationAzureAccount{
+			SubscriptionID:                         *subscription.SubscriptionID,
+			TenantID:                               tenantID,
+			AuthFile:                               tenant.AuthFile,
+			ExtensionConfigurationAzureCredentials: tenant.ExtensionConfigurationAzureCredentials,
+		})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].SubscriptionID < accounts[j].SubscriptionID
+	})
+	return accounts
+}
+
+// matchSubscriptionTags returns true if given subscription tags have all of the tags, with a value matching one of their patterns
+func matchSubscriptionTags(subscriptionTags map[string]*string, tags map[string][]string) bool {
+	for key, patterns := range tags {
+		value, found := subscriptionTags[key]
+		if !found || value == nil {
+			return false
+		}
+		matched := false
+		for _, pattern := range patterns {
+			if utilities.MatchGlob(pattern, *value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// RefreshTenantSubscriptions lists the subscriptions of the configured tenants again, and replaces the discovered subscriptions.
+// The subscriptions discovered before in a tenant are kept if the listing of the tenant fails.
+func RefreshTenantSubscriptions(ctx context.Context) {
+	tenants := utilities.GetExtConfiguration().ExtConfAzure.Tenants
+	previous := utilities.GetDiscoveredAzureAccounts()
+	accounts := make([]utilities.ExtensionConfigurationAzureAccount, 0)
+	for idx := range tenants {
+		tenant := &tenants[idx]
+		discovered, err := DiscoverTenantSubscriptions(ctx, tenant)
+		if err != nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"tenant":    tenant.TenantID,
+				"errString": err.Error(),
+			}).Error("failed to discover tenant subscriptions")
+			ReportError(utilities.DiscoveryTable, tenant.TenantID, "", err)
+			for _, account := range previous {
+				if account.AuthFile == tenant.AuthFile && account.ExtensionConfigurationAzureCredentials == tenant.ExtensionConfigurationAzureCredentials &&
+					(tenant.TenantID == "" || account.TenantID == tenant.TenantID) {
+					accounts = append(accounts, account)
+				}
+			}
+			continue
+		}
+		utilities.GetLogger().WithFields(log.Fields{
+			"tenant":             tenant.TenantID,
+			"totalSubscriptions": len(discovered),
+		}).Info("discovered tenant subscriptions")
+		accounts = append(accounts, discovered...)
+	}
+	utilities.SetDiscoveredAzureAccounts(accounts)
+}
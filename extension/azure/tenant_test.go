@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2021-01-01/subscriptions"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectSubscriptions(t *testing.T) {
+	list := []subscriptions.Subscription{
+		{SubscriptionID: stringPtr("sub-3"), TenantID: stringPtr("tenant-1"), State: subscriptions.StateEnabled, Tags: map[string]*string{"env": stringPtr("prod-eu")}},
+		{SubscriptionID: stringPtr("sub-1"), TenantID: stringPtr("tenant-1"), State: subscriptions.StateEnabled},
+		{SubscriptionID: stringPtr("sub-2"), TenantID: stringPtr("tenant-1"), State: subscriptions.StateDisabled},
+		{SubscriptionID: stringPtr("sub-4"), TenantID: stringPtr("tenant-2"), State: subscriptions.StateEnabled},
+	}
+	tenant := &utilities.ExtensionConfigurationAzureTenant{AuthFile: "/auth.json"}
+	assert.Equal(t, []utilities.ExtensionConfigurationAzureAccount{
+		{SubscriptionID: "sub-1", TenantID: "tenant-1", AuthFile: "/auth.json"},
+		{SubscriptionID: "sub-3", TenantID: "tenant-1", AuthFile: "/auth.json"},
+	}, selectSubscriptions(list, nil, "tenant-1", tenant))
+
+	// Subscriptions of the management groups
+	accounts := selectSubscriptions(list, map[string]bool{"sub-1": true}, "tenant-1", tenant)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "sub-1", accounts[0].SubscriptionID)
+
+	tenant.Tags = map[string][]string{"env": {"prod-*"}}
+	accounts = selectSubscriptions(list, nil, "tenant-1", tenant)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "sub-3", accounts[0].SubscriptionID)
+}
+
+func TestGetAuthSession(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	err := ioutil.WriteFile(authFile, []byte(`{
+		"clientId": "client-1",
+		"clientSecret": "secret",
+		"subscriptionId": "sub-1",
+		"tenantId": "tenant-1",
+		"activeDirectoryEndpointUrl": "https://login.microsoftonline.com",
+		"resourceManagerEndpointUrl": "https://management.azure.com/",
+		"activeDirectoryGraphResourceId": "https://graph.windows.net/",
+		"managementEndpointUrl": "https://management.core.windows.net/"
+	}`), 0600)
+	assert.Nil(t, err)
+
+	session, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{AuthFile: authFile})
+	assert.Nil(t, err)
+	assert.Equal(t, "sub-1", session.SubscriptionId)
+	assert.Equal(t, "tenant-1", session.TenantId)
+
+	// Subscriptions of an auth file share its authorizers
+	other, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub-2", AuthFile: authFile})
+	assert.Nil(t, err)
+	assert.Equal(t, "sub-2", other.SubscriptionId)
+	assert.True(t, session.Authorizer == other.Authorizer)
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestRefreshTenantSubscriptionsError(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAzure.Tenants = []utilities.ExtensionConfigurationAzureTenant{{TenantID: "tenant-1", AuthFile: "/nonexistent/auth.json"}}
+	utilities.SetExtConfiguration(&extConfig)
+
+	// Failures are reported with the tenant
+	RefreshTenantSubscriptions(context.Background())
+	collectionErrors := utilities.GetLastCollectionErrors(utilities.DiscoveryTable, 1)
+	assert.Equal(t, 1, len(collectionErrors))
+	assert.Equal(t, "tenant-1", collectionErrors[0].Account)
+	assert.Equal(t, utilities.ProviderAzure, collectionErrors[0].Provider)
+}
@@ -12,31 +12,69 @@ package azure
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-02-01/resources"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/fatih/structs"
 	"github.com/pkg/errors"
 )
 
 // AzureSession is an object representing session for subscription
 type AzureSession struct {
 	SubscriptionId  string
+	TenantId        string
 	Authorizer      autorest.Authorizer
 	GraphAuthorizer autorest.Authorizer
 	VaultAuthorizer autorest.Authorizer
 }
 
+// authorizerExpiryWindow is how long before their tokens expire the authorizers which do not refresh them are created again
+const authorizerExpiryWindow = 5 * time.Minute
+
 var (
 	authGeneratorMutex sync.Mutex
+	// authorizerCache holds the authorizers of each credentials, guarded by authGeneratorMutex.
+	// The subscriptions with the same credentials share their authorizers, and their tokens.
+	authorizerCache = make(map[string]*azureAuthorizers)
 )
 
+// azureResources are the resources of the resource manager, graph and key vault authorizers
+var azureResources = []string{
+	azure.PublicCloud.ResourceManagerEndpoint,
+	azure.PublicCloud.GraphEndpoint,
+	strings.Trim(azure.PublicCloud.KeyVaultEndpoint, "/"),
+}
+
+// azureAuthorizers holds the authorizers created from the credentials of an account,
+// as of the modification time of their auth file
+type azureAuthorizers struct {
+	modTime time.Time
+	// expires is the expiry of the tokens of the authorizers which do not refresh them, zero otherwise
+	expires         time.Time
+	subscriptionID  string
+	tenantID        string
+	authorizer      autorest.Authorizer
+	graphAuthorizer autorest.Authorizer
+	vaultAuthorizer autorest.Authorizer
+}
+
+func init() {
+	// Azure SDK models are converted to maps using their json tags.
+	// Set once here, tables are generated concurrently.
+	structs.DefaultTagName = "json"
+}
+
 func readJSON(path string) (*map[string]interface{}, error) {
 	data, err := ioutil.ReadFile(path)
 
@@ -56,39 +94,231 @@ func readJSON(path string) (*map[string]interface{}, error) {
 
 // GetAuthSession creates an authorizer for the given account
 // If account is nil, it creates an authorizer for the default account,
-// by locating the auth file by reading "AZURE_AUTH_LOCATION" env variable
+// by locating the auth file by reading "AZURE_AUTH_LOCATION" env variable.
+// The subscription of the account is used if set, the subscription of its credentials otherwise.
 func GetAuthSession(account *utilities.ExtensionConfigurationAzureAccount) (*AzureSession, error) {
+	if account == nil {
+		account = &utilities.ExtensionConfigurationAzureAccount{}
+	}
 	authGeneratorMutex.Lock()
 	defer authGeneratorMutex.Unlock()
 
-	if account != nil {
-		os.Setenv("AZURE_AUTH_LOCATION", account.AuthFile)
+	authorizers, err := getAuthorizers(account)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptionId := authorizers.subscriptionID
+	if account.SubscriptionID != "" {
+		subscriptionId = account.SubscriptionID
+	}
+	tenantId := authorizers.tenantID
+	if tenantId == "" {
+		tenantId = account.TenantID
+	}
+	session := AzureSession{
+		SubscriptionId:  subscriptionId,
+		TenantId:        tenantId,
+		Authorizer:      authorizers.authorizer,
+		GraphAuthorizer: authorizers.graphAuthorizer,
+		VaultAuthorizer: authorizers.vaultAuthorizer,
+	}
+
+	return &session, nil
+}
+
+// getAuthFile returns the auth file of given account, AZURE_AUTH_LOCATION if not set
+func getAuthFile(account *utilities.ExtensionConfigurationAzureAccount) string {
+	if account.AuthFile != "" {
+		return account.AuthFile
+	}
+	return os.Getenv("AZURE_AUTH_LOCATION")
+}
+
+// getAuthorizers returns the authorizers of the credentials of given account, created again if their auth file changed,
+// or if their tokens are about to expire. authGeneratorMutex must be held.
+func getAuthorizers(account *utilities.ExtensionConfigurationAzureAccount) (*azureAuthorizers, error) {
+	credentials := *account
+	credentials.SubscriptionID = ""
+	modTime := time.Time{}
+	if account.GetAuthType() == utilities.AzureAuthFile {
+		credentials.AuthFile = getAuthFile(account)
+		info, err := os.Stat(credentials.AuthFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't get authinfo")
+		}
+		modTime = info.ModTime()
 	}
-	authorizer, err := auth.NewAuthorizerFromFile(azure.PublicCloud.ResourceManagerEndpoint)
+	key, _ := json.Marshal(credentials)
+	if cached, ok := authorizerCache[string(key)]; ok && cached.modTime.Equal(modTime) &&
+		(cached.expires.IsZero() || time.Until(cached.expires) > authorizerExpiryWindow) {
+		return cached, nil
+	}
+
+	authorizers, err := newAuthorizers(&credentials)
 	if err != nil {
+		return nil, err
+	}
+	authorizers.modTime = modTime
+	authorizerCache[string(key)] = authorizers
+	return authorizers, nil
+}
+
+// newAuthorizers creates the authorizers of the credentials of given account, as set by its authentication type
+func newAuthorizers(account *utilities.ExtensionConfigurationAzureAccount) (*azureAuthorizers, error) {
+	authorizers := &azureAuthorizers{tenantID: account.TenantID}
+	var newAuthorizer func(resource string) (autorest.Authorizer, error)
+	switch account.GetAuthType() {
+	case utilities.AzureAuthFile:
+		settings, err := readAuthFile(account.AuthFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't get authinfo")
+		}
+		authorizers.subscriptionID = settings.GetSubscriptionID()
+		authorizers.tenantID = settings.Values[auth.TenantID]
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			if authorizer, err := settings.ClientCredentialsAuthorizerWithResource(resource); err == nil {
+				return authorizer, nil
+			}
+			if authorizer, err := settings.ClientCertificateAuthorizerWithResource(resource); err == nil {
+				return authorizer, nil
+			}
+			return nil, errors.New("auth file missing client and certificate credentials")
+		}
+	case utilities.AzureAuthClientSecret:
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			config := auth.NewClientCredentialsConfig(account.ClientID, account.ClientSecret, account.TenantID)
+			config.Resource = resource
+			return config.Authorizer()
+		}
+	case utilities.AzureAuthClientCertificate:
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			config := auth.NewClientCertificateConfig(account.CertificatePath, account.CertificatePassword, account.ClientID, account.TenantID)
+			config.Resource = resource
+			return config.Authorizer()
+		}
+	case utilities.AzureAuthManagedIdentity:
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			config := auth.NewMSIConfig()
+			config.ClientID = account.ClientID
+			config.Resource = resource
+			return config.Authorizer()
+		}
+	case utilities.AzureAuthWorkloadIdentity:
+		authorizers.tenantID = getSetting(account.TenantID, "AZURE_TENANT_ID")
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			return newWorkloadIdentityAuthorizer(account, resource)
+		}
+	case utilities.AzureAuthEnvironment:
+		settings, err := auth.GetSettingsFromEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		authorizers.subscriptionID = settings.GetSubscriptionID()
+		authorizers.tenantID = settings.Values[auth.TenantID]
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			settings.Values[auth.Resource] = resource
+			return settings.GetAuthorizer()
+		}
+	case utilities.AzureAuthCli:
+		newAuthorizer = func(resource string) (autorest.Authorizer, error) {
+			authorizer, err := auth.NewAuthorizerFromCLIWithResource(resource)
+			if err != nil {
+				return nil, err
+			}
+			// The tokens of the CLI are not refreshed, the authorizers are created again before they expire
+			if bearer, ok := authorizer.(*autorest.BearerAuthorizer); ok {
+				if token, ok := bearer.TokenProvider().(*adal.Token); ok {
+					if authorizers.expires.IsZero() || token.Expires().Before(authorizers.expires) {
+						authorizers.expires = token.Expires()
+					}
+				}
+			}
+			return authorizer, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown authType %q", account.AuthType)
+	}
+
+	var err error
+	if authorizers.authorizer, err = newAuthorizer(azureResources[0]); err != nil {
 		return nil, errors.Wrap(err, "Can't initialize authorizer")
 	}
-	graphrbacAuthorizer, err := auth.NewAuthorizerFromFile(azure.PublicCloud.GraphEndpoint)
-	if err != nil {
+	if authorizers.graphAuthorizer, err = newAuthorizer(azureResources[1]); err != nil {
 		return nil, errors.Wrap(err, "Can't initialize graph authorizer")
 	}
-	vaultAuthorizer, err := auth.NewAuthorizerFromFileWithResource(strings.Trim(azure.PublicCloud.KeyVaultEndpoint, "/"))
-	if err != nil {
+	if authorizers.vaultAuthorizer, err = newAuthorizer(azureResources[2]); err != nil {
 		return nil, errors.Wrap(err, "Can't initialize vault authorizer")
 	}
-	authInfo, err := readJSON(os.Getenv("AZURE_AUTH_LOCATION"))
+	return authorizers, nil
+}
+
+// readAuthFile returns the settings of given auth file, as auth.GetSettingsFromFile without AZURE_AUTH_LOCATION
+func readAuthFile(authFile string) (auth.FileSettings, error) {
+	settings := auth.FileSettings{Values: make(map[string]string)}
+	contents, err := readJSON(authFile)
 	if err != nil {
-		return nil, errors.Wrap(err, "Can't get authinfo")
+		return settings, err
+	}
+	keys := map[string]string{
+		auth.ClientID:                "clientId",
+		auth.ClientSecret:            "clientSecret",
+		auth.CertificatePath:         "clientCertificate",
+		auth.CertificatePassword:     "clientCertificatePassword",
+		auth.SubscriptionID:          "subscriptionId",
+		auth.TenantID:                "tenantId",
+		auth.ActiveDirectoryEndpoint: "activeDirectoryEndpointUrl",
+		auth.ResourceManagerEndpoint: "resourceManagerEndpointUrl",
+		auth.GraphResourceID:         "activeDirectoryGraphResourceId",
+		auth.SQLManagementEndpoint:   "sqlManagementEndpointUrl",
+		auth.GalleryEndpoint:         "galleryEndpointUrl",
+		auth.ManagementEndpoint:      "managementEndpointUrl",
 	}
+	for key, name := range keys {
+		if value, ok := (*contents)[name].(string); ok {
+			settings.Values[key] = value
+		}
+	}
+	return settings, nil
+}
 
-	session := AzureSession{
-		SubscriptionId:  (*authInfo)["subscriptionId"].(string),
-		Authorizer:      authorizer,
-		GraphAuthorizer: graphrbacAuthorizer,
-		VaultAuthorizer: vaultAuthorizer,
+// getSetting returns value if set, the value of given environment variable otherwise
+func getSetting(value string, envName string) string {
+	if value != "" {
+		return value
 	}
+	return os.Getenv(envName)
+}
 
-	return &session, nil
+// newWorkloadIdentityAuthorizer creates an authorizer exchanging the federated token of given account for tokens of the resource.
+// The client, tenant and token file default to the variables set by the Azure workload identity webhook.
+func newWorkloadIdentityAuthorizer(account *utilities.ExtensionConfigurationAzureAccount, resource string) (autorest.Authorizer, error) {
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, getSetting(account.TenantID, "AZURE_TENANT_ID"))
+	if err != nil {
+		return nil, err
+	}
+	secret := &federatedTokenSecret{tokenFile: getSetting(account.FederatedTokenFile, "AZURE_FEDERATED_TOKEN_FILE")}
+	token, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, getSetting(account.ClientID, "AZURE_CLIENT_ID"), resource, secret)
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// federatedTokenSecret authenticates a service principal with a federated token, read again from its file for each token request
+type federatedTokenSecret struct {
+	tokenFile string
+}
+
+// SetAuthenticationValues sets the federated token as the client assertion of the token request
+func (secret *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, values *url.Values) error {
+	token, err := ioutil.ReadFile(secret.tokenFile)
+	if err != nil {
+		return errors.Wrap(err, "Can't read federated token")
+	}
+	values.Set("client_assertion", strings.TrimSpace(string(token)))
+	values.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	return nil
 }
 
 // RowToMap converts JSON row into osquery row.
@@ -110,14 +340,14 @@ func RowToMap(row map[string]interface{}, subscriptionId string, tenantId string
 }
 
 // GetGroups returns the list of resource groups for given azure session
-func GetGroups(session *AzureSession) ([]string, error) {
+func GetGroups(ctx context.Context, session *AzureSession) ([]string, error) {
 	tab := make([]string, 0)
 	var err error
 
 	grClient := resources.NewGroupsClient(session.SubscriptionId)
-	grClient.Authorizer = session.Authorizer
+	ConfigureClient(&grClient.Client, session.Authorizer)
 
-	for list, err := grClient.ListComplete(context.Background(), "", nil); list.NotDone(); err = list.Next() {
+	for list, err := grClient.ListComplete(ctx, "", nil); list.NotDone(); err = list.Next() {
 		if err != nil {
 			return nil, errors.Wrap(err, "error traverising resource group list")
 		}
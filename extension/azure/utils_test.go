@@ -10,9 +10,17 @@
 package azure
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Uptycs/basequery-go/plugin/table"
 	"github.com/Uptycs/cloudquery/utilities"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,9 +49,187 @@ func TestRowToMap(t *testing.T) {
 
 	subID, tenantID, rscGroup := "test-account", "us-east4", ""
 	inRow := make(map[string]interface{})
-	tabConfig := utilities.TableConfigurationMap["test_table_1"]
+	tabConfig, _ := utilities.GetTableConfig("test_table_1")
 	outRow := RowToMap(inRow, subID, tenantID, rscGroup, tabConfig)
 
 	assert.Equal(t, subID, outRow["subscription_id"])
 	assert.Equal(t, tenantID, outRow["abc"])
 }
+
+var filterTableConfigJSON = `
+{
+	"test_table_2": {
+		"aws": {},
+		"gcp": {},
+		"azure": {
+			"subscriptionIdAttribute": "subscription_id",
+			"resourceGroupAttribute": "resource_group"
+		},
+		"parsedAttributes": []
+	}
+}`
+
+func TestFilterGroups(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(filterTableConfigJSON))
+	assert.Nil(t, err)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"subscription_id": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "sub1"},
+				},
+			},
+			"resource_group": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorLike, Expression: "prod-%"},
+				},
+			},
+		},
+	}
+
+	assert.True(t, ShouldProcessSubscription(queryContext, "test_table_2", "sub1"))
+	assert.False(t, ShouldProcessSubscription(queryContext, "test_table_2", "sub2"))
+
+	groups := FilterGroups(queryContext, "test_table_2", "sub1", []string{"prod-web", "dev-web", "PROD-db"})
+	assert.Equal(t, []string{"prod-web", "PROD-db"}, groups)
+
+	// Credentials of skipped subscriptions are not read
+	account := &utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub2"}
+	account.AuthType = "password"
+	session, err := GetTableSession(queryContext, "test_table_2", account)
+	assert.Nil(t, err)
+	assert.Nil(t, session)
+
+	account.SubscriptionID = "sub1"
+	_, err = GetTableSession(queryContext, "test_table_2", account)
+	assert.NotNil(t, err)
+}
+
+func TestGetRequestAccount(t *testing.T) {
+	for url, account := range map[string]string{
+		"https://management.azure.com/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines": "sub-1",
+		"https://graph.windows.net/tenant-1/users?api-version=1.6":                                                       "tenant-1",
+		"https://vault-1.vault.azure.net/keys":                                                                           "vault-1.vault.azure.net",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		assert.Equal(t, account, getRequestAccount(req))
+	}
+}
+
+func TestReportError(t *testing.T) {
+	ReportError("azure_test_errors", "sub-1", "rg-1", autorest.DetailedError{
+		PackageType: "compute.VirtualMachinesClient",
+		Method:      "List",
+		StatusCode:  http.StatusForbidden,
+		Original:    &azure.RequestError{ServiceError: &azure.ServiceError{Code: "AuthorizationFailed"}},
+	})
+	collectionErrors := utilities.GetCollectionErrors()
+	collectionError := collectionErrors[len(collectionErrors)-1]
+	assert.Equal(t, "sub-1", collectionError.Account)
+	assert.Equal(t, "rg-1", collectionError.Region)
+	assert.Equal(t, "compute.VirtualMachinesClient.List", collectionError.API)
+	assert.Equal(t, "AuthorizationFailed", collectionError.Code)
+}
+
+func TestGetRequestService(t *testing.T) {
+	for url, service := range map[string]string{
+		"https://management.azure.com/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines":                                             "Microsoft.Compute",
+		"https://management.azure.com/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa/providers/Microsoft.Insights/diagnostics": "Microsoft.Insights",
+		"https://management.azure.com/subscriptions/sub-1/resourcegroups":                                                                                            "management.azure.com",
+		"https://graph.windows.net/tenant-1/users?api-version=1.6":                                                                                                   "graph",
+		"https://vault-1.vault.azure.net/keys":                                                                                                                       "vault",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		assert.Equal(t, service, getRequestService(req))
+	}
+}
+
+func TestConfigureClient(t *testing.T) {
+	requests := make([]string, 0)
+	diskCalls := 0
+	client := autorest.NewClientWithUserAgent("test")
+	client.Sender = autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		body := `{"registrationState": "Registered"}`
+		status := http.StatusOK
+		if strings.HasSuffix(req.URL.Path, "/disks") {
+			diskCalls++
+			body = `{"value": []}`
+			if diskCalls == 1 {
+				status = http.StatusConflict
+				body = `{"error": {"code": "MissingSubscriptionRegistration", "message": "not registered", "details": [{"target": "Microsoft.Compute"}]}}`
+			}
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	ConfigureClient(&client, autorest.NullAuthorizer{})
+
+	// The missing resource provider is registered before the request is sent again
+	req, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/sub-1/providers/Microsoft.Compute/disks", nil)
+	resp, err := client.Send(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{
+		"GET /subscriptions/sub-1/providers/Microsoft.Compute/disks",
+		"POST /subscriptions/sub-1/providers/Microsoft.Compute/register",
+		"GET /subscriptions/sub-1/providers/Microsoft.Compute",
+		"GET /subscriptions/sub-1/providers/Microsoft.Compute/disks",
+	}, requests)
+}
+
+func TestGetAuthSessionCredentials(t *testing.T) {
+	t.Setenv("AZURE_AUTH_LOCATION", "/default/auth.json")
+	credentials := utilities.ExtensionConfigurationAzureCredentials{ClientID: "client-1", ClientSecret: "secret"}
+	assert.Equal(t, utilities.AzureAuthClientSecret, credentials.GetAuthType())
+
+	session, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub-1", TenantID: "tenant-1", ExtensionConfigurationAzureCredentials: credentials})
+	assert.Nil(t, err)
+	assert.Equal(t, "sub-1", session.SubscriptionId)
+	assert.Equal(t, "tenant-1", session.TenantId)
+
+	// Subscriptions with the same credentials share their authorizers, without changing the environment
+	other, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub-2", TenantID: "tenant-1", ExtensionConfigurationAzureCredentials: credentials})
+	assert.Nil(t, err)
+	assert.True(t, session.Authorizer == other.Authorizer)
+	assert.Equal(t, "/default/auth.json", os.Getenv("AZURE_AUTH_LOCATION"))
+
+	credentials.ClientSecret = "other-secret"
+	other, err = GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub-1", TenantID: "tenant-1", ExtensionConfigurationAzureCredentials: credentials})
+	assert.Nil(t, err)
+	assert.False(t, session.Authorizer == other.Authorizer)
+
+	managedIdentity := &utilities.ExtensionConfigurationAzureAccount{SubscriptionID: "sub-1", TenantID: "tenant-1"}
+	managedIdentity.AuthType = utilities.AzureAuthManagedIdentity
+	assert.Equal(t, "managed_identity", getCredentialSource(managedIdentity))
+
+	managedIdentity.AuthType = "password"
+	_, err = GetAuthSession(managedIdentity)
+	assert.NotNil(t, err)
+}
+
+func TestFederatedTokenSecret(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Nil(t, ioutil.WriteFile(tokenFile, []byte("federated-token\n"), 0600))
+
+	values := url.Values{}
+	secret := &federatedTokenSecret{tokenFile: tokenFile}
+	assert.Nil(t, secret.SetAuthenticationValues(nil, &values))
+	assert.Equal(t, "federated-token", values.Get("client_assertion"))
+	assert.Equal(t, "urn:ietf:params:oauth:client-assertion-type:jwt-bearer", values.Get("client_assertion_type"))
+
+	_, err := GetAuthSession(&utilities.ExtensionConfigurationAzureAccount{
+		SubscriptionID: "sub-1",
+		TenantID:       "tenant-1",
+		ExtensionConfigurationAzureCredentials: utilities.ExtensionConfigurationAzureCredentials{
+			ClientID:           "client-1",
+			FederatedTokenFile: tokenFile,
+		},
+	})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,284 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	cacheHitColumn = "cache_hit"
+	cacheAgeColumn = "cache_age"
+)
+
+// cacheEntry holds the rows generated for one set of accounts/regions of a table
+type cacheEntry struct {
+	// mutex is held while the rows are generated in the foreground,
+	// so that concurrent queries wait for the result instead of generating it again
+	mutex      sync.Mutex
+	rows       []map[string]string
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// tableCache caches the results of a table across queries
+type tableCache struct {
+	tableName string
+	generate  table.GenerateFunc
+	now       func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+var (
+	tableCachesMutex sync.Mutex
+	// tableCaches holds the caches of registered tables, so that they can be invalidated on configuration reload
+	tableCaches []*tableCache
+)
+
+func newTableCache(tableName string, generate table.GenerateFunc) *tableCache {
+	return &tableCache{
+		tableName: tableName,
+		generate:  generate,
+		now:       time.Now,
+		entries:   make(map[string]*cacheEntry),
+	}
+}
+
+// newCachedTable returns the definition of given table, with the results cached
+// as configured in the table configuration. Cache status is exposed via hidden columns.
+func newCachedTable(tableName string, columns []table.ColumnDefinition, generate table.GenerateFunc) TableDefinition {
+	cache := newTableCache(tableName, generate)
+	tableCachesMutex.Lock()
+	tableCaches = append(tableCaches, cache)
+	tableCachesMutex.Unlock()
+	cachedColumns := make([]table.ColumnDefinition, 0, len(columns)+2)
+	cachedColumns = append(cachedColumns, columns...)
+	cachedColumns = append(cachedColumns,
+		table.IntegerColumn(cacheHitColumn, table.HIDDEN),
+		table.BigIntColumn(cacheAgeColumn, table.HIDDEN),
+	)
+	return TableDefinition{
+		Name:     tableName,
+		Columns:  cachedColumns,
+		Generate: cache.Generate,
+	}
+}
+
+// invalidateTableCaches drops the cached rows of all tables, they may belong to accounts no longer configured
+func invalidateTableCaches() {
+	tableCachesMutex.Lock()
+	defer tableCachesMutex.Unlock()
+	for _, cache := range tableCaches {
+		cache.clear()
+	}
+}
+
+// clear drops all entries. Refreshes in progress update entries which are no longer reachable.
+func (c *tableCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+}
+
+func (c *tableCache) getConfig() utilities.CacheConfig {
+	tableConfig, ok := utilities.GetTableConfig(c.tableName)
+	if !ok {
+		return utilities.CacheConfig{}
+	}
+	return tableConfig.Cache
+}
+
+// Generate returns the cached rows if they are fresh enough, otherwise generates them.
+// Rows older than TTL are still returned for up to MaxStale seconds, while they are refreshed in the background.
+// Rows are not cached if collection errors were reported for the table while they were generated.
+func (c *tableCache) Generate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	config := c.getConfig()
+	if config.TTL <= 0 {
+		rows, err := c.generate(osqCtx, queryContext)
+		for _, row := range rows {
+			row[cacheHitColumn] = "0"
+			row[cacheAgeColumn] = "0"
+		}
+		return rows, err
+	}
+	ttl := time.Duration(config.TTL) * time.Second
+	maxStale := time.Duration(config.MaxStale) * time.Second
+
+	key := getCacheKey(c.tableName, queryContext)
+	if key != "" {
+		// Rows of all accounts and regions are a superset of the rows for any of them,
+		// osquery applies the constraints to the returned rows anyway
+		if rows, age, ok := c.getFresh("", ttl); ok {
+			return withCacheStatus(rows, true, age), nil
+		}
+	}
+
+	entry := c.getEntry(key)
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	if !entry.fetchedAt.IsZero() {
+		age := c.now().Sub(entry.fetchedAt)
+		if age < ttl {
+			return withCacheStatus(entry.rows, true, age), nil
+		}
+		if age < ttl+maxStale {
+			if !entry.refreshing {
+				entry.refreshing = true
+				go c.refresh(entry, queryContext)
+			}
+			return withCacheStatus(entry.rows, true, age), nil
+		}
+	}
+
+	// Cached rows serve queries using any of the columns
+	errorCount := utilities.GetCollectionErrorCount(c.tableName)
+	rows, err := c.generate(utilities.WithColumnsUsed(osqCtx, nil), queryContext)
+	if err != nil || osqCtx.Err() != nil || utilities.GetCollectionErrorCount(c.tableName) != errorCount {
+		// Rows of a cancelled generation, or of a generation which reported collection errors, are incomplete
+		return withCacheStatus(rows, false, 0), err
+	}
+	entry.rows = rows
+	entry.fetchedAt = c.now()
+	return withCacheStatus(rows, false, 0), nil
+}
+
+func (c *tableCache) getEntry(key string) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	return entry
+}
+
+func (c *tableCache) getFresh(key string, ttl time.Duration) ([]map[string]string, time.Duration, bool) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	c.mutex.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	if entry.fetchedAt.IsZero() {
+		return nil, 0, false
+	}
+	age := c.now().Sub(entry.fetchedAt)
+	if age >= ttl {
+		return nil, 0, false
+	}
+	return entry.rows, age, true
+}
+
+// refresh generates the rows of given entry in the background.
+// The query which triggered the refresh may finish before it, so it is not bound to its context,
+// only to the timeout of the table.
+func (c *tableCache) refresh(entry *cacheEntry, queryContext table.QueryContext) {
+	ctx, cancel := newTableContext(context.Background(), c.tableName)
+	defer cancel()
+	errorCount := utilities.GetCollectionErrorCount(c.tableName)
+	rows, err := c.generate(ctx, queryContext)
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	entry.refreshing = false
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err == nil && utilities.GetCollectionErrorCount(c.tableName) != errorCount {
+		err = fmt.Errorf("collection errors were reported")
+	}
+	if err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": c.tableName,
+			"errString": err.Error(),
+		}).Error("failed to refresh cached rows")
+		return
+	}
+	entry.rows = rows
+	entry.fetchedAt = c.now()
+}
+
+// getCacheKey returns the key identifying the accounts/regions (or projects/zones,
+// subscriptions/resource groups) selected by the query.
+// Only the constraints used for pruning contribute, other constraints do not change the generated rows.
+func getCacheKey(tableName string, queryContext table.QueryContext) string {
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok || len(queryContext.Constraints) == 0 {
+		return ""
+	}
+	columns := []string{
+		tableConfig.Aws.AccountIDAttribute,
+		tableConfig.Aws.RegionCodeAttribute,
+		tableConfig.Aws.RegionAttribute,
+		tableConfig.Gcp.ProjectIDAttribute,
+		tableConfig.Gcp.ZoneAttribute,
+		tableConfig.Azure.SubscriptionIDAttribute,
+		tableConfig.Azure.ResourceGroupAttribute,
+	}
+	parts := make([]string, 0)
+	for _, column := range columns {
+		if column == "" {
+			continue
+		}
+		constraintList, ok := queryContext.Constraints[column]
+		if !ok {
+			continue
+		}
+		expressions := make([]string, 0)
+		for _, constraint := range constraintList.Constraints {
+			switch constraint.Operator {
+			case table.OperatorEquals, table.OperatorLike, table.OperatorGlob:
+				expressions = append(expressions, fmt.Sprintf("%d:%s", constraint.Operator, constraint.Expression))
+			}
+		}
+		if len(expressions) == 0 {
+			continue
+		}
+		sort.Strings(expressions)
+		parts = append(parts, column+"="+strings.Join(expressions, ","))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// withCacheStatus returns copies of given rows with the cache columns set.
+// Cached rows are shared between queries, so they are never modified.
+func withCacheStatus(rows []map[string]string, hit bool, age time.Duration) []map[string]string {
+	hitValue := "0"
+	if hit {
+		hitValue = "1"
+	}
+	ageValue := strconv.FormatInt(int64(age/time.Second), 10)
+	resultMap := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		result := make(map[string]string, len(row)+2)
+		for key, value := range row {
+			result[key] = value
+		}
+		result[cacheHitColumn] = hitValue
+		result[cacheAgeColumn] = ageValue
+		resultMap = append(resultMap, result)
+	}
+	return resultMap
+}
@@ -0,0 +1,185 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+var cacheTableConfigJSON = `
+{
+	"test_cached_table": {
+		"aws": {
+			"regionCodeAttribute": "region_code",
+			"accountIdAttribute": "account_id"
+		},
+		"gcp": {},
+		"azure": {},
+		"cache": {
+			"ttl": 60,
+			"maxStale": 60
+		},
+		"parsedAttributes": []
+	},
+	"test_uncached_table": {
+		"aws": {},
+		"gcp": {},
+		"azure": {},
+		"parsedAttributes": []
+	}
+}`
+
+func TestMain(m *testing.M) {
+	utilities.CreateLogger(true, 20, 1, 30)
+	os.Exit(m.Run())
+}
+
+// newTestCache returns a cache with a fake clock, and the function advancing the clock
+func newTestCache(tableName string, calls *int32) (*tableCache, func(time.Duration)) {
+	now := time.Unix(1000, 0)
+	cache := newTableCache(tableName, func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		call := atomic.AddInt32(calls, 1)
+		return []map[string]string{{"call": fmt.Sprintf("%d", call)}}, nil
+	})
+	var mutex sync.Mutex
+	cache.now = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+	advance := func(duration time.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		now = now.Add(duration)
+	}
+	return cache, advance
+}
+
+func TestTableCache(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(cacheTableConfigJSON))
+	assert.Nil(t, err)
+
+	var calls int32
+	cache, advance := newTestCache("test_cached_table", &calls)
+
+	rows, err := cache.Generate(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", rows[0]["call"])
+	assert.Equal(t, "0", rows[0][cacheHitColumn])
+
+	advance(30 * time.Second)
+	rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "1", rows[0]["call"])
+	assert.Equal(t, "1", rows[0][cacheHitColumn])
+	assert.Equal(t, "30", rows[0][cacheAgeColumn])
+
+	// Queries for a subset of accounts are served from the rows of all accounts
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"account_id": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "test-account"},
+				},
+			},
+		},
+	}
+	rows, _ = cache.Generate(context.Background(), queryContext)
+	assert.Equal(t, "1", rows[0]["call"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Stale rows are returned while they are refreshed in the background
+	advance(60 * time.Second)
+	rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "1", rows[0]["call"])
+	assert.Equal(t, "90", rows[0][cacheAgeColumn])
+	assert.Eventually(t, func() bool {
+		rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+		return rows[0]["call"] == "2"
+	}, time.Second, time.Millisecond)
+
+	// Rows past maxStale are generated again
+	advance(200 * time.Second)
+	rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "3", rows[0]["call"])
+	assert.Equal(t, "0", rows[0][cacheHitColumn])
+}
+
+func TestTableCacheCollectionErrors(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(cacheTableConfigJSON))
+	assert.Nil(t, err)
+
+	var calls int32
+	cache, _ := newTestCache("test_cached_table", &calls)
+	generate := cache.generate
+	cache.generate = func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		rows, err := generate(ctx, queryContext)
+		if atomic.LoadInt32(&calls) == 1 {
+			utilities.ReportCollectionError(utilities.CollectionError{Table: "test_cached_table", Message: "failed"})
+		}
+		return rows, err
+	}
+
+	// Rows of a generation which reported collection errors are incomplete, they are not cached
+	rows, err := cache.Generate(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", rows[0]["call"])
+	rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "2", rows[0]["call"])
+	assert.Equal(t, "0", rows[0][cacheHitColumn])
+	rows, _ = cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "2", rows[0]["call"])
+	assert.Equal(t, "1", rows[0][cacheHitColumn])
+}
+
+func TestTableCacheDisabled(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(cacheTableConfigJSON))
+	assert.Nil(t, err)
+
+	var calls int32
+	cache, _ := newTestCache("test_uncached_table", &calls)
+	cache.Generate(context.Background(), table.QueryContext{})
+	rows, _ := cache.Generate(context.Background(), table.QueryContext{})
+	assert.Equal(t, "2", rows[0]["call"])
+	assert.Equal(t, "0", rows[0][cacheHitColumn])
+}
+
+func TestGetCacheKey(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(cacheTableConfigJSON))
+	assert.Nil(t, err)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"region_code": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "us-west-2"},
+					{Operator: table.OperatorEquals, Expression: "us-east-1"},
+				},
+			},
+			"name": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "ignored"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "region_code=2:us-east-1,2:us-west-2", getCacheKey("test_cached_table", queryContext))
+	assert.Equal(t, "", getCacheKey("test_cached_table", table.QueryContext{}))
+	assert.Equal(t, "", getCacheKey("unknown_table", queryContext))
+}
@@ -0,0 +1,91 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// registerChildTables adds the definitions of the child tables, whose rows are the elements of a JSON column of their parent.
+// The parent of a child table must be a table registered with registerTable.
+func registerChildTables() {
+	configs := utilities.GetTableConfigurations()
+	tableNames := make([]string, 0, len(configs))
+	for tableName, tableConfig := range configs {
+		if tableConfig.Parent != nil {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		parentName := configs[tableName].Parent.Table
+		found := false
+		for _, definition := range tableDefinitions {
+			if definition.Name == parentName {
+				registerTable(tableName, newChildGenerate(tableName, definition.Generate))
+				found = true
+				break
+			}
+		}
+		if !found {
+			utilities.GetLogger().WithFields(log.Fields{
+				"tableName":  tableName,
+				"parentName": parentName,
+			}).Error("failed to find parent table, skipping table")
+			unconfiguredTables = append(unconfiguredTables, tableName)
+		}
+	}
+}
+
+// newChildGenerate returns the generate function of a child table, generating the rows of its parent with parentGenerate.
+// The constraints on the key columns are applied to the parent, the filters of the parent select its rows.
+func newChildGenerate(tableName string, parentGenerate table.GenerateFunc) table.GenerateFunc {
+	return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		resultMap := make([]map[string]string, 0)
+		tableConfig, ok := utilities.GetTableConfig(tableName)
+		if !ok || tableConfig.Parent == nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"tableName": tableName,
+			}).Error("failed to get table configuration")
+			return resultMap, fmt.Errorf("table configuration not found")
+		}
+		parent := tableConfig.Parent
+
+		parentContext := table.QueryContext{Constraints: make(map[string]table.ConstraintList)}
+		for _, column := range parent.KeyColumns {
+			if constraintList, found := queryContext.Constraints[column]; found {
+				parentContext.Constraints[column] = constraintList
+			}
+		}
+		columnsUsed := append([]string{parent.Column}, parent.KeyColumns...)
+		parentRows, err := parentGenerate(utilities.WithColumnsUsed(ctx, columnsUsed), parentContext)
+		for _, parentRow := range parentRows {
+			value := parentRow[parent.Column]
+			if value == "" || value == "null" {
+				continue
+			}
+			tab := utilities.NewTable(ctx, []byte(value), tableConfig)
+			for _, row := range tab.Rows {
+				result := make(map[string]string)
+				for _, column := range parent.KeyColumns {
+					result[column] = parentRow[column]
+				}
+				resultMap = append(resultMap, utilities.RowToMap(result, row, tableConfig))
+			}
+		}
+		return resultMap, err
+	}
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+var childTableConfigJSON = `
+{
+	"test_parent_table": {
+		"aws": {
+			"accountIdAttribute": "account_id"
+		},
+		"gcp": {},
+		"azure": {},
+		"parsedAttributes": [
+			{"sourceName": "Id", "targetName": "id", "targetType": "TEXT", "enabled": true},
+			{"sourceName": "Items", "targetName": "items", "targetType": "TEXT", "enabled": true, "flatten": "child"}
+		]
+	},
+	"test_parent_table_item": {
+		"aws": {},
+		"gcp": {},
+		"azure": {},
+		"parent": {"table": "test_parent_table", "column": "items", "keyColumns": ["account_id", "id"]},
+		"parsedAttributes": [
+			{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true},
+			{"sourceName": "Size", "targetName": "size", "targetType": "BIGINT", "enabled": true}
+		]
+	}
+}`
+
+func TestChildTable(t *testing.T) {
+	assert.Nil(t, utilities.ReadTableConfig([]byte(childTableConfigJSON)))
+
+	columns := utilities.GetTableColumns("test_parent_table")
+	assert.Equal(t, table.ColumnOptions(table.HIDDEN), columns[2].Op)
+	assert.Equal(t, []table.ColumnDefinition{
+		table.TextColumn("account_id"),
+		table.TextColumn("id"),
+		table.TextColumn("name"),
+		table.BigIntColumn("size"),
+	}, utilities.GetTableColumns("test_parent_table_item"))
+
+	var parentContext table.QueryContext
+	var columnsUsed []string
+	generate := newChildGenerate("test_parent_table_item", func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		parentContext = queryContext
+		columnsUsed, _ = utilities.GetColumnsUsed(ctx)
+		return []map[string]string{
+			{"account_id": "111", "id": "a", "items": `[{"Name": "first", "Size": 1}, {"Name": "second", "Size": 2}]`},
+			{"account_id": "111", "id": "b", "items": "null"},
+			{"account_id": "222", "id": "c", "items": `[{"Name": "third", "Size": 3}]`},
+		}, nil
+	})
+
+	queryContext := table.QueryContext{Constraints: map[string]table.ConstraintList{
+		"account_id": {Affinity: table.ColumnTypeText, Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "111"}}},
+		"name":       {Affinity: table.ColumnTypeText, Constraints: []table.Constraint{{Operator: table.OperatorEquals, Expression: "first"}}},
+	}}
+	rows, err := generate(context.Background(), queryContext)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{"account_id": "111", "id": "a", "name": "first", "size": "1"},
+		{"account_id": "111", "id": "a", "name": "second", "size": "2"},
+		{"account_id": "222", "id": "c", "name": "third", "size": "3"},
+	}, rows)
+	// Only the constraints on the key columns apply to the parent
+	assert.Equal(t, 1, len(parentContext.Constraints))
+	assert.Contains(t, parentContext.Constraints, "account_id")
+	assert.Equal(t, []string{"items", "account_id", "id"}, columnsUsed)
+}
@@ -0,0 +1,52 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Uptycs/basequery-go/gen/osquery"
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// columnsUsedPlugin is a table plugin passing the columns used by the query, sent by osquery in the query context,
+// to the generate function. The table plugin of basequery drops them when parsing the query context.
+type columnsUsedPlugin struct {
+	*table.Plugin
+}
+
+func newColumnsUsedPlugin(definition TableDefinition) *columnsUsedPlugin {
+	return &columnsUsedPlugin{Plugin: table.NewPlugin(definition.Name, definition.Columns, definition.Generate)}
+}
+
+func (p *columnsUsedPlugin) Call(ctx context.Context, request osquery.ExtensionPluginRequest) osquery.ExtensionResponse {
+	if request["action"] == "generate" {
+		if columns, ok := parseColumnsUsed(request["context"]); ok {
+			ctx = utilities.WithColumnsUsed(ctx, columns)
+		}
+	}
+	return p.Plugin.Call(ctx, request)
+}
+
+// parseColumnsUsed returns the colsUsed of a query context, false if osquery did not send them
+func parseColumnsUsed(contextJSON string) ([]string, bool) {
+	if contextJSON == "" {
+		return nil, false
+	}
+	var parsed struct {
+		ColsUsed []string `json:"colsUsed"`
+	}
+	if err := json.Unmarshal([]byte(contextJSON), &parsed); err != nil || parsed.ColsUsed == nil {
+		return nil, false
+	}
+	return parsed.ColsUsed, true
+}
@@ -0,0 +1,49 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnsUsedPlugin(t *testing.T) {
+	var columnsUsed []string
+	var known bool
+	plugin := newColumnsUsedPlugin(TableDefinition{
+		Name:    "test_columns_used",
+		Columns: []table.ColumnDefinition{table.TextColumn("name"), table.TextColumn("size")},
+		Generate: func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			columnsUsed, known = utilities.GetColumnsUsed(ctx)
+			return []map[string]string{{"name": "first", "size": "1"}}, nil
+		},
+	})
+
+	response := plugin.Call(context.Background(), map[string]string{
+		"action":  "generate",
+		"context": `{"constraints":[{"name":"size","list":[],"affinity":"TEXT"}],"colsUsed":["name","size"]}`,
+	})
+	assert.Equal(t, int32(0), response.Status.Code)
+	assert.Equal(t, 1, len(response.Response))
+	assert.True(t, known)
+	assert.Equal(t, []string{"name", "size"}, columnsUsed)
+
+	// All of the columns may be used when osquery does not send them
+	response = plugin.Call(context.Background(), map[string]string{
+		"action":  "generate",
+		"context": `{"constraints":[]}`,
+	})
+	assert.Equal(t, int32(0), response.Status.Code)
+	assert.False(t, known)
+}
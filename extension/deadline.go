@@ -0,0 +1,95 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// timeoutErrorCode is the error code of the collection errors reporting a table which timed out
+const timeoutErrorCode = "Timeout"
+
+// shutdownContext holds a contextHolder, the stored values of an atomic.Value must have the same type
+var shutdownContext atomic.Value
+
+type contextHolder struct {
+	ctx context.Context
+}
+
+func init() {
+	shutdownContext.Store(contextHolder{ctx: context.Background()})
+}
+
+// SetShutdownContext sets the context which cancels the generations of tables in progress when it is done
+func SetShutdownContext(ctx context.Context) {
+	shutdownContext.Store(contextHolder{ctx: ctx})
+}
+
+func getTableTimeout(tableName string) time.Duration {
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return 0
+	}
+	return time.Duration(tableConfig.Timeout) * time.Second
+}
+
+// newTableContext returns the context of a generation of given table.
+// It is done once the timeout of the table expires, or on shutdown.
+func newTableContext(ctx context.Context, tableName string) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if timeout := getTableTimeout(tableName); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	shutdownCtx := shutdownContext.Load().(contextHolder).ctx
+	go func() {
+		select {
+		case <-shutdownCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// withDeadline bounds the generation of a table by its timeout, and cancels it on shutdown.
+// The rows collected before the timeout are returned, and the timeout is reported as a collection error.
+func withDeadline(tableName string, generate table.GenerateFunc) table.GenerateFunc {
+	return func(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		ctx, cancel := newTableContext(osqCtx, tableName)
+		defer cancel()
+		rows, err := generate(ctx, queryContext)
+		if ctx.Err() != context.DeadlineExceeded || osqCtx.Err() != nil {
+			return rows, err
+		}
+		message := fmt.Sprintf("table timed out after %s, partial results returned", getTableTimeout(tableName))
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": tableName,
+			"rows":      len(rows),
+		}).Warn(message)
+		utilities.ReportCollectionError(utilities.CollectionError{
+			Table:   tableName,
+			Code:    timeoutErrorCode,
+			Message: message,
+		})
+		if rows == nil {
+			rows = make([]map[string]string, 0)
+		}
+		return rows, nil
+	}
+}
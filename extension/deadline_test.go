@@ -0,0 +1,58 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+var deadlineTableConfigJSON = `
+{
+	"test_deadline_table": {
+		"aws": {},
+		"gcp": {},
+		"azure": {},
+		"timeout": 1,
+		"parsedAttributes": []
+	}
+}`
+
+// generateUntilDone returns a row, and another one once ctx is done, as a table collecting its accounts would
+func generateUntilDone(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	rows := []map[string]string{{"name": "collected"}}
+	<-ctx.Done()
+	return rows, ctx.Err()
+}
+
+func TestWithDeadline(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(deadlineTableConfigJSON))
+	assert.Nil(t, err)
+
+	rows, err := withDeadline("test_deadline_table", generateUntilDone)(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "collected"}}, rows)
+	collectionErrors := utilities.GetCollectionErrors()
+	lastError := collectionErrors[len(collectionErrors)-1]
+	assert.Equal(t, "test_deadline_table", lastError.Table)
+	assert.Equal(t, timeoutErrorCode, lastError.Code)
+
+	// Generations in progress are cancelled on shutdown
+	shutdownCtx, shutdown := context.WithCancel(context.Background())
+	SetShutdownContext(shutdownCtx)
+	defer SetShutdownContext(context.Background())
+	shutdown()
+	_, err = withDeadline("test_deadline_table", generateUntilDone)(context.Background(), table.QueryContext{})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,80 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	extaws "github.com/Uptycs/cloudquery/extension/aws"
+	"github.com/Uptycs/cloudquery/extension/azure"
+	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// discoveryTrigger requests the accounts to be discovered again before the next refresh
+var discoveryTrigger = make(chan struct{}, 1)
+
+// triggerAccountDiscovery requests the accounts to be discovered again, once the configuration changed
+func triggerAccountDiscovery() {
+	select {
+	case discoveryTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// DiscoverAccounts discovers the accounts of the configured AWS organization, GCP organizations and folders, and Azure tenants, which are processed along with the configured accounts.
+// The cached rows are dropped when the accounts change, they would miss the new accounts.
+func DiscoverAccounts(ctx context.Context) {
+	awsAccounts := utilities.GetAwsAccounts()
+	gcpAccounts := utilities.GetGcpAccounts()
+	azureAccounts := utilities.GetAzureAccounts()
+	extaws.RefreshOrganizationAccounts(ctx)
+	extgcp.RefreshGcpProjects(ctx)
+	azure.RefreshTenantSubscriptions(ctx)
+	if !reflect.DeepEqual(awsAccounts, utilities.GetAwsAccounts()) || !reflect.DeepEqual(gcpAccounts, utilities.GetGcpAccounts()) ||
+		!reflect.DeepEqual(azureAccounts, utilities.GetAzureAccounts()) {
+		invalidateTableCaches()
+	}
+}
+
+// getDiscoveryInterval returns the shortest interval at which the accounts of the providers are discovered again
+func getDiscoveryInterval() time.Duration {
+	extConfig := utilities.GetExtConfiguration()
+	interval := extaws.GetOrganizationRefreshInterval(extConfig.ExtConfAws.Organization)
+	if discoveryInterval := extgcp.GetDiscoveryRefreshInterval(extConfig.ExtConfGcp.Discovery); discoveryInterval < interval {
+		interval = discoveryInterval
+	}
+	if tenantInterval := azure.GetTenantRefreshInterval(extConfig.ExtConfAzure.Tenants); tenantInterval < interval {
+		interval = tenantInterval
+	}
+	return interval
+}
+
+// WatchAccounts discovers the accounts of the configured organizations, and discovers them again periodically,
+// and when the configuration is reloaded. The caller adds the watcher to wg, it is done once ctx is done.
+func WatchAccounts(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for {
+		DiscoverAccounts(ctx)
+		timer := time.NewTimer(getDiscoveryInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-discoveryTrigger:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+const collectionErrorsTableName = "cloudquery_errors"
+
+func getCollectionErrorsColumns() []table.ColumnDefinition {
+	return []table.ColumnDefinition{
+		table.BigIntColumn("time"),
+		table.TextColumn("table_name"),
+		table.TextColumn("provider"),
+		table.TextColumn("account"),
+		table.TextColumn("region"),
+		table.TextColumn("api"),
+		table.TextColumn("error_code"),
+		table.TextColumn("message"),
+	}
+}
+
+// generateCollectionErrors returns the most recent failures to collect the rows of the tables, oldest first
+func generateCollectionErrors(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+	collectionErrors := utilities.GetCollectionErrors()
+	resultMap := make([]map[string]string, 0, len(collectionErrors))
+	for _, collectionError := range collectionErrors {
+		resultMap = append(resultMap, map[string]string{
+			"time":       strconv.FormatInt(collectionError.Time.Unix(), 10),
+			"table_name": collectionError.Table,
+			"provider":   collectionError.Provider,
+			"account":    collectionError.Account,
+			"region":     collectionError.Region,
+			"api":        collectionError.API,
+			"error_code": collectionError.Code,
+			"message":    collectionError.Message,
+		})
+	}
+	return resultMap, nil
+}
@@ -0,0 +1,45 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCollectionErrors(t *testing.T) {
+	utilities.ReportCollectionError(utilities.CollectionError{
+		Time:    time.Unix(1600000000, 0),
+		Table:   "aws_ec2_instance",
+		Account: "123456789012",
+		Region:  "us-east-1",
+		API:     "EC2.DescribeInstances",
+		Code:    "UnauthorizedOperation",
+		Message: "not authorized",
+	})
+	rows, err := generateCollectionErrors(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"time":       "1600000000",
+		"table_name": "aws_ec2_instance",
+		"provider":   "aws",
+		"account":    "123456789012",
+		"region":     "us-east-1",
+		"api":        "EC2.DescribeInstances",
+		"error_code": "UnauthorizedOperation",
+		"message":    "not authorized",
+	}, rows[len(rows)-1])
+	assert.Equal(t, len(getCollectionErrorsColumns()), len(rows[len(rows)-1]))
+}
@@ -0,0 +1,228 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// Output formats of ExportTables
+const (
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatCSV     = "csv"
+	ExportFormatParquet = "parquet"
+)
+
+const (
+	defaultExportConcurrency = 4
+	exportManifestName       = "manifest.json"
+)
+
+// ExportOptions holds the options of ExportTables
+type ExportOptions struct {
+	// Destination is a local directory, or an s3://bucket/prefix, gs://bucket/prefix
+	// or azblob://account/container/prefix URL
+	Destination string
+	Format      string
+	// Tables to export, all the registered tables if empty
+	Tables      []string
+	Concurrency int
+}
+
+// ExportTableResult is the manifest entry of an exported table
+type ExportTableResult struct {
+	Table    string  `json:"table"`
+	File     string  `json:"file,omitempty"`
+	Rows     int     `json:"rows"`
+	Duration float64 `json:"durationSeconds"`
+	Error    string  `json:"error,omitempty"`
+	// CollectionErrorCount is the number of accounts, regions or resources which failed during the export of the table,
+	// the most recent of them are listed in CollectionErrors
+	CollectionErrorCount uint64                  `json:"collectionErrorCount,omitempty"`
+	CollectionErrors     []ExportCollectionError `json:"collectionErrors,omitempty"`
+}
+
+// ExportCollectionError is a collection error reported during the export of a table, as listed by cloudquery_errors
+type ExportCollectionError struct {
+	Time      int64  `json:"time"`
+	Account   string `json:"account,omitempty"`
+	Region    string `json:"region,omitempty"`
+	API       string `json:"api,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ExportManifest describes the files written by ExportTables
+type ExportManifest struct {
+	StartedAt  time.Time           `json:"startedAt"`
+	FinishedAt time.Time           `json:"finishedAt"`
+	Format     string              `json:"format"`
+	Tables     []ExportTableResult `json:"tables"`
+}
+
+// ErrorCount returns the number of tables which failed to export
+func (manifest *ExportManifest) ErrorCount() int {
+	count := 0
+	for _, result := range manifest.Tables {
+		if result.Error != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// ExportTables runs the generate function of the registered tables without osquery, writes
+// one file per table in given format, and a manifest with the row counts, durations and errors.
+// A table which fails is recorded in the manifest, and does not stop the other tables.
+func ExportTables(ctx context.Context, options ExportOptions) (*ExportManifest, error) {
+	switch options.Format {
+	case ExportFormatNDJSON, ExportFormatCSV, ExportFormatParquet:
+	default:
+		return nil, fmt.Errorf("unknown format %s, expected %s, %s or %s", options.Format, ExportFormatNDJSON, ExportFormatCSV, ExportFormatParquet)
+	}
+
+	definitions := GetTableDefinitions()
+	if len(options.Tables) > 0 {
+		definitions = make([]TableDefinition, 0, len(options.Tables))
+		for _, tableName := range options.Tables {
+			definition, found := GetTableDefinition(tableName)
+			if !found {
+				return nil, fmt.Errorf("unknown table %s, or the table is not configured", tableName)
+			}
+			definitions = append(definitions, definition)
+		}
+	}
+
+	sink, err := newExportSink(ctx, options.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultExportConcurrency
+	}
+
+	manifest := &ExportManifest{
+		StartedAt: time.Now().UTC(),
+		Format:    options.Format,
+		Tables:    make([]ExportTableResult, len(definitions)),
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for idx, definition := range definitions {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(idx int, definition TableDefinition) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			startTime := time.Now()
+			result := exportTable(ctx, sink, definition, options.Format)
+			result.Duration = time.Since(startTime).Seconds()
+			manifest.Tables[idx] = result
+		}(idx, definition)
+	}
+	wg.Wait()
+	manifest.FinishedAt = time.Now().UTC()
+
+	sort.Slice(manifest.Tables, func(i, j int) bool {
+		return manifest.Tables[i].Table < manifest.Tables[j].Table
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := sink.put(ctx, exportManifestName, "application/json", data); err != nil {
+		return manifest, fmt.Errorf("failed to write %s: %w", sink.location(exportManifestName), err)
+	}
+	return manifest, nil
+}
+
+func exportTable(ctx context.Context, sink exportSink, definition TableDefinition, format string) ExportTableResult {
+	result := ExportTableResult{Table: definition.Name}
+	utilities.GetLogger().WithFields(log.Fields{
+		"tableName": definition.Name,
+	}).Info("exporting table")
+
+	errorCount := utilities.GetCollectionErrorCount(definition.Name)
+	rows, err := definition.Generate(ctx, table.QueryContext{Constraints: make(map[string]table.ConstraintList)})
+	result.CollectionErrorCount = utilities.GetCollectionErrorCount(definition.Name) - errorCount
+	for _, collectionError := range utilities.GetLastCollectionErrors(definition.Name, result.CollectionErrorCount) {
+		result.CollectionErrors = append(result.CollectionErrors, ExportCollectionError{
+			Time:      collectionError.Time.Unix(),
+			Account:   collectionError.Account,
+			Region:    collectionError.Region,
+			API:       collectionError.API,
+			ErrorCode: collectionError.Code,
+			Message:   collectionError.Message,
+		})
+	}
+	if err != nil {
+		return exportTableFailed(result, fmt.Errorf("failed to generate table: %w", err))
+	}
+	columns := make([]table.ColumnDefinition, 0, len(definition.Columns))
+	for _, column := range definition.Columns {
+		if column.Op&table.HIDDEN == 0 {
+			columns = append(columns, column)
+		}
+	}
+
+	var buffer bytes.Buffer
+	var contentType string
+	switch format {
+	case ExportFormatCSV:
+		contentType = "text/csv"
+		err = writeCSVRows(&buffer, getColumnNames(columns), rows)
+	case ExportFormatParquet:
+		contentType = "application/vnd.apache.parquet"
+		err = writeParquetRows(&buffer, columns, rows)
+	default:
+		contentType = "application/x-ndjson"
+		err = writeNDJSONRows(&buffer, getColumnNames(columns), rows)
+	}
+	if err != nil {
+		return exportTableFailed(result, fmt.Errorf("failed to write %s: %w", format, err))
+	}
+
+	fileName := definition.Name + "." + format
+	if err := sink.put(ctx, fileName, contentType, buffer.Bytes()); err != nil {
+		return exportTableFailed(result, fmt.Errorf("failed to write %s: %w", sink.location(fileName), err))
+	}
+	result.File = fileName
+	result.Rows = len(rows)
+	return result
+}
+
+func exportTableFailed(result ExportTableResult, err error) ExportTableResult {
+	utilities.GetLogger().WithFields(log.Fields{
+		"tableName": result.Table,
+		"errString": err.Error(),
+	}).Error("failed to export table")
+	result.Error = err.Error()
+	return result
+}
+
+func getColumnNames(columns []table.ColumnDefinition) []string {
+	names := make([]string, len(columns))
+	for idx, column := range columns {
+		names[idx] = column.Name
+	}
+	return names
+}
@@ -0,0 +1,187 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	azureStorageKeyEnv      = "AZURE_STORAGE_KEY"
+	azureStorageSasTokenEnv = "AZURE_STORAGE_SAS_TOKEN"
+)
+
+// exportSink stores the files of an export
+type exportSink interface {
+	// put stores a file with given name and content
+	put(ctx context.Context, name string, contentType string, data []byte) error
+	// location returns where a file with given name is stored
+	location(name string) string
+}
+
+// newExportSink returns the sink for given destination: a local directory, or an
+// s3://bucket/prefix, gs://bucket/prefix or azblob://account/container/prefix URL
+func newExportSink(ctx context.Context, destination string) (exportSink, error) {
+	destinationURL, err := url.Parse(destination)
+	if err != nil || destinationURL.Scheme == "" || destinationURL.Scheme == "file" {
+		dir := destination
+		if err == nil && destinationURL.Scheme == "file" {
+			dir = destinationURL.Path
+		}
+		return &localSink{dir: dir}, nil
+	}
+	prefix := strings.Trim(destinationURL.Path, "/")
+	switch destinationURL.Scheme {
+	case "s3":
+		return newS3Sink(ctx, destinationURL.Host, prefix)
+	case "gs":
+		return newGcsSink(ctx, destinationURL.Host, prefix)
+	case "azblob":
+		return newAzureBlobSink(destinationURL.Host, prefix)
+	}
+	return nil, fmt.Errorf("unsupported export destination %s, expected a directory, s3://, gs:// or azblob:// URL", destination)
+}
+
+type localSink struct {
+	dir string
+}
+
+func (sink *localSink) put(ctx context.Context, name string, contentType string, data []byte) error {
+	if err := os.MkdirAll(sink.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sink.location(name), data, 0644)
+}
+
+func (sink *localSink) location(name string) string {
+	return filepath.Join(sink.dir, name)
+}
+
+type s3Sink struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// newS3Sink uses the default AWS credentials and region, like the AWS CLI
+func newS3Sink(ctx context.Context, bucket string, prefix string) (*s3Sink, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return &s3Sink{client: s3.NewFromConfig(cfg), bucket: bucket, prefix: prefix}, nil
+}
+
+func (sink *s3Sink) put(ctx context.Context, name string, contentType string, data []byte) error {
+	_, err := sink.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(sink.bucket),
+		Key:           aws.String(path.Join(sink.prefix, name)),
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+		ContentType:   aws.String(contentType),
+	})
+	return err
+}
+
+func (sink *s3Sink) location(name string) string {
+	return "s3://" + sink.bucket + "/" + path.Join(sink.prefix, name)
+}
+
+type gcsSink struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+// newGcsSink uses the application default credentials
+func newGcsSink(ctx context.Context, bucket string, prefix string) (*gcsSink, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	return &gcsSink{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+func (sink *gcsSink) put(ctx context.Context, name string, contentType string, data []byte) error {
+	writer := sink.client.Bucket(sink.bucket).Object(path.Join(sink.prefix, name)).NewWriter(ctx)
+	writer.ContentType = contentType
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (sink *gcsSink) location(name string) string {
+	return "gs://" + sink.bucket + "/" + path.Join(sink.prefix, name)
+}
+
+type azureBlobSink struct {
+	containerURL azblob.ContainerURL
+	account      string
+	container    string
+	prefix       string
+}
+
+// newAzureBlobSink authenticates with the account key in AZURE_STORAGE_KEY,
+// or the SAS token in AZURE_STORAGE_SAS_TOKEN
+func newAzureBlobSink(account string, containerPath string) (*azureBlobSink, error) {
+	parts := strings.SplitN(containerPath, "/", 2)
+	if account == "" || parts[0] == "" {
+		return nil, fmt.Errorf("expected azblob://account/container/prefix")
+	}
+	sink := &azureBlobSink{account: account, container: parts[0]}
+	if len(parts) > 1 {
+		sink.prefix = parts[1]
+	}
+
+	containerURL, _ := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, sink.container))
+	var credential azblob.Credential
+	if key := os.Getenv(azureStorageKeyEnv); key != "" {
+		sharedKeyCredential, err := azblob.NewSharedKeyCredential(account, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", azureStorageKeyEnv, err)
+		}
+		credential = sharedKeyCredential
+	} else if sasToken := os.Getenv(azureStorageSasTokenEnv); sasToken != "" {
+		containerURL.RawQuery = strings.TrimPrefix(sasToken, "?")
+		credential = azblob.NewAnonymousCredential()
+	} else {
+		return nil, fmt.Errorf("missing %s or %s for Azure Blob storage", azureStorageKeyEnv, azureStorageSasTokenEnv)
+	}
+	sink.containerURL = azblob.NewContainerURL(*containerURL, azblob.NewPipeline(credential, azblob.PipelineOptions{}))
+	return sink, nil
+}
+
+func (sink *azureBlobSink) put(ctx context.Context, name string, contentType string, data []byte) error {
+	blobURL := sink.containerURL.NewBlockBlobURL(path.Join(sink.prefix, name))
+	_, err := azblob.UploadBufferToBlockBlob(ctx, data, blobURL, azblob.UploadToBlockBlobOptions{
+		BlobHTTPHeaders: azblob.BlobHTTPHeaders{ContentType: contentType},
+	})
+	return err
+}
+
+func (sink *azureBlobSink) location(name string) string {
+	return "azblob://" + sink.account + "/" + sink.container + "/" + path.Join(sink.prefix, name)
+}
@@ -0,0 +1,124 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestExportTables(t *testing.T) {
+	utilities.CreateLogger(false, 0, 0, 0)
+	tablesOnce.Do(func() {})
+	savedDefinitions := tableDefinitions
+	defer func() { tableDefinitions = savedDefinitions }()
+	tableDefinitions = append(tableDefinitions, TableDefinition{
+		Name: "test_export_table",
+		Columns: []table.ColumnDefinition{
+			table.TextColumn("name"),
+			table.BigIntColumn("size"),
+			table.DoubleColumn("ratio"),
+			table.IntegerColumn("cache_hit", table.HIDDEN),
+		},
+		Generate: func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			return []map[string]string{
+				{"name": "first,one", "size": "1", "ratio": "0.5", "cache_hit": "0"},
+				{"name": "second", "size": "", "ratio": "n/a", "cache_hit": "0"},
+			}, nil
+		},
+	}, TableDefinition{
+		Name:    "test_export_partial",
+		Columns: []table.ColumnDefinition{table.TextColumn("name")},
+		Generate: func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			utilities.ReportCollectionError(utilities.CollectionError{Table: "test_export_partial", Account: "111", Region: "us-east-1", Code: "AccessDenied", Message: "access denied"})
+			return []map[string]string{{"name": "first"}}, nil
+		},
+	}, TableDefinition{
+		Name:    "test_export_failure",
+		Columns: []table.ColumnDefinition{table.TextColumn("name")},
+		Generate: func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			return nil, fmt.Errorf("access denied")
+		},
+	})
+
+	outputDir := filepath.Join(t.TempDir(), "export")
+	options := ExportOptions{
+		Destination: outputDir,
+		Format:      ExportFormatNDJSON,
+		Tables:      []string{"test_export_table", "test_export_partial", "test_export_failure"},
+	}
+	manifest, err := ExportTables(context.Background(), options)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, manifest.ErrorCount())
+	assert.Equal(t, "test_export_failure", manifest.Tables[0].Table)
+	assert.Equal(t, "failed to generate table: access denied", manifest.Tables[0].Error)
+	assert.Equal(t, ExportTableResult{Table: "test_export_table", File: "test_export_table.ndjson", Rows: 2, Duration: manifest.Tables[2].Duration}, manifest.Tables[2])
+
+	// The collection errors reported while generating the table are recorded in its entry
+	partial := manifest.Tables[1]
+	assert.Equal(t, 1, partial.Rows)
+	assert.Equal(t, uint64(1), partial.CollectionErrorCount)
+	if assert.Equal(t, 1, len(partial.CollectionErrors)) {
+		assert.Equal(t, ExportCollectionError{Time: partial.CollectionErrors[0].Time, Account: "111", Region: "us-east-1", ErrorCode: "AccessDenied", Message: "access denied"},
+			partial.CollectionErrors[0])
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, "test_export_table.ndjson"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"first,one","ratio":"0.5","size":"1"}`+"\n"+`{"name":"second","ratio":"n/a","size":""}`+"\n", string(data))
+	var written ExportManifest
+	data, err = ioutil.ReadFile(filepath.Join(outputDir, exportManifestName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &written))
+	assert.Equal(t, manifest.Tables, written.Tables)
+
+	options.Format = ExportFormatCSV
+	options.Tables = []string{"test_export_table"}
+	_, err = ExportTables(context.Background(), options)
+	assert.Nil(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(outputDir, "test_export_table.csv"))
+	assert.Nil(t, err)
+	assert.Equal(t, "name,size,ratio\n\"first,one\",1,0.5\nsecond,,n/a\n", string(data))
+
+	options.Format = ExportFormatParquet
+	_, err = ExportTables(context.Background(), options)
+	assert.Nil(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(outputDir, "test_export_table.parquet"))
+	assert.Nil(t, err)
+	parquetFile, err := buffer.NewBufferFile(data)
+	assert.Nil(t, err)
+	parquetReader, err := reader.NewParquetColumnReader(parquetFile, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), parquetReader.GetNumRows())
+	sizes, _, _, err := parquetReader.ReadColumnByIndex(1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(1), nil}, sizes)
+	ratios, _, _, err := parquetReader.ReadColumnByIndex(2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{0.5, nil}, ratios)
+	parquetReader.ReadStop()
+
+	_, err = ExportTables(context.Background(), ExportOptions{Destination: outputDir, Format: "xml"})
+	assert.EqualError(t, err, "unknown format xml, expected ndjson, csv or parquet")
+	_, err = ExportTables(context.Background(), ExportOptions{Destination: outputDir, Format: ExportFormatCSV, Tables: []string{"unknown_table"}})
+	assert.EqualError(t, err, "unknown table unknown_table, or the table is not configured")
+	_, err = ExportTables(context.Background(), ExportOptions{Destination: "ftp://host/path", Format: ExportFormatCSV})
+	assert.EqualError(t, err, "unsupported export destination ftp://host/path, expected a directory, s3://, gs:// or azblob:// URL")
+}
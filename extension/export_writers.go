@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+func writeNDJSONRows(writer io.Writer, columns []string, rows []map[string]string) error {
+	encoder := json.NewEncoder(writer)
+	projectedRow := make(map[string]string, len(columns))
+	for _, row := range rows {
+		for _, column := range columns {
+			projectedRow[column] = row[column]
+		}
+		if err := encoder.Encode(projectedRow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeParquetRows writes one optional parquet column per table column. Numeric columns
+// keep their type, and values which are empty or not numbers are written as null.
+func writeParquetRows(w io.Writer, columns []table.ColumnDefinition, rows []map[string]string) (err error) {
+	// parquet-go panics on invalid schemas and values
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	metadata := make([]string, len(columns))
+	for idx, column := range columns {
+		switch string(column.Type) {
+		case table.ColumnTypeInteger, table.ColumnTypeBigInt:
+			metadata[idx] = fmt.Sprintf("name=%s, type=INT64, repetitiontype=OPTIONAL", column.Name)
+		case table.ColumnTypeDouble:
+			metadata[idx] = fmt.Sprintf("name=%s, type=DOUBLE, repetitiontype=OPTIONAL", column.Name)
+		default:
+			metadata[idx] = fmt.Sprintf("name=%s, type=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL", column.Name)
+		}
+	}
+	parquetWriter, err := writer.NewCSVWriterFromWriter(metadata, w, 1)
+	if err != nil {
+		return err
+	}
+
+	values := make([]*string, len(columns))
+	for _, row := range rows {
+		for idx, column := range columns {
+			values[idx] = getParquetValue(column, row[column.Name])
+		}
+		if err = parquetWriter.WriteString(values); err != nil {
+			return err
+		}
+	}
+	return parquetWriter.WriteStop()
+}
+
+func getParquetValue(column table.ColumnDefinition, value string) *string {
+	switch string(column.Type) {
+	case table.ColumnTypeInteger, table.ColumnTypeBigInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil
+		}
+	case table.ColumnTypeDouble:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil
+		}
+	}
+	return &value
+}
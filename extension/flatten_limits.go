@@ -0,0 +1,31 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// withFlattenLimits applies the flatten limits of a table to each of its generations: the rows exceeding the limits
+// are truncated, or the generation fails if the table is configured to fail on overflow.
+// The overflows of the objects flattened by a generation only affect that generation.
+func withFlattenLimits(tableName string, generate table.GenerateFunc) table.GenerateFunc {
+	return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		ctx, generation := utilities.WithFlattenGeneration(ctx, tableName)
+		rows, err := generate(ctx, queryContext)
+		if err != nil {
+			return rows, err
+		}
+		return generation.ApplyLimits(rows)
+	}
+}
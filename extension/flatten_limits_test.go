@@ -0,0 +1,51 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFlattenLimits(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(`{"test_limits_table": {"aws": {}, "gcp": {}, "azure": {},
+		"limits": {"maxRows": 1, "onOverflow": "fail"},
+		"parsedAttributes": [{"sourceName": "Names", "targetName": "name", "targetType": "TEXT", "enabled": true}]}}`))
+	assert.Nil(t, err)
+	tableConfig, _ := utilities.GetTableConfig("test_limits_table")
+	generate := func(jsonStr string) table.GenerateFunc {
+		return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			resultMap := make([]map[string]string, 0)
+			for _, row := range utilities.NewTable(ctx, []byte(jsonStr), tableConfig).Rows {
+				resultMap = append(resultMap, utilities.RowToMap(make(map[string]string), row, tableConfig))
+			}
+			return resultMap, nil
+		}
+	}
+
+	rows, err := withFlattenLimits("test_limits_table", generate(`{"Names": ["a"]}`))(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "a"}}, rows)
+
+	rows, err = withFlattenLimits("test_limits_table", generate(`{"Names": ["a", "b"]}`))(context.Background(), table.QueryContext{})
+	assert.NotNil(t, err)
+	assert.Nil(t, rows)
+
+	// Truncated rows are returned
+	tableConfig.Limits.OnOverflow = utilities.OverflowTruncate
+	defer func() { tableConfig.Limits.OnOverflow = utilities.OverflowFail }()
+	rows, err = withFlattenLimits("test_limits_table", generate(`{"Names": ["a", "b"]}`))(context.Background(), table.QueryContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"name": "a"}}, rows)
+}
@@ -24,7 +24,6 @@ import (
 	osquery "github.com/Uptycs/basequery-go"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
 
 	"sync"
 	"time"
@@ -97,19 +96,11 @@ func (cl *CloudLogEventTable) GetGenFunction() table.GenerateFunc {
 }
 
 func (cl *CloudLogEventTable) initialize(ctx context.Context, socket string, timeout time.Duration) {
-	cl.ctx = ctx
+	cl.ctx = utilities.WithTableName(ctx, TABLE_NAME)
 	cl.markerDelayMinutes = MARKER_DELAY_MINUTES
 	cl.objectCache = cache.New(time.Duration(CACHE_TIMEOUT_MINUTES)*time.Minute, time.Duration(CACHE_TIMEOUT_MINUTES)*time.Minute)
 	cl.markerMap = make(map[string]*ObjectMarker)
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) > 0 {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			for _, bucket := range account.CloudLogStorageBuckets {
-				for _, logName := range bucket.LogNames {
-					cl.markerMap[bucket.Name+logName] = nil
-				}
-			}
-		}
-	}
+	cl.syncBuckets(utilities.GetExtConfiguration().ExtConfGcp.Accounts)
 	cl.client, _ = osquery.NewClient(socket, timeout)
 }
 
@@ -139,10 +130,40 @@ func (cl *CloudLogEventTable) CloudLogGenerate(osqCtx context.Context, queryCont
 	return nil, nil
 }
 
+// syncBuckets tracks the buckets and logs of given accounts, and forgets the markers of those no longer configured.
+// Accounts and buckets may change when the configuration is reloaded.
+func (cl *CloudLogEventTable) syncBuckets(accounts []utilities.ExtensionConfigurationGcpAccount) {
+	configured := make(map[string]bool)
+	for _, account := range accounts {
+		for _, bucket := range account.CloudLogStorageBuckets {
+			for _, logName := range bucket.LogNames {
+				configured[bucket.Name+logName] = true
+				if _, found := cl.markerMap[bucket.Name+logName]; !found {
+					utilities.GetLogger().WithFields(log.Fields{
+						"tableName": TABLE_NAME,
+						"projectID": account.ProjectID,
+						"bucket":    bucket.Name,
+						"logName":   logName,
+					}).Info("tracking bucket")
+					cl.markerMap[bucket.Name+logName] = nil
+				}
+			}
+		}
+	}
+	for key := range cl.markerMap {
+		if !configured[key] {
+			delete(cl.markerMap, key)
+		}
+	}
+}
+
 func (cl *CloudLogEventTable) runEventLoop() {
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) > 0 {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject(TABLE_NAME, account.ProjectID) {
+	// Use the same configuration for the whole loop
+	accounts := utilities.GetExtConfiguration().ExtConfGcp.Accounts
+	cl.syncBuckets(accounts)
+	if len(accounts) > 0 {
+		for _, account := range accounts {
+			if !extgcp.ShouldProcessProject(table.QueryContext{}, TABLE_NAME, account.ProjectID) {
 				continue
 			}
 			utilities.GetLogger().WithFields(log.Fields{
@@ -273,6 +294,7 @@ func (cl *CloudLogEventTable) processSingleObject(client *storage.Client, accoun
 			"key":       obj.Name,
 			"errString": err.Error(),
 		}).Error("failed to process object")
+		extgcp.ReportError(TABLE_NAME, account.ProjectID, "", err)
 		return err
 	}
 	defer rc.Close()
@@ -301,7 +323,24 @@ func (cl *CloudLogEventTable) processSingleObject(client *storage.Client, accoun
 		"key":       obj.Name,
 	}).Debug("Added events ", len(events))
 	// Send events
-	cl.client.StreamEvents(TABLE_NAME, events)
+	status, streamErr := cl.client.StreamEvents(TABLE_NAME, events)
+	if streamErr == nil && status.Code != 0 {
+		streamErr = fmt.Errorf("%s", status.Message)
+	}
+	if streamErr != nil {
+		utilities.RecordStreamEventsFailure(TABLE_NAME)
+		utilities.GetLogger().WithFields(log.Fields{
+			"tableName": TABLE_NAME,
+			"projectID": account.ProjectID,
+			"region":    bucket.Region,
+			"task":      "LookupEvents",
+			"bucket":    bucket.Name,
+			"logName":   logName,
+			"key":       obj.Name,
+			"errString": streamErr.Error(),
+		}).Error("failed to stream events")
+		return streamErr
+	}
 
 	if isPrefix {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -346,14 +385,19 @@ func (cl *CloudLogEventTable) processObjects(client *storage.Client, account *ut
 	sort.Slice(objs, func(p, q int) bool {
 		return objs[p].Updated.Before(objs[q].Updated)
 	})
-	for _, obj := range objs {
+	// Logs of a bucket are processed separately
+	metricsBucket := bucket.Name + "/" + logName
+	for idx, obj := range objs {
+		utilities.SetEventBacklog(TABLE_NAME, account.ProjectID, metricsBucket, len(objs)-idx)
 		if currentMarker == nil && obj.Updated.Before(currentTime.Add(-time.Duration(LOOKBACK_MINUTES)*time.Minute)) {
 			// we dont have a marker set, and current file is not within latest 1 hour. Ignore
 			utilities.GetLogger().Info("Ignoring file:", bucket.Name+obj.Name)
 			continue
 		}
 		// Process object
-		cl.processSingleObject(client, account, bucket, logName, obj)
+		if cl.processSingleObject(client, account, bucket, logName, obj) == nil {
+			utilities.SetEventLastProcessed(TABLE_NAME, account.ProjectID, metricsBucket, obj.Updated)
+		}
 		// if object is not within latest cl.markerDelayMinutes
 		// and if it is modified after current marker, update the marker
 		if currentTime.Sub(obj.Updated) >= time.Duration(cl.markerDelayMinutes)*time.Minute {
@@ -368,6 +412,7 @@ func (cl *CloudLogEventTable) processObjects(client *storage.Client, account *ut
 			}
 		}
 	}
+	utilities.SetEventBacklog(TABLE_NAME, account.ProjectID, metricsBucket, 0)
 	if currentMarker != nil {
 		cl.markerMap[bucket.Name+logName] = currentMarker
 	}
@@ -379,7 +424,7 @@ func (cl *CloudLogEventTable) getStorageServiceForAccount(account *utilities.Ext
 	var err error
 	if account != nil {
 		projectID = account.ProjectID
-		client, err = storage.NewClient(cl.ctx, option.WithCredentialsFile(account.KeyFile))
+		client, err = storage.NewClient(cl.ctx, extgcp.GetClientOptions(cl.ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
 		client, err = storage.NewClient(cl.ctx)
@@ -390,12 +435,13 @@ func (cl *CloudLogEventTable) getStorageServiceForAccount(account *utilities.Ext
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create client")
+		extgcp.ReportError(TABLE_NAME, projectID, "", err)
 		return nil, ""
 	}
 	return client, projectID
 }
 
-func (cl *CloudLogEventTable) getObjectList(client *storage.Client, bucketName, dirPath string) []*storage.ObjectAttrs {
+func (cl *CloudLogEventTable) getObjectList(client *storage.Client, projectID, bucketName, dirPath string) []*storage.ObjectAttrs {
 	q := storage.Query{
 		Prefix: dirPath,
 	}
@@ -412,6 +458,7 @@ func (cl *CloudLogEventTable) getObjectList(client *storage.Client, bucketName,
 				"bucketName": bucketName,
 				"errString":  err.Error(),
 			}).Error("failed to iterate objects")
+			extgcp.ReportError(TABLE_NAME, projectID, "", err)
 		}
 		objList = append(objList, attrs)
 	}
@@ -433,11 +480,11 @@ func (cl *CloudLogEventTable) processBucket(account *utilities.ExtensionConfigur
 		pastDirPath := cl.getDirPath(logName, currentTime.Add(-time.Duration(cl.markerDelayMinutes)*time.Minute))
 		if dirPath != pastDirPath {
 			// we just moved to new day, but we need to process last few files in past day as well
-			storageObjects := cl.getObjectList(client, bucket.Name, pastDirPath)
+			storageObjects := cl.getObjectList(client, account.ProjectID, bucket.Name, pastDirPath)
 			cl.processObjects(client, account, bucket, storageObjects, pastDirPath, logName)
 		}
 		// process current day
-		storageObjects := cl.getObjectList(client, bucket.Name, dirPath)
+		storageObjects := cl.getObjectList(client, account.ProjectID, bucket.Name, dirPath)
 		cl.processObjects(client, account, bucket, storageObjects, dirPath, logName)
 	}
 }
@@ -446,7 +493,7 @@ func (cl *CloudLogEventTable) processAccountLookupEvents(account *utilities.Exte
 	if account == nil || len(account.CloudLogStorageBuckets) == 0 {
 		return
 	}
-	_, ok := utilities.TableConfigurationMap[TABLE_NAME]
+	_, ok := utilities.GetTableConfig(TABLE_NAME)
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": TABLE_NAME,
@@ -15,13 +15,12 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -29,58 +28,6 @@ type myGcpComputeDisksItemsContainer struct {
 	Items []*compute.Disk `json:"items"`
 }
 
-// GcpComputeDisksColumns returns the list of columns for gcp_compute_disk
-func (handler *GcpComputeHandler) GcpComputeDisksColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.TextColumn("disk_encryption_key"),
-		//table.TextColumn("disk_encryption_key_kms_key_name"),
-		//table.TextColumn("disk_encryption_key_kms_key_service_account"),
-		//table.TextColumn("disk_encryption_key_raw_key"),
-		//table.TextColumn("disk_encryption_key_sha256"),
-		table.TextColumn("guest_os_features"),
-		//table.TextColumn("guest_os_features_type"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("label_fingerprint"),
-		table.TextColumn("labels"),
-		table.TextColumn("last_attach_timestamp"),
-		table.TextColumn("last_detach_timestamp"),
-		table.TextColumn("license_codes"),
-		table.TextColumn("licenses"),
-		table.TextColumn("name"),
-		table.TextColumn("options"),
-		table.BigIntColumn("physical_block_size_bytes"),
-		table.TextColumn("region"),
-		table.TextColumn("replica_zones"),
-		table.TextColumn("resource_policies"),
-		//table.TextColumn("self_link"),
-		table.BigIntColumn("size_gb"),
-		table.TextColumn("source_disk"),
-		table.TextColumn("source_disk_id"),
-		table.TextColumn("source_image"),
-		table.TextColumn("source_image_encryption_key"),
-		//table.TextColumn("source_image_encryption_key_kms_key_name"),
-		//table.TextColumn("source_image_encryption_key_kms_key_service_account"),
-		//table.TextColumn("source_image_encryption_key_raw_key"),
-		//table.TextColumn("source_image_encryption_key_sha256"),
-		table.TextColumn("source_image_id"),
-		table.TextColumn("source_snapshot"),
-		//table.TextColumn("source_snapshot_encryption_key"),
-		//table.TextColumn("source_snapshot_encryption_key_kms_key_name"),
-		//table.TextColumn("source_snapshot_encryption_key_kms_key_service_account"),
-		//table.TextColumn("source_snapshot_encryption_key_raw_key"),
-		//table.TextColumn("source_snapshot_encryption_key_sha256"),
-		table.TextColumn("source_snapshot_id"),
-		table.TextColumn("status"),
-		table.TextColumn("type"),
-		table.TextColumn("users"),
-		table.TextColumn("zone"),
-	}
-}
-
 // GcpComputeDisksGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeDisksGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -88,20 +35,24 @@ func (handler *GcpComputeHandler) GcpComputeDisksGenerate(osqCtx context.Context
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_disk", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_disk", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeDisks(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_disk", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_disk", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_disk", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeDisks(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_disk", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -114,13 +65,13 @@ func (handler *GcpComputeHandler) getGcpComputeDisksNewServiceForAccount(ctx con
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -128,6 +79,7 @@ func (handler *GcpComputeHandler) getGcpComputeDisksNewServiceForAccount(ctx con
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_disk", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -162,8 +114,11 @@ func (handler *GcpComputeHandler) processAccountGcpComputeDisks(ctx context.Cont
 			for _, inst := range item.Disks {
 				zonePathSplit := strings.Split(inst.Zone, "/")
 				inst.Zone = zonePathSplit[len(zonePathSplit)-1]
+				if !extgcp.ShouldProcessZone(queryContext, "gcp_compute_disk", projectID, inst.Zone) {
+					continue
+				}
+				itemsContainer.Items = append(itemsContainer.Items, inst)
 			}
-			itemsContainer.Items = append(itemsContainer.Items, item.Disks...)
 		}
 
 		return nil
@@ -173,6 +128,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeDisks(ctx context.Cont
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_disk", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -184,14 +140,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeDisks(ctx context.Cont
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_disk"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_disk")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_disk",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_disk\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_disk", projectID, "", row) {
 			continue
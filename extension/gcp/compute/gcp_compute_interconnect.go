@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,46 +27,6 @@ type myGcpComputeInterconnectsItemsContainer struct {
 	Items []*compute.Interconnect `json:"items"`
 }
 
-// GcpComputeInterconnectsColumns returns the list of columns for gcp_compute_interconnect
-func (handler *GcpComputeHandler) GcpComputeInterconnectsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("admin_enabled"),
-		table.TextColumn("circuit_infos"),
-		//table.TextColumn("circuit_infos_customer_demarc_id"),
-		//table.TextColumn("circuit_infos_google_circuit_id"),
-		//table.TextColumn("circuit_infos_google_demarc_id"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("customer_name"),
-		table.TextColumn("description"),
-		table.TextColumn("expected_outages"),
-		//table.TextColumn("expected_outages_affected_circuits"),
-		//table.TextColumn("expected_outages_description"),
-		//table.BigIntColumn("expected_outages_end_time"),
-		//table.TextColumn("expected_outages_issue_type"),
-		//table.TextColumn("expected_outages_name"),
-		//table.TextColumn("expected_outages_source"),
-		//table.BigIntColumn("expected_outages_start_time"),
-		//table.TextColumn("expected_outages_state"),
-		table.TextColumn("google_ip_address"),
-		table.TextColumn("google_reference_id"),
-		table.BigIntColumn("id"),
-		table.TextColumn("interconnect_attachments"),
-		table.TextColumn("interconnect_type"),
-		table.TextColumn("kind"),
-		table.TextColumn("link_type"),
-		table.TextColumn("location"),
-		table.TextColumn("name"),
-		table.TextColumn("noc_contact_email"),
-		table.TextColumn("operational_status"),
-		table.TextColumn("peer_ip_address"),
-		table.BigIntColumn("provisioned_link_count"),
-		table.BigIntColumn("requested_link_count"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("state"),
-	}
-}
-
 // GcpComputeInterconnectsGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeInterconnectsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -75,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeInterconnectsGenerate(osqCtx context
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_interconnect", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_interconnect", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeInterconnects(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_interconnect", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_interconnect", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_interconnect", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeInterconnects(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_interconnect", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -101,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeInterconnectsNewServiceForAccount
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -115,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeInterconnectsNewServiceForAccount
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_interconnect", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -154,6 +118,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeInterconnects(ctx cont
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_interconnect", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -165,14 +130,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeInterconnects(ctx cont
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_interconnect"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_interconnect")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_interconnect",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_interconnect\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_interconnect", projectID, "", row) {
 			continue
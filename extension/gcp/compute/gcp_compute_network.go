@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,38 +27,6 @@ type myGcpComputeNetworksItemsContainer struct {
 	Items []*compute.Network `json:"items"`
 }
 
-// GcpComputeNetworksColumns returns the list of columns for gcp_compute_network
-func (handler *GcpComputeHandler) GcpComputeNetworksColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("ipv4_range"),
-		table.TextColumn("auto_create_subnetworks"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.TextColumn("gateway_ipv4"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.BigIntColumn("mtu"),
-		table.TextColumn("name"),
-		table.TextColumn("peerings"),
-		//table.TextColumn("peerings_auto_create_routes"),
-		//table.TextColumn("peerings_exchange_subnet_routes"),
-		//table.TextColumn("peerings_export_custom_routes"),
-		//table.TextColumn("peerings_export_subnet_routes_with_public_ip"),
-		//table.TextColumn("peerings_import_custom_routes"),
-		//table.TextColumn("peerings_import_subnet_routes_with_public_ip"),
-		//table.TextColumn("peerings_name"),
-		//table.TextColumn("peerings_network"),
-		//table.BigIntColumn("peerings_peer_mtu"),
-		//table.TextColumn("peerings_state"),
-		//table.TextColumn("peerings_state_details"),
-		table.TextColumn("routing_config"),
-		//table.TextColumn("routing_config_routing_mode"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("subnetworks"),
-	}
-}
-
 // GcpComputeNetworksGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeNetworksGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -67,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeNetworksGenerate(osqCtx context.Cont
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_network", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_network", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeNetworks(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_network", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_network", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_network", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeNetworks(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_network", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -93,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeNetworksNewServiceForAccount(ctx
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -107,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeNetworksNewServiceForAccount(ctx
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_network", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -146,6 +118,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeNetworks(ctx context.C
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_network", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -157,14 +130,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeNetworks(ctx context.C
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_network"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_network")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_network",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_network\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_network", projectID, "", row) {
 			continue
@@ -15,13 +15,12 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -29,35 +28,6 @@ type myGcpComputeReservationsItemsContainer struct {
 	Items []*compute.Reservation `json:"items"`
 }
 
-// GcpComputeReservationsColumns returns the list of columns for gcp_compute_reservation
-func (handler *GcpComputeHandler) GcpComputeReservationsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("commitment"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("specific_reservation"),
-		table.TextColumn("specific_reservation_required"),
-		//table.BigIntColumn("specific_reservation_count"),
-		//table.BigIntColumn("specific_reservation_in_use_count"),
-		//table.TextColumn("specific_reservation_instance_properties"),
-		//table.TextColumn("specific_reservation_instance_properties_guest_accelerators"),
-		//table.BigIntColumn("specific_reservation_instance_properties_guest_accelerators_accelerator_count"),
-		//table.TextColumn("specific_reservation_instance_properties_guest_accelerators_accelerator_type"),
-		//table.TextColumn("specific_reservation_instance_properties_local_ssds"),
-		//table.BigIntColumn("specific_reservation_instance_properties_local_ssds_disk_size_gb"),
-		//table.TextColumn("specific_reservation_instance_properties_local_ssds_interface"),
-		//table.TextColumn("specific_reservation_instance_properties_machine_type"),
-		//table.TextColumn("specific_reservation_instance_properties_min_cpu_platform"),
-		table.TextColumn("status"),
-		table.TextColumn("zone"),
-	}
-}
-
 // GcpComputeReservationsGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeReservationsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -65,20 +35,24 @@ func (handler *GcpComputeHandler) GcpComputeReservationsGenerate(osqCtx context.
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_reservation", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_reservation", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeReservations(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_reservation", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_reservation", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_reservation", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeReservations(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_reservation", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -91,13 +65,13 @@ func (handler *GcpComputeHandler) getGcpComputeReservationsNewServiceForAccount(
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -105,6 +79,7 @@ func (handler *GcpComputeHandler) getGcpComputeReservationsNewServiceForAccount(
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_reservation", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -139,8 +114,11 @@ func (handler *GcpComputeHandler) processAccountGcpComputeReservations(ctx conte
 			for _, inst := range item.Reservations {
 				zonePathSplit := strings.Split(inst.Zone, "/")
 				inst.Zone = zonePathSplit[len(zonePathSplit)-1]
+				if !extgcp.ShouldProcessZone(queryContext, "gcp_compute_reservation", projectID, inst.Zone) {
+					continue
+				}
+				itemsContainer.Items = append(itemsContainer.Items, inst)
 			}
-			itemsContainer.Items = append(itemsContainer.Items, item.Reservations...)
 		}
 
 		return nil
@@ -150,6 +128,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeReservations(ctx conte
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_reservation", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -161,14 +140,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeReservations(ctx conte
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_reservation"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_reservation")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_reservation",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_reservation\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_reservation", projectID, "", row) {
 			continue
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,37 +27,6 @@ type myGcpComputeRoutesItemsContainer struct {
 	Items []*compute.Route `json:"items"`
 }
 
-// GcpComputeRoutesColumns returns the list of columns for gcp_compute_route
-func (handler *GcpComputeHandler) GcpComputeRoutesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.TextColumn("dest_range"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		table.TextColumn("network"),
-		table.TextColumn("next_hop_gateway"),
-		table.TextColumn("next_hop_ilb"),
-		table.TextColumn("next_hop_instance"),
-		table.TextColumn("next_hop_ip"),
-		table.TextColumn("next_hop_network"),
-		table.TextColumn("next_hop_peering"),
-		table.TextColumn("next_hop_vpn_tunnel"),
-		table.BigIntColumn("priority"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("tags"),
-		table.TextColumn("warnings"),
-		//table.TextColumn("warnings_code"),
-		//table.TextColumn("warnings_data"),
-		//table.TextColumn("warnings_data_key"),
-		//table.TextColumn("warnings_data_value"),
-		//table.TextColumn("warnings_message"),
-
-	}
-}
-
 // GcpComputeRoutesGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeRoutesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -66,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeRoutesGenerate(osqCtx context.Contex
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_route", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_route", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeRoutes(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_route", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_route", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_route", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeRoutes(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_route", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -92,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeRoutesNewServiceForAccount(ctx co
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -106,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeRoutesNewServiceForAccount(ctx co
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_route", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -145,6 +118,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeRoutes(ctx context.Con
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_route", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -156,14 +130,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeRoutes(ctx context.Con
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_route"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_route")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_route",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_route\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_route", projectID, "", row) {
 			continue
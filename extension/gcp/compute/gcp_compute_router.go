@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,67 +27,6 @@ type myGcpComputeRoutersItemsContainer struct {
 	Items []*compute.Router `json:"items"`
 }
 
-// GcpComputeRoutersColumns returns the list of columns for gcp_compute_router
-func (handler *GcpComputeHandler) GcpComputeRoutersColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("bgp"),
-		table.TextColumn("bgp_peers"),
-		//table.TextColumn("bgp_peers_advertise_mode"),
-		//table.TextColumn("bgp_peers_advertised_groups"),
-		//table.TextColumn("bgp_peers_advertised_ip_ranges"),
-		//table.TextColumn("bgp_peers_advertised_ip_ranges_description"),
-		//table.TextColumn("bgp_peers_advertised_ip_ranges_range"),
-		//table.BigIntColumn("bgp_peers_advertised_route_priority"),
-		//table.TextColumn("bgp_peers_interface_name"),
-		//table.TextColumn("bgp_peers_ip_address"),
-		//table.TextColumn("bgp_peers_management_type"),
-		//table.TextColumn("bgp_peers_name"),
-		//table.BigIntColumn("bgp_peers_peer_asn"),
-		//table.TextColumn("bgp_peers_peer_ip_address"),
-		//table.TextColumn("bgp_advertise_mode"),
-		//table.TextColumn("bgp_advertised_groups"),
-		//table.TextColumn("bgp_advertised_ip_ranges"),
-		//table.TextColumn("bgp_advertised_ip_ranges_description"),
-		//table.TextColumn("bgp_advertised_ip_ranges_range"),
-		//table.BigIntColumn("bgp_asn"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.BigIntColumn("id"),
-		table.TextColumn("interfaces"),
-		//table.TextColumn("interfaces_ip_range"),
-		//table.TextColumn("interfaces_linked_interconnect_attachment"),
-		//table.TextColumn("interfaces_linked_vpn_tunnel"),
-		//table.TextColumn("interfaces_management_type"),
-		//table.TextColumn("interfaces_name"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		table.TextColumn("nats"),
-		//table.TextColumn("nats_drain_nat_ips"),
-		//table.TextColumn("nats_enable_endpoint_independent_mapping"),
-		//table.BigIntColumn("nats_icmp_idle_timeout_sec"),
-		//table.TextColumn("nats_log_config"),
-		//table.TextColumn("nats_log_config_enable"),
-		//table.TextColumn("nats_log_config_filter"),
-		//table.BigIntColumn("nats_min_ports_per_vm"),
-		//table.TextColumn("nats_name"),
-		//table.TextColumn("nats_nat_ip_allocate_option"),
-		//table.TextColumn("nats_nat_ips"),
-		//table.TextColumn("nats_source_subnetwork_ip_ranges_to_nat"),
-		//table.TextColumn("nats_subnetworks"),
-		//table.TextColumn("nats_subnetworks_name"),
-		//table.TextColumn("nats_subnetworks_secondary_ip_range_names"),
-		//table.TextColumn("nats_subnetworks_source_ip_ranges_to_nat"),
-		//table.BigIntColumn("nats_tcp_established_idle_timeout_sec"),
-		//table.BigIntColumn("nats_tcp_transitory_idle_timeout_sec"),
-		//table.BigIntColumn("nats_udp_idle_timeout_sec"),
-		table.TextColumn("network"),
-		table.TextColumn("region"),
-		//table.TextColumn("self_link"),
-
-	}
-}
-
 // GcpComputeRoutersGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeRoutersGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -96,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeRoutersGenerate(osqCtx context.Conte
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_router", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_router", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeRouters(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_router", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_router", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_router", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeRouters(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_router", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -122,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeRoutersNewServiceForAccount(ctx c
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -136,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeRoutersNewServiceForAccount(ctx c
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_router", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -178,6 +121,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeRouters(ctx context.Co
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_router", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -189,14 +133,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeRouters(ctx context.Co
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_router"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_router")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_router",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_router\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_router", projectID, "", row) {
 			continue
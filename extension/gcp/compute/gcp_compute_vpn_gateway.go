@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,27 +27,6 @@ type myGcpComputeVpnGatewaysItemsContainer struct {
 	Items []*compute.VpnGateway `json:"items"`
 }
 
-// GcpComputeVpnGatewaysColumns returns the list of columns for gcp_compute_vpn_gateway
-func (handler *GcpComputeHandler) GcpComputeVpnGatewaysColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("label_fingerprint"),
-		table.TextColumn("labels"),
-		table.TextColumn("name"),
-		table.TextColumn("network"),
-		table.TextColumn("region"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("vpn_interfaces"),
-		//table.BigIntColumn("vpn_interfaces_id"),
-		//table.TextColumn("vpn_interfaces_ip_address"),
-
-	}
-}
-
 // GcpComputeVpnGatewaysGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeVpnGatewaysGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -56,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeVpnGatewaysGenerate(osqCtx context.C
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_vpn_gateway", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_vpn_gateway", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeVpnGateways(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_vpn_gateway", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_vpn_gateway", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_vpn_gateway", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeVpnGateways(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_vpn_gateway", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -82,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeVpnGatewaysNewServiceForAccount(c
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -96,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeVpnGatewaysNewServiceForAccount(c
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_vpn_gateway", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -138,6 +121,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeVpnGateways(ctx contex
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_vpn_gateway", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -149,14 +133,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeVpnGateways(ctx contex
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_vpn_gateway"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_vpn_gateway")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_vpn_gateway",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_vpn_gateway\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_vpn_gateway", projectID, "", row) {
 			continue
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	compute "google.golang.org/api/compute/v1"
 )
 
@@ -28,35 +27,6 @@ type myGcpComputeVpnTunnelsItemsContainer struct {
 	Items []*compute.VpnTunnel `json:"items"`
 }
 
-// GcpComputeVpnTunnelsColumns returns the list of columns for gcp_compute_vpn_tunnel
-func (handler *GcpComputeHandler) GcpComputeVpnTunnelsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("creation_timestamp"),
-		table.TextColumn("description"),
-		table.TextColumn("detailed_status"),
-		table.BigIntColumn("id"),
-		table.BigIntColumn("ike_version"),
-		table.TextColumn("kind"),
-		table.TextColumn("local_traffic_selector"),
-		table.TextColumn("name"),
-		table.TextColumn("peer_external_gateway"),
-		table.BigIntColumn("peer_external_gateway_interface"),
-		table.TextColumn("peer_gcp_gateway"),
-		table.TextColumn("peer_ip"),
-		table.TextColumn("region"),
-		table.TextColumn("remote_traffic_selector"),
-		table.TextColumn("router"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("shared_secret"),
-		table.TextColumn("shared_secret_hash"),
-		table.TextColumn("status"),
-		table.TextColumn("target_vpn_gateway"),
-		table.TextColumn("vpn_gateway"),
-		table.BigIntColumn("vpn_gateway_interface"),
-	}
-}
-
 // GcpComputeVpnTunnelsGenerate returns the rows in the table for all configured accounts
 func (handler *GcpComputeHandler) GcpComputeVpnTunnelsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -64,20 +34,24 @@ func (handler *GcpComputeHandler) GcpComputeVpnTunnelsGenerate(osqCtx context.Co
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_compute_vpn_tunnel", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_compute_vpn_tunnel", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := handler.processAccountGcpComputeVpnTunnels(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_compute_vpn_tunnel", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_compute_vpn_tunnel", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_compute_vpn_tunnel", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := handler.processAccountGcpComputeVpnTunnels(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_compute_vpn_tunnel", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -90,13 +64,13 @@ func (handler *GcpComputeHandler) getGcpComputeVpnTunnelsNewServiceForAccount(ct
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = handler.svcInterface.NewService(ctx)
+		service, err = handler.svcInterface.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -104,6 +78,7 @@ func (handler *GcpComputeHandler) getGcpComputeVpnTunnelsNewServiceForAccount(ct
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_compute_vpn_tunnel", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -146,6 +121,7 @@ func (handler *GcpComputeHandler) processAccountGcpComputeVpnTunnels(ctx context
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_compute_vpn_tunnel", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -157,14 +133,14 @@ func (handler *GcpComputeHandler) processAccountGcpComputeVpnTunnels(ctx context
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_compute_vpn_tunnel"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_compute_vpn_tunnel")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_compute_vpn_tunnel",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_compute_vpn_tunnel\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_compute_vpn_tunnel", projectID, "", row) {
 			continue
@@ -0,0 +1,30 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package compute
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"gcp_compute_instance":     {Type: reflect.TypeOf((*myGcpComputeInstancesItemsContainer)(nil)).Elem()},
+	"gcp_compute_network":      {Type: reflect.TypeOf((*myGcpComputeNetworksItemsContainer)(nil)).Elem()},
+	"gcp_compute_disk":         {Type: reflect.TypeOf((*myGcpComputeDisksItemsContainer)(nil)).Elem()},
+	"gcp_compute_image":        {Type: reflect.TypeOf((*myGcpComputeImagesItemsContainer)(nil)).Elem()},
+	"gcp_compute_interconnect": {Type: reflect.TypeOf((*myGcpComputeInterconnectsItemsContainer)(nil)).Elem()},
+	"gcp_compute_route":        {Type: reflect.TypeOf((*myGcpComputeRoutesItemsContainer)(nil)).Elem()},
+	"gcp_compute_reservation":  {Type: reflect.TypeOf((*myGcpComputeReservationsItemsContainer)(nil)).Elem()},
+	"gcp_compute_router":       {Type: reflect.TypeOf((*myGcpComputeRoutersItemsContainer)(nil)).Elem()},
+	"gcp_compute_vpn_tunnel":   {Type: reflect.TypeOf((*myGcpComputeVpnTunnelsItemsContainer)(nil)).Elem()},
+	"gcp_compute_vpn_gateway":  {Type: reflect.TypeOf((*myGcpComputeVpnGatewaysItemsContainer)(nil)).Elem()},
+}
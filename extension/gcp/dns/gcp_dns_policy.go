@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpdns "google.golang.org/api/dns/v1beta2"
 )
 
@@ -28,29 +27,6 @@ type myGcpDNSPoliciesItemsContainer struct {
 	Items []*gcpdns.Policy `json:"items"`
 }
 
-// GcpDNSPoliciesColumns returns the list of columns for gcp_dns_policy
-func GcpDNSPoliciesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("alternative_name_server_config"),
-		//table.TextColumn("alternative_name_server_config_kind"),
-		//table.TextColumn("alternative_name_server_config_target_name_servers"),
-		//table.TextColumn("alternative_name_server_config_target_name_servers_forwarding_path"),
-		//table.TextColumn("alternative_name_server_config_target_name_servers_ipv4_address"),
-		//table.TextColumn("alternative_name_server_config_target_name_servers_kind"),
-		table.TextColumn("description"),
-		table.TextColumn("enable_inbound_forwarding"),
-		table.TextColumn("enable_logging"),
-		table.BigIntColumn("id"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		table.TextColumn("networks"),
-		//table.TextColumn("networks_kind"),
-		//table.TextColumn("networks_network_url"),
-
-	}
-}
-
 // GcpDNSPoliciesGenerate returns the rows in the table for all configured accounts
 func GcpDNSPoliciesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -58,20 +34,24 @@ func GcpDNSPoliciesGenerate(osqCtx context.Context, queryContext table.QueryCont
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_dns_policy", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_dns_policy", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpDNSPolicies(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_dns_policy", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_dns_policy", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_dns_policy", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpDNSPolicies(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_dns_policy", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -84,13 +64,13 @@ func getGcpDNSPoliciesNewServiceForAccount(ctx context.Context, account *utiliti
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpdns.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpdns.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpdns.NewService(ctx)
+		service, err = gcpdns.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpdns.NewService(ctx)
+		service, err = gcpdns.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -98,6 +78,7 @@ func getGcpDNSPoliciesNewServiceForAccount(ctx context.Context, account *utiliti
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_dns_policy", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -133,6 +114,7 @@ func processAccountGcpDNSPolicies(ctx context.Context, queryContext table.QueryC
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_dns_policy", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -144,14 +126,14 @@ func processAccountGcpDNSPolicies(ctx context.Context, queryContext table.QueryC
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_dns_policy"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_dns_policy")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_dns_policy",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_dns_policy\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_dns_policy", projectID, "", row) {
 			continue
@@ -0,0 +1,38 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package gcp
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"google.golang.org/api/googleapi"
+)
+
+// ReportError records a failure to collect the rows of given table for a project, or one of its zones.
+// The error code is the reason given by the API, or the HTTP status code. The API is not reported by the SDK.
+func ReportError(tableName string, projectID string, zone string, err error) {
+	collectionError := utilities.CollectionError{
+		Table:    tableName,
+		Provider: utilities.ProviderGcp,
+		Account:  projectID,
+		Region:   zone,
+		Message:  err.Error(),
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		collectionError.Code = strconv.Itoa(apiErr.Code)
+		if len(apiErr.Errors) > 0 && apiErr.Errors[0].Reason != "" {
+			collectionError.Code = apiErr.Errors[0].Reason
+		}
+	}
+	utilities.ReportCollectionError(collectionError)
+}
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpfile "google.golang.org/api/file/v1beta1"
 )
 
@@ -28,24 +27,6 @@ type myGcpFileBackupsItemsContainer struct {
 	Items []*gcpfile.Backup `json:"items"`
 }
 
-// GcpFileBackupsColumns returns the list of columns for gcp_file_backup
-func GcpFileBackupsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.BigIntColumn("capacity_gb"),
-		table.TextColumn("create_time"),
-		table.TextColumn("description"),
-		table.BigIntColumn("download_bytes"),
-		table.TextColumn("labels"),
-		table.TextColumn("name"),
-		table.TextColumn("source_file_share"),
-		table.TextColumn("source_instance"),
-		//table.TextColumn("source_instance_tier"),
-		table.TextColumn("state"),
-		table.BigIntColumn("storage_bytes"),
-	}
-}
-
 // GcpFileBackupsGenerate returns the rows in the table for all configured accounts
 func GcpFileBackupsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -53,20 +34,24 @@ func GcpFileBackupsGenerate(osqCtx context.Context, queryContext table.QueryCont
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_file_backup", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_file_backup", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpFileBackups(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_file_backup", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_file_backup", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_file_backup", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpFileBackups(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_file_backup", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -79,13 +64,13 @@ func getGcpFileBackupsNewServiceForAccount(ctx context.Context, account *utiliti
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpfile.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpfile.NewService(ctx)
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpfile.NewService(ctx)
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -93,6 +78,7 @@ func getGcpFileBackupsNewServiceForAccount(ctx context.Context, account *utiliti
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_file_backup", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -128,6 +114,7 @@ func processAccountGcpFileBackups(ctx context.Context, queryContext table.QueryC
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_file_backup", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -139,14 +126,14 @@ func processAccountGcpFileBackups(ctx context.Context, queryContext table.QueryC
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_file_backup"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_file_backup")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_file_backup",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_file_backup\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_file_backup", projectID, "", row) {
 			continue
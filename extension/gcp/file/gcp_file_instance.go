@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpfile "google.golang.org/api/file/v1beta1"
 )
 
@@ -28,36 +27,6 @@ type myGcpFileInstancesItemsContainer struct {
 	Items []*gcpfile.Instance `json:"items"`
 }
 
-// GcpFileInstancesColumns returns the list of columns for gcp_file_instance
-func GcpFileInstancesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("create_time"),
-		table.TextColumn("description"),
-		table.TextColumn("etag"),
-		table.TextColumn("file_shares"),
-		//table.BigIntColumn("file_shares_capacity_gb"),
-		//table.TextColumn("file_shares_name"),
-		//table.TextColumn("file_shares_nfs_export_options"),
-		//table.TextColumn("file_shares_nfs_export_options_access_mode"),
-		//table.BigIntColumn("file_shares_nfs_export_options_anon_gid"),
-		//table.BigIntColumn("file_shares_nfs_export_options_anon_uid"),
-		//table.TextColumn("file_shares_nfs_export_options_ip_ranges"),
-		//table.TextColumn("file_shares_nfs_export_options_squash_mode"),
-		//table.TextColumn("file_shares_source_backup"),
-		table.TextColumn("labels"),
-		table.TextColumn("name"),
-		table.TextColumn("networks"),
-		//table.TextColumn("networks_ip_addresses"),
-		//table.TextColumn("networks_modes"),
-		//table.TextColumn("networks_network"),
-		//table.TextColumn("networks_reserved_ip_range"),
-		table.TextColumn("state"),
-		table.TextColumn("status_message"),
-		table.TextColumn("tier"),
-	}
-}
-
 // GcpFileInstancesGenerate returns the rows in the table for all configured accounts
 func GcpFileInstancesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -65,20 +34,24 @@ func GcpFileInstancesGenerate(osqCtx context.Context, queryContext table.QueryCo
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_file_instance", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_file_instance", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpFileInstances(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_file_instance", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_file_instance", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_file_instance", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpFileInstances(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_file_instance", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -91,13 +64,13 @@ func getGcpFileInstancesNewServiceForAccount(ctx context.Context, account *utili
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpfile.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpfile.NewService(ctx)
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpfile.NewService(ctx)
+		service, err = gcpfile.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -105,6 +78,7 @@ func getGcpFileInstancesNewServiceForAccount(ctx context.Context, account *utili
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_file_instance", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -140,6 +114,7 @@ func processAccountGcpFileInstances(ctx context.Context, queryContext table.Quer
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_file_instance", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -151,14 +126,14 @@ func processAccountGcpFileInstances(ctx context.Context, queryContext table.Quer
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_file_instance"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_file_instance")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_file_instance",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_file_instance\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_file_instance", projectID, "", row) {
 			continue
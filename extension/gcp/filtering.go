@@ -11,28 +11,41 @@ package gcp
 import (
 	"context"
 	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
 )
 
-// ShouldProcessProject returns false if given project is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
-func ShouldProcessProject(tableName string, projectId string) bool {
-	return true
+// ShouldProcessProject returns false if given project is not supposed to be processed for given table.
+// Projects excluded by the filter rules, or which can not satisfy the project id constraints of the query are skipped.
+func ShouldProcessProject(queryContext table.QueryContext, tableName string, projectId string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfGcp.Filters, utilities.FilterLevelAccount, tableName, projectId, "", nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Gcp.ProjectIDAttribute, projectId)
 }
 
-// ShouldProcessZone returns false if given zone for given project is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
-func ShouldProcessZone(tableName string, projectId string, zone string) bool {
-	return true
+// ShouldProcessZone returns false if given zone for given project is not supposed to be processed for given table.
+// Zones excluded by the filter rules, or which can not satisfy the zone constraints of the query are skipped.
+func ShouldProcessZone(queryContext table.QueryContext, tableName string, projectId string, zone string) bool {
+	if !utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfGcp.Filters, utilities.FilterLevelRegion, tableName, projectId, zone, nil) {
+		return false
+	}
+	tableConfig, ok := utilities.GetTableConfig(tableName)
+	if !ok {
+		return true
+	}
+	return utilities.MatchConstraints(queryContext, tableConfig.Gcp.ZoneAttribute, zone)
 }
 
-// ShouldProcessRow returns false if given row is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
+// ShouldProcessRow returns false if given row is excluded by the filter rules for given table
 func ShouldProcessRow(osqCtx context.Context, queryContext table.QueryContext, tableName string, projectId string, zone string, row map[string]interface{}) bool {
-	return true
+	return utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfGcp.Filters, utilities.FilterLevelRow, tableName, projectId, zone, utilities.InterfaceRow(row))
 }
 
-// ShouldProcessEvent returns false if given event is not supposed to be processed for given table
-// Default implementation is no-op (return true always). Add custom logic here if required
+// ShouldProcessEvent returns false if given event is excluded by the filter rules for given table
 func ShouldProcessEvent(tableName string, projectId string, zone string, row map[string]string) bool {
-	return true
+	return utilities.MatchFilterRules(utilities.GetExtConfiguration().ExtConfGcp.Filters, utilities.FilterLevelRow, tableName, projectId, zone, utilities.StringRow(row))
 }
@@ -13,6 +13,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -20,8 +21,6 @@ import (
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpfunction "google.golang.org/api/cloudfunctions/v1beta2"
 )
 
@@ -29,46 +28,6 @@ type myGcpCloudFunctionsItemsContainer struct {
 	Items []*gcpfunction.CloudFunction `json:"items"`
 }
 
-// GcpCloudFunctionsColumns returns the list of columns for gcp_cloud_function
-func GcpCloudFunctionsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.BigIntColumn("available_memory_mb"),
-		table.TextColumn("entry_point"),
-		table.TextColumn("environment_variables"),
-		table.TextColumn("event_trigger"),
-		//table.TextColumn("event_trigger_event_type"),
-		//table.TextColumn("event_trigger_failure_policy"),
-		//table.TextColumn("event_trigger_failure_policy_retry"),
-		//table.TextColumn("event_trigger_resource"),
-		//table.TextColumn("event_trigger_service"),
-		table.TextColumn("https_trigger"),
-		//table.TextColumn("https_trigger_url"),
-		table.TextColumn("labels"),
-		table.TextColumn("latest_operation"),
-		table.BigIntColumn("max_instances"),
-		table.TextColumn("name"),
-		table.TextColumn("network"),
-		table.TextColumn("runtime"),
-		table.TextColumn("service_account"),
-		table.TextColumn("source_archive_url"),
-		table.TextColumn("source_repository"),
-		table.TextColumn("source_repository_url"),
-		//table.TextColumn("source_repository_branch"),
-		//table.TextColumn("source_repository_deployed_revision"),
-		//table.TextColumn("source_repository_repository_url"),
-		//table.TextColumn("source_repository_revision"),
-		//table.TextColumn("source_repository_source_path"),
-		//table.TextColumn("source_repository_tag"),
-		table.TextColumn("source_upload_url"),
-		table.TextColumn("status"),
-		table.TextColumn("timeout"),
-		table.TextColumn("update_time"),
-		table.BigIntColumn("version_id"),
-		table.TextColumn("vpc_connector"),
-	}
-}
-
 // GcpCloudFunctionsGenerate returns the rows in the table for all configured accounts
 func GcpCloudFunctionsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -76,20 +35,24 @@ func GcpCloudFunctionsGenerate(osqCtx context.Context, queryContext table.QueryC
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_cloud_function", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_cloud_function", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpCloudFunctions(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_cloud_function", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_cloud_function", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_cloud_function", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpCloudFunctions(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_cloud_function", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -102,13 +65,13 @@ func getGcpCloudFunctionsNewServiceForAccount(ctx context.Context, account *util
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpfunction.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpfunction.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpfunction.NewService(ctx)
+		service, err = gcpfunction.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpfunction.NewService(ctx)
+		service, err = gcpfunction.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -116,6 +79,7 @@ func getGcpCloudFunctionsNewServiceForAccount(ctx context.Context, account *util
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_cloud_function", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -140,13 +104,14 @@ func processAccountGcpCloudFunctions(ctx context.Context, queryContext table.Que
 		return resultMap, nil
 	}
 	itemsContainer := myGcpCloudFunctionsItemsContainer{Items: make([]*gcpfunction.CloudFunction, 0)}
-	rsp, err := listCall.Do()
+	rsp, err := listCall.Context(ctx).Do()
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_cloud_function",
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed listCall.Do()")
+		extgcp.ReportError("gcp_cloud_function", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -160,14 +125,14 @@ func processAccountGcpCloudFunctions(ctx context.Context, queryContext table.Que
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_cloud_function"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_cloud_function")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_cloud_function",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_cloud_function\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_cloud_function", projectID, "", row) {
 			continue
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpiam "google.golang.org/api/iam/v1"
 )
 
@@ -28,20 +27,6 @@ type myGcpIamRolesItemsContainer struct {
 	Items []*gcpiam.Role `json:"items"`
 }
 
-// GcpIamRolesColumns returns the list of columns for gcp_iam_role
-func GcpIamRolesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("deleted"),
-		table.TextColumn("description"),
-		table.TextColumn("etag"),
-		table.TextColumn("included_permissions"),
-		table.TextColumn("name"),
-		table.TextColumn("stage"),
-		table.TextColumn("title"),
-	}
-}
-
 // GcpIamRolesGenerate returns the rows in the table for all configured accounts
 func GcpIamRolesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -49,20 +34,24 @@ func GcpIamRolesGenerate(osqCtx context.Context, queryContext table.QueryContext
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_iam_role", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_iam_role", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpIamRoles(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_iam_role", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_iam_role", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_iam_role", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpIamRoles(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_iam_role", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -75,13 +64,13 @@ func getGcpIamRolesNewServiceForAccount(ctx context.Context, account *utilities.
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpiam.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpiam.NewService(ctx)
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpiam.NewService(ctx)
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -89,6 +78,7 @@ func getGcpIamRolesNewServiceForAccount(ctx context.Context, account *utilities.
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_iam_role", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -124,6 +114,7 @@ func processAccountGcpIamRoles(ctx context.Context, queryContext table.QueryCont
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_iam_role", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -135,14 +126,14 @@ func processAccountGcpIamRoles(ctx context.Context, queryContext table.QueryCont
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_iam_role"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_iam_role")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_iam_role",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_iam_role\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_iam_role", projectID, "", row) {
 			continue
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpiam "google.golang.org/api/iam/v1"
 )
 
@@ -28,21 +27,6 @@ type myGcpIamServiceAccountsItemsContainer struct {
 	Items []*gcpiam.ServiceAccount `json:"items"`
 }
 
-// GcpIamServiceAccountsColumns returns the list of columns for gcp_iam_service_account
-func GcpIamServiceAccountsColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("description"),
-		table.TextColumn("disabled"),
-		table.TextColumn("display_name"),
-		table.TextColumn("email"),
-		table.TextColumn("etag"),
-		table.TextColumn("name"),
-		table.TextColumn("oauth2_client_id"),
-		table.TextColumn("project_id"),
-		table.TextColumn("unique_id"),
-	}
-}
-
 // GcpIamServiceAccountsGenerate returns the rows in the table for all configured accounts
 func GcpIamServiceAccountsGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -50,20 +34,24 @@ func GcpIamServiceAccountsGenerate(osqCtx context.Context, queryContext table.Qu
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_iam_service_account", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_iam_service_account", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpIamServiceAccounts(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_iam_service_account", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_iam_service_account", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_iam_service_account", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpIamServiceAccounts(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_iam_service_account", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -76,13 +64,13 @@ func getGcpIamServiceAccountsNewServiceForAccount(ctx context.Context, account *
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpiam.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpiam.NewService(ctx)
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpiam.NewService(ctx)
+		service, err = gcpiam.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -90,6 +78,7 @@ func getGcpIamServiceAccountsNewServiceForAccount(ctx context.Context, account *
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_iam_service_account", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
@@ -125,6 +114,7 @@ func processAccountGcpIamServiceAccounts(ctx context.Context, queryContext table
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to get aggregate list page")
+		extgcp.ReportError("gcp_iam_service_account", projectID, "", err)
 		return resultMap, nil
 	}
 
@@ -136,14 +126,14 @@ func processAccountGcpIamServiceAccounts(ctx context.Context, queryContext table
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_iam_service_account"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_iam_service_account")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_iam_service_account",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_iam_service_account\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_iam_service_account", projectID, "", row) {
 			continue
@@ -0,0 +1,22 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package iam
+
+import (
+	"reflect"
+
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// TableSources describes the objects the rows of the tables of the package are flattened from
+var TableSources = map[string]utilities.TableSource{
+	"gcp_iam_role":            {Type: reflect.TypeOf((*myGcpIamRolesItemsContainer)(nil)).Elem()},
+	"gcp_iam_service_account": {Type: reflect.TypeOf((*myGcpIamServiceAccountsItemsContainer)(nil)).Elem()},
+}
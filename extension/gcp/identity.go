@@ -0,0 +1,82 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	userInfoEmailScope = "https://www.googleapis.com/auth/userinfo.email"
+	tokenInfoURL       = "https://oauth2.googleapis.com/tokeninfo"
+)
+
+// GetAccountIdentity returns the email the credentials of given account resolve to, and the expiry of their access token.
+// The key file of the account is used if set, the application default credentials otherwise.
+func GetAccountIdentity(ctx context.Context, account *utilities.ExtensionConfigurationGcpAccount) (utilities.AccountIdentity, error) {
+	identity := utilities.AccountIdentity{CredentialSource: "adc"}
+	var credentials *google.Credentials
+	var err error
+	if account != nil && account.KeyFile != "" {
+		identity.CredentialSource = "key_file"
+		data, err := ioutil.ReadFile(account.KeyFile)
+		if err != nil {
+			return identity, err
+		}
+		credentials, err = google.CredentialsFromJSON(ctx, data, cloudPlatformScope, userInfoEmailScope)
+		if err != nil {
+			return identity, err
+		}
+	} else {
+		credentials, err = google.FindDefaultCredentials(ctx, cloudPlatformScope, userInfoEmailScope)
+		if err != nil {
+			return identity, err
+		}
+	}
+	token, err := credentials.TokenSource.Token()
+	if err != nil {
+		return identity, err
+	}
+	identity.Expiry = token.Expiry
+	identity.Identity, err = getTokenEmail(ctx, token)
+	return identity, err
+}
+
+// getTokenEmail returns the email of the account an access token was issued to
+func getTokenEmail(ctx context.Context, token *oauth2.Token) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?access_token="+url.QueryEscape(token.AccessToken), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token info request failed with status %s", resp.Status)
+	}
+	tokenInfo := struct {
+		Email string `json:"email"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenInfo); err != nil {
+		return "", err
+	}
+	return tokenInfo.Email, nil
+}
@@ -0,0 +1,162 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package gcp
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/cloudresourcemanager/v3"
+)
+
+// defaultDiscoveryRefreshInterval is the interval at which the projects of the organizations and folders are listed again
+const defaultDiscoveryRefreshInterval = time.Hour
+
+// projectStateActive is the lifecycle state of the projects and folders which are not pending deletion
+const projectStateActive = "ACTIVE"
+
+// GetDiscoveryRefreshInterval returns the shortest interval at which the projects of given discoveries are listed again
+func GetDiscoveryRefreshInterval(discoveries []utilities.ExtensionConfigurationGcpDiscovery) time.Duration {
+	interval := defaultDiscoveryRefreshInterval
+	for idx, discovery := range discoveries {
+		discoveryInterval := defaultDiscoveryRefreshInterval
+		if discovery.RefreshInterval > 0 {
+			discoveryInterval = time.Duration(discovery.RefreshInterval) * time.Second
+		}
+		if idx == 0 || discoveryInterval < interval {
+			interval = discoveryInterval
+		}
+	}
+	return interval
+}
+
+// DiscoverProjects returns the active projects of the organization or folder of given discovery, and of its folders,
+// selected by their id and labels
+func DiscoverProjects(ctx context.Context, discovery *utilities.ExtensionConfigurationGcpDiscovery) ([]utilities.ExtensionConfigurationGcpAccount, error) {
+	service, err := cloudresourcemanager.NewService(ctx, GetClientOptions(ctx, &utilities.ExtensionConfigurationGcpAccount{KeyFile: discovery.KeyFile})...)
+	if err != nil {
+		return nil, err
+	}
+	projects := make([]*cloudresourcemanager.Project, 0)
+	if err := listProjects(ctx, service, discovery.Parent, &projects); err != nil {
+		return nil, err
+	}
+	return selectProjects(projects, discovery), nil
+}
+
+// listProjects adds the projects of given parent, and of its active folders, to projects
+func listProjects(ctx context.Context, service *cloudresourcemanager.Service, parent string, projects *[]*cloudresourcemanager.Project) error {
+	err := service.Projects.List().Parent(parent).Pages(ctx, func(page *cloudresourcemanager.ListProjectsResponse) error {
+		*projects = append(*projects, page.Projects...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	folders := make([]string, 0)
+	err = service.Folders.List().Parent(parent).Pages(ctx, func(page *cloudresourcemanager.ListFoldersResponse) error {
+		for _, folder := range page.Folders {
+			if folder.State == projectStateActive {
+				folders = append(folders, folder.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		if err := listProjects(ctx, service, folder, projects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectProjects returns the accounts of the active projects matching the project ids and labels of given discovery
+func selectProjects(projects []*cloudresourcemanager.Project, discovery *utilities.ExtensionConfigurationGcpDiscovery) []utilities.ExtensionConfigurationGcpAccount {
+	accounts := make([]utilities.ExtensionConfigurationGcpAccount, 0, len(projects))
+	ids := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		if project == nil || project.ProjectId == "" || project.State != projectStateActive || ids[project.ProjectId] {
+			continue
+		}
+		if len(discovery.ProjectIDs) > 0 && !matchPatterns(discovery.ProjectIDs, project.ProjectId) {
+			continue
+		}
+		if !matchProjectLabels(project.Labels, discovery.Labels) {
+			continue
+		}
+		ids[project.ProjectId] = true
+		accounts = append(accounts, utilities.ExtensionConfigurationGcpAccount{
+			ProjectID: project.ProjectId,
+			KeyFile:   discovery.KeyFile,
+		})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ProjectID < accounts[j].ProjectID
+	})
+	return accounts
+}
+
+// matchProjectLabels returns true if given project labels have all of the labels, with a value matching one of their patterns
+func matchProjectLabels(projectLabels map[string]string, labels map[string][]string) bool {
+	for key, patterns := range labels {
+		value, found := projectLabels[key]
+		if !found || !matchPatterns(patterns, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPatterns returns true if given value matches one of the glob patterns
+func matchPatterns(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if utilities.MatchGlob(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshGcpProjects lists the projects of the configured organizations and folders again, and replaces the discovered projects.
+// The projects discovered before with the key file of a discovery are kept if its listing fails.
+func RefreshGcpProjects(ctx context.Context) {
+	discoveries := utilities.GetExtConfiguration().ExtConfGcp.Discovery
+	previous := utilities.GetDiscoveredGcpAccounts()
+	accounts := make([]utilities.ExtensionConfigurationGcpAccount, 0)
+	for idx := range discoveries {
+		discovery := &discoveries[idx]
+		discovered, err := DiscoverProjects(ctx, discovery)
+		if err != nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"parent":    discovery.Parent,
+				"errString": err.Error(),
+			}).Error("failed to discover projects")
+			ReportError(utilities.DiscoveryTable, discovery.Parent, "", err)
+			for _, account := range previous {
+				if account.KeyFile == discovery.KeyFile {
+					accounts = append(accounts, account)
+				}
+			}
+			continue
+		}
+		utilities.GetLogger().WithFields(log.Fields{
+			"parent":        discovery.Parent,
+			"totalProjects": len(discovered),
+		}).Info("discovered projects")
+		accounts = append(accounts, discovered...)
+	}
+	utilities.SetDiscoveredGcpAccounts(accounts)
+}
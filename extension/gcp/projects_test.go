@@ -0,0 +1,58 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package gcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/cloudresourcemanager/v3"
+)
+
+func TestSelectProjects(t *testing.T) {
+	projects := []*cloudresourcemanager.Project{
+		{ProjectId: "prod-api", State: "ACTIVE", Labels: map[string]string{"env": "prod-eu"}},
+		{ProjectId: "dev-api", State: "ACTIVE"},
+		{ProjectId: "prod-old", State: "DELETE_REQUESTED", Labels: map[string]string{"env": "prod-eu"}},
+		{ProjectId: "prod-web", State: "ACTIVE", Labels: map[string]string{"env": "dev"}},
+		{ProjectId: "dev-api", State: "ACTIVE"},
+	}
+	discovery := &utilities.ExtensionConfigurationGcpDiscovery{Parent: "organizations/1", KeyFile: "/key.json"}
+	assert.Equal(t, []utilities.ExtensionConfigurationGcpAccount{
+		{ProjectID: "dev-api", KeyFile: "/key.json"},
+		{ProjectID: "prod-api", KeyFile: "/key.json"},
+		{ProjectID: "prod-web", KeyFile: "/key.json"},
+	}, selectProjects(projects, discovery))
+
+	discovery.ProjectIDs = []string{"prod-*"}
+	assert.Equal(t, 2, len(selectProjects(projects, discovery)))
+
+	discovery.Labels = map[string][]string{"env": {"prod-*"}}
+	accounts := selectProjects(projects, discovery)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "prod-api", accounts[0].ProjectID)
+}
+
+func TestRefreshGcpProjectsError(t *testing.T) {
+	savedConfiguration := utilities.GetExtConfiguration()
+	defer utilities.SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfGcp.Discovery = []utilities.ExtensionConfigurationGcpDiscovery{{Parent: "folders/123", KeyFile: "/nonexistent/key.json"}}
+	utilities.SetExtConfiguration(&extConfig)
+
+	// Failures are reported with the parent
+	RefreshGcpProjects(context.Background())
+	collectionErrors := utilities.GetLastCollectionErrors(utilities.DiscoveryTable, 1)
+	assert.Equal(t, 1, len(collectionErrors))
+	assert.Equal(t, "folders/123", collectionErrors[0].Account)
+	assert.Equal(t, utilities.ProviderGcp, collectionErrors[0].Provider)
+}
@@ -14,13 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 
 	"github.com/Uptycs/basequery-go/plugin/table"
 	extgcp "github.com/Uptycs/cloudquery/extension/gcp"
 	"github.com/Uptycs/cloudquery/utilities"
 
-	"google.golang.org/api/option"
-
 	gcpsql "google.golang.org/api/sqladmin/v1beta4"
 )
 
@@ -28,25 +27,6 @@ type myGcpSQLDatabasesItemsContainer struct {
 	Items []*gcpsql.Database `json:"items"`
 }
 
-// GcpSQLDatabasesColumns returns the list of columns for gcp_sql_database
-func GcpSQLDatabasesColumns() []table.ColumnDefinition {
-	return []table.ColumnDefinition{
-		table.TextColumn("project_id"),
-		table.TextColumn("charset"),
-		table.TextColumn("collation"),
-		table.TextColumn("etag"),
-		table.TextColumn("instance"),
-		table.TextColumn("kind"),
-		table.TextColumn("name"),
-		table.TextColumn("project"),
-		//table.TextColumn("self_link"),
-		table.TextColumn("sqlserver_database_details"),
-		//table.BigIntColumn("sqlserver_database_details_compatibility_level"),
-		//table.TextColumn("sqlserver_database_details_recovery_model"),
-
-	}
-}
-
 // GcpSQLDatabasesGenerate returns the rows in the table for all configured accounts
 func GcpSQLDatabasesGenerate(osqCtx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
 	ctx, cancel := context.WithCancel(osqCtx)
@@ -54,20 +34,24 @@ func GcpSQLDatabasesGenerate(osqCtx context.Context, queryContext table.QueryCon
 
 	resultMap := make([]map[string]string, 0)
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 && extgcp.ShouldProcessProject("gcp_sql_database", utilities.DefaultGcpProjectID) {
+	if len(utilities.GetGcpAccounts()) == 0 && extgcp.ShouldProcessProject(queryContext, "gcp_sql_database", utilities.DefaultGcpProjectID) {
+		startTime := time.Now()
 		results, err := processAccountGcpSQLDatabases(ctx, queryContext, nil)
 		if err == nil {
+			utilities.RecordCollectionSuccess("gcp_sql_database", utilities.DefaultGcpProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	} else {
-		for _, account := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-			if !extgcp.ShouldProcessProject("gcp_sql_database", account.ProjectID) {
+		for _, account := range utilities.GetGcpAccounts() {
+			if !extgcp.ShouldProcessProject(queryContext, "gcp_sql_database", account.ProjectID) {
 				continue
 			}
+			startTime := time.Now()
 			results, err := processAccountGcpSQLDatabases(ctx, queryContext, &account)
 			if err != nil {
 				continue
 			}
+			utilities.RecordCollectionSuccess("gcp_sql_database", account.ProjectID, startTime)
 			resultMap = append(resultMap, results...)
 		}
 	}
@@ -80,13 +64,13 @@ func getGcpSQLDatabasesNewServiceForAccount(ctx context.Context, account *utilit
 	var err error
 	if account != nil && account.KeyFile != "" {
 		projectID = account.ProjectID
-		service, err = gcpsql.NewService(ctx, option.WithCredentialsFile(account.KeyFile))
+		service, err = gcpsql.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else if account != nil && account.ProjectID != "" {
 		projectID = account.ProjectID
-		service, err = gcpsql.NewService(ctx)
+		service, err = gcpsql.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	} else {
 		projectID = utilities.DefaultGcpProjectID
-		service, err = gcpsql.NewService(ctx)
+		service, err = gcpsql.NewService(ctx, extgcp.GetClientOptions(ctx, account)...)
 	}
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
@@ -94,12 +78,13 @@ func getGcpSQLDatabasesNewServiceForAccount(ctx context.Context, account *utilit
 			"projectId": projectID,
 			"errString": err.Error(),
 		}).Error("failed to create service")
+		extgcp.ReportError("gcp_sql_database", projectID, "", err)
 		return nil, ""
 	}
 	return service, projectID
 }
 
-func getGcpSQLDatabasesKeys(service *gcpsql.Service, projectID string) ([]string, error) {
+func getGcpSQLDatabasesKeys(ctx context.Context, service *gcpsql.Service, projectID string) ([]string, error) {
 	resultList := make([]string, 0)
 	listCall := service.Instances.List(projectID)
 	if listCall == nil {
@@ -109,12 +94,13 @@ func getGcpSQLDatabasesKeys(service *gcpsql.Service, projectID string) ([]string
 		return resultList, nil
 	}
 
-	rsp, doErr := listCall.Do()
+	rsp, doErr := listCall.Context(ctx).Do()
 	if doErr != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"projectId": projectID,
 			"errString": doErr.Error(),
 		}).Error("failed to get list.Do")
+		extgcp.ReportError("gcp_sql_database", projectID, "", doErr)
 		return resultList, nil
 	}
 
@@ -134,7 +120,7 @@ func processAccountGcpSQLDatabases(ctx context.Context, queryContext table.Query
 		return resultMap, fmt.Errorf("failed to initialize gcpsql.Service")
 	}
 
-	keys, err := getGcpSQLDatabasesKeys(service, projectID)
+	keys, err := getGcpSQLDatabasesKeys(ctx, service, projectID)
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_sql_database",
@@ -162,7 +148,7 @@ func getGcpSQLDatabasesRowsForKey(ctx context.Context, queryContext table.QueryC
 		}).Debug("list call is nil")
 		return resultMap, nil
 	}
-	rsp, err := listCall.Do()
+	rsp, err := listCall.Context(ctx).Do()
 	if err != nil {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_sql_database",
@@ -170,6 +156,7 @@ func getGcpSQLDatabasesRowsForKey(ctx context.Context, queryContext table.QueryC
 			"key":       key,
 			"errString": err.Error(),
 		}).Error("failed to List.Do()")
+		extgcp.ReportError("gcp_sql_database", projectID, "", err)
 		return resultMap, nil
 	}
 	itemsContainer := myGcpSQLDatabasesItemsContainer{Items: rsp.Items}
@@ -181,14 +168,14 @@ func getGcpSQLDatabasesRowsForKey(ctx context.Context, queryContext table.QueryC
 		}).Error("failed to marshal response")
 		return resultMap, err
 	}
-	tableConfig, ok := utilities.TableConfigurationMap["gcp_sql_database"]
+	tableConfig, ok := utilities.GetTableConfig("gcp_sql_database")
 	if !ok {
 		utilities.GetLogger().WithFields(log.Fields{
 			"tableName": "gcp_sql_database",
 		}).Error("failed to get table configuration")
 		return resultMap, fmt.Errorf("table configuration not found for \"gcp_sql_database\"")
 	}
-	jsonTable := utilities.NewTable(byteArr, tableConfig)
+	jsonTable := utilities.NewTable(ctx, byteArr, tableConfig)
 	for _, row := range jsonTable.Rows {
 		if !extgcp.ShouldProcessRow(ctx, queryContext, "gcp_sql_database", projectID, "", row) {
 			continue
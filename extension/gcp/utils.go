@@ -10,9 +10,55 @@
 package gcp
 
 import (
+	"context"
+	"net/http"
+	"strings"
+
 	"github.com/Uptycs/cloudquery/utilities"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// GetClientOptions returns the options of the API clients for given account.
+// The key file of the account is used if set, the application default credentials otherwise.
+// The requests are retried, and their rate limited, as configured for their service.
+// If metrics are enabled, the requests are counted for the project of the account.
+func GetClientOptions(ctx context.Context, account *utilities.ExtensionConfigurationGcpAccount) []option.ClientOption {
+	options := make([]option.ClientOption, 0)
+	if account != nil && account.KeyFile != "" {
+		options = append(options, option.WithCredentialsFile(account.KeyFile))
+	}
+	projectID := utilities.DefaultGcpProjectID
+	if account != nil && account.ProjectID != "" {
+		projectID = account.ProjectID
+	}
+	client, _, err := htransport.NewClient(ctx, append(options, option.WithScopes(cloudPlatformScope))...)
+	if err != nil {
+		// Creating the service fails the same way, and reports the error
+		return options
+	}
+	getProjectID := func(req *http.Request) string {
+		return projectID
+	}
+	if utilities.MetricsEnabled() {
+		client.Transport = utilities.NewMetricsTransport(client.Transport, utilities.ProviderGcp, getProjectID)
+	}
+	client.Transport = utilities.NewRetryTransport(client.Transport, utilities.ProviderGcp, getRequestService, getProjectID)
+	return []option.ClientOption{option.WithHTTPClient(client)}
+}
+
+// getRequestService returns the service of a request from its host, such as compute for compute.googleapis.com,
+// or from the first segment of its path for www.googleapis.com
+func getRequestService(req *http.Request) string {
+	service := strings.TrimSuffix(req.URL.Hostname(), ".googleapis.com")
+	if service == "www" {
+		return strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+	}
+	return service
+}
+
 // RowToMap converts JSON row into osquery row
 // If configured it will copy some metadata values into appropriate columns
 func RowToMap(row map[string]interface{}, projectID string, zone string, tableConfig *utilities.TableConfig) map[string]string {
@@ -10,11 +10,15 @@
 package gcp
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"testing"
 
+	"github.com/Uptycs/basequery-go/plugin/table"
 	"github.com/Uptycs/cloudquery/utilities"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
 )
 
 var tableConfigJSON = `
@@ -39,9 +43,64 @@ func TestRowToMap(t *testing.T) {
 
 	projName, zone := "test-project", "us-east4-zone1"
 	inRow := make(map[string]interface{})
-	tabConfig := utilities.TableConfigurationMap["test_table_1"]
+	tabConfig, _ := utilities.GetTableConfig("test_table_1")
 	outRow := RowToMap(inRow, projName, zone, tabConfig)
 
 	assert.Equal(t, projName, outRow["project_id"])
 	assert.Equal(t, "", outRow["zone"])
 }
+
+func TestShouldProcessProjectAndZone(t *testing.T) {
+	err := utilities.ReadTableConfig([]byte(`{
+		"test_table_2": {
+			"gcp": {
+				"projectIdAttribute": "project_id",
+				"zoneAttribute": "zone"
+			},
+			"parsedAttributes": []
+		}
+	}`))
+	assert.Nil(t, err)
+
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"project_id": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "test-project"},
+				},
+			},
+			"zone": {
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorLike, Expression: "us-east4-%"},
+				},
+			},
+		},
+	}
+
+	assert.True(t, ShouldProcessProject(queryContext, "test_table_2", "test-project"))
+	assert.False(t, ShouldProcessProject(queryContext, "test_table_2", "other-project"))
+	assert.True(t, ShouldProcessZone(queryContext, "test_table_2", "test-project", "us-east4-a"))
+	assert.False(t, ShouldProcessZone(queryContext, "test_table_2", "test-project", "us-west1-a"))
+	// Table test_table_1 has no zone attribute, zones are never pruned
+	assert.True(t, ShouldProcessZone(queryContext, "test_table_1", "test-project", "us-west1-a"))
+}
+
+func TestReportError(t *testing.T) {
+	ReportError("gcp_test_errors", "test-project", "", fmt.Errorf("failed: %w", &googleapi.Error{Code: 403}))
+	ReportError("gcp_test_errors", "test-project", "", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "accessNotConfigured"}}})
+	collectionErrors := utilities.GetCollectionErrors()
+	assert.Equal(t, "403", collectionErrors[len(collectionErrors)-2].Code)
+	assert.Equal(t, "accessNotConfigured", collectionErrors[len(collectionErrors)-1].Code)
+	assert.Equal(t, utilities.ProviderGcp, collectionErrors[len(collectionErrors)-1].Provider)
+}
+
+func TestGetRequestService(t *testing.T) {
+	for url, service := range map[string]string{
+		"https://compute.googleapis.com/compute/v1/projects/p1/zones":       "compute",
+		"https://www.googleapis.com/storage/v1/b?project=p1":                "storage",
+		"https://sqladmin.googleapis.com/sql/v1beta4/projects/p1/instances": "sqladmin",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		assert.Equal(t, service, getRequestService(req))
+	}
+}
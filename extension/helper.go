@@ -21,9 +21,10 @@ import (
 
 // InitializeLogger TODO
 func InitializeLogger(verbose bool) {
-	utilities.CreateLogger(verbose, utilities.ExtConfiguration.ExtConfLog.MaxSize,
-		utilities.ExtConfiguration.ExtConfLog.MaxBackups, utilities.ExtConfiguration.ExtConfLog.MaxAge,
-		utilities.ExtConfiguration.ExtConfLog.FileName)
+	extConfig := utilities.GetExtConfiguration()
+	utilities.CreateLogger(verbose, extConfig.ExtConfLog.MaxSize,
+		extConfig.ExtConfLog.MaxBackups, extConfig.ExtConfLog.MaxAge,
+		extConfig.ExtConfLog.FileName)
 }
 
 func readProjectIDFromCredentialFile(filePath string) string {
@@ -57,36 +58,42 @@ func readProjectIDFromCredentialFile(filePath string) string {
 
 // ReadExtensionConfigurations TODO
 func ReadExtensionConfigurations(filePath string, verbose bool) error {
-	utilities.AwsAccountID = os.Getenv("AWS_ACCOUNT_ID")
-	reader, err := ioutil.ReadFile(filePath)
+	extConfig, err := parseExtensionConfiguration(filePath)
 	if err != nil {
 		fmt.Printf("failed to read configuration file %s. err:%v\n", filePath, err)
 		return err
 	}
-	extConfig := utilities.ExtensionConfiguration{}
-	errUnmarshal := json.Unmarshal(reader, &extConfig)
-	if errUnmarshal != nil {
-		return errUnmarshal
-	}
-	utilities.ExtConfiguration = extConfig
+	utilities.SetExtConfiguration(extConfig)
 
 	// Log config is read. Init the logger now.
 	InitializeLogger(verbose)
 
-	// Set projectID for GCP accounts
-	for idx := range utilities.ExtConfiguration.ExtConfGcp.Accounts {
-		keyFilePath := utilities.ExtConfiguration.ExtConfGcp.Accounts[idx].KeyFile
-		if keyFilePath != "" {
-			projectID := readProjectIDFromCredentialFile(keyFilePath)
-			// Read ProjectID from keyFile
-			utilities.ExtConfiguration.ExtConfGcp.Accounts[idx].ProjectID = projectID
-		} else {
-			// This is case where we are not using shared credentials.
-			// ProjectID must be set in config.
-			if utilities.ExtConfiguration.ExtConfGcp.Accounts[idx].ProjectID == "" {
-				utilities.GetLogger().Error("GCP account is missing projectId setting")
-			}
-		}
+	applyExtensionConfiguration(filePath, extConfig)
+	return nil
+}
+
+// LoadExtensionConfiguration reads the extension configuration, using the logger already created by the caller
+func LoadExtensionConfiguration(filePath string) error {
+	extConfig, err := parseExtensionConfiguration(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file %s: %w", filePath, err)
+	}
+	utilities.SetExtConfiguration(extConfig)
+	applyExtensionConfiguration(filePath, extConfig)
+	return nil
+}
+
+// applyExtensionConfiguration completes the extension configuration in effect, and sets the default credentials
+func applyExtensionConfiguration(filePath string, extConfig *utilities.ExtensionConfiguration) {
+	utilities.AwsAccountID = os.Getenv("AWS_ACCOUNT_ID")
+
+	// Nothing reads the configuration yet, it is completed in place
+	setGcpProjectIDs(extConfig)
+	if err := extConfig.Validate(); err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"fileName":  filePath,
+			"errString": err.Error(),
+		}).Error("invalid extension configuration")
 	}
 
 	// Read project ID from ADC
@@ -95,7 +102,7 @@ func ReadExtensionConfigurations(filePath string, verbose bool) error {
 		utilities.DefaultGcpProjectID = readProjectIDFromCredentialFile(adcFilePath)
 	}
 
-	if len(utilities.ExtConfiguration.ExtConfGcp.Accounts) == 0 {
+	if len(extConfig.ExtConfGcp.Accounts) == 0 {
 		if adcFilePath == "" {
 			utilities.GetLogger().Warn("missing env GOOGLE_APPLICATION_CREDENTIALS")
 		} else if utilities.DefaultGcpProjectID == "" {
@@ -104,6 +111,35 @@ func ReadExtensionConfigurations(filePath string, verbose bool) error {
 			utilities.GetLogger().Warn("Gcp accounts not found in extension_config. Falling back to ADC\n")
 		}
 	}
+}
 
-	return nil
+func parseExtensionConfiguration(filePath string) (*utilities.ExtensionConfiguration, error) {
+	reader, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	extConfig := utilities.ExtensionConfiguration{}
+	errUnmarshal := json.Unmarshal(reader, &extConfig)
+	if errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+	return &extConfig, nil
+}
+
+// setGcpProjectIDs sets projectID of the GCP accounts using a key file
+func setGcpProjectIDs(extConfig *utilities.ExtensionConfiguration) {
+	for idx := range extConfig.ExtConfGcp.Accounts {
+		keyFilePath := extConfig.ExtConfGcp.Accounts[idx].KeyFile
+		if keyFilePath != "" {
+			projectID := readProjectIDFromCredentialFile(keyFilePath)
+			// Read ProjectID from keyFile
+			extConfig.ExtConfGcp.Accounts[idx].ProjectID = projectID
+		} else {
+			// This is case where we are not using shared credentials.
+			// ProjectID must be set in config.
+			if extConfig.ExtConfGcp.Accounts[idx].ProjectID == "" {
+				utilities.GetLogger().Error("GCP account is missing projectId setting")
+			}
+		}
+	}
 }
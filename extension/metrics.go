@@ -0,0 +1,40 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"time"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/extension/azure"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// StartMetrics serves the Prometheus metrics on given address, and instruments the API clients.
+// It must be called before any table is generated.
+func StartMetrics(address string) error {
+	if err := utilities.StartMetricsServer(address); err != nil {
+		return err
+	}
+	azure.EnableMetrics()
+	return nil
+}
+
+// instrumentGenerate records the metrics of the generate function of a table,
+// and attributes the API calls made with its context to the table
+func instrumentGenerate(tableName string, generate table.GenerateFunc) table.GenerateFunc {
+	return func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+		startTime := time.Now()
+		rows, err := generate(utilities.WithTableName(ctx, tableName), queryContext)
+		utilities.ObserveGenerate(tableName, time.Since(startTime), len(rows), err)
+		return rows, err
+	}
+}
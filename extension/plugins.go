@@ -10,8 +10,7 @@
 package extension
 
 import (
-	"io/ioutil"
-	"os"
+	"sync"
 
 	osquery "github.com/Uptycs/basequery-go"
 	"github.com/Uptycs/basequery-go/plugin/table"
@@ -72,272 +71,258 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// ReadTableConfigurations TODO
-func ReadTableConfigurations(homeDir string) {
-	var awsConfigFileList = []string{
-		"aws/codepipeline/table_config.json",
-		"aws/directoryservice/table_config.json",
-		"aws/ec2/table_config.json",
-		"aws/apigateway/table_config.json",
-		"aws/cloudformation/table_config.json",
-		"aws/codedeploy/table_config.json",
-		"aws/codecommit/table_config.json",
-		"aws/s3/table_config.json",
-		"aws/guardduty/table_config.json",
-		"aws/iam/table_config.json",
-		"aws/organizations/table_config.json",
-		"aws/cloudtrail/table_config.json",
-		"aws/acm/table_config.json",
-		"aws/cloudwatch/table_config.json",
-		"aws/config/table_config.json",
-		"aws/kms/table_config.json",
-		"aws/workspaces/table_config.json",
-		"aws/efs/table_config.json",
-		"aws/s3_glacier/table_config.json",
-		"aws/ecr/table_config.json",
-		"aws/eks/table_config.json",
-		"aws/ecs/table_config.json",
-		"aws/sns/table_config.json",
-		"aws/sqs/table_config.json",
-		"aws/elb/table_config.json",
-		"aws/elbv2/table_config.json",
-		"aws/cloudtrail/table_config.json",
-		"aws/rds/table_config.json",
-	}
+var gcpComputeHandler = compute.NewGcpComputeHandler(compute.NewGcpComputeImpl())
+var gcpStorageHandler = storage.NewGcpStorageHandler(storage.NewGcpStorageImpl())
 
-	var gcpConfigFileList = []string{
-		"gcp/compute/table_config.json",
-		"gcp/storage/table_config.json",
-		"gcp/iam/table_config.json",
-		"gcp/sql/table_config.json",
-		"gcp/dns/table_config.json",
-		"gcp/file/table_config.json",
-		"gcp/container/table_config.json",
-		"gcp/function/table_config.json",
-		"gcp/run/table_config.json",
-		"gcp/cloudlog/table_config.json",
-	}
+// TableDefinition holds the columns and the generate function of a table
+type TableDefinition struct {
+	Name     string
+	Columns  []table.ColumnDefinition
+	Generate table.GenerateFunc
+}
+
+var (
+	tablesOnce       sync.Once
+	tableDefinitions []TableDefinition
+	// registeredTables and unconfiguredTables track the tables for the startup report
+	registeredTables, unconfiguredTables []string
+)
 
-	var azureConfigFileList = []string{
-		"azure/appservice/table_config.json",
-		"azure/compute/table_config.json",
-		"azure/containerservice/table_config.json",
-		"azure/cosmosdb/table_config.json",
-		"azure/graphrbac/table_config.json",
-		"azure/keyvault/table_config.json",
-		"azure/mysql/table_config.json",
-		"azure/monitor/table_config.json",
-		"azure/network/table_config.json",
-		"azure/postgresql/table_config.json",
-		"azure/securitycenter/table_config.json",
-		"azure/storage/table_config.json",
-		"azure/sql/table_config.json",
-		"azure/network/table_config.json",
-		"azure/dns/table_config.json",
-		"azure/redis/table_config.json",
-		"azure/graphrbac/table_config.json",
+func registerEventTables(server *osquery.ExtensionManagerServer) {
+	for _, eventTable := range GetEventTables() {
+		server.RegisterPlugin(table.NewPlugin(eventTable.GetName(), eventTable.GetColumns(), eventTable.GetGenFunction()))
+		registeredTables = append(registeredTables, eventTable.GetName())
 	}
-	var configFileList = append(awsConfigFileList, gcpConfigFileList...)
-	configFileList = append(configFileList, azureConfigFileList...)
+}
 
-	for _, fileName := range configFileList {
+// registerTable adds the definition of given table, with the columns generated from its configuration.
+// Tables without configuration are skipped.
+func registerTable(tableName string, generate table.GenerateFunc) {
+	columns := utilities.GetTableColumns(tableName)
+	if len(columns) == 0 {
 		utilities.GetLogger().WithFields(log.Fields{
-			"fileName": homeDir + string(os.PathSeparator) + fileName,
-		}).Info("reading config file")
-		filePath := homeDir + string(os.PathSeparator) + fileName
-		jsonEncoded, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			utilities.GetLogger().WithFields(log.Fields{
-				"fileName":  homeDir + string(os.PathSeparator) + fileName,
-				"errString": err.Error(),
-			}).Error("failed to read config file")
-			continue
-		}
-		readErr := utilities.ReadTableConfig(jsonEncoded)
-		if readErr != nil {
-			utilities.GetLogger().WithFields(log.Fields{
-				"fileName":  homeDir + string(os.PathSeparator) + fileName,
-				"errString": readErr.Error(),
-			}).Error("failed to parse config file")
-			continue
-		}
+			"tableName": tableName,
+		}).Error("failed to get table configuration, skipping table")
+		unconfiguredTables = append(unconfiguredTables, tableName)
+		return
 	}
-	utilities.GetLogger().WithFields(log.Fields{
-		"totalTables": len(utilities.TableConfigurationMap),
-	}).Info("read all config files")
+	definition := newCachedTable(tableName, columns, instrumentGenerate(tableName, withFlattenLimits(tableName, generate)))
+	definition.Generate = withDeadline(tableName, definition.Generate)
+	tableDefinitions = append(tableDefinitions, definition)
+	registeredTables = append(registeredTables, tableName)
 }
 
-var gcpComputeHandler = compute.NewGcpComputeHandler(compute.NewGcpComputeImpl())
-var gcpStorageHandler = storage.NewGcpStorageHandler(storage.NewGcpStorageImpl())
+// GetTableDefinitions returns the definitions of the configured tables, event tables excluded.
+// Table configurations must be read first, columns are generated only once.
+func GetTableDefinitions() []TableDefinition {
+	tablesOnce.Do(registerTables)
+	return tableDefinitions
+}
 
-func registerEventTables(server *osquery.ExtensionManagerServer) {
-	for _, eventTable := range GetEventTables() {
-		server.RegisterPlugin(table.NewPlugin(eventTable.GetName(), eventTable.GetColumns(), eventTable.GetGenFunction()))
+// GetTableDefinition returns the definition of given table
+func GetTableDefinition(tableName string) (TableDefinition, bool) {
+	for _, definition := range GetTableDefinitions() {
+		if definition.Name == tableName {
+			return definition, true
+		}
 	}
+	return TableDefinition{}, false
 }
 
-// RegisterPlugins
+// RegisterPlugins registers the configured tables, the event tables, and the collection errors and accounts tables with osquery
 func RegisterPlugins(server *osquery.ExtensionManagerServer) {
-	// AWS ACM
-	server.RegisterPlugin(table.NewPlugin("aws_acm_certificate", acm.ListCertificatesColumns(), acm.ListCertificatesGenerate))
+	for _, definition := range GetTableDefinitions() {
+		server.RegisterPlugin(newColumnsUsedPlugin(definition))
+	}
+	registerEventTables(server)
+	server.RegisterPlugin(table.NewPlugin(collectionErrorsTableName, getCollectionErrorsColumns(), generateCollectionErrors))
+	server.RegisterPlugin(table.NewPlugin(accountsTableName, getAccountsColumns(), generateAccounts))
+	registeredTables = append(registeredTables, collectionErrorsTableName, accountsTableName)
+
+	reportTableConfigurations(registeredTables, unconfiguredTables)
+}
+
+// registerTables adds the definitions of all tables
+func registerTables() {
+	registerTable("aws_acm_certificate", acm.ListCertificatesGenerate)
 	// AWS CLOUDFORMATION
-	server.RegisterPlugin(table.NewPlugin("aws_cloudformation_stack", cloudformation.DescribeStacksColumns(), cloudformation.DescribeStacksGenerate))
+	registerTable("aws_cloudformation_stack", cloudformation.DescribeStacksGenerate)
 	// AWS CODEPIPELINE
-	server.RegisterPlugin(table.NewPlugin("aws_codepipeline_pipeline", codepipeline.ListPipelinesColumns(), codepipeline.ListPipelinesGenerate))
+	registerTable("aws_codepipeline_pipeline", codepipeline.ListPipelinesGenerate)
 	// AWS DIRECTORY
-	server.RegisterPlugin(table.NewPlugin("aws_directoryservice_directory", directoryservice.DescribeDirectoriesColumns(), directoryservice.DescribeDirectoriesGenerate))
+	registerTable("aws_directoryservice_directory", directoryservice.DescribeDirectoriesGenerate)
 	// AWS APIGATEWAY
-	server.RegisterPlugin(table.NewPlugin("aws_apigateway_rest_api", apigateway.GetRestApisColumns(), apigateway.GetRestApisGenerate))
+	registerTable("aws_apigateway_rest_api", apigateway.GetRestApisGenerate)
 	// AWS CODEDEPLOY
-	server.RegisterPlugin(table.NewPlugin("aws_codedeploy_application", codedeploy.ListApplicationsColumns(), codedeploy.ListApplicationsGenerate))
+	registerTable("aws_codedeploy_application", codedeploy.ListApplicationsGenerate)
 	// AWS CODECOMMIT
-	server.RegisterPlugin(table.NewPlugin("aws_codecommit_repository", codecommit.ListRepositoriesColumns(), codecommit.ListRepositoriesGenerate))
+	registerTable("aws_codecommit_repository", codecommit.ListRepositoriesGenerate)
 	// AWS RDS
-	server.RegisterPlugin(table.NewPlugin("aws_rds_snapshot", rds.ListSnapshotsColumns(), rds.DescribeSnapshotsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_rds_instance", rds.ListInstanceColumns(), rds.DescribeDBInstances))
-	server.RegisterPlugin(table.NewPlugin("aws_rds_cluster", rds.ListClustersColumns(), rds.DescribeClustersGenerate))
+	registerTable("aws_rds_snapshot", rds.DescribeSnapshotsGenerate)
+	registerTable("aws_rds_instance", rds.DescribeDBInstances)
+	registerTable("aws_rds_cluster", rds.DescribeClustersGenerate)
 	// AWS EC2
 
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_instance", ec2.DescribeInstancesColumns(), ec2.DescribeInstancesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_vpc", ec2.DescribeVpcsColumns(), ec2.DescribeVpcsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_subnet", ec2.DescribeSubnetsColumns(), ec2.DescribeSubnetsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_image", ec2.DescribeImagesColumns(), ec2.DescribeImagesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_egress_only_internet_gateway", ec2.DescribeEgressOnlyInternetGatewaysColumns(), ec2.DescribeEgressOnlyInternetGatewaysGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_internet_gateway", ec2.DescribeInternetGatewaysColumns(), ec2.DescribeInternetGatewaysGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_nat_gateway", ec2.DescribeNatGatewaysColumns(), ec2.DescribeNatGatewaysGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_network_acl", ec2.DescribeNetworkAclsColumns(), ec2.DescribeNetworkAclsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_route_table", ec2.DescribeRouteTablesColumns(), ec2.DescribeRouteTablesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_security_group", ec2.DescribeSecurityGroupsColumns(), ec2.DescribeSecurityGroupsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_tag", ec2.DescribeTagsColumns(), ec2.DescribeTagsGenerate))
-	//server.RegisterPlugin(table.NewPlugin("aws_ec2_address", ec2.DescribeAddressesColumns(), ec2.DescribeAddressesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_flowlog", ec2.DescribeFlowLogsColumns(), ec2.DescribeFlowLogsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_keypair", ec2.DescribeKeyPairsColumns(), ec2.DescribeKeyPairsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_snapshot", ec2.DescribeSnapshotsColumns(), ec2.DescribeSnapshotsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ec2_volume", ec2.DescribeVolumesColumns(), ec2.DescribeVolumesGenerate))
+	registerTable("aws_ec2_instance", ec2.DescribeInstancesGenerate)
+	registerTable("aws_ec2_vpc", ec2.DescribeVpcsGenerate)
+	registerTable("aws_ec2_subnet", ec2.DescribeSubnetsGenerate)
+	registerTable("aws_ec2_image", ec2.DescribeImagesGenerate)
+	registerTable("aws_ec2_egress_only_internet_gateway", ec2.DescribeEgressOnlyInternetGatewaysGenerate)
+	registerTable("aws_ec2_internet_gateway", ec2.DescribeInternetGatewaysGenerate)
+	registerTable("aws_ec2_nat_gateway", ec2.DescribeNatGatewaysGenerate)
+	registerTable("aws_ec2_network_acl", ec2.DescribeNetworkAclsGenerate)
+	registerTable("aws_ec2_route_table", ec2.DescribeRouteTablesGenerate)
+	registerTable("aws_ec2_security_group", ec2.DescribeSecurityGroupsGenerate)
+	registerTable("aws_ec2_tag", ec2.DescribeTagsGenerate)
+	registerTable("aws_ec2_address", ec2.DescribeAddressesGenerate)
+	registerTable("aws_ec2_flowlog", ec2.DescribeFlowLogsGenerate)
+	registerTable("aws_ec2_keypair", ec2.DescribeKeyPairsGenerate)
+	registerTable("aws_ec2_snapshot", ec2.DescribeSnapshotsGenerate)
+	registerTable("aws_ec2_volume", ec2.DescribeVolumesGenerate)
 	// AWS organizations
-	server.RegisterPlugin(table.NewPlugin("aws_organizations_organization", organizations.DescribeOrganizationColumns(), organizations.DescribeOrganizationGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_organizations_account", organizations.ListAccountsColumns(), organizations.ListAccountsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_organizations_root", organizations.ListRootsColumns(), organizations.ListRootsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_organizations_delegated_administrator", organizations.ListDelegatedAdministratorsColumns(), organizations.ListDelegatedAdministratorsGenerate))
+	registerTable("aws_organizations_organization", organizations.DescribeOrganizationGenerate)
+	registerTable("aws_organizations_account", organizations.ListAccountsGenerate)
+	registerTable("aws_organizations_root", organizations.ListRootsGenerate)
+	registerTable("aws_organizations_delegated_administrator", organizations.ListDelegatedAdministratorsGenerate)
 	// AWS S3
-	server.RegisterPlugin(table.NewPlugin("aws_s3_bucket", s3.ListBucketsColumns(), s3.ListBucketsGenerate))
+	registerTable("aws_s3_bucket", s3.ListBucketsGenerate)
 	// AWS IAM
-	server.RegisterPlugin(table.NewPlugin("aws_iam_user", iam.ListUsersColumns(), iam.ListUsersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_iam_role", iam.ListRolesColumns(), iam.ListRolesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_iam_group", iam.ListGroupsColumns(), iam.ListGroupsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_iam_policy", iam.ListPoliciesColumns(), iam.ListPoliciesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_iam_account_password_policy", iam.GetAccountPasswordPolicyColumns(), iam.GetAccountPasswordPolicyGenerate))
+	registerTable("aws_iam_user", iam.ListUsersGenerate)
+	registerTable("aws_iam_role", iam.ListRolesGenerate)
+	registerTable("aws_iam_group", iam.ListGroupsGenerate)
+	registerTable("aws_iam_policy", iam.ListPoliciesGenerate)
+	registerTable("aws_iam_account_password_policy", iam.GetAccountPasswordPolicyGenerate)
 	// AWS GUARDDUTY
-	server.RegisterPlugin(table.NewPlugin("aws_guardduty_detector", guardduty.ListDetectorsColumns(), guardduty.ListDetectorsGenerate))
+	registerTable("aws_guardduty_detector", guardduty.ListDetectorsGenerate)
 	// aws cloudwatch
-	server.RegisterPlugin(table.NewPlugin("aws_cloudwatch_alarm", cloudwatch.DescribeAlarmsColumns(), cloudwatch.DescribeAlarmsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_cloudwatch_event_bus", cloudwatch.ListEventBusesColumns(), cloudwatch.ListEventBusesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_cloudwatch_event_rule", cloudwatch.ListRulesColumns(), cloudwatch.ListRulesGenerate))
+	registerTable("aws_cloudwatch_alarm", cloudwatch.DescribeAlarmsGenerate)
+	registerTable("aws_cloudwatch_event_bus", cloudwatch.ListEventBusesGenerate)
+	registerTable("aws_cloudwatch_event_rule", cloudwatch.ListRulesGenerate)
 	//aws config
-	server.RegisterPlugin(table.NewPlugin("aws_config_recorder", config.DescribeConfigurationRecordersColumns(), config.DescribeConfigurationRecordersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_config_delivery_channel", config.DescribeDeliveryChannelsColumns(), config.DescribeDeliveryChannelsGenerate))
+	registerTable("aws_config_recorder", config.DescribeConfigurationRecordersGenerate)
+	registerTable("aws_config_delivery_channel", config.DescribeDeliveryChannelsGenerate)
 	//aws kms
-	server.RegisterPlugin(table.NewPlugin("aws_kms_key", kms.ListKeysColumns(), kms.ListKeysGenerate))
+	registerTable("aws_kms_key", kms.ListKeysGenerate)
 	//aws workspace
-	server.RegisterPlugin(table.NewPlugin("aws_workspaces_workspace", workspaces.DescribeWorkspacesColumns(), workspaces.DescribeWorkspacesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_elb_loadbalancer", elb.DescribeLoadBalancersColumns(), elb.DescribeLoadBalancersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_elbv2_loadbalancer", elbv2.DescribeLoadBalancersColumns(), elbv2.DescribeLoadBalancersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_efs_file_system", efs.DescribeFileSystemsColumns(), efs.DescribeFileSystemsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_s3_glacier_vault", glacier.ListVaultsColumns(), glacier.ListVaultsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ecr_repository", ecr.DescribeRepositoriesColumns(), ecr.DescribeRepositoriesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_eks_cluster", eks.ListClustersColumns(), eks.ListClustersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_ecs_cluster", ecs.ListClustersColumns(), ecs.ListClustersGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_sns_topic", sns.ListTopicsColumns(), sns.ListTopicsGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_sqs_queue", sqs.ListQueuesColumns(), sqs.ListQueuesGenerate))
-	server.RegisterPlugin(table.NewPlugin("aws_cloudtrail_trail", cloudtrail.DescribeTrailsColumns(), cloudtrail.DescribeTrailsGenerate))
+	registerTable("aws_workspaces_workspace", workspaces.DescribeWorkspacesGenerate)
+	registerTable("aws_elb_loadbalancer", elb.DescribeLoadBalancersGenerate)
+	registerTable("aws_elbv2_loadbalancer", elbv2.DescribeLoadBalancersGenerate)
+	registerTable("aws_efs_file_system", efs.DescribeFileSystemsGenerate)
+	registerTable("aws_s3_glacier_vault", glacier.ListVaultsGenerate)
+	registerTable("aws_ecr_repository", ecr.DescribeRepositoriesGenerate)
+	registerTable("aws_eks_cluster", eks.ListClustersGenerate)
+	registerTable("aws_ecs_cluster", ecs.ListClustersGenerate)
+	registerTable("aws_sns_topic", sns.ListTopicsGenerate)
+	registerTable("aws_sqs_queue", sqs.ListQueuesGenerate)
+	registerTable("aws_cloudtrail_trail", cloudtrail.DescribeTrailsGenerate)
 	// GCP Compute
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_instance", gcpComputeHandler.GcpComputeInstancesColumns(), gcpComputeHandler.GcpComputeInstancesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_network", gcpComputeHandler.GcpComputeNetworksColumns(), gcpComputeHandler.GcpComputeNetworksGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_disk", gcpComputeHandler.GcpComputeDisksColumns(), gcpComputeHandler.GcpComputeDisksGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_image", gcpComputeHandler.GcpComputeImagesColumns(), gcpComputeHandler.GcpComputeImagesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_interconnect", gcpComputeHandler.GcpComputeInterconnectsColumns(), gcpComputeHandler.GcpComputeInterconnectsGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_route", gcpComputeHandler.GcpComputeRoutesColumns(), gcpComputeHandler.GcpComputeRoutesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_reservation", gcpComputeHandler.GcpComputeReservationsColumns(), gcpComputeHandler.GcpComputeReservationsGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_router", gcpComputeHandler.GcpComputeRoutersColumns(), gcpComputeHandler.GcpComputeRoutersGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_vpn_tunnel", gcpComputeHandler.GcpComputeVpnTunnelsColumns(), gcpComputeHandler.GcpComputeVpnTunnelsGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_compute_vpn_gateway", gcpComputeHandler.GcpComputeVpnGatewaysColumns(), gcpComputeHandler.GcpComputeVpnGatewaysGenerate))
+	registerTable("gcp_compute_instance", gcpComputeHandler.GcpComputeInstancesGenerate)
+	registerTable("gcp_compute_network", gcpComputeHandler.GcpComputeNetworksGenerate)
+	registerTable("gcp_compute_disk", gcpComputeHandler.GcpComputeDisksGenerate)
+	registerTable("gcp_compute_image", gcpComputeHandler.GcpComputeImagesGenerate)
+	registerTable("gcp_compute_interconnect", gcpComputeHandler.GcpComputeInterconnectsGenerate)
+	registerTable("gcp_compute_route", gcpComputeHandler.GcpComputeRoutesGenerate)
+	registerTable("gcp_compute_reservation", gcpComputeHandler.GcpComputeReservationsGenerate)
+	registerTable("gcp_compute_router", gcpComputeHandler.GcpComputeRoutersGenerate)
+	registerTable("gcp_compute_vpn_tunnel", gcpComputeHandler.GcpComputeVpnTunnelsGenerate)
+	registerTable("gcp_compute_vpn_gateway", gcpComputeHandler.GcpComputeVpnGatewaysGenerate)
 	// GCP Storage
-	server.RegisterPlugin(table.NewPlugin("gcp_storage_bucket", gcpStorageHandler.GcpStorageBucketColumns(), gcpStorageHandler.GcpStorageBucketGenerate))
+	registerTable("gcp_storage_bucket", gcpStorageHandler.GcpStorageBucketGenerate)
 	// GCP IAM
-	server.RegisterPlugin(table.NewPlugin("gcp_iam_role", gcpiam.GcpIamRolesColumns(), gcpiam.GcpIamRolesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_iam_service_account", gcpiam.GcpIamServiceAccountsColumns(), gcpiam.GcpIamServiceAccountsGenerate))
+	registerTable("gcp_iam_role", gcpiam.GcpIamRolesGenerate)
+	registerTable("gcp_iam_service_account", gcpiam.GcpIamServiceAccountsGenerate)
 	// GCP SQL
-	server.RegisterPlugin(table.NewPlugin("gcp_sql_instance", gcpsql.GcpSQLInstancesColumns(), gcpsql.GcpSQLInstancesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_sql_database", gcpsql.GcpSQLDatabasesColumns(), gcpsql.GcpSQLDatabasesGenerate))
+	registerTable("gcp_sql_instance", gcpsql.GcpSQLInstancesGenerate)
+	registerTable("gcp_sql_database", gcpsql.GcpSQLDatabasesGenerate)
 	// GCP DNS
-	server.RegisterPlugin(table.NewPlugin("gcp_dns_managed_zone", gcpdns.GcpDNSManagedZonesColumns(), gcpdns.GcpDNSManagedZonesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_dns_policy", gcpdns.GcpDNSPoliciesColumns(), gcpdns.GcpDNSPoliciesGenerate))
+	registerTable("gcp_dns_managed_zone", gcpdns.GcpDNSManagedZonesGenerate)
+	registerTable("gcp_dns_policy", gcpdns.GcpDNSPoliciesGenerate)
 	// GCP File
-	server.RegisterPlugin(table.NewPlugin("gcp_file_instance", gcpfile.GcpFileInstancesColumns(), gcpfile.GcpFileInstancesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_file_backup", gcpfile.GcpFileBackupsColumns(), gcpfile.GcpFileBackupsGenerate))
+	registerTable("gcp_file_instance", gcpfile.GcpFileInstancesGenerate)
+	registerTable("gcp_file_backup", gcpfile.GcpFileBackupsGenerate)
 	// GCP Container
-	server.RegisterPlugin(table.NewPlugin("gcp_container_cluster", gcpcontainer.GcpContainerClustersColumns(), gcpcontainer.GcpContainerClustersGenerate))
+	registerTable("gcp_container_cluster", gcpcontainer.GcpContainerClustersGenerate)
 	// GCP Cloud Function
-	server.RegisterPlugin(table.NewPlugin("gcp_cloud_function", gcpfunction.GcpCloudFunctionsColumns(), gcpfunction.GcpCloudFunctionsGenerate))
+	registerTable("gcp_cloud_function", gcpfunction.GcpCloudFunctionsGenerate)
 	// GCP Cloud Run
-	server.RegisterPlugin(table.NewPlugin("gcp_cloud_run_service", gcprun.GcpCloudRunServicesColumns(), gcprun.GcpCloudRunServicesGenerate))
-	server.RegisterPlugin(table.NewPlugin("gcp_cloud_run_revision", gcprun.GcpCloudRunRevisionsColumns(), gcprun.GcpCloudRunRevisionsGenerate))
+	registerTable("gcp_cloud_run_service", gcprun.GcpCloudRunServicesGenerate)
+	registerTable("gcp_cloud_run_revision", gcprun.GcpCloudRunRevisionsGenerate)
 	// Azure Compute
-	server.RegisterPlugin(table.NewPlugin("azure_compute_vm", azurecompute.VirtualMachinesColumns(), azurecompute.VirtualMachinesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_compute_networkinterface", azurecompute.InterfacesColumns(), azurecompute.InterfacesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_compute_virtual_network", azurecompute.VirtualNetworkColumns(), azurecompute.VirtualNetworksGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_compute_subnet", azurecompute.VirtualSubnetColumns(), azurecompute.VirtualSubnetsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_compute_disk", azurecompute.DiskColumns(), azurecompute.DiskGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_compute_security_group", azurecompute.SecurityGroupsColumns(), azurecompute.SecurityGroupsGenerate))
+	registerTable("azure_compute_vm", azurecompute.VirtualMachinesGenerate)
+	registerTable("azure_compute_networkinterface", azurecompute.InterfacesGenerate)
+	registerTable("azure_compute_virtual_network", azurecompute.VirtualNetworksGenerate)
+	registerTable("azure_compute_subnet", azurecompute.VirtualSubnetsGenerate)
+	registerTable("azure_compute_disk", azurecompute.DiskGenerate)
+	registerTable("azure_compute_security_group", azurecompute.SecurityGroupsGenerate)
 	// Azure Cosmosdb
-	server.RegisterPlugin(table.NewPlugin("azure_cosmosdb_account", azurecosmosdb.CosmosdbAccountColumns(), azurecosmosdb.CosmosdbAccountsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_cosmosdb_mongodb", azurecosmosdb.CosmosdbMongodbColumns(), azurecosmosdb.CosmosdbMongodbGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_cosmosdb_sqldb", azurecosmosdb.CosmosdbSqldbsColumns(), azurecosmosdb.CosmosdbSqldbsGenerate))
+	registerTable("azure_cosmosdb_account", azurecosmosdb.CosmosdbAccountsGenerate)
+	registerTable("azure_cosmosdb_mongodb", azurecosmosdb.CosmosdbMongodbGenerate)
+	registerTable("azure_cosmosdb_sqldb", azurecosmosdb.CosmosdbSqldbsGenerate)
 	// Azure Graphrbac
-	server.RegisterPlugin(table.NewPlugin("azure_graphrbac_user", azuregraphrbac.GraphrbacUserColumns(), azuregraphrbac.GraphrbacUsersGenerate))
+	registerTable("azure_graphrbac_user", azuregraphrbac.GraphrbacUsersGenerate)
 	// Azure Postgresql
-	server.RegisterPlugin(table.NewPlugin("azure_postgresql_server", azurepostgresql.PostgresqlServerColumns(), azurepostgresql.PostgresqlServersGenerate))
+	registerTable("azure_postgresql_server", azurepostgresql.PostgresqlServersGenerate)
 	// Azure Storage
-	server.RegisterPlugin(table.NewPlugin("azure_storage_account", azurestorage.StorageAccountColumns(), azurestorage.StorageAccountsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_blob_container", azurestorage.StorageBlobContainerColumns(), azurestorage.StorageBlobContainerGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_diagnostic_setting", azurestorage.StorageDiagnosticSettingColumns(), azurestorage.StorageDiagnosticSettingsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_file_service", azurestorage.StorageFileServiceColumns(), azurestorage.StorageFileServicesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_blob_service", azurestorage.StorageBlobServiceColumns(), azurestorage.StorageBlobServicesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_queue_service", azurestorage.StorageQueueServicesColumns(), azurestorage.StorageQueueServicesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_table_service", azurestorage.StorageTableServicesColumns(), azurestorage.StorageTableServicesGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_storage_blob", azurestorage.StorageBlobColumns(), azurestorage.StorageBlobGenerate))
+	registerTable("azure_storage_account", azurestorage.StorageAccountsGenerate)
+	registerTable("azure_storage_blob_container", azurestorage.StorageBlobContainerGenerate)
+	registerTable("azure_storage_diagnostic_setting", azurestorage.StorageDiagnosticSettingsGenerate)
+	registerTable("azure_storage_file_service", azurestorage.StorageFileServicesGenerate)
+	registerTable("azure_storage_blob_service", azurestorage.StorageBlobServicesGenerate)
+	registerTable("azure_storage_queue_service", azurestorage.StorageQueueServicesGenerate)
+	registerTable("azure_storage_table_service", azurestorage.StorageTableServicesGenerate)
+	registerTable("azure_storage_blob", azurestorage.StorageBlobGenerate)
 	//Azure MySQl
-	server.RegisterPlugin(table.NewPlugin("azure_mysql_server", azuremysql.MysqlServerColumns(), azuremysql.MysqlServerGenerate))
+	registerTable("azure_mysql_server", azuremysql.MysqlServerGenerate)
 	//Azure Monitor
-	server.RegisterPlugin(table.NewPlugin("azure_monitor_diagnostic_settings_resource", azuremonitor.DiagnosticSettingsResourceColumns(), azuremonitor.DiagnosticSettingsResourceGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_monitor_diagnostic_settings_subscription", azuremonitor.DiagnosticSettingsSubscriptionColumns(), azuremonitor.DiagnosticSettingsSubscriptionGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_monitor_activity_log_alert", azuremonitor.MonitorActivityLogAlertColumns(), azuremonitor.MonitorActivityLogAlertsGenerate))
+	registerTable("azure_monitor_diagnostic_settings_resource", azuremonitor.DiagnosticSettingsResourceGenerate)
+	registerTable("azure_monitor_diagnostic_settings_subscription", azuremonitor.DiagnosticSettingsSubscriptionGenerate)
+	registerTable("azure_monitor_activity_log_alert", azuremonitor.MonitorActivityLogAlertsGenerate)
 	// Azure Appservice
-	server.RegisterPlugin(table.NewPlugin("azure_appservice_site", azureappservice.AppserviceSiteColumns(), azureappservice.AppserviceSitesGenerate))
+	registerTable("azure_appservice_site", azureappservice.AppserviceSitesGenerate)
 	// Azure SQL
-	server.RegisterPlugin(table.NewPlugin("azure_sql_server", azuresql.SqlServerCloumns(), azuresql.SqlServerGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_sql_database", azuresql.SqlDatabaseColumns(), azuresql.SqlDatabaseGenerate))
+	registerTable("azure_sql_server", azuresql.SqlServerGenerate)
+	registerTable("azure_sql_database", azuresql.SqlDatabaseGenerate)
 	// Azure Keyvault
-	server.RegisterPlugin(table.NewPlugin("azure_keyvault_vault", azurekeyvault.KeyvaultVaultColumns(), azurekeyvault.KeyvaultVaultsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_keyvault_key", azurekeyvault.KeyvaultKeyColumns(), azurekeyvault.KeyvaultKeysGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_keyvault_secret", azurekeyvault.KeyvaultSecretColumns(), azurekeyvault.KeyvaultSecretsGenerate))
+	registerTable("azure_keyvault_vault", azurekeyvault.KeyvaultVaultsGenerate)
+	registerTable("azure_keyvault_key", azurekeyvault.KeyvaultKeysGenerate)
+	registerTable("azure_keyvault_secret", azurekeyvault.KeyvaultSecretsGenerate)
 	// Azure Network
-	server.RegisterPlugin(table.NewPlugin("azure_network_watcher_flow_log", azurenetwork.AzureNetworkWatcherFlowLogColumns(), azurenetwork.AzureNetworkWatcherFlowLogsGenerate))
-  server.RegisterPlugin(table.NewPlugin("azure_network_load_balancer", azurenetwork.NetworkLoadBalancerColumns(), azurenetwork.NetworkLoadBalancersGenerate))
+	registerTable("azure_network_watcher_flow_log", azurenetwork.AzureNetworkWatcherFlowLogsGenerate)
+  registerTable("azure_network_load_balancer", azurenetwork.NetworkLoadBalancersGenerate)
 	//Azure Securitycenter
-	server.RegisterPlugin(table.NewPlugin("azure_securitycenter_security_contact", azuresecurity.SecuritycenterSecurityContactColumns(), azuresecurity.SecuritycenterSecurityContactsGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_securitycenter_setting", azuresecurity.SecuritycenterSettingColumns(), azuresecurity.SecuritycenterSettingGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_securitycenter_subscription_pricing", azuresecurity.SecuritycenterSubscriptionPricingColumns(), azuresecurity.SecuritycenterSubscriptionPricingGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_securitycenter_auto_provisioning", azuresecurity.SecuritycenterAutoProvisioningColumns(), azuresecurity.SecuritycenterAutoProvisioningGenerate))
+	registerTable("azure_securitycenter_security_contact", azuresecurity.SecuritycenterSecurityContactsGenerate)
+	registerTable("azure_securitycenter_setting", azuresecurity.SecuritycenterSettingGenerate)
+	registerTable("azure_securitycenter_subscription_pricing", azuresecurity.SecuritycenterSubscriptionPricingGenerate)
+	registerTable("azure_securitycenter_auto_provisioning", azuresecurity.SecuritycenterAutoProvisioningGenerate)
 	//Azure Containerservice
-	server.RegisterPlugin(table.NewPlugin("azure_containerservice_managed_cluster", azurecontainerservice.ContainerserviceManagedClustersColumns(), azurecontainerservice.ContainerserviceManagedClustersGenerate))
+	registerTable("azure_containerservice_managed_cluster", azurecontainerservice.ContainerserviceManagedClustersGenerate)
 	// Azure DNS
-	server.RegisterPlugin(table.NewPlugin("azure_dns_record_set", azuredns.DnsRecordSetColumns(), azuredns.DnsRecordSetGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_dns_zone", azuredns.DnsZoneColumns(), azuredns.DnsZoneGenerate)) // Azure Redis
-	server.RegisterPlugin(table.NewPlugin("azure_redis_cache", azureredis.RedisCacheColumns(), azureredis.RedisCacheGenerate))
+	registerTable("azure_dns_record_set", azuredns.DnsRecordSetGenerate)
+	registerTable("azure_dns_zone", azuredns.DnsZoneGenerate) // Azure Redis
+	registerTable("azure_redis_cache", azureredis.RedisCacheGenerate)
 	// Azure Graphrbac
-	server.RegisterPlugin(table.NewPlugin("azure_graphrbac_service_principal", azuregraphrbac.GraphrbacServicePrincipalColumns(), azuregraphrbac.GraphrbacServicePrincipalGenerate))
-	server.RegisterPlugin(table.NewPlugin("azure_graphrbac_group", azuregraphrbac.GraphrbacGroupColunmns(), azuregraphrbac.GraphrbacGroupGenerate))
-	// Event tables
-	registerEventTables(server)
+	registerTable("azure_graphrbac_service_principal", azuregraphrbac.GraphrbacServicePrincipalGenerate)
+	registerTable("azure_graphrbac_group", azuregraphrbac.GraphrbacGroupGenerate)
+
+	registerChildTables()
+}
+
+// GetTableSource returns the description of the objects the rows of given table are flattened from
+func GetTableSource(tableName string) (utilities.TableSource, bool) {
+	for _, tableSources := range []map[string]utilities.TableSource{
+		acm.TableSources, apigateway.TableSources, cloudformation.TableSources, cloudtrail.TableSources, cloudwatch.TableSources, codecommit.TableSources,
+		codedeploy.TableSources, codepipeline.TableSources, config.TableSources, directoryservice.TableSources, ec2.TableSources, ecr.TableSources,
+		ecs.TableSources, efs.TableSources, eks.TableSources, elb.TableSources, elbv2.TableSources, guardduty.TableSources,
+		iam.TableSources, kms.TableSources, organizations.TableSources, rds.TableSources, s3.TableSources, glacier.TableSources,
+		sns.TableSources, sqs.TableSources, workspaces.TableSources, compute.TableSources, storage.TableSources, azureappservice.TableSources,
+		azurecompute.TableSources, azurecontainerservice.TableSources, azurecosmosdb.TableSources, azuredns.TableSources, azuregraphrbac.TableSources, azurekeyvault.TableSources,
+		azuremonitor.TableSources, azuremysql.TableSources, azurenetwork.TableSources, azurepostgresql.TableSources, azureredis.TableSources, azuresecurity.TableSources,
+		azuresql.TableSources, azurestorage.TableSources, gcpcontainer.TableSources, gcpdns.TableSources, gcpfile.TableSources, gcpfunction.TableSources,
+		gcpiam.TableSources, gcprun.TableSources, gcpsql.TableSources,
+	} {
+		if source, found := tableSources[tableName]; found {
+			return source, true
+		}
+	}
+	return utilities.TableSource{}, false
 }
@@ -0,0 +1,173 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+// Output formats of QueryTable
+const (
+	QueryFormatTable = "table"
+	QueryFormatJSON  = "json"
+	QueryFormatCSV   = "csv"
+)
+
+// QueryTable runs the generate function of a table without osquery, and writes the rows in given format.
+// Only given columns are written, or all the columns which are not hidden if none is given.
+// Constraints hold the accepted values by column, like an IN list in the WHERE clause of a query.
+func QueryTable(ctx context.Context, writer io.Writer, tableName string, columns []string, constraints map[string][]string, format string) error {
+	switch format {
+	case QueryFormatTable, QueryFormatJSON, QueryFormatCSV:
+	default:
+		return fmt.Errorf("unknown format %s, expected %s, %s or %s", format, QueryFormatTable, QueryFormatJSON, QueryFormatCSV)
+	}
+	definition, found := GetTableDefinition(tableName)
+	if !found {
+		if getEventTable(tableName) != nil {
+			return fmt.Errorf("%s is an event table, its rows are only collected by the running extension", tableName)
+		}
+		return fmt.Errorf("unknown table %s, or the table is not configured", tableName)
+	}
+
+	columnTypes := make(map[string]table.ColumnType, len(definition.Columns))
+	for _, column := range definition.Columns {
+		columnTypes[column.Name] = column.Type
+	}
+	if len(columns) == 0 {
+		for _, column := range definition.Columns {
+			if column.Op&table.HIDDEN == 0 {
+				columns = append(columns, column.Name)
+			}
+		}
+	}
+	for _, column := range columns {
+		if _, found := columnTypes[column]; !found {
+			return fmt.Errorf("table %s has no column %s", tableName, column)
+		}
+	}
+
+	queryContext := table.QueryContext{Constraints: make(map[string]table.ConstraintList)}
+	for column, values := range constraints {
+		columnType, found := columnTypes[column]
+		if !found {
+			return fmt.Errorf("table %s has no column %s", tableName, column)
+		}
+		constraintList := table.ConstraintList{Affinity: columnType}
+		for _, value := range values {
+			constraintList.Constraints = append(constraintList.Constraints, table.Constraint{
+				Operator:   table.OperatorEquals,
+				Expression: value,
+			})
+		}
+		queryContext.Constraints[column] = constraintList
+	}
+
+	columnsUsed := append([]string{}, columns...)
+	for column := range constraints {
+		columnsUsed = append(columnsUsed, column)
+	}
+	rows, err := definition.Generate(utilities.WithColumnsUsed(ctx, columnsUsed), queryContext)
+	if err != nil {
+		return fmt.Errorf("failed to generate table %s: %w", tableName, err)
+	}
+	// Tables only use the constraints to skip API calls, osquery filters the rows
+	selected := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		if matchQueryConstraints(row, constraints) {
+			selected = append(selected, row)
+		}
+	}
+
+	switch format {
+	case QueryFormatJSON:
+		return writeJSONRows(writer, columns, selected)
+	case QueryFormatCSV:
+		return writeCSVRows(writer, columns, selected)
+	}
+	return writeTableRows(writer, columns, selected)
+}
+
+func matchQueryConstraints(row map[string]string, constraints map[string][]string) bool {
+	for column, values := range constraints {
+		matched := false
+		for _, value := range values {
+			if row[column] == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTableRows(writer io.Writer, columns []string, rows []map[string]string) error {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	separators := make([]string, len(columns))
+	for idx, column := range columns {
+		separators[idx] = strings.Repeat("-", len(column))
+	}
+	fmt.Fprintln(tabWriter, strings.Join(columns, "\t"))
+	fmt.Fprintln(tabWriter, strings.Join(separators, "\t"))
+	// Keep one line per row
+	replacer := strings.NewReplacer("\n", " ", "\t", " ")
+	values := make([]string, len(columns))
+	for _, row := range rows {
+		for idx, column := range columns {
+			values[idx] = replacer.Replace(row[column])
+		}
+		fmt.Fprintln(tabWriter, strings.Join(values, "\t"))
+	}
+	return tabWriter.Flush()
+}
+
+func writeJSONRows(writer io.Writer, columns []string, rows []map[string]string) error {
+	projected := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		projectedRow := make(map[string]string, len(columns))
+		for _, column := range columns {
+			projectedRow[column] = row[column]
+		}
+		projected = append(projected, projectedRow)
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(projected)
+}
+
+func writeCSVRows(writer io.Writer, columns []string, rows []map[string]string) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(columns); err != nil {
+		return err
+	}
+	values := make([]string, len(columns))
+	for _, row := range rows {
+		for idx, column := range columns {
+			values[idx] = row[column]
+		}
+		if err := csvWriter.Write(values); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
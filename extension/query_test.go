@@ -0,0 +1,79 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTable(t *testing.T) {
+	var queryContexts []table.QueryContext
+	tablesOnce.Do(func() {})
+	savedDefinitions := tableDefinitions
+	defer func() { tableDefinitions = savedDefinitions }()
+	tableDefinitions = append(tableDefinitions, TableDefinition{
+		Name: "test_query_table",
+		Columns: []table.ColumnDefinition{
+			table.TextColumn("account_id"),
+			table.TextColumn("name"),
+			table.BigIntColumn("size"),
+			table.IntegerColumn("cache_hit", table.HIDDEN),
+		},
+		Generate: func(ctx context.Context, queryContext table.QueryContext) ([]map[string]string, error) {
+			queryContexts = append(queryContexts, queryContext)
+			return []map[string]string{
+				{"account_id": "111", "name": "first,one", "size": "1", "cache_hit": "0"},
+				{"account_id": "111", "name": "second\none", "size": "2", "cache_hit": "0"},
+				{"account_id": "222", "name": "third", "size": "3", "cache_hit": "0"},
+			}, nil
+		},
+	})
+
+	var output bytes.Buffer
+	assert.Nil(t, QueryTable(context.Background(), &output, "test_query_table", nil, nil, QueryFormatTable))
+	assert.Equal(t, "account_id  name        size\n"+
+		"----------  ----        ----\n"+
+		"111         first,one   1\n"+
+		"111         second one  2\n"+
+		"222         third       3\n", output.String())
+
+	output.Reset()
+	constraints := map[string][]string{"account_id": {"111", "333"}, "size": {"2", "3"}}
+	assert.Nil(t, QueryTable(context.Background(), &output, "test_query_table", []string{"name", "cache_hit"}, constraints, QueryFormatCSV))
+	assert.Equal(t, "name,cache_hit\n\"second\none\",0\n", output.String())
+	assert.Equal(t, table.ConstraintList{
+		Affinity: table.ColumnTypeText,
+		Constraints: []table.Constraint{
+			{Operator: table.OperatorEquals, Expression: "111"},
+			{Operator: table.OperatorEquals, Expression: "333"},
+		},
+	}, queryContexts[1].Constraints["account_id"])
+	assert.Equal(t, table.ColumnType(table.ColumnTypeBigInt), queryContexts[1].Constraints["size"].Affinity)
+
+	output.Reset()
+	assert.Nil(t, QueryTable(context.Background(), &output, "test_query_table", []string{"name"}, map[string][]string{"account_id": {"222"}}, QueryFormatJSON))
+	assert.Equal(t, "[\n  {\n    \"name\": \"third\"\n  }\n]\n", output.String())
+
+	assert.EqualError(t, QueryTable(context.Background(), &output, "test_query_table", []string{"missing"}, nil, QueryFormatJSON),
+		"table test_query_table has no column missing")
+	assert.EqualError(t, QueryTable(context.Background(), &output, "test_query_table", nil, map[string][]string{"missing": {"1"}}, QueryFormatJSON),
+		"table test_query_table has no column missing")
+	assert.EqualError(t, QueryTable(context.Background(), &output, "test_query_table", nil, nil, "xml"),
+		"unknown format xml, expected table, json or csv")
+	assert.EqualError(t, QueryTable(context.Background(), &output, "aws_cloudtrail_events", nil, nil, QueryFormatJSON),
+		"aws_cloudtrail_events is an event table, its rows are only collected by the running extension")
+	assert.EqualError(t, QueryTable(context.Background(), &output, "unknown_table", nil, nil, QueryFormatJSON),
+		"unknown table unknown_table, or the table is not configured")
+}
@@ -0,0 +1,150 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+// reloadMutex serializes the reloads triggered by signals and file changes
+var reloadMutex sync.Mutex
+
+// ReloadConfigurations reads the extension and table configurations again, and replaces the ones in effect
+// if they are valid. The current configurations are kept if any of the files fails to read or validate.
+// Columns of registered tables can not change without restarting the extension, changed columns are only logged.
+func ReloadConfigurations(homeDir string, extConfigFile string) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	extConfig, err := parseExtensionConfiguration(extConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read extension configuration %s: %w", extConfigFile, err)
+	}
+	setGcpProjectIDs(extConfig)
+	if err := extConfig.Validate(); err != nil {
+		return err
+	}
+	tables, errorCount := loadTableConfigurations(homeDir)
+	if errorCount > 0 {
+		return fmt.Errorf("found %d error(s) in table configurations", errorCount)
+	}
+
+	changed := getChangedColumns(utilities.GetTableConfigurations(), tables, registeredTables)
+	if len(changed) > 0 {
+		utilities.GetLogger().WithFields(log.Fields{
+			"tables": strings.Join(changed, ","),
+		}).Warn("columns changed, restart the extension to apply the new columns")
+	}
+
+	utilities.SetConfiguration(extConfig, tables)
+	invalidateTableCaches()
+	triggerAccountDiscovery()
+	utilities.GetLogger().WithFields(log.Fields{
+		"totalTables": len(tables),
+	}).Info("reloaded configuration")
+	return nil
+}
+
+// getChangedColumns returns the sorted names of given tables whose generated columns differ
+func getChangedColumns(oldTables map[string]*utilities.TableConfig, newTables map[string]*utilities.TableConfig, tableNames []string) []string {
+	changed := make([]string, 0)
+	for _, tableName := range tableNames {
+		oldConfig, oldFound := oldTables[tableName]
+		newConfig, newFound := newTables[tableName]
+		if !oldFound || !newFound {
+			if oldFound != newFound {
+				changed = append(changed, tableName)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(oldConfig.GetColumns(), newConfig.GetColumns()) {
+			changed = append(changed, tableName)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// getConfigFingerprint returns a string which changes when any of the configuration files
+// is added, removed or modified
+func getConfigFingerprint(homeDir string, extConfigFile string) string {
+	overlayDirs := GetTableConfigOverlayDirs(homeDir)
+	files := []string{extConfigFile}
+	files = append(files, findTableConfigFiles(homeDir, overlayDirs, func(name string) bool {
+		return name == tableConfigFileName
+	})...)
+	for _, dir := range overlayDirs {
+		files = append(files, findTableConfigFiles(dir, nil, func(name string) bool {
+			return strings.HasSuffix(name, ".json")
+		})...)
+	}
+	var fingerprint strings.Builder
+	for _, file := range files {
+		fingerprint.WriteString(file)
+		if info, err := os.Stat(file); err == nil {
+			fingerprint.WriteString(fmt.Sprintf(":%d:%d", info.ModTime().UnixNano(), info.Size()))
+		}
+		fingerprint.WriteString(";")
+	}
+	return fingerprint.String()
+}
+
+// WatchConfigurations reloads the configurations on the signals received from hangup, and when the configuration files change.
+// Files are checked for changes every interval, a zero interval disables the checks.
+// The caller adds the watcher to wg, it is done once ctx is done.
+func WatchConfigurations(ctx context.Context, wg *sync.WaitGroup, hangup <-chan os.Signal, homeDir string, extConfigFile string, interval time.Duration) {
+	defer wg.Done()
+
+	var ticker <-chan time.Time
+	if interval > 0 {
+		pollTicker := time.NewTicker(interval)
+		defer pollTicker.Stop()
+		ticker = pollTicker.C
+	}
+	fingerprint := getConfigFingerprint(homeDir, extConfigFile)
+
+	reload := func(reason string) {
+		utilities.GetLogger().WithFields(log.Fields{
+			"reason": reason,
+		}).Info("reloading configuration")
+		if err := ReloadConfigurations(homeDir, extConfigFile); err != nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"errString": err.Error(),
+			}).Error("failed to reload configuration, keeping the current one")
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			fingerprint = getConfigFingerprint(homeDir, extConfigFile)
+			reload("SIGHUP")
+		case <-ticker:
+			current := getConfigFingerprint(homeDir, extConfigFile)
+			if current == fingerprint {
+				continue
+			}
+			fingerprint = current
+			reload("file change")
+		}
+	}
+}
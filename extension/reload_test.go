@@ -0,0 +1,79 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+var reloadTableConfigJSON = `{
+	"test_reload_table": {
+		"aws": {"accountIdAttribute": "account_id"},
+		"parsedAttributes": [
+			{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true}
+		]
+	}
+}`
+
+func TestReloadConfigurations(t *testing.T) {
+	savedExtConfiguration := utilities.GetExtConfiguration()
+	savedTables := utilities.GetTableConfigurations()
+	defer utilities.SetConfiguration(savedExtConfiguration, savedTables)
+	t.Setenv(tableConfigDirsEnv, "")
+
+	homeDir := t.TempDir()
+	extConfigFile := filepath.Join(homeDir, "config", "extension_config.json")
+	tableConfigFile := filepath.Join(homeDir, "aws", "test", tableConfigFileName)
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": [{"id": "111"}]}}`)
+	writeTestFile(t, tableConfigFile, reloadTableConfigJSON)
+
+	assert.Nil(t, ReloadConfigurations(homeDir, extConfigFile))
+	assert.Equal(t, "111", utilities.GetExtConfiguration().ExtConfAws.Accounts[0].ID)
+	_, found := utilities.GetTableConfig("test_reload_table")
+	assert.True(t, found)
+
+	// Invalid configurations are not applied
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": [{"id": "222"}]}}`)
+	writeTestFile(t, tableConfigFile, `{"test_reload_table": {"parsedAttributes": [{"sourceName": "Name"}]}}`)
+	assert.NotNil(t, ReloadConfigurations(homeDir, extConfigFile))
+	assert.Equal(t, "111", utilities.GetExtConfiguration().ExtConfAws.Accounts[0].ID)
+
+	writeTestFile(t, tableConfigFile, reloadTableConfigJSON)
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": [{"id": ""}]}}`)
+	assert.NotNil(t, ReloadConfigurations(homeDir, extConfigFile))
+	assert.Equal(t, "111", utilities.GetExtConfiguration().ExtConfAws.Accounts[0].ID)
+
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": [{"id": "222"}]}}`)
+	assert.Nil(t, ReloadConfigurations(homeDir, extConfigFile))
+	assert.Equal(t, "222", utilities.GetExtConfiguration().ExtConfAws.Accounts[0].ID)
+}
+
+func TestGetChangedColumns(t *testing.T) {
+	oldTables, err := utilities.ParseTableConfig([]byte(reloadTableConfigJSON))
+	assert.Nil(t, err)
+	newTables, err := utilities.ParseTableConfig([]byte(`{
+		"test_reload_table": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"parsedAttributes": [
+				{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Size", "targetName": "size", "targetType": "BIGINT", "enabled": true}
+			]
+		}
+	}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{}, getChangedColumns(oldTables, oldTables, []string{"test_reload_table"}))
+	assert.Equal(t, []string{"test_reload_table"}, getChangedColumns(oldTables, newTables, []string{"test_reload_table", "unknown_table"}))
+	assert.Equal(t, []string{"test_reload_table"}, getChangedColumns(oldTables, map[string]*utilities.TableConfig{}, []string{"test_reload_table"}))
+}
@@ -0,0 +1,234 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"encoding/json"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	tableConfigFileName = "table_config.json"
+	// tableConfigDirsEnv lists additional overlay directories, separated by the OS path list separator
+	tableConfigDirsEnv = "CLOUDQUERY_TABLE_CONFIG_DIRS"
+)
+
+// rawTableConfigs holds the top level attributes of table configurations by table name
+type rawTableConfigs map[string]map[string]json.RawMessage
+
+// GetTableConfigOverlayDirs returns the directories holding user table configurations:
+// "config/tables" under the home directory, followed by the directories listed in CLOUDQUERY_TABLE_CONFIG_DIRS.
+func GetTableConfigOverlayDirs(homeDir string) []string {
+	dirs := []string{filepath.Join(homeDir, "config", "tables")}
+	for _, dir := range filepath.SplitList(os.Getenv(tableConfigDirsEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// ReadTableConfigurations reads all table_config.json files found under the home directory,
+// then the JSON files of the overlay directories.
+// Overlay configurations add new tables, or replace the top level attributes (e.g. "cache" or
+// "parsedAttributes") of the shipped configuration of a table.
+// Files and tables which fail to parse are skipped.
+func ReadTableConfigurations(homeDir string) {
+	tables, _ := loadTableConfigurations(homeDir)
+	utilities.SetTableConfigurations(tables)
+}
+
+// loadTableConfigurations reads the table configurations, and returns them along with the number of errors found
+func loadTableConfigurations(homeDir string) (map[string]*utilities.TableConfig, int) {
+	errorCount := 0
+	overlayDirs := GetTableConfigOverlayDirs(homeDir)
+	configs := rawTableConfigs{}
+	sources := map[string]string{}
+
+	shippedFiles := findTableConfigFiles(homeDir, overlayDirs, func(name string) bool {
+		return name == tableConfigFileName
+	})
+	for _, filePath := range shippedFiles {
+		fileConfigs, err := readRawTableConfigs(filePath)
+		if err != nil {
+			errorCount++
+		}
+		for tableName, config := range fileConfigs {
+			if source, found := sources[tableName]; found {
+				utilities.GetLogger().WithFields(log.Fields{
+					"tableName": tableName,
+					"fileName":  filePath,
+					"previous":  source,
+				}).Warn("duplicate table configuration, replacing previous one")
+			}
+			configs[tableName] = config
+			sources[tableName] = filePath
+		}
+	}
+
+	for _, dir := range overlayDirs {
+		overlayFiles := findTableConfigFiles(dir, nil, func(name string) bool {
+			return strings.HasSuffix(name, ".json")
+		})
+		for _, filePath := range overlayFiles {
+			fileConfigs, err := readRawTableConfigs(filePath)
+			if err != nil {
+				errorCount++
+			}
+			for tableName, config := range fileConfigs {
+				shipped, found := configs[tableName]
+				if !found {
+					configs[tableName] = config
+					sources[tableName] = filePath
+					continue
+				}
+				utilities.GetLogger().WithFields(log.Fields{
+					"tableName": tableName,
+					"fileName":  filePath,
+					"previous":  sources[tableName],
+				}).Info("overriding table configuration")
+				for key, value := range config {
+					shipped[key] = value
+				}
+				sources[tableName] = filePath
+			}
+		}
+	}
+
+	tables := make(map[string]*utilities.TableConfig, len(configs))
+	tableNames := make([]string, 0, len(configs))
+	for tableName := range configs {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		var parsed map[string]*utilities.TableConfig
+		jsonEncoded, err := json.Marshal(rawTableConfigs{tableName: configs[tableName]})
+		if err == nil {
+			parsed, err = utilities.ParseTableConfig(jsonEncoded)
+		}
+		if err != nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"tableName": tableName,
+				"fileName":  sources[tableName],
+				"errString": err.Error(),
+			}).Error("failed to parse table configuration")
+			errorCount++
+			continue
+		}
+		tables[tableName] = parsed[tableName]
+	}
+	utilities.GetLogger().WithFields(log.Fields{
+		"totalFiles":  len(shippedFiles),
+		"totalTables": len(tables),
+	}).Info("read all config files")
+	return tables, errorCount
+}
+
+// findTableConfigFiles returns the sorted paths of the files under given directory accepted by match.
+// Directories in skipDirs are not visited.
+func findTableConfigFiles(dir string, skipDirs []string, match func(name string) bool) []string {
+	files := make([]string, 0)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files
+	}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			utilities.GetLogger().WithFields(log.Fields{
+				"fileName":  path,
+				"errString": err.Error(),
+			}).Error("failed to read config directory")
+			return nil
+		}
+		if entry.IsDir() {
+			for _, skipDir := range skipDirs {
+				if path != dir && filepath.Clean(path) == filepath.Clean(skipDir) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if match(entry.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"fileName":  dir,
+			"errString": err.Error(),
+		}).Error("failed to read config directory")
+	}
+	sort.Strings(files)
+	return files
+}
+
+func readRawTableConfigs(filePath string) (rawTableConfigs, error) {
+	utilities.GetLogger().WithFields(log.Fields{
+		"fileName": filePath,
+	}).Info("reading config file")
+	jsonEncoded, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"fileName":  filePath,
+			"errString": err.Error(),
+		}).Error("failed to read config file")
+		return nil, err
+	}
+	configs := rawTableConfigs{}
+	if err := json.Unmarshal(jsonEncoded, &configs); err != nil {
+		utilities.GetLogger().WithFields(log.Fields{
+			"fileName":  filePath,
+			"errString": err.Error(),
+		}).Error("failed to parse config file")
+		return nil, err
+	}
+	return configs, nil
+}
+
+// reportTableConfigurations logs the registered tables without configuration,
+// and the configurations which do not belong to any registered table
+func reportTableConfigurations(registered []string, unconfigured []string) {
+	registeredSet := make(map[string]bool, len(registered))
+	for _, tableName := range registered {
+		registeredSet[tableName] = true
+	}
+	unused := make([]string, 0)
+	for tableName := range utilities.GetTableConfigurations() {
+		if !registeredSet[tableName] {
+			unused = append(unused, tableName)
+		}
+	}
+	sort.Strings(unused)
+	sort.Strings(unconfigured)
+
+	if len(unconfigured) > 0 {
+		utilities.GetLogger().WithFields(log.Fields{
+			"tables": strings.Join(unconfigured, ","),
+		}).Warn("tables without configuration were not registered")
+	}
+	if len(unused) > 0 {
+		utilities.GetLogger().WithFields(log.Fields{
+			"tables": strings.Join(unused, ","),
+		}).Warn("table configurations do not match any table")
+	}
+	utilities.GetLogger().WithFields(log.Fields{
+		"registeredTables":   len(registered),
+		"unconfiguredTables": len(unconfigured),
+	}).Info("registered tables")
+}
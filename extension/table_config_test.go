@@ -0,0 +1,81 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Uptycs/cloudquery/utilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestReadTableConfigurations(t *testing.T) {
+	homeDir := t.TempDir()
+	overlayDir := t.TempDir()
+	t.Setenv(tableConfigDirsEnv, overlayDir)
+
+	writeTestFile(t, filepath.Join(homeDir, "aws", "ec2", tableConfigFileName), `{
+		"test_discovered_table": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"parsedAttributes": [
+				{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true}
+			]
+		}
+	}`)
+	writeTestFile(t, filepath.Join(homeDir, "gcp", "nested", "deeper", tableConfigFileName), `{
+		"test_nested_table": {
+			"gcp": {"projectIdAttribute": "project_id"},
+			"parsedAttributes": []
+		}
+	}`)
+	// Only table_config.json files are read from the home directory
+	writeTestFile(t, filepath.Join(homeDir, "config", "extension_config.json"), `{"aws": {}}`)
+	// Overlays replace top level attributes of shipped configurations, and add new tables
+	writeTestFile(t, filepath.Join(homeDir, "config", "tables", "cache.json"), `{
+		"test_discovered_table": {
+			"cache": {"ttl": 300}
+		}
+	}`)
+	writeTestFile(t, filepath.Join(overlayDir, "custom.json"), `{
+		"test_overlay_table": {
+			"azure": {"subscriptionIdAttribute": "subscription_id"},
+			"parsedAttributes": []
+		}
+	}`)
+
+	ReadTableConfigurations(homeDir)
+
+	tableConfig, found := utilities.GetTableConfig("test_discovered_table")
+	assert.True(t, found)
+	assert.Equal(t, 300, tableConfig.Cache.TTL)
+	assert.Equal(t, "account_id", tableConfig.Aws.AccountIDAttribute)
+	assert.Equal(t, 1, len(tableConfig.ParsedAttributes))
+
+	_, found = utilities.GetTableConfig("test_nested_table")
+	assert.True(t, found)
+	_, found = utilities.GetTableConfig("test_overlay_table")
+	assert.True(t, found)
+	_, found = utilities.GetTableConfig("aws")
+	assert.False(t, found)
+}
+
+func TestGetTableConfigOverlayDirs(t *testing.T) {
+	t.Setenv(tableConfigDirsEnv, "/etc/one"+string(os.PathListSeparator)+"/etc/two")
+	dirs := GetTableConfigOverlayDirs("/opt/cloudquery")
+	assert.Equal(t, []string{filepath.Join("/opt/cloudquery", "config", "tables"), "/etc/one", "/etc/two"}, dirs)
+}
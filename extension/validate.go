@@ -0,0 +1,532 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/Uptycs/cloudquery/utilities"
+)
+
+const (
+	severityError   = "ERROR"
+	severityWarning = "WARNING"
+)
+
+// ValidationIssue is a problem found in a configuration file
+type ValidationIssue struct {
+	Severity string
+	// Location is the file, and the table if any, the issue was found in
+	Location string
+	Message  string
+}
+
+// ValidationReport holds the issues found by ValidateConfigurations
+type ValidationReport struct {
+	Issues []ValidationIssue
+}
+
+func (report *ValidationReport) addIssue(severity string, location string, format string, args ...interface{}) {
+	report.Issues = append(report.Issues, ValidationIssue{
+		Severity: severity,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (report *ValidationReport) addError(location string, format string, args ...interface{}) {
+	report.addIssue(severityError, location, format, args...)
+}
+
+func (report *ValidationReport) addWarning(location string, format string, args ...interface{}) {
+	report.addIssue(severityWarning, location, format, args...)
+}
+
+// ErrorCount returns the number of errors in the report
+func (report *ValidationReport) ErrorCount() int {
+	count := 0
+	for _, issue := range report.Issues {
+		if issue.Severity == severityError {
+			count++
+		}
+	}
+	return count
+}
+
+// Print writes the issues, followed by a summary line
+func (report *ValidationReport) Print(writer io.Writer) {
+	for _, issue := range report.Issues {
+		fmt.Fprintf(writer, "%-7s %s: %s\n", issue.Severity, issue.Location, issue.Message)
+	}
+	errorCount := report.ErrorCount()
+	fmt.Fprintf(writer, "found %d error(s) and %d warning(s)\n", errorCount, len(report.Issues)-errorCount)
+}
+
+// ValidateConfigurations checks the extension configuration, the credential files it refers to,
+// and the table configurations read from the home and overlay directories.
+// Table configurations are checked against the objects their tables are flattened from,
+// and against the columns of the event tables.
+func ValidateConfigurations(homeDir string, extConfigFile string) *ValidationReport {
+	report := &ValidationReport{Issues: make([]ValidationIssue, 0)}
+	validateExtensionConfiguration(report, extConfigFile)
+
+	overlayDirs := GetTableConfigOverlayDirs(homeDir)
+	files := findTableConfigFiles(homeDir, overlayDirs, func(name string) bool {
+		return name == tableConfigFileName
+	})
+	for _, dir := range overlayDirs {
+		files = append(files, findTableConfigFiles(dir, nil, func(name string) bool {
+			return strings.HasSuffix(name, ".json")
+		})...)
+	}
+	if len(files) == 0 {
+		report.addError(homeDir, "no table configuration found")
+	}
+	for _, filePath := range files {
+		validateTableConfigFile(report, filePath)
+	}
+
+	tables, _ := loadTableConfigurations(homeDir)
+	tableNames := make([]string, 0, len(tables))
+	for tableName := range tables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		validateTableConfig(report, tableName, tables[tableName], tables)
+	}
+	return report
+}
+
+// decodeStrict decodes JSON, rejecting the attributes unknown to value
+func decodeStrict(jsonEncoded []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(jsonEncoded))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(value)
+}
+
+func validateExtensionConfiguration(report *ValidationReport, extConfigFile string) {
+	jsonEncoded, err := ioutil.ReadFile(extConfigFile)
+	if err != nil {
+		report.addError(extConfigFile, "failed to read extension configuration: %s", err)
+		return
+	}
+	extConfig := utilities.ExtensionConfiguration{}
+	if err := decodeStrict(jsonEncoded, &extConfig); err != nil {
+		report.addError(extConfigFile, "invalid extension configuration: %s", err)
+		// Keep checking the known settings
+		extConfig = utilities.ExtensionConfiguration{}
+		if err := json.Unmarshal(jsonEncoded, &extConfig); err != nil {
+			return
+		}
+	}
+
+	for _, account := range extConfig.ExtConfAws.Accounts {
+		validateAwsCredentialFiles(report, extConfigFile, account)
+	}
+	if org := extConfig.ExtConfAws.Organization; org != nil {
+		validateAwsCredentialFiles(report, extConfigFile, utilities.ExtensionConfigurationAwsAccount{
+			ID:                   "organization",
+			CredentialFile:       org.CredentialFile,
+			ConfigFile:           org.ConfigFile,
+			ProfileName:          org.ProfileName,
+			WebIdentityTokenFile: org.WebIdentityTokenFile,
+		})
+	}
+	gcpAccounts := make([]utilities.ExtensionConfigurationGcpAccount, 0, len(extConfig.ExtConfGcp.Accounts))
+	for _, account := range extConfig.ExtConfGcp.Accounts {
+		if account.KeyFile != "" {
+			// The project of accounts with a key file is read from the key file
+			account.ProjectID = validateGcpKeyFile(report, extConfigFile, account.KeyFile)
+		}
+		gcpAccounts = append(gcpAccounts, account)
+	}
+	extConfig.ExtConfGcp.Accounts = gcpAccounts
+	for _, discovery := range extConfig.ExtConfGcp.Discovery {
+		if discovery.KeyFile != "" {
+			validateGcpKeyFile(report, extConfigFile, discovery.KeyFile)
+		}
+	}
+	for _, account := range extConfig.ExtConfAzure.Accounts {
+		validateAzureCredentialFiles(report, extConfigFile, account)
+	}
+	for _, tenant := range extConfig.ExtConfAzure.Tenants {
+		validateAzureCredentialFiles(report, extConfigFile, utilities.ExtensionConfigurationAzureAccount{
+			SubscriptionID:                         "tenant " + tenant.TenantID,
+			AuthFile:                               tenant.AuthFile,
+			ExtensionConfigurationAzureCredentials: tenant.ExtensionConfigurationAzureCredentials,
+		})
+	}
+
+	if err := extConfig.Validate(); err != nil {
+		report.addError(extConfigFile, "%s", err)
+	}
+}
+
+// validateAwsCredentialFiles checks the credential and config files of an account exist, and one of them holds the profile of the account,
+// and that its web identity token file exists
+func validateAwsCredentialFiles(report *ValidationReport, extConfigFile string, account utilities.ExtensionConfigurationAwsAccount) {
+	if account.WebIdentityTokenFile != "" {
+		if _, err := os.Stat(account.WebIdentityTokenFile); err != nil {
+			report.addError(extConfigFile, "aws account %s: failed to read web identity token file: %s", account.ID, err)
+		}
+	}
+	files := []struct {
+		kind string
+		path string
+	}{{"credential file", account.CredentialFile}, {"config file", account.ConfigFile}}
+	found := account.ProfileName == ""
+	checked := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		hasProfile, err := hasAwsProfile(file.path, account.ProfileName)
+		if err != nil {
+			report.addError(extConfigFile, "aws account %s: failed to read %s: %s", account.ID, file.kind, err)
+			return
+		}
+		found = found || hasProfile
+		checked = append(checked, file.kind+" "+file.path)
+	}
+	if !found && len(checked) > 0 {
+		report.addError(extConfigFile, "aws account %s: profile %s not found in %s",
+			account.ID, account.ProfileName, strings.Join(checked, " or "))
+	}
+}
+
+// hasAwsProfile returns true if given credential or config file holds given profile
+func hasAwsProfile(path string, profileName string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if profileName == "" {
+		return false, nil
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+		section := strings.TrimSpace(strings.TrimPrefix(line[1:len(line)-1], "profile "))
+		if section == profileName {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// validateGcpKeyFile checks the key file of an account is a service account key, and returns its project
+func validateGcpKeyFile(report *ValidationReport, extConfigFile string, keyFile string) string {
+	jsonEncoded, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		report.addError(extConfigFile, "gcp account: failed to read key file: %s", err)
+		return ""
+	}
+	key := struct {
+		ProjectID string `json:"project_id"`
+	}{}
+	if err := json.Unmarshal(jsonEncoded, &key); err != nil {
+		report.addError(extConfigFile, "gcp account: failed to parse key file %s: %s", keyFile, err)
+		return ""
+	}
+	if key.ProjectID == "" {
+		report.addError(extConfigFile, "gcp account: key file %s is missing project_id", keyFile)
+	}
+	return key.ProjectID
+}
+
+// validateAzureCredentialFiles checks the files of the credentials of an account exist, and its auth file holds the client credentials
+func validateAzureCredentialFiles(report *ValidationReport, extConfigFile string, account utilities.ExtensionConfigurationAzureAccount) {
+	switch account.GetAuthType() {
+	case utilities.AzureAuthFile:
+		if account.AuthFile != "" {
+			validateAzureAuthFile(report, extConfigFile, account)
+		}
+	case utilities.AzureAuthClientCertificate:
+		if account.CertificatePath == "" {
+			return
+		}
+		if _, err := os.Stat(account.CertificatePath); err != nil {
+			report.addError(extConfigFile, "azure account %s: failed to read certificate: %s", account.SubscriptionID, err)
+		}
+	case utilities.AzureAuthWorkloadIdentity:
+		if account.FederatedTokenFile == "" {
+			return
+		}
+		if _, err := os.Stat(account.FederatedTokenFile); err != nil {
+			report.addError(extConfigFile, "azure account %s: failed to read federated token file: %s", account.SubscriptionID, err)
+		}
+	}
+}
+
+// validateAzureAuthFile checks the auth file of an account holds the client credentials
+func validateAzureAuthFile(report *ValidationReport, extConfigFile string, account utilities.ExtensionConfigurationAzureAccount) {
+	jsonEncoded, err := ioutil.ReadFile(account.AuthFile)
+	if err != nil {
+		report.addError(extConfigFile, "azure account %s: failed to read auth file: %s", account.SubscriptionID, err)
+		return
+	}
+	authFile := struct {
+		ClientID string `json:"clientId"`
+		TenantID string `json:"tenantId"`
+	}{}
+	if err := json.Unmarshal(jsonEncoded, &authFile); err != nil {
+		report.addError(extConfigFile, "azure account %s: failed to parse auth file %s: %s", account.SubscriptionID, account.AuthFile, err)
+		return
+	}
+	if authFile.ClientID == "" || authFile.TenantID == "" {
+		report.addError(extConfigFile, "azure account %s: auth file %s is missing clientId or tenantId", account.SubscriptionID, account.AuthFile)
+	}
+}
+
+// validateTableConfigFile checks the attributes of the table configurations of a file are all known
+func validateTableConfigFile(report *ValidationReport, filePath string) {
+	jsonEncoded, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		report.addError(filePath, "failed to read table configuration: %s", err)
+		return
+	}
+	configs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(jsonEncoded, &configs); err != nil {
+		report.addError(filePath, "failed to parse table configuration: %s", err)
+		return
+	}
+	tableNames := make([]string, 0, len(configs))
+	for tableName := range configs {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		if err := decodeStrict(configs[tableName], &utilities.TableConfig{}); err != nil {
+			report.addError(filePath+": "+tableName, "invalid table configuration: %s", err)
+		}
+	}
+}
+
+// validateTableConfig checks the attributes and columns of a table configuration, and the parent of a child table among tables
+func validateTableConfig(report *ValidationReport, tableName string, tableConfig *utilities.TableConfig, tables map[string]*utilities.TableConfig) {
+	var sourceNames *utilities.SourceNames
+	var eventColumns map[string]string
+	if source, found := GetTableSource(tableName); found {
+		sourceNames = source.GetSourceNames()
+	} else if eventTable := getEventTable(tableName); eventTable != nil {
+		eventColumns = make(map[string]string)
+		for _, column := range eventTable.GetColumns() {
+			eventColumns[column.Name] = string(column.Type)
+		}
+	} else if tableConfig.Parent == nil {
+		report.addWarning(tableName, "no table is named %s, the configuration is not used", tableName)
+	}
+
+	if tableConfig.Timeout < 0 {
+		report.addError(tableName, "timeout %d is negative", tableConfig.Timeout)
+	}
+	if err := tableConfig.Limits.Validate(); err != nil {
+		report.addError(tableName, "%s", err.Error())
+	}
+	if tableConfig.Parent != nil {
+		validateParentTable(report, tableName, tableConfig.Parent, tables)
+	}
+
+	sourceNamesSeen := make(map[string]bool)
+	targetNamesSeen := make(map[string]bool)
+	for _, attr := range tableConfig.ParsedAttributes {
+		if sourceNamesSeen[attr.SourceName] {
+			report.addError(tableName, "sourceName %s is mapped more than once", attr.SourceName)
+		}
+		sourceNamesSeen[attr.SourceName] = true
+		switch attr.Flatten {
+		case "", utilities.FlattenJSON, utilities.FlattenChild:
+		default:
+			report.addError(tableName, "sourceName %s has unknown flatten %s, expected %s or %s",
+				attr.SourceName, attr.Flatten, utilities.FlattenJSON, utilities.FlattenChild)
+		}
+		if !attr.Enabled {
+			continue
+		}
+		if targetNamesSeen[attr.TargetName] {
+			report.addError(tableName, "targetName %s is used by more than one enabled attribute", attr.TargetName)
+		}
+		targetNamesSeen[attr.TargetName] = true
+
+		targetType := strings.ToUpper(attr.TargetType)
+		switch targetType {
+		case string(table.ColumnTypeText), table.ColumnTypeInteger, table.ColumnTypeBigInt, table.ColumnTypeDouble:
+		default:
+			report.addError(tableName, "targetName %s has unknown targetType %s", attr.TargetName, attr.TargetType)
+			continue
+		}
+		if tableConfig.Cache.TTL > 0 && (attr.TargetName == cacheHitColumn || attr.TargetName == cacheAgeColumn) {
+			report.addError(tableName, "targetName %s conflicts with the cache columns", attr.TargetName)
+		}
+
+		if sourceNames != nil {
+			if !sourceNames.IsReachable(attr.SourceName) {
+				report.addError(tableName, "sourceName %s is not an attribute of the table objects", attr.SourceName)
+				continue
+			}
+			if kind, found := sourceNames.GetKind(attr.SourceName); found && !isKindCompatible(kind, targetType) {
+				report.addError(tableName, "targetName %s has targetType %s, but sourceName %s holds %s values",
+					attr.TargetName, attr.TargetType, attr.SourceName, kind)
+			}
+		}
+		if eventColumns != nil {
+			columnType, found := eventColumns[attr.TargetName]
+			if !found {
+				report.addError(tableName, "targetName %s is not a column of the table", attr.TargetName)
+			} else if columnType != targetType {
+				report.addError(tableName, "targetName %s has targetType %s, but the column is %s", attr.TargetName, attr.TargetType, columnType)
+			}
+		}
+	}
+	if sourceNames != nil {
+		validateFlattenedLists(report, tableName, tableConfig, sourceNames)
+	}
+}
+
+// validateFlattenedLists checks the lists flattened into rows are not siblings, whose rows would be multiplied.
+// Lists are siblings if they are nested in the same element of a flattened list, or in the table objects.
+func validateFlattenedLists(report *ValidationReport, tableName string, tableConfig *utilities.TableConfig, sourceNames *utilities.SourceNames) {
+	keptNames := make([]string, 0)
+	for _, attr := range tableConfig.ParsedAttributes {
+		if attr.Flatten != "" {
+			keptNames = append(keptNames, attr.SourceName)
+		}
+	}
+	isKept := func(name string) bool {
+		for _, keptName := range keptNames {
+			if name == keptName || strings.HasPrefix(name, keptName+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The enabled attributes and their ancestors which are lists, and not kept as JSON
+	lists := make(map[string]bool)
+	for _, attr := range tableConfig.ParsedAttributes {
+		if !attr.Enabled {
+			continue
+		}
+		for name := attr.SourceName; name != ""; {
+			if sourceNames.IsList(name) && !isKept(name) {
+				lists[name] = true
+			}
+			idx := strings.LastIndex(name, "_")
+			if idx < 0 {
+				break
+			}
+			name = name[:idx]
+		}
+	}
+	listNames := make([]string, 0, len(lists))
+	for name := range lists {
+		listNames = append(listNames, name)
+	}
+	sort.Strings(listNames)
+
+	// Lists grouped by the innermost list they are nested in
+	siblings := make(map[string][]string)
+	for _, name := range listNames {
+		parent := ""
+		for _, other := range listNames {
+			if strings.HasPrefix(name, other+"_") && len(other) > len(parent) {
+				parent = other
+			}
+		}
+		siblings[parent] = append(siblings[parent], name)
+	}
+	for _, name := range append([]string{""}, listNames...) {
+		if len(siblings[name]) > 1 {
+			report.addWarning(tableName, "sourceNames %s are sibling lists, whose rows are multiplied, set flatten to %s or %s on all but one of them",
+				strings.Join(siblings[name], ", "), utilities.FlattenJSON, utilities.FlattenChild)
+		}
+	}
+}
+
+// validateParentTable checks the parent of a child table is configured, and holds the column and key columns of the child
+func validateParentTable(report *ValidationReport, tableName string, parent *utilities.ParentTableConfig, tables map[string]*utilities.TableConfig) {
+	parentConfig, found := tables[parent.Table]
+	if !found {
+		report.addError(tableName, "parent table %s is not configured", parent.Table)
+		return
+	}
+	if parentConfig.Parent != nil {
+		report.addError(tableName, "parent table %s is a child table", parent.Table)
+	}
+	columnFound := false
+	for _, attr := range parentConfig.ParsedAttributes {
+		if attr.Enabled && attr.TargetName == parent.Column {
+			columnFound = true
+			if attr.Flatten != utilities.FlattenJSON && attr.Flatten != utilities.FlattenChild {
+				report.addError(tableName, "parent column %s of table %s is not flattened as %s or %s",
+					parent.Column, parent.Table, utilities.FlattenJSON, utilities.FlattenChild)
+			}
+		}
+	}
+	if !columnFound {
+		report.addError(tableName, "parent column %s is not a column of table %s", parent.Column, parent.Table)
+	}
+	parentColumns := make(map[string]bool)
+	for _, column := range parentConfig.GetColumns() {
+		parentColumns[column.Name] = true
+	}
+	for _, column := range parent.KeyColumns {
+		if !parentColumns[column] {
+			report.addError(tableName, "key column %s is not a column of table %s", column, parent.Table)
+		}
+	}
+}
+
+func getEventTable(tableName string) EventTable {
+	for _, eventTable := range GetEventTables() {
+		if eventTable.GetName() == tableName {
+			return eventTable
+		}
+	}
+	return nil
+}
+
+// isKindCompatible returns true if values of given kind can be converted to targetType
+func isKindCompatible(kind reflect.Kind, targetType string) bool {
+	switch targetType {
+	case table.ColumnTypeInteger, table.ColumnTypeBigInt:
+		switch kind {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Interface:
+			return true
+		}
+		return false
+	case table.ColumnTypeDouble:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Interface:
+			return true
+		}
+		return false
+	}
+	return true
+}
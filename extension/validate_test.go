@@ -0,0 +1,186 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package extension
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getIssueMessages(report *ValidationReport) []string {
+	messages := make([]string, 0)
+	for _, issue := range report.Issues {
+		messages = append(messages, issue.Severity+" "+issue.Message)
+	}
+	return messages
+}
+
+func TestValidateConfigurations(t *testing.T) {
+	t.Setenv(tableConfigDirsEnv, "")
+	homeDir := t.TempDir()
+	extConfigFile := filepath.Join(homeDir, "config", "extension_config.json")
+	credentialFile := filepath.Join(homeDir, "config", "credentials")
+	keyFile := filepath.Join(homeDir, "config", "key.json")
+	writeTestFile(t, credentialFile, "[default]\naws_access_key_id = x\n\n[profile audit]\naws_access_key_id = y\n")
+	writeTestFile(t, keyFile, `{"type": "service_account"}`)
+	writeTestFile(t, extConfigFile, `{
+		"aws": {"accounts": [
+			{"id": "111", "credentialFile": "`+credentialFile+`", "profileName": "audit"},
+			{"id": "222", "credentialFile": "`+credentialFile+`", "profileName": "missing"},
+			{"id": "333", "credentialFile": "/nonexistent/credentials"}
+		]},
+		"gcp": {"accounts": [{"keyFile": "`+keyFile+`"}]},
+		"azure": {"accounts": [{"subscriptionId": "sub"}], "maxConcurency": 2}
+	}`)
+	writeTestFile(t, filepath.Join(homeDir, "aws", "acm", tableConfigFileName), `{
+		"aws_acm_certificate": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"cache": {"ttl": 60},
+			"parsedAttributes": [
+				{"sourceName": "CertificateSummaryList_CertificateArn", "targetName": "certificate_arn", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "CertificateSummaryList_DomainName", "targetName": "domain_name", "targetType": "INTEGER", "enabled": true},
+				{"sourceName": "CertificateSummaryList_Missing", "targetName": "missing", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "CertificateSummaryList_Disabled", "targetName": "disabled", "targetType": "TEXT", "enabled": false},
+				{"sourceName": "NextToken", "targetName": "certificate_arn", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "ResultMetadata", "targetName": "cache_hit", "targetType": "TEXT", "enabled": true}
+			]
+		},
+		"aws_cloudtrail_events": {
+			"parsedAttributes": [
+				{"sourceName": "eventName", "targetName": "event_name", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "eventTime", "targetName": "event_time", "targetType": "BIGINT", "enabled": true},
+				{"sourceName": "unknown", "targetName": "unknown_column", "targetType": "TEXT", "enabled": true}
+			]
+		},
+		"aws_unknown_table": {
+			"parsedAttribute": [],
+			"parsedAttributes": [
+				{"sourceName": "Name", "targetName": "name", "targetType": "STRING", "enabled": true}
+			]
+		}
+	}`)
+
+	report := ValidateConfigurations(homeDir, extConfigFile)
+	assert.Equal(t, []string{
+		`ERROR invalid extension configuration: json: unknown field "maxConcurency"`,
+		"ERROR aws account 222: profile missing not found in credential file " + credentialFile,
+		"ERROR aws account 333: failed to read credential file: open /nonexistent/credentials: no such file or directory",
+		"ERROR gcp account: key file " + keyFile + " is missing project_id",
+		"ERROR invalid extension configuration: gcp account 0 is missing projectId",
+		`ERROR invalid table configuration: json: unknown field "parsedAttribute"`,
+		"ERROR targetName domain_name has targetType INTEGER, but sourceName CertificateSummaryList_DomainName holds string values",
+		"ERROR sourceName CertificateSummaryList_Missing is not an attribute of the table objects",
+		"ERROR targetName certificate_arn is used by more than one enabled attribute",
+		"ERROR targetName cache_hit conflicts with the cache columns",
+		"ERROR targetName event_time has targetType BIGINT, but the column is TEXT",
+		"ERROR targetName unknown_column is not a column of the table",
+		"WARNING no table is named aws_unknown_table, the configuration is not used",
+		"ERROR targetName name has unknown targetType STRING",
+	}, getIssueMessages(report))
+	assert.Equal(t, 13, report.ErrorCount())
+
+	var output bytes.Buffer
+	report.Print(&output)
+	assert.Contains(t, output.String(), "ERROR   aws_acm_certificate: sourceName CertificateSummaryList_Missing is not an attribute of the table objects\n")
+	assert.Contains(t, output.String(), "found 13 error(s) and 1 warning(s)\n")
+
+	report = ValidateConfigurations(t.TempDir(), extConfigFile+".missing")
+	assert.Equal(t, 2, report.ErrorCount())
+}
+
+func TestValidateChildTables(t *testing.T) {
+	t.Setenv(tableConfigDirsEnv, "")
+	homeDir := t.TempDir()
+	extConfigFile := filepath.Join(homeDir, "config", "extension_config.json")
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": []}, "gcp": {"accounts": []}, "azure": {"accounts": []}}`)
+	writeTestFile(t, filepath.Join(homeDir, "aws", "test", tableConfigFileName), `{
+		"aws_test_parent": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"parsedAttributes": [
+				{"sourceName": "Id", "targetName": "id", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Items", "targetName": "items", "targetType": "TEXT", "enabled": true, "flatten": "child"},
+				{"sourceName": "Tags", "targetName": "tags", "targetType": "TEXT", "enabled": true, "flatten": "xml"}
+			]
+		},
+		"aws_test_parent_item": {
+			"parent": {"table": "aws_test_parent", "column": "items", "keyColumns": ["account_id", "id", "name"]},
+			"parsedAttributes": [
+				{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true}
+			]
+		},
+		"aws_test_parent_tag": {
+			"parent": {"table": "aws_test_parent", "column": "tags", "keyColumns": ["id"]},
+			"parsedAttributes": []
+		},
+		"aws_test_orphan": {
+			"parent": {"table": "aws_test_missing", "column": "items", "keyColumns": ["id"]},
+			"parsedAttributes": []
+		}
+	}`)
+
+	report := ValidateConfigurations(homeDir, extConfigFile)
+	assert.Equal(t, []string{
+		"ERROR parent table aws_test_missing is not configured",
+		"WARNING no table is named aws_test_parent, the configuration is not used",
+		"ERROR sourceName Tags has unknown flatten xml, expected json or child",
+		"ERROR key column name is not a column of table aws_test_parent",
+		"ERROR parent column tags of table aws_test_parent is not flattened as json or child",
+	}, getIssueMessages(report))
+}
+
+func TestValidateSiblingLists(t *testing.T) {
+	t.Setenv(tableConfigDirsEnv, "")
+	homeDir := t.TempDir()
+	extConfigFile := filepath.Join(homeDir, "config", "extension_config.json")
+	writeTestFile(t, extConfigFile, `{"aws": {"accounts": []}, "gcp": {"accounts": []}, "azure": {"accounts": []}}`)
+	writeTestFile(t, filepath.Join(homeDir, "aws", "ec2", tableConfigFileName), `{
+		"aws_ec2_vpc": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"parsedAttributes": [
+				{"sourceName": "Vpcs_VpcId", "targetName": "vpc_id", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Vpcs_CidrBlockAssociationSet_CidrBlock", "targetName": "cidr_block", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Vpcs_Ipv6CidrBlockAssociationSet", "targetName": "ipv6_cidr_blocks", "targetType": "TEXT", "enabled": true, "flatten": "json"},
+				{"sourceName": "Vpcs_Ipv6CidrBlockAssociationSet_Ipv6CidrBlock", "targetName": "ipv6_cidr_block", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Vpcs_Tags", "targetName": "tags", "targetType": "TEXT", "enabled": true}
+			]
+		},
+		"aws_ec2_subnet": {
+			"aws": {"accountIdAttribute": "account_id"},
+			"parsedAttributes": [
+				{"sourceName": "Subnets_SubnetId", "targetName": "subnet_id", "targetType": "TEXT", "enabled": true},
+				{"sourceName": "Subnets_Tags", "targetName": "tags", "targetType": "TEXT", "enabled": true, "flatten": "json"}
+			]
+		}
+	}`)
+
+	// By default the lists nested in the same element of Vpcs are flattened, Vpcs itself is not a sibling
+	report := ValidateConfigurations(homeDir, extConfigFile)
+	assert.Equal(t, []string{
+		"WARNING sourceNames Vpcs_CidrBlockAssociationSet, Vpcs_Tags are sibling lists, whose rows are multiplied, set flatten to json or child on all but one of them",
+	}, getIssueMessages(report))
+}
+
+func TestValidateShippedConfigurations(t *testing.T) {
+	t.Setenv(tableConfigDirsEnv, "")
+	extConfigFile := filepath.Join(t.TempDir(), "extension_config.json")
+	writeTestFile(t, extConfigFile, `{}`)
+
+	// The table configurations of the repository are read from the directories of the providers,
+	// and are valid without warnings
+	report := ValidateConfigurations(".", extConfigFile)
+	issues := make([]string, 0)
+	for _, issue := range report.Issues {
+		issues = append(issues, issue.Location+": "+issue.Message)
+	}
+	assert.Equal(t, []string{}, issues)
+}
@@ -0,0 +1,103 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCollectionErrors is the number of collection errors kept, older errors are dropped
+const maxCollectionErrors = 1000
+
+// DiscoveryTable is the table of the errors reported while discovering the accounts of an AWS organization,
+// the projects of a GCP parent or the subscriptions of an Azure tenant
+const DiscoveryTable = "discovery"
+
+// CollectionError is a failure to collect the rows of a table for an account or a region.
+// Such failures do not fail the query, the rows of the other accounts and regions are still returned.
+type CollectionError struct {
+	Time     time.Time
+	Table    string
+	Provider string
+	Account  string
+	Region   string
+	API      string
+	Code     string
+	Message  string
+}
+
+// collectionErrors is a ring buffer of the most recent collection errors
+var collectionErrors = struct {
+	sync.Mutex
+	errors []CollectionError
+	next   int
+	// counts holds the number of errors reported for each table, including the dropped ones
+	counts map[string]uint64
+}{counts: make(map[string]uint64)}
+
+// ReportCollectionError records a collection error, and the failure in the collection status of the account if any.
+// Time and Provider are set when empty.
+func ReportCollectionError(collectionError CollectionError) {
+	if collectionError.Time.IsZero() {
+		collectionError.Time = time.Now()
+	}
+	if collectionError.Provider == "" {
+		collectionError.Provider = GetProvider(collectionError.Table)
+	}
+	if collectionError.Account != "" {
+		recordCollectionFailure(collectionError)
+	}
+
+	collectionErrors.Lock()
+	defer collectionErrors.Unlock()
+	collectionErrors.counts[collectionError.Table]++
+	if len(collectionErrors.errors) < maxCollectionErrors {
+		collectionErrors.errors = append(collectionErrors.errors, collectionError)
+		return
+	}
+	collectionErrors.errors[collectionErrors.next] = collectionError
+	collectionErrors.next = (collectionErrors.next + 1) % maxCollectionErrors
+}
+
+// GetCollectionErrors returns the recorded collection errors, oldest first
+func GetCollectionErrors() []CollectionError {
+	collectionErrors.Lock()
+	defer collectionErrors.Unlock()
+	result := make([]CollectionError, 0, len(collectionErrors.errors))
+	result = append(result, collectionErrors.errors[collectionErrors.next:]...)
+	return append(result, collectionErrors.errors[:collectionErrors.next]...)
+}
+
+// GetCollectionErrorCount returns the number of collection errors reported for given table so far
+func GetCollectionErrorCount(tableName string) uint64 {
+	collectionErrors.Lock()
+	defer collectionErrors.Unlock()
+	return collectionErrors.counts[tableName]
+}
+
+// GetLastCollectionErrors returns the last count collection errors reported for given table, oldest first.
+// Fewer errors are returned if some of them were dropped.
+func GetLastCollectionErrors(tableName string, count uint64) []CollectionError {
+	result := make([]CollectionError, 0)
+	if count == 0 {
+		return result
+	}
+	recorded := GetCollectionErrors()
+	for idx := len(recorded) - 1; idx >= 0 && uint64(len(result)) < count; idx-- {
+		if recorded[idx].Table == tableName {
+			result = append(result, recorded[idx])
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
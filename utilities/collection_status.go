@@ -0,0 +1,90 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AccountIdentity is the identity the credentials of an account resolve to.
+// Expiry is zero if the credentials do not expire.
+type AccountIdentity struct {
+	Identity         string
+	CredentialSource string
+	Expiry           time.Time
+}
+
+// CollectionStatus holds the last successful and failed collections of a table for an account
+type CollectionStatus struct {
+	Table       string
+	Provider    string
+	Account     string
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+type collectionStatusKey struct {
+	provider string
+	account  string
+	table    string
+}
+
+var collectionStatuses = struct {
+	sync.Mutex
+	statuses map[collectionStatusKey]*CollectionStatus
+}{statuses: make(map[collectionStatusKey]*CollectionStatus)}
+
+func getCollectionStatus(provider string, account string, tableName string) *CollectionStatus {
+	key := collectionStatusKey{provider: provider, account: account, table: tableName}
+	status, ok := collectionStatuses.statuses[key]
+	if !ok {
+		status = &CollectionStatus{Table: tableName, Provider: provider, Account: account}
+		collectionStatuses.statuses[key] = status
+	}
+	return status
+}
+
+func recordCollectionFailure(collectionError CollectionError) {
+	collectionStatuses.Lock()
+	defer collectionStatuses.Unlock()
+	status := getCollectionStatus(collectionError.Provider, collectionError.Account, collectionError.Table)
+	status.LastFailure = collectionError.Time
+	status.LastError = collectionError.Message
+}
+
+// RecordCollectionSuccess records the collection of given table for an account, started at startTime.
+// The collection is not successful if a collection error was reported for the account since startTime.
+func RecordCollectionSuccess(tableName string, account string, startTime time.Time) {
+	collectionStatuses.Lock()
+	defer collectionStatuses.Unlock()
+	status := getCollectionStatus(GetProvider(tableName), account, tableName)
+	if status.LastFailure.Before(startTime) {
+		status.LastSuccess = time.Now()
+	}
+}
+
+// GetCollectionStatuses returns the collection statuses of the tables of given account, ordered by table
+func GetCollectionStatuses(provider string, account string) []CollectionStatus {
+	collectionStatuses.Lock()
+	defer collectionStatuses.Unlock()
+	result := make([]CollectionStatus, 0)
+	for key, status := range collectionStatuses.statuses {
+		if key.provider == provider && key.account == account {
+			result = append(result, *status)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Table < result[j].Table
+	})
+	return result
+}
@@ -0,0 +1,93 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"strings"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+)
+
+// GetColumns returns the osquery columns of the table generated from its configuration.
+// Columns for account, region, project etc. attributes come first, followed by the key columns
+// of the parent of a child table, and the enabled parsed attributes with their declared targetType.
+// Attributes flattened as child are hidden columns.
+func (tableConfig *TableConfig) GetColumns() []table.ColumnDefinition {
+	columns := make([]table.ColumnDefinition, 0)
+	added := make(map[string]bool)
+	addColumn := func(column table.ColumnDefinition) {
+		if column.Name == "" || added[column.Name] {
+			return
+		}
+		added[column.Name] = true
+		columns = append(columns, column)
+	}
+
+	for _, name := range []string{
+		tableConfig.Aws.AccountIDAttribute,
+		tableConfig.Aws.RegionCodeAttribute,
+		tableConfig.Aws.RegionAttribute,
+		tableConfig.Gcp.ProjectIDAttribute,
+		tableConfig.Gcp.ZoneAttribute,
+		tableConfig.Azure.SubscriptionIDAttribute,
+		tableConfig.Azure.TenantIDAttribute,
+		tableConfig.Azure.ResourceGroupAttribute,
+	} {
+		addColumn(table.TextColumn(name))
+	}
+	if tableConfig.Parent != nil {
+		parentColumns := make(map[string]table.ColumnDefinition)
+		for _, column := range GetTableColumns(tableConfig.Parent.Table) {
+			parentColumns[column.Name] = column
+		}
+		for _, name := range tableConfig.Parent.KeyColumns {
+			column, found := parentColumns[name]
+			if !found {
+				column = table.TextColumn(name)
+			}
+			column.Op = table.DEFAULT
+			addColumn(column)
+		}
+	}
+	for _, attr := range tableConfig.ParsedAttributes {
+		if !attr.Enabled {
+			continue
+		}
+		column := getColumnDefinition(attr.TargetName, attr.TargetType)
+		if attr.Flatten == FlattenChild {
+			column.Op = table.HIDDEN
+		}
+		addColumn(column)
+	}
+	return columns
+}
+
+func getColumnDefinition(name string, targetType string) table.ColumnDefinition {
+	switch strings.ToUpper(targetType) {
+	case table.ColumnTypeInteger:
+		return table.IntegerColumn(name)
+	case table.ColumnTypeBigInt:
+		return table.BigIntColumn(name)
+	case table.ColumnTypeDouble:
+		return table.DoubleColumn(name)
+	default:
+		return table.TextColumn(name)
+	}
+}
+
+// GetTableColumns returns the osquery columns of given table generated from its configuration,
+// or nil if the table is not configured
+func GetTableColumns(tableName string) []table.ColumnDefinition {
+	tableConfig, ok := GetTableConfig(tableName)
+	if !ok {
+		return nil
+	}
+	return tableConfig.GetColumns()
+}
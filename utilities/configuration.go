@@ -0,0 +1,87 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// configSnapshot holds the extension and table configurations in effect.
+// Snapshots are never modified once stored, a new snapshot is stored instead.
+type configSnapshot struct {
+	extConfiguration *ExtensionConfiguration
+	tables           map[string]*TableConfig
+}
+
+var (
+	currentConfig atomic.Value
+	// configMutex serializes the updates of currentConfig
+	configMutex sync.Mutex
+)
+
+func init() {
+	currentConfig.Store(&configSnapshot{
+		extConfiguration: &ExtensionConfiguration{},
+		tables:           map[string]*TableConfig{},
+	})
+}
+
+func getSnapshot() *configSnapshot {
+	return currentConfig.Load().(*configSnapshot)
+}
+
+// GetExtConfiguration returns the extension configuration in effect.
+// It must not be modified, use SetExtConfiguration to replace it.
+func GetExtConfiguration() *ExtensionConfiguration {
+	return getSnapshot().extConfiguration
+}
+
+// GetTableConfig returns the configuration of given table
+func GetTableConfig(tableName string) (*TableConfig, bool) {
+	tableConfig, ok := getSnapshot().tables[tableName]
+	return tableConfig, ok
+}
+
+// GetTableConfigurations returns the map of tableName->TableConfig in effect.
+// It must not be modified, use SetTableConfigurations to replace it.
+func GetTableConfigurations() map[string]*TableConfig {
+	return getSnapshot().tables
+}
+
+// SetConfiguration atomically replaces both the extension and table configurations
+func SetConfiguration(extConfiguration *ExtensionConfiguration, tables map[string]*TableConfig) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	currentConfig.Store(&configSnapshot{
+		extConfiguration: extConfiguration,
+		tables:           tables,
+	})
+}
+
+// SetExtConfiguration replaces the extension configuration
+func SetExtConfiguration(extConfiguration *ExtensionConfiguration) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	currentConfig.Store(&configSnapshot{
+		extConfiguration: extConfiguration,
+		tables:           getSnapshot().tables,
+	})
+}
+
+// SetTableConfigurations replaces the table configurations
+func SetTableConfigurations(tables map[string]*TableConfig) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	currentConfig.Store(&configSnapshot{
+		extConfiguration: getSnapshot().extConfiguration,
+		tables:           tables,
+	})
+}
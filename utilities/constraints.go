@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
+)
+
+// MatchConstraints returns false if given value can not satisfy the constraints
+// placed on given column in the WHERE clause of the query.
+// Only equality (including IN lists, which osquery sends as multiple equality
+// constraints), LIKE and GLOB are evaluated. Any other operator is treated as a match,
+// osquery will apply it to the returned rows anyway.
+func MatchConstraints(queryContext table.QueryContext, column string, value string) bool {
+	if column == "" || queryContext.Constraints == nil {
+		return true
+	}
+	constraintList, ok := queryContext.Constraints[column]
+	if !ok {
+		return true
+	}
+
+	equalsFound := false
+	equalsMatched := false
+	for _, constraint := range constraintList.Constraints {
+		switch constraint.Operator {
+		case table.OperatorEquals:
+			equalsFound = true
+			if constraint.Expression == value {
+				equalsMatched = true
+			}
+		case table.OperatorLike:
+			if !matchLike(constraint.Expression, value) {
+				return false
+			}
+		case table.OperatorGlob:
+			if !MatchGlob(constraint.Expression, value) {
+				return false
+			}
+		}
+	}
+	return !equalsFound || equalsMatched
+}
+
+// matchLike evaluates SQL LIKE pattern against given value.
+// Like SQLite, the match is case insensitive for ASCII characters.
+func matchLike(pattern string, value string) bool {
+	var builder strings.Builder
+	builder.WriteString("(?is)^")
+	for _, ch := range pattern {
+		switch ch {
+		case '%':
+			builder.WriteString(".*")
+		case '_':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	builder.WriteString("$")
+	re, err := regexp.Compile(builder.String())
+	if err != nil {
+		return true
+	}
+	return re.MatchString(value)
+}
+
+// MatchGlob evaluates glob pattern against given value.
+// Like SQLite GLOB, the match is case sensitive and '*' also matches '/'.
+func MatchGlob(pattern string, value string) bool {
+	var builder strings.Builder
+	builder.WriteString("(?s)^")
+	inClass := false
+	for _, ch := range pattern {
+		switch {
+		case inClass:
+			if ch == ']' {
+				inClass = false
+			}
+			if ch == '\\' {
+				builder.WriteString("\\\\")
+			} else {
+				builder.WriteRune(ch)
+			}
+		case ch == '*':
+			builder.WriteString(".*")
+		case ch == '?':
+			builder.WriteString(".")
+		case ch == '[':
+			inClass = true
+			builder.WriteRune(ch)
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	builder.WriteString("$")
+	re, err := regexp.Compile(builder.String())
+	if err != nil {
+		return false
+	}
+	return re.MatchString(value)
+}
@@ -0,0 +1,117 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"sync"
+)
+
+// discoveredAccounts holds the accounts discovered in the organizations of the providers.
+// The slices are never modified once stored, they are replaced instead.
+var discoveredAccounts = struct {
+	sync.RWMutex
+	aws   []ExtensionConfigurationAwsAccount
+	gcp   []ExtensionConfigurationGcpAccount
+	azure []ExtensionConfigurationAzureAccount
+}{}
+
+// SetDiscoveredAwsAccounts replaces the AWS accounts discovered in the organization
+func SetDiscoveredAwsAccounts(accounts []ExtensionConfigurationAwsAccount) {
+	discoveredAccounts.Lock()
+	defer discoveredAccounts.Unlock()
+	discoveredAccounts.aws = accounts
+}
+
+// GetAwsAccounts returns the configured AWS accounts, followed by the discovered accounts which are not configured
+func GetAwsAccounts() []ExtensionConfigurationAwsAccount {
+	configured := GetExtConfiguration().ExtConfAws.Accounts
+	discoveredAccounts.RLock()
+	discovered := discoveredAccounts.aws
+	discoveredAccounts.RUnlock()
+
+	accounts := make([]ExtensionConfigurationAwsAccount, 0, len(configured)+len(discovered))
+	accounts = append(accounts, configured...)
+	ids := make(map[string]bool, len(configured))
+	for _, account := range configured {
+		ids[account.ID] = true
+	}
+	for _, account := range discovered {
+		if !ids[account.ID] {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// SetDiscoveredGcpAccounts replaces the GCP projects discovered in the organizations and folders
+func SetDiscoveredGcpAccounts(accounts []ExtensionConfigurationGcpAccount) {
+	discoveredAccounts.Lock()
+	defer discoveredAccounts.Unlock()
+	discoveredAccounts.gcp = accounts
+}
+
+// GetDiscoveredGcpAccounts returns the GCP projects discovered in the organizations and folders
+func GetDiscoveredGcpAccounts() []ExtensionConfigurationGcpAccount {
+	discoveredAccounts.RLock()
+	defer discoveredAccounts.RUnlock()
+	return discoveredAccounts.gcp
+}
+
+// GetGcpAccounts returns the configured GCP accounts, followed by the discovered projects which are not configured
+func GetGcpAccounts() []ExtensionConfigurationGcpAccount {
+	configured := GetExtConfiguration().ExtConfGcp.Accounts
+	discovered := GetDiscoveredGcpAccounts()
+
+	accounts := make([]ExtensionConfigurationGcpAccount, 0, len(configured)+len(discovered))
+	accounts = append(accounts, configured...)
+	ids := make(map[string]bool, len(configured))
+	for _, account := range configured {
+		ids[account.ProjectID] = true
+	}
+	for _, account := range discovered {
+		if !ids[account.ProjectID] {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// SetDiscoveredAzureAccounts replaces the Azure subscriptions discovered in the tenants
+func SetDiscoveredAzureAccounts(accounts []ExtensionConfigurationAzureAccount) {
+	discoveredAccounts.Lock()
+	defer discoveredAccounts.Unlock()
+	discoveredAccounts.azure = accounts
+}
+
+// GetDiscoveredAzureAccounts returns the Azure subscriptions discovered in the tenants
+func GetDiscoveredAzureAccounts() []ExtensionConfigurationAzureAccount {
+	discoveredAccounts.RLock()
+	defer discoveredAccounts.RUnlock()
+	return discoveredAccounts.azure
+}
+
+// GetAzureAccounts returns the configured Azure accounts, followed by the discovered subscriptions which are not configured
+func GetAzureAccounts() []ExtensionConfigurationAzureAccount {
+	configured := GetExtConfiguration().ExtConfAzure.Accounts
+	discovered := GetDiscoveredAzureAccounts()
+
+	accounts := make([]ExtensionConfigurationAzureAccount, 0, len(configured)+len(discovered))
+	accounts = append(accounts, configured...)
+	ids := make(map[string]bool, len(configured))
+	for _, account := range configured {
+		ids[account.SubscriptionID] = true
+	}
+	for _, account := range discovered {
+		if !ids[account.SubscriptionID] {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
@@ -0,0 +1,117 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type columnsUsedKey struct{}
+
+// WithColumnsUsed returns a context telling the tables which of their columns are used by the query.
+// Nil columns mean that all of them may be used.
+func WithColumnsUsed(ctx context.Context, columns []string) context.Context {
+	return context.WithValue(ctx, columnsUsedKey{}, columns)
+}
+
+// GetColumnsUsed returns the columns used by the query of given context, false if all of them may be used
+func GetColumnsUsed(ctx context.Context) ([]string, bool) {
+	columns, ok := ctx.Value(columnsUsedKey{}).([]string)
+	return columns, ok && columns != nil
+}
+
+// Enrichment is an API call filling some top-level attributes of a resource, in addition to the call listing the resources.
+// Attributes are the source names of the attributes it fills, Enrich must only write these attributes.
+type Enrichment struct {
+	Attributes []string
+	Enrich     func(ctx context.Context)
+}
+
+func getFilterRules(provider string) []FilterRule {
+	switch provider {
+	case ProviderAws:
+		return GetExtConfiguration().ExtConfAws.Filters
+	case ProviderGcp:
+		return GetExtConfiguration().ExtConfGcp.Filters
+	case ProviderAzure:
+		return GetExtConfiguration().ExtConfAzure.Filters
+	}
+	return nil
+}
+
+// getUsedAttributes returns the source names of the attributes of given table used by the query of ctx,
+// or by the row predicates of the filter rules. It returns false if all of them may be used.
+func getUsedAttributes(ctx context.Context, tableName string) (map[string]bool, bool) {
+	columns, ok := GetColumnsUsed(ctx)
+	if !ok {
+		return nil, false
+	}
+	tableConfig, ok := GetTableConfig(tableName)
+	if !ok {
+		return nil, false
+	}
+	usedColumns := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		usedColumns[column] = true
+	}
+	used := make(map[string]bool)
+	for _, attr := range tableConfig.ParsedAttributes {
+		if attr.Enabled && usedColumns[attr.TargetName] {
+			used[attr.SourceName] = true
+		}
+	}
+	for _, rule := range getFilterRules(GetProvider(tableName)) {
+		if !matchAnyGlob(rule.Tables, tableName) {
+			continue
+		}
+		for _, predicate := range rule.Rows {
+			if predicate.Tag != "" {
+				used["Tags"] = true
+				used["Labels"] = true
+			} else {
+				used[predicate.Attribute] = true
+			}
+		}
+	}
+	return used, true
+}
+
+// isUsed returns true if any of the attributes of the enrichment, or of their nested attributes, is used
+func (enrichment *Enrichment) isUsed(used map[string]bool) bool {
+	for _, attribute := range enrichment.Attributes {
+		for name := range used {
+			if strings.EqualFold(name, attribute) || strings.HasPrefix(strings.ToLower(name), strings.ToLower(attribute)+"_") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RunEnrichments runs in parallel the enrichments of a resource of given table whose attributes are used,
+// by the columns of the query or by the filter rules, and waits for them.
+// All of the enrichments run when the columns used by the query are not known.
+func RunEnrichments(ctx context.Context, tableName string, enrichments []Enrichment) {
+	used, known := getUsedAttributes(ctx, tableName)
+	var wg sync.WaitGroup
+	for idx := range enrichments {
+		if known && !enrichments[idx].isUsed(used) {
+			continue
+		}
+		wg.Add(1)
+		go func(enrich func(ctx context.Context)) {
+			defer wg.Done()
+			enrich(ctx)
+		}(enrichments[idx].Enrich)
+	}
+	wg.Wait()
+}
@@ -9,6 +9,11 @@
 
 package utilities
 
+import (
+	"fmt"
+	"strings"
+)
+
 // ExtensionConfigurationLogging represents configuration of a logger
 type ExtensionConfigurationLogging struct {
 	FileName   string `json:"fileName"`
@@ -23,19 +28,74 @@ type CtS3Bucket struct {
 	Prefix string `json:"prefix"`
 }
 
-// ExtensionConfigurationAwsAccount represents configuration of an AWS account
+// ExtensionConfigurationAwsRole is a role assumed with the credentials of the previous role of a chain.
+// SessionName defaults to "cloudquery", and SessionDuration, in seconds, to 3600.
+type ExtensionConfigurationAwsRole struct {
+	RoleArn         string            `json:"roleArn"`
+	ExternalID      string            `json:"externalId"`
+	SessionName     string            `json:"sessionName"`
+	SessionTags     map[string]string `json:"sessionTags"`
+	SessionDuration int               `json:"sessionDuration"`
+}
+
+// ExtensionConfigurationAwsAccount represents configuration of an AWS account.
+// The base credentials are read from ProfileName in CredentialFile and ConfigFile, which may use SSO or credential_process,
+// or from WebIdentityTokenFile by assuming WebIdentityRoleArn, or are the default credentials.
+// The roles of RoleChain are then assumed in order, followed by RoleArn with ExternalID and the session options.
 type ExtensionConfigurationAwsAccount struct {
-	ID             string       `json:"id"`
-	CredentialFile string       `json:"credentialFile"`
-	ProfileName    string       `json:"profileName"`
-	RoleArn        string       `json:"roleArn"`
-	ExternalID     string       `json:"externalId"`
-	CtS3Buckets    []CtS3Bucket `json:"ctS3Buckets"`
+	ID                   string                          `json:"id"`
+	CredentialFile       string                          `json:"credentialFile"`
+	ConfigFile           string                          `json:"configFile"`
+	ProfileName          string                          `json:"profileName"`
+	WebIdentityTokenFile string                          `json:"webIdentityTokenFile"`
+	WebIdentityRoleArn   string                          `json:"webIdentityRoleArn"`
+	RoleChain            []ExtensionConfigurationAwsRole `json:"roleChain"`
+	RoleArn              string                          `json:"roleArn"`
+	ExternalID           string                          `json:"externalId"`
+	SessionName          string                          `json:"sessionName"`
+	SessionTags          map[string]string               `json:"sessionTags"`
+	SessionDuration      int                             `json:"sessionDuration"`
+	CtS3Buckets          []CtS3Bucket                    `json:"ctS3Buckets"`
+}
+
+// ExtensionConfigurationAwsOrganization configures the discovery of the member accounts of an AWS organization.
+// The accounts are listed with the credentials of the management account, built as those of an account
+// from CredentialFile, ConfigFile, ProfileName, the web identity and RoleChain, or the default credentials.
+// RoleName is the name of the role assumed in each member account with these credentials, ExternalID and the session options,
+// "{accountId}" in it is replaced by the id of the account.
+// OrganizationalUnits and Tags select the accounts: they must be in one of the organizational units, or in their children,
+// and have all of the tags, with a value matching one of the glob patterns.
+// The accounts are listed again every RefreshInterval seconds, 3600 by default.
+type ExtensionConfigurationAwsOrganization struct {
+	CredentialFile       string                          `json:"credentialFile"`
+	ConfigFile           string                          `json:"configFile"`
+	ProfileName          string                          `json:"profileName"`
+	WebIdentityTokenFile string                          `json:"webIdentityTokenFile"`
+	WebIdentityRoleArn   string                          `json:"webIdentityRoleArn"`
+	RoleChain            []ExtensionConfigurationAwsRole `json:"roleChain"`
+	RoleName             string                          `json:"roleName"`
+	ExternalID           string                          `json:"externalId"`
+	SessionName          string                          `json:"sessionName"`
+	SessionTags          map[string]string               `json:"sessionTags"`
+	SessionDuration      int                             `json:"sessionDuration"`
+	OrganizationalUnits  []string                        `json:"organizationalUnits"`
+	Tags                 map[string][]string             `json:"tags"`
+	RefreshInterval      int                             `json:"refreshInterval"`
 }
 
 // ExtensionConfigurationAws holds Accounts which is a list of AWS account configurations
+// MaxConcurrency limits the number of accounts, and region API calls across all tables, processed in parallel.
+// MaxConcurrencyPerAccount limits the number of regions of an account processed in parallel.
+// Filters select the accounts, regions and rows processed for each table.
+// Retry configures the retries and the rate of the API calls.
+// Organization adds the member accounts of an organization to Accounts.
 type ExtensionConfigurationAws struct {
-	Accounts []ExtensionConfigurationAwsAccount `json:"accounts"`
+	Accounts                 []ExtensionConfigurationAwsAccount     `json:"accounts"`
+	MaxConcurrency           int                                    `json:"maxConcurrency"`
+	MaxConcurrencyPerAccount int                                    `json:"maxConcurrencyPerAccount"`
+	Filters                  []FilterRule                           `json:"filters"`
+	Retry                    ProviderRetryPolicy                    `json:"retry"`
+	Organization             *ExtensionConfigurationAwsOrganization `json:"organization,omitempty"`
 }
 
 type CloudLogStorageBucket struct {
@@ -51,27 +111,239 @@ type ExtensionConfigurationGcpAccount struct {
 	CloudLogStorageBuckets []CloudLogStorageBucket `json:"cloudLogStorageBuckets"`
 }
 
+// ExtensionConfigurationGcpDiscovery configures the discovery of the projects of a GCP organization or folder.
+// Parent is either "organizations/<id>" or "folders/<id>", its projects and the projects of its folders are listed
+// with the service account of KeyFile, or the application default credentials, and collected with the same credentials.
+// ProjectIDs and Labels select the projects: their id must match one of the glob patterns of ProjectIDs, if any,
+// and they must have all of the labels, with a value matching one of the glob patterns.
+// The projects are listed again every RefreshInterval seconds, 3600 by default.
+type ExtensionConfigurationGcpDiscovery struct {
+	Parent          string              `json:"parent"`
+	KeyFile         string              `json:"keyFile"`
+	ProjectIDs      []string            `json:"projectIds"`
+	Labels          map[string][]string `json:"labels"`
+	RefreshInterval int                 `json:"refreshInterval"`
+}
+
 // ExtensionConfigurationGcp holds Accounts which is a list of GCP account configurations
+// Filters select the projects, zones and rows processed for each table.
+// Retry configures the retries and the rate of the API calls.
+// Discovery adds the projects of organizations or folders to Accounts.
 type ExtensionConfigurationGcp struct {
-	Accounts []ExtensionConfigurationGcpAccount `json:"accounts"`
+	Accounts  []ExtensionConfigurationGcpAccount   `json:"accounts"`
+	Filters   []FilterRule                         `json:"filters"`
+	Retry     ProviderRetryPolicy                  `json:"retry"`
+	Discovery []ExtensionConfigurationGcpDiscovery `json:"discovery"`
+}
+
+// Authentication types of the Azure accounts
+const (
+	AzureAuthFile              = "authFile"
+	AzureAuthClientSecret      = "clientSecret"
+	AzureAuthClientCertificate = "clientCertificate"
+	AzureAuthManagedIdentity   = "managedIdentity"
+	AzureAuthWorkloadIdentity  = "workloadIdentity"
+	AzureAuthEnvironment       = "environment"
+	AzureAuthCli               = "cli"
+)
+
+// ExtensionConfigurationAzureCredentials holds the credentials of an Azure account, used as set by AuthType:
+// "authFile" the auth file, "clientSecret" ClientID and ClientSecret, "clientCertificate" ClientID and CertificatePath,
+// "managedIdentity" the managed identity of the host, or the user assigned identity of ClientID,
+// "workloadIdentity" the federated token of FederatedTokenFile, or of AZURE_FEDERATED_TOKEN_FILE,
+// "environment" the AZURE_* environment variables, and "cli" the Azure CLI login.
+// AuthType is inferred from the credentials set if empty, and defaults to "authFile".
+type ExtensionConfigurationAzureCredentials struct {
+	AuthType            string `json:"authType"`
+	ClientID            string `json:"clientId"`
+	ClientSecret        string `json:"clientSecret"`
+	CertificatePath     string `json:"certificatePath"`
+	CertificatePassword string `json:"certificatePassword"`
+	FederatedTokenFile  string `json:"federatedTokenFile"`
+}
+
+// GetAuthType returns the authentication type of the credentials
+func (credentials ExtensionConfigurationAzureCredentials) GetAuthType() string {
+	switch {
+	case credentials.AuthType != "":
+		return credentials.AuthType
+	case credentials.ClientSecret != "":
+		return AzureAuthClientSecret
+	case credentials.CertificatePath != "":
+		return AzureAuthClientCertificate
+	case credentials.FederatedTokenFile != "":
+		return AzureAuthWorkloadIdentity
+	}
+	return AzureAuthFile
+}
+
+// validate returns the problems of the credentials of the Azure account or tenant with given name
+func (credentials ExtensionConfigurationAzureCredentials) validate(name string, tenantID string) []string {
+	problems := make([]string, 0)
+	switch credentials.GetAuthType() {
+	case AzureAuthClientSecret, AzureAuthClientCertificate:
+		if credentials.ClientID == "" || tenantID == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing clientId or tenantId", name))
+		}
+		if credentials.GetAuthType() == AzureAuthClientCertificate && credentials.CertificatePath == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing certificatePath", name))
+		}
+	case AzureAuthFile, AzureAuthManagedIdentity, AzureAuthWorkloadIdentity, AzureAuthEnvironment, AzureAuthCli:
+	default:
+		problems = append(problems, fmt.Sprintf("%s has unknown authType %q", name, credentials.AuthType))
+	}
+	return problems
 }
 
-// ExtensionConfigurationAzureAccount represents configuration of an Azure account
+// ExtensionConfigurationAzureAccount represents configuration of an Azure account.
+// The credentials are read from AuthFile, or AZURE_AUTH_LOCATION if not set, unless other credentials are configured.
 type ExtensionConfigurationAzureAccount struct {
 	SubscriptionID string `json:"subscriptionId"`
 	TenantID       string `json:"tenantId"`
 	AuthFile       string `json:"authFile"`
+	ExtensionConfigurationAzureCredentials
+}
+
+// ExtensionConfigurationAzureTenant configures the discovery of the subscriptions of an Azure tenant.
+// The subscriptions are listed with the service principal of AuthFile, or of AZURE_AUTH_LOCATION if not set,
+// or with the other credentials configured, and collected with the same authorizer. ManagementGroups and Tags select the subscriptions: they must be in one of
+// the management groups, or in their children, and have all of the tags, with a value matching one of the glob patterns.
+// The subscriptions are listed again every RefreshInterval seconds, 3600 by default.
+type ExtensionConfigurationAzureTenant struct {
+	TenantID         string              `json:"tenantId"`
+	AuthFile         string              `json:"authFile"`
+	ManagementGroups []string            `json:"managementGroups"`
+	Tags             map[string][]string `json:"tags"`
+	RefreshInterval  int                 `json:"refreshInterval"`
+	ExtensionConfigurationAzureCredentials
 }
 
 // ExtensionConfigurationAzure holds Accounts which is a list of Azure account configurations
+// MaxConcurrency limits the number of resource groups of a subscription processed in parallel.
+// Filters select the subscriptions, resource groups and rows processed for each table.
+// Retry configures the retries and the rate of the API calls.
+// Tenants add the subscriptions of the tenants to Accounts.
 type ExtensionConfigurationAzure struct {
-	Accounts []ExtensionConfigurationAzureAccount `json:"accounts"`
+	Accounts       []ExtensionConfigurationAzureAccount `json:"accounts"`
+	MaxConcurrency int                                  `json:"maxConcurrency"`
+	Filters        []FilterRule                         `json:"filters"`
+	Retry          ProviderRetryPolicy                  `json:"retry"`
+	Tenants        []ExtensionConfigurationAzureTenant  `json:"tenants"`
 }
 
 // ExtensionConfiguration represents the configuration for cloudquery extension
+// Limits are the default flatten limits of the tables.
 type ExtensionConfiguration struct {
 	ExtConfLog   ExtensionConfigurationLogging `json:"logging"`
 	ExtConfAws   ExtensionConfigurationAws     `json:"aws"`
 	ExtConfGcp   ExtensionConfigurationGcp     `json:"gcp"`
 	ExtConfAzure ExtensionConfigurationAzure   `json:"azure"`
+	Limits       FlattenLimits                 `json:"limits"`
+}
+
+// Validate returns an error describing the invalid settings of the configuration
+func (extConfig *ExtensionConfiguration) Validate() error {
+	problems := make([]string, 0)
+	for idx, account := range extConfig.ExtConfAws.Accounts {
+		if account.ID == "" {
+			problems = append(problems, fmt.Sprintf("aws account %d is missing id", idx))
+		}
+		for _, bucket := range account.CtS3Buckets {
+			if bucket.Name == "" || bucket.Region == "" {
+				problems = append(problems, fmt.Sprintf("aws account %s has ctS3Buckets entry without name or region", account.ID))
+			}
+		}
+		problems = append(problems, validateAwsCredentials("aws account "+account.ID, account.WebIdentityTokenFile, account.WebIdentityRoleArn,
+			account.RoleChain, account.SessionDuration)...)
+	}
+	if org := extConfig.ExtConfAws.Organization; org != nil {
+		if org.RoleName == "" {
+			problems = append(problems, "aws organization is missing roleName")
+		}
+		problems = append(problems, validateAwsCredentials("aws organization", org.WebIdentityTokenFile, org.WebIdentityRoleArn,
+			org.RoleChain, org.SessionDuration)...)
+		if org.RefreshInterval < 0 {
+			problems = append(problems, "aws organization has negative refreshInterval")
+		}
+	}
+	for idx, account := range extConfig.ExtConfGcp.Accounts {
+		if account.ProjectID == "" {
+			problems = append(problems, fmt.Sprintf("gcp account %d is missing projectId", idx))
+		}
+		for _, bucket := range account.CloudLogStorageBuckets {
+			if bucket.Name == "" {
+				problems = append(problems, fmt.Sprintf("gcp project %s has cloudLogStorageBuckets entry without name", account.ProjectID))
+			}
+		}
+	}
+	for idx, account := range extConfig.ExtConfAzure.Accounts {
+		if account.SubscriptionID == "" {
+			problems = append(problems, fmt.Sprintf("azure account %d is missing subscriptionId", idx))
+		}
+		problems = append(problems, account.validate("azure account "+account.SubscriptionID, account.TenantID)...)
+	}
+	for idx, discovery := range extConfig.ExtConfGcp.Discovery {
+		if !strings.HasPrefix(discovery.Parent, "organizations/") && !strings.HasPrefix(discovery.Parent, "folders/") {
+			problems = append(problems, fmt.Sprintf("gcp discovery %d has invalid parent %q, expected organizations/<id> or folders/<id>", idx, discovery.Parent))
+		}
+		if discovery.RefreshInterval < 0 {
+			problems = append(problems, fmt.Sprintf("gcp discovery %d has negative refreshInterval", idx))
+		}
+	}
+	for idx, tenant := range extConfig.ExtConfAzure.Tenants {
+		if tenant.RefreshInterval < 0 {
+			problems = append(problems, fmt.Sprintf("azure tenant %d has negative refreshInterval", idx))
+		}
+		problems = append(problems, tenant.validate(fmt.Sprintf("azure tenant %d", idx), tenant.TenantID)...)
+	}
+	providers := []string{"aws", "gcp", "azure"}
+	for idx, rules := range [][]FilterRule{extConfig.ExtConfAws.Filters, extConfig.ExtConfGcp.Filters, extConfig.ExtConfAzure.Filters} {
+		for ruleIdx, rule := range rules {
+			if rule.Action != "" && !strings.EqualFold(rule.Action, FilterActionInclude) && !strings.EqualFold(rule.Action, FilterActionExclude) {
+				problems = append(problems, fmt.Sprintf("%s filter %d has invalid action %q", providers[idx], ruleIdx, rule.Action))
+			}
+		}
+	}
+	for idx, retry := range []ProviderRetryPolicy{extConfig.ExtConfAws.Retry, extConfig.ExtConfGcp.Retry, extConfig.ExtConfAzure.Retry} {
+		if !retry.RetryPolicy.isValid() {
+			problems = append(problems, fmt.Sprintf("%s retry has negative values", providers[idx]))
+		}
+		for service, policy := range retry.Services {
+			if !policy.isValid() {
+				problems = append(problems, fmt.Sprintf("%s retry of service %s has negative values", providers[idx], service))
+			}
+		}
+	}
+	if err := extConfig.Limits.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid extension configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateAwsCredentials returns the problems of the web identity and role options of an AWS account or organization
+func validateAwsCredentials(name string, webIdentityTokenFile string, webIdentityRoleArn string, roleChain []ExtensionConfigurationAwsRole, sessionDuration int) []string {
+	problems := make([]string, 0)
+	if webIdentityTokenFile != "" && webIdentityRoleArn == "" {
+		problems = append(problems, fmt.Sprintf("%s has webIdentityTokenFile without webIdentityRoleArn", name))
+	}
+	if !isValidSessionDuration(sessionDuration) {
+		problems = append(problems, fmt.Sprintf("%s has invalid sessionDuration %d, expected 900 to 43200 seconds", name, sessionDuration))
+	}
+	for idx, role := range roleChain {
+		if role.RoleArn == "" {
+			problems = append(problems, fmt.Sprintf("%s has roleChain entry %d without roleArn", name, idx))
+		}
+		if !isValidSessionDuration(role.SessionDuration) {
+			problems = append(problems, fmt.Sprintf("%s has roleChain entry %d with invalid sessionDuration %d, expected 900 to 43200 seconds", name, idx, role.SessionDuration))
+		}
+	}
+	return problems
+}
+
+// isValidSessionDuration returns false if given duration of a role session, in seconds, is out of the range allowed by STS
+func isValidSessionDuration(duration int) bool {
+	return duration == 0 || (duration >= 900 && duration <= 43200)
 }
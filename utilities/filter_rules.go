@@ -0,0 +1,204 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Filter rule actions
+const (
+	FilterActionInclude = "include"
+	FilterActionExclude = "exclude"
+)
+
+// Levels at which filter rules are evaluated
+const (
+	FilterLevelAccount = iota
+	FilterLevelRegion
+	FilterLevelRow
+)
+
+// FilterRowPredicate matches rows whose attribute (or tag) value matches any of the glob patterns in Values.
+// Attribute is the flattened source name of the attribute, e.g. "State_Name".
+// Tag is the key of a tag (AWS) or label (GCP, Azure), read from the "Tags" or "Labels" attribute.
+type FilterRowPredicate struct {
+	Attribute string   `json:"attribute,omitempty"`
+	Tag       string   `json:"tag,omitempty"`
+	Values    []string `json:"values"`
+}
+
+// FilterRule includes or excludes the matching accounts, regions and rows of the matching tables.
+// Tables, Accounts and Regions are lists of glob patterns, an empty list matches everything.
+// Accounts are AWS account ids, GCP project ids or Azure subscription ids.
+// Regions are AWS regions, GCP zones or Azure resource groups.
+// All of the Rows predicates must match.
+// Action is either "include" (default) or "exclude".
+type FilterRule struct {
+	Action   string               `json:"action,omitempty"`
+	Tables   []string             `json:"tables,omitempty"`
+	Accounts []string             `json:"accounts,omitempty"`
+	Regions  []string             `json:"regions,omitempty"`
+	Rows     []FilterRowPredicate `json:"rows,omitempty"`
+}
+
+// FilterRow gives access to the attributes of a row by name
+type FilterRow func(name string) (interface{}, bool)
+
+// InterfaceRow adapts a flattened row to FilterRow
+func InterfaceRow(row map[string]interface{}) FilterRow {
+	return func(name string) (interface{}, bool) {
+		value, ok := row[name]
+		return value, ok
+	}
+}
+
+// StringRow adapts an event row to FilterRow
+func StringRow(row map[string]string) FilterRow {
+	return func(name string) (interface{}, bool) {
+		value, ok := row[name]
+		return value, ok
+	}
+}
+
+// MatchFilterRules returns false if given account, region or row is excluded by the rules.
+// Rules are evaluated at given level: rules with region criteria are only considered once the
+// region is known, and rules with row predicates only for rows.
+// Region is empty for the tables which are not regional, in which case region criteria are not applied.
+// An item is excluded if any exclude rule matches it, or if there are include rules for the
+// table and none of them matches it.
+func MatchFilterRules(rules []FilterRule, level int, tableName string, account string, region string, row FilterRow) bool {
+	includeFound := false
+	includeMatched := false
+	for _, rule := range rules {
+		if !matchAnyGlob(rule.Tables, tableName) {
+			continue
+		}
+		if strings.EqualFold(rule.Action, FilterActionExclude) {
+			if rule.getLevel() > level || (len(rule.Regions) > 0 && region == "") {
+				// Not all of the criteria are known yet
+				continue
+			}
+			if rule.match(level, account, region, row) {
+				return false
+			}
+			continue
+		}
+		includeFound = true
+		if !includeMatched && rule.match(level, account, region, row) {
+			includeMatched = true
+		}
+	}
+	return !includeFound || includeMatched
+}
+
+// getLevel returns the level at which all of the criteria of the rule are known
+func (rule *FilterRule) getLevel() int {
+	if len(rule.Rows) > 0 {
+		return FilterLevelRow
+	}
+	if len(rule.Regions) > 0 {
+		return FilterLevelRegion
+	}
+	return FilterLevelAccount
+}
+
+// match evaluates the criteria of the rule known at given level
+func (rule *FilterRule) match(level int, account string, region string, row FilterRow) bool {
+	if !matchAnyGlob(rule.Accounts, account) {
+		return false
+	}
+	if level >= FilterLevelRegion && region != "" && !matchAnyGlob(rule.Regions, region) {
+		return false
+	}
+	if level >= FilterLevelRow && row != nil {
+		for _, predicate := range rule.Rows {
+			if !predicate.match(row) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (predicate *FilterRowPredicate) match(row FilterRow) bool {
+	var values []string
+	if predicate.Tag != "" {
+		values = getTagValues(row, predicate.Tag)
+	} else if value, ok := row(predicate.Attribute); ok {
+		values = []string{GetStringValue(value)}
+	}
+	for _, value := range values {
+		if matchAnyGlob(predicate.Values, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyGlob(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// getTagValues returns the values of given tag key.
+// Tags are either a list of Key/Value objects (AWS), or a map (GCP labels, Azure tags),
+// possibly serialized as JSON. A row flattened on the tag list has them as "Tags_Key" and "Tags_Value".
+func getTagValues(row FilterRow, key string) []string {
+	values := make([]string, 0)
+	for _, name := range []string{"Tags", "tags", "Labels", "labels"} {
+		if keyValue, ok := row(name + "_Key"); ok && GetStringValue(keyValue) == key {
+			if value, ok := row(name + "_Value"); ok {
+				values = append(values, GetStringValue(value))
+			}
+		}
+		tags, ok := row(name)
+		if !ok {
+			continue
+		}
+		if tagString, ok := tags.(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(tagString), &parsed); err != nil {
+				continue
+			}
+			tags = parsed
+		}
+		switch tags := tags.(type) {
+		case map[string]interface{}:
+			// Rows flattened on the tag list hold a single Key/Value object
+			if tagKey, ok := tags["Key"]; ok {
+				if GetStringValue(tagKey) == key {
+					values = append(values, GetStringValue(tags["Value"]))
+				}
+			} else if value, ok := tags[key]; ok {
+				values = append(values, GetStringValue(value))
+			}
+		case []interface{}:
+			for _, item := range tags {
+				tag, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if GetStringValue(tag["Key"]) == key {
+					values = append(values, GetStringValue(tag["Value"]))
+				}
+			}
+		}
+	}
+	return values
+}
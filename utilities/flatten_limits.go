@@ -0,0 +1,262 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Handling of the objects whose flattening exceeds the limits
+const (
+	OverflowTruncate = "truncate"
+	OverflowFail     = "fail"
+)
+
+// flattenLimitErrorCode is the code of the collection errors reporting the objects exceeding the flatten limits
+const flattenLimitErrorCode = "FlattenLimitExceeded"
+
+// FlattenLimits bounds the rows of a generation of a table: MaxRows bounds their number, MaxBytes their estimated size,
+// and MaxNesting the depth of the nested attributes flattened. The flattening of an object stops as soon as it exceeds them.
+// Limits left to 0 use the global limits, and are not enforced if these are 0 too.
+// OnOverflow is either "truncate" (default), keeping the rows within the limits with a warning,
+// or "fail", failing the query.
+type FlattenLimits struct {
+	MaxRows    int    `json:"maxRows,omitempty"`
+	MaxNesting int    `json:"maxNesting,omitempty"`
+	MaxBytes   int64  `json:"maxBytes,omitempty"`
+	OnOverflow string `json:"onOverflow,omitempty"`
+}
+
+// Validate returns an error describing the invalid limits
+func (limits FlattenLimits) Validate() error {
+	if limits.MaxRows < 0 || limits.MaxNesting < 0 || limits.MaxBytes < 0 {
+		return fmt.Errorf("limits have negative values")
+	}
+	switch strings.ToLower(limits.OnOverflow) {
+	case "", OverflowTruncate, OverflowFail:
+		return nil
+	}
+	return fmt.Errorf("limits have invalid onOverflow %q, expected %s or %s", limits.OnOverflow, OverflowTruncate, OverflowFail)
+}
+
+// merge returns the limits, with the limits left to 0 taken from defaults
+func (limits FlattenLimits) merge(defaults FlattenLimits) FlattenLimits {
+	if limits.MaxRows == 0 {
+		limits.MaxRows = defaults.MaxRows
+	}
+	if limits.MaxNesting == 0 {
+		limits.MaxNesting = defaults.MaxNesting
+	}
+	if limits.MaxBytes == 0 {
+		limits.MaxBytes = defaults.MaxBytes
+	}
+	if limits.OnOverflow == "" {
+		limits.OnOverflow = defaults.OnOverflow
+	}
+	limits.OnOverflow = strings.ToLower(limits.OnOverflow)
+	if limits.OnOverflow == "" {
+		limits.OnOverflow = OverflowTruncate
+	}
+	return limits
+}
+
+// GetFlattenLimits returns the flatten limits of given table, merged with the global limits
+func GetFlattenLimits(tableName string) FlattenLimits {
+	limits := FlattenLimits{}
+	if tableConfig, ok := GetTableConfig(tableName); ok {
+		limits = tableConfig.Limits
+	}
+	return limits.merge(GetExtConfiguration().Limits)
+}
+
+type flattenGenerationKey struct{}
+
+// FlattenGeneration tracks the flatten limits of one generation of a table, it is shared by the objects of the generation
+type FlattenGeneration struct {
+	tableName string
+	limits    FlattenLimits
+
+	mutex    sync.Mutex
+	overflow string
+}
+
+// WithFlattenGeneration returns a context tracking the flatten limits of a new generation of given table.
+// The tables created with the context record their overflows in the generation instead of reporting them.
+func WithFlattenGeneration(ctx context.Context, tableName string) (context.Context, *FlattenGeneration) {
+	generation := &FlattenGeneration{tableName: tableName, limits: GetFlattenLimits(tableName)}
+	return context.WithValue(ctx, flattenGenerationKey{}, generation), generation
+}
+
+func getFlattenGeneration(ctx context.Context) *FlattenGeneration {
+	if ctx == nil {
+		return nil
+	}
+	generation, _ := ctx.Value(flattenGenerationKey{}).(*FlattenGeneration)
+	return generation
+}
+
+func (generation *FlattenGeneration) setOverflow(overflow string) {
+	generation.mutex.Lock()
+	defer generation.mutex.Unlock()
+	if generation.overflow == "" {
+		generation.overflow = overflow
+	}
+}
+
+// ApplyLimits truncates the rows of the generation exceeding its limits, and reports the overflow of the generation if any.
+// An error is returned if the generation overflowed and the table must fail on overflow.
+func (generation *FlattenGeneration) ApplyLimits(rows []map[string]string) ([]map[string]string, error) {
+	count, size := 0, int64(0)
+	for _, row := range rows {
+		rowBytes := int64(0)
+		for name, value := range row {
+			rowBytes += int64(len(name) + len(value))
+		}
+		if generation.limits.MaxRows > 0 && count+1 > generation.limits.MaxRows {
+			generation.setOverflow(fmt.Sprintf("maxRows %d", generation.limits.MaxRows))
+			break
+		}
+		if generation.limits.MaxBytes > 0 && size+rowBytes > generation.limits.MaxBytes {
+			generation.setOverflow(fmt.Sprintf("maxBytes %d", generation.limits.MaxBytes))
+			break
+		}
+		count++
+		size += rowBytes
+	}
+	rows = rows[:count]
+
+	generation.mutex.Lock()
+	overflow := generation.overflow
+	generation.mutex.Unlock()
+	if overflow == "" {
+		return rows, nil
+	}
+	reportOverflow(generation.tableName, generation.limits, overflow)
+	if generation.limits.OnOverflow == OverflowFail {
+		return nil, fmt.Errorf("table %s exceeded its flatten limits: %s", generation.tableName, overflow)
+	}
+	return rows, nil
+}
+
+// flattenBudget tracks the limits of the flattening of an object, it is shared by the intermediate tables
+type flattenBudget struct {
+	tableName string
+	limits    FlattenLimits
+	// prefixes holds the ancestors of the enabled attributes, the other subtrees are not flattened.
+	// It is nil if all of the attributes are flattened.
+	prefixes map[string]bool
+	overflow string
+	// generation is the generation of the table the object belongs to, nil outside of a generation
+	generation *FlattenGeneration
+}
+
+func newFlattenBudget(ctx context.Context, tableConfig *TableConfig) *flattenBudget {
+	if tableConfig == nil {
+		return &flattenBudget{limits: FlattenLimits{}.merge(GetExtConfiguration().Limits), generation: getFlattenGeneration(ctx)}
+	}
+	return &flattenBudget{
+		tableName:  tableConfig.name,
+		limits:     tableConfig.Limits.merge(GetExtConfiguration().Limits),
+		prefixes:   tableConfig.attributePrefixes,
+		generation: getFlattenGeneration(ctx),
+	}
+}
+
+// isUsed returns false if no enabled attribute is nested in the attribute with given name
+func (budget *flattenBudget) isUsed(name string) bool {
+	return budget == nil || budget.prefixes == nil || budget.prefixes[name]
+}
+
+// allowsNesting returns false, recording the overflow, if the attributes nested in the attribute with given name and depth are too deep
+func (budget *flattenBudget) allowsNesting(name string, depth int) bool {
+	if budget == nil || budget.limits.MaxNesting <= 0 || depth < budget.limits.MaxNesting {
+		return true
+	}
+	budget.setOverflow(fmt.Sprintf("maxNesting %d at attribute %s", budget.limits.MaxNesting, name))
+	return false
+}
+
+// allowsRows returns false, recording the overflow, if given number of rows of given estimated size exceed the limits
+func (budget *flattenBudget) allowsRows(count int, size int64) bool {
+	if budget == nil {
+		return true
+	}
+	if budget.limits.MaxRows > 0 && count > budget.limits.MaxRows {
+		budget.setOverflow(fmt.Sprintf("maxRows %d", budget.limits.MaxRows))
+		return false
+	}
+	if budget.limits.MaxBytes > 0 && size > budget.limits.MaxBytes {
+		budget.setOverflow(fmt.Sprintf("maxBytes %d", budget.limits.MaxBytes))
+		return false
+	}
+	return true
+}
+
+func (budget *flattenBudget) setOverflow(overflow string) {
+	if budget.overflow == "" {
+		budget.overflow = overflow
+	}
+}
+
+// report records the overflow of the flattening in its generation, or logs and reports it outside of a generation
+func (budget *flattenBudget) report() {
+	if budget == nil || budget.overflow == "" {
+		return
+	}
+	if budget.generation != nil {
+		budget.generation.setOverflow(budget.overflow)
+		return
+	}
+	reportOverflow(budget.tableName, budget.limits, budget.overflow)
+}
+
+// reportOverflow logs and records the overflow of the flatten limits of given table
+func reportOverflow(tableName string, limits FlattenLimits, overflow string) {
+	fields := log.Fields{
+		"tableName":  tableName,
+		"limit":      overflow,
+		"onOverflow": limits.OnOverflow,
+	}
+	if limits.OnOverflow == OverflowFail {
+		GetLogger().WithFields(fields).Error("flattening exceeded the limits, dropping the rows")
+	} else {
+		GetLogger().WithFields(fields).Warn("flattening exceeded the limits, truncating the rows")
+	}
+	if tableName == "" {
+		return
+	}
+	ReportCollectionError(CollectionError{
+		Table:   tableName,
+		Code:    flattenLimitErrorCode,
+		Message: "flattening exceeded " + overflow,
+	})
+}
+
+// attributeSize estimates the memory used by an attribute of a row
+func attributeSize(name string, value interface{}) int64 {
+	if value, ok := value.(string); ok {
+		return int64(len(name) + len(value))
+	}
+	return int64(len(name) + 16)
+}
+
+// rowSize estimates the memory used by a row
+func rowSize(row map[string]interface{}) int64 {
+	size := int64(0)
+	for name, value := range row {
+		size += attributeSize(name, value)
+	}
+	return size
+}
@@ -0,0 +1,191 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Providers used as label of the metrics
+const (
+	ProviderAws   = "aws"
+	ProviderGcp   = "gcp"
+	ProviderAzure = "azure"
+)
+
+type tableNameKey struct{}
+
+var (
+	metricsEnabled int32
+
+	metricsRegistry = prometheus.NewRegistry()
+
+	apiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_api_calls_total",
+		Help: "Number of cloud provider API calls, retries included.",
+	}, []string{"table", "provider", "account", "region"})
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_api_errors_total",
+		Help: "Number of cloud provider API calls which failed, throttled calls included.",
+	}, []string{"table", "provider", "account", "region"})
+	apiThrottles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_api_throttles_total",
+		Help: "Number of cloud provider API calls which were throttled.",
+	}, []string{"table", "provider", "account", "region"})
+
+	generateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cloudquery_generate_duration_seconds",
+		Help:    "Duration of the generate function of the tables.",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"table", "provider"})
+	generateErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_generate_errors_total",
+		Help: "Number of generate calls which returned an error.",
+	}, []string{"table", "provider"})
+	generateRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_rows_total",
+		Help: "Number of rows returned by the tables.",
+	}, []string{"table", "provider"})
+
+	eventBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cloudquery_event_backlog_objects",
+		Help: "Number of log objects of a bucket waiting to be processed by an event table.",
+	}, []string{"table", "account", "bucket"})
+	eventLastProcessed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cloudquery_event_last_processed_object_timestamp_seconds",
+		Help: "Modification time of the last log object of a bucket processed by an event table.",
+	}, []string{"table", "account", "bucket"})
+	streamEventsFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudquery_stream_events_failures_total",
+		Help: "Number of event batches which could not be streamed to osquery.",
+	}, []string{"table"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		apiCalls, apiErrors, apiThrottles,
+		generateDuration, generateErrors, generateRows,
+		eventBacklog, eventLastProcessed, streamEventsFailures,
+	)
+}
+
+// StartMetricsServer serves the Prometheus metrics on /metrics of given address, in the background.
+// API clients created after this call are instrumented.
+func StartMetricsServer(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	atomic.StoreInt32(&metricsEnabled, 1)
+	go func() {
+		err := http.Serve(listener, mux)
+		GetLogger().WithFields(log.Fields{
+			"address":   address,
+			"errString": err.Error(),
+		}).Error("metrics server stopped")
+	}()
+	GetLogger().WithFields(log.Fields{
+		"address": listener.Addr().String(),
+	}).Info("serving metrics")
+	return nil
+}
+
+// MetricsEnabled returns true if the metrics are served, and the API clients should be instrumented
+func MetricsEnabled() bool {
+	return atomic.LoadInt32(&metricsEnabled) == 1
+}
+
+// WithTableName returns a context attributing the API calls made with it to given table
+func WithTableName(ctx context.Context, tableName string) context.Context {
+	return context.WithValue(ctx, tableNameKey{}, tableName)
+}
+
+// GetTableName returns the table the API calls of given context are attributed to
+func GetTableName(ctx context.Context) string {
+	if tableName, ok := ctx.Value(tableNameKey{}).(string); ok {
+		return tableName
+	}
+	return ""
+}
+
+// GetProvider returns the provider of a table, from the prefix of its name
+func GetProvider(tableName string) string {
+	return strings.SplitN(tableName, "_", 2)[0]
+}
+
+// RecordAPICall counts an API call of given table, and whether it failed or was throttled
+func RecordAPICall(tableName string, provider string, account string, region string, failed bool, throttled bool) {
+	apiCalls.WithLabelValues(tableName, provider, account, region).Inc()
+	if failed {
+		apiErrors.WithLabelValues(tableName, provider, account, region).Inc()
+	}
+	if throttled {
+		apiThrottles.WithLabelValues(tableName, provider, account, region).Inc()
+	}
+}
+
+// ObserveGenerate records the duration, the number of rows and the failure of a generate call
+func ObserveGenerate(tableName string, duration time.Duration, rows int, err error) {
+	provider := GetProvider(tableName)
+	generateDuration.WithLabelValues(tableName, provider).Observe(duration.Seconds())
+	generateRows.WithLabelValues(tableName, provider).Add(float64(rows))
+	if err != nil {
+		generateErrors.WithLabelValues(tableName, provider).Inc()
+	}
+}
+
+// SetEventBacklog records the number of objects of a bucket waiting to be processed by an event table
+func SetEventBacklog(tableName string, account string, bucket string, count int) {
+	eventBacklog.WithLabelValues(tableName, account, bucket).Set(float64(count))
+}
+
+// SetEventLastProcessed records the modification time of the last object of a bucket processed by an event table
+func SetEventLastProcessed(tableName string, account string, bucket string, modifiedTime time.Time) {
+	eventLastProcessed.WithLabelValues(tableName, account, bucket).Set(float64(modifiedTime.Unix()))
+}
+
+// RecordStreamEventsFailure counts a batch of events which could not be streamed to osquery
+func RecordStreamEventsFailure(tableName string) {
+	streamEventsFailures.WithLabelValues(tableName).Inc()
+}
+
+// metricsTransport counts the HTTP requests sent to a provider API
+type metricsTransport struct {
+	base       http.RoundTripper
+	provider   string
+	getAccount func(req *http.Request) string
+}
+
+// NewMetricsTransport returns a RoundTripper counting the requests sent with given base.
+// Requests are attributed to the table of their context, and to the account returned by getAccount.
+func NewMetricsTransport(base http.RoundTripper, provider string, getAccount func(req *http.Request) string) http.RoundTripper {
+	return &metricsTransport{base: base, provider: provider, getAccount: getAccount}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	failed := err != nil || resp.StatusCode >= 400
+	throttled := err == nil && resp.StatusCode == http.StatusTooManyRequests
+	RecordAPICall(GetTableName(req.Context()), t.provider, t.getAccount(req), "", failed, throttled)
+	return resp, err
+}
@@ -0,0 +1,316 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Defaults of the retry policy settings which are not configured
+const (
+	DefaultMaxAttempts = 3
+	DefaultMaxBackoff  = 20 * time.Second
+)
+
+// RetryPolicy configures the retries of the API calls, and limits their rate.
+// Settings left to zero use the defaults, the rate is not limited by default.
+type RetryPolicy struct {
+	MaxAttempts       int     `json:"maxAttempts"`
+	MaxBackoffSeconds float64 `json:"maxBackoffSeconds"`
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+}
+
+// ProviderRetryPolicy is the retry policy of the API calls of a provider.
+// Services override its settings for the API calls of some services, a service with its own rate is limited separately.
+type ProviderRetryPolicy struct {
+	RetryPolicy
+	Services map[string]RetryPolicy `json:"services"`
+}
+
+// retryableStatusCodes are the HTTP status codes of the responses whose request is retried
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+func (policy RetryPolicy) isValid() bool {
+	return policy.MaxAttempts >= 0 && policy.MaxBackoffSeconds >= 0 && policy.RequestsPerSecond >= 0
+}
+
+// GetMaxBackoff returns the maximum delay between two attempts
+func (policy RetryPolicy) GetMaxBackoff() time.Duration {
+	return time.Duration(policy.MaxBackoffSeconds * float64(time.Second))
+}
+
+// GetBackoff returns the delay before the attempt following given attempt, counted from 1.
+// The delay grows exponentially from one second, is jittered and is capped by the maximum backoff.
+func (policy RetryPolicy) GetBackoff(attempt int) time.Duration {
+	backoff := policy.GetMaxBackoff()
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt < 32 && time.Duration(1<<uint(attempt-1))*time.Second < backoff {
+		backoff = time.Duration(1<<uint(attempt-1)) * time.Second
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+func getProviderRetryPolicy(provider string) ProviderRetryPolicy {
+	switch provider {
+	case ProviderAws:
+		return GetExtConfiguration().ExtConfAws.Retry
+	case ProviderGcp:
+		return GetExtConfiguration().ExtConfGcp.Retry
+	case ProviderAzure:
+		return GetExtConfiguration().ExtConfAzure.Retry
+	}
+	return ProviderRetryPolicy{}
+}
+
+// getServicePolicy returns the policy configured for given service, whose name is matched ignoring case
+func (policy ProviderRetryPolicy) getServicePolicy(service string) (RetryPolicy, bool) {
+	for name, servicePolicy := range policy.Services {
+		if strings.EqualFold(name, service) {
+			return servicePolicy, true
+		}
+	}
+	return RetryPolicy{}, false
+}
+
+// GetRetryPolicy returns the retry policy of the API calls of given provider and service, with the defaults applied
+func GetRetryPolicy(provider string, service string) RetryPolicy {
+	providerPolicy := getProviderRetryPolicy(provider)
+	policy := providerPolicy.RetryPolicy
+	if servicePolicy, ok := providerPolicy.getServicePolicy(service); ok {
+		if servicePolicy.MaxAttempts != 0 {
+			policy.MaxAttempts = servicePolicy.MaxAttempts
+		}
+		if servicePolicy.MaxBackoffSeconds != 0 {
+			policy.MaxBackoffSeconds = servicePolicy.MaxBackoffSeconds
+		}
+		if servicePolicy.RequestsPerSecond != 0 {
+			policy.RequestsPerSecond = servicePolicy.RequestsPerSecond
+		}
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.MaxBackoffSeconds == 0 {
+		policy.MaxBackoffSeconds = DefaultMaxBackoff.Seconds()
+	}
+	return policy
+}
+
+// rateLimiter spaces the requests sent to an API, so that their rate does not exceed the configured one
+type rateLimiter struct {
+	sync.Mutex
+	next time.Time
+}
+
+// reserve returns how long to wait before sending a request at given rate
+func (limiter *rateLimiter) reserve(requestsPerSecond float64) time.Duration {
+	limiter.Lock()
+	defer limiter.Unlock()
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	delay := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(time.Duration(float64(time.Second) / requestsPerSecond))
+	return delay
+}
+
+var rateLimiters = struct {
+	sync.Mutex
+	limiters map[string]*rateLimiter
+}{limiters: make(map[string]*rateLimiter)}
+
+func getRateLimiter(key string) *rateLimiter {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+	limiter, ok := rateLimiters.limiters[key]
+	if !ok {
+		limiter = &rateLimiter{}
+		rateLimiters.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// WaitForRateLimit waits until a request of given account can be sent to given service without exceeding the configured rate.
+// The rate is limited per account, the services without a rate of their own share the rate of the provider.
+func WaitForRateLimit(ctx context.Context, provider string, service string, account string) error {
+	providerPolicy := getProviderRetryPolicy(provider)
+	requestsPerSecond := providerPolicy.RequestsPerSecond
+	limitedService := ""
+	if servicePolicy, ok := providerPolicy.getServicePolicy(service); ok && servicePolicy.RequestsPerSecond > 0 {
+		requestsPerSecond = servicePolicy.RequestsPerSecond
+		limitedService = strings.ToLower(service)
+	}
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	delay := getRateLimiter(provider + "/" + limitedService + "/" + account).reserve(requestsPerSecond)
+	return sleepWithContext(ctx, delay)
+}
+
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// LogRetries logs the number of retries of an API call, and how many of its attempts were throttled
+func LogRetries(ctx context.Context, provider string, service string, account string, retries int, throttles int, err error) {
+	if retries == 0 && throttles == 0 {
+		return
+	}
+	fields := log.Fields{
+		"tableName": GetTableName(ctx),
+		"provider":  provider,
+		"service":   service,
+		"account":   account,
+		"retries":   retries,
+		"throttles": throttles,
+	}
+	if err != nil {
+		fields["errString"] = err.Error()
+		GetLogger().WithFields(fields).Warn("api call failed after retries")
+		return
+	}
+	GetLogger().WithFields(fields).Info("api call retried")
+}
+
+// getRetryAfter returns the delay requested by the Retry-After header of a response
+func getRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// SendWithRetry sends a request with send, limiting the rate and retrying as configured for given provider and service.
+// Connection errors, throttled requests and server errors are retried, honouring the Retry-After header up to the maximum backoff.
+func SendWithRetry(req *http.Request, send func(*http.Request) (*http.Response, error), provider string, service string, account string) (*http.Response, error) {
+	ctx := req.Context()
+	policy := GetRetryPolicy(provider, service)
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(ctx)
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	retries, throttles := 0, 0
+	for attempt := 1; ; attempt++ {
+		if err := WaitForRateLimit(ctx, provider, service, account); err != nil {
+			LogRetries(ctx, provider, service, account, retries, throttles, err)
+			return nil, err
+		}
+		resp, err := send(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			throttles++
+		}
+		retryable := (err != nil && ctx.Err() == nil) || (err == nil && retryableStatusCodes[resp.StatusCode])
+		if !retryable || attempt >= policy.MaxAttempts {
+			if err == nil && resp.StatusCode >= 400 {
+				LogRetries(ctx, provider, service, account, retries, throttles, fmt.Errorf("response status %s", resp.Status))
+			} else {
+				LogRetries(ctx, provider, service, account, retries, throttles, err)
+			}
+			return resp, err
+		}
+
+		delay := policy.GetBackoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := getRetryAfter(resp); ok {
+				delay = retryAfter
+				if delay > policy.GetMaxBackoff() {
+					delay = policy.GetMaxBackoff()
+				}
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		retries++
+		GetLogger().WithFields(log.Fields{
+			"tableName": GetTableName(ctx),
+			"provider":  provider,
+			"service":   service,
+			"account":   account,
+			"attempt":   attempt,
+			"delay":     delay.String(),
+		}).Debug("retrying api call")
+		if err := sleepWithContext(ctx, delay); err != nil {
+			LogRetries(ctx, provider, service, account, retries, throttles, err)
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryTransport limits the rate of the HTTP requests sent to a provider API, and retries them
+type retryTransport struct {
+	base       http.RoundTripper
+	provider   string
+	getService func(req *http.Request) string
+	getAccount func(req *http.Request) string
+}
+
+// NewRetryTransport returns a RoundTripper sending requests with given base, as configured by the retry policy of given provider.
+// The policy of a request depends on the service returned by getService, and its rate is limited for the account returned by getAccount.
+func NewRetryTransport(base http.RoundTripper, provider string, getService func(req *http.Request) string, getAccount func(req *http.Request) string) http.RoundTripper {
+	return &retryTransport{base: base, provider: provider, getService: getService, getAccount: getAccount}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return SendWithRetry(req, t.base.RoundTrip, t.provider, t.getService(req), t.getAccount(req))
+}
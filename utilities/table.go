@@ -10,6 +10,8 @@
 package utilities
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -23,11 +25,15 @@ type Table struct {
 	Rows                     []map[string]interface{}
 	MaxLevel                 int
 	ParsedAttributeConfigMap map[string]ParsedAttributeConfig
+
+	budget *flattenBudget
+	size   int64
 }
 
-// NewTable creates a table from given data (in json form) and table configuration
-func NewTable(jsonStr []byte, tableConfig *TableConfig) Table {
-	tab := Table{}
+// NewTable creates a table from given data (in json form) and table configuration.
+// The overflows of the flatten limits are recorded in the generation of the table of ctx, if any.
+func NewTable(ctx context.Context, jsonStr []byte, tableConfig *TableConfig) Table {
+	tab := Table{budget: newFlattenBudget(ctx, tableConfig)}
 	maxLevel := 10
 	parsedAttributeConfigMap := make(map[string]ParsedAttributeConfig)
 	if tableConfig != nil {
@@ -35,12 +41,49 @@ func NewTable(jsonStr []byte, tableConfig *TableConfig) Table {
 		parsedAttributeConfigMap = tableConfig.getParsedAttributeConfigMap()
 	}
 	tab.init(jsonStr, maxLevel, parsedAttributeConfigMap)
+	tab.applyLimits()
 	return tab
 }
 
+// applyLimits truncates the rows exceeding the limits of the table, or drops all of them if it must fail on overflow
+func (tab *Table) applyLimits() {
+	if tab.budget == nil {
+		return
+	}
+	for len(tab.Rows) > 0 && !tab.budget.allowsRows(len(tab.Rows), tab.size) {
+		tab.size -= rowSize(tab.Rows[len(tab.Rows)-1])
+		tab.Rows = tab.Rows[:len(tab.Rows)-1]
+	}
+	if tab.budget.overflow != "" && tab.budget.limits.OnOverflow == OverflowFail {
+		tab.Rows = nil
+		tab.size = 0
+	}
+	tab.budget.report()
+}
+
+// newTable returns an intermediate table, sharing the configuration and the limits of tab
+func (tab *Table) newTable() Table {
+	return Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap, budget: tab.budget}
+}
+
+// allowsDescent returns true if the attributes nested in the attribute with given name and depth must be flattened.
+// The elements of a list are named after the list, only the maps nest their attributes.
+func (tab *Table) allowsDescent(name string, depth int, value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}:
+		return tab.budget.isUsed(name) && tab.budget.allowsNesting(name, depth)
+	case []interface{}:
+		return tab.budget.isUsed(name)
+	}
+	return true
+}
+
 func (tab *Table) init(jsonStr []byte, maxLevel int, parsedAttributeConfigMap map[string]ParsedAttributeConfig) {
 	var fields interface{}
-	json.Unmarshal(jsonStr, &fields)
+	// Keep numbers as json.Number, converting them to float64 would lose precision
+	decoder := json.NewDecoder(bytes.NewReader(jsonStr))
+	decoder.UseNumber()
+	decoder.Decode(&fields)
 	tab.MaxLevel = maxLevel
 	if maxLevel != 0 {
 		tab.MaxLevel = maxLevel + 1
@@ -48,11 +91,11 @@ func (tab *Table) init(jsonStr []byte, maxLevel int, parsedAttributeConfigMap ma
 	tab.ParsedAttributeConfigMap = parsedAttributeConfigMap
 	switch fields := fields.(type) {
 	case map[string]interface{}:
-		tab.flattenMap(0, "", fields)
+		tab.flattenMap(0, 0, "", fields)
 	case []interface{}:
-		tab.flattenList(0, "", fields)
+		tab.flattenList(0, 0, "", fields)
 	case reflect.Value:
-		tab.flattenValue(0, "", fields)
+		tab.flattenValue(0, 0, "", fields)
 	default:
 		GetLogger().WithFields(log.Fields{
 			"type": reflect.TypeOf(fields),
@@ -88,7 +131,11 @@ func (tab *Table) addAttribute(name string, value interface{}) {
 				tab.Rows = append(tab.Rows, row)
 			}
 			for _, item := range tab.Rows {
+				if old, ok := item[name]; ok {
+					tab.size -= attributeSize(name, old)
+				}
 				item[name] = value
+				tab.size += attributeSize(name, value)
 			}
 		}
 	}
@@ -100,20 +147,40 @@ func (tab *Table) addRows(newRows []map[string]interface{}) {
 		return
 	}
 
-	tab.Rows = append(tab.Rows, newRows...)
+	for _, row := range newRows {
+		size := rowSize(row)
+		if !tab.budget.allowsRows(len(tab.Rows)+1, tab.size+size) {
+			return
+		}
+		tab.Rows = append(tab.Rows, row)
+		tab.size += size
+	}
 }
 
 func (tab *Table) addRowsAndFlatten(newRows []map[string]interface{}) {
 	if len(tab.Rows) == 0 {
-		tab.Rows = newRows
+		tab.addRows(newRows)
 		return
 	} else if len(newRows) == 0 {
 		// nothing to flatten
 		return
 	}
+	// The sizes are checked before merging, the cross product of the rows may not fit in memory
+	newSizes := make([]int64, len(newRows))
+	for idx, item2 := range newRows {
+		newSizes[idx] = rowSize(item2)
+	}
 	mergedRows := make([]map[string]interface{}, 0)
+	mergedSize := int64(0)
 	for _, item1 := range tab.Rows {
-		for _, item2 := range newRows {
+		size1 := rowSize(item1)
+		for idx, item2 := range newRows {
+			if !tab.budget.allowsRows(len(mergedRows)+1, mergedSize+size1+newSizes[idx]) {
+				tab.Rows = mergedRows
+				tab.size = mergedSize
+				return
+			}
+			mergedSize += size1 + newSizes[idx]
 			row := make(map[string]interface{})
 			// Add attributes from existing rows
 			for key1, value1 := range item1 {
@@ -127,6 +194,7 @@ func (tab *Table) addRowsAndFlatten(newRows []map[string]interface{}) {
 		}
 	}
 	tab.Rows = mergedRows
+	tab.size = mergedSize
 }
 
 func getKey(prefix, key string) string {
@@ -137,37 +205,44 @@ func getKey(prefix, key string) string {
 }
 
 // Flatten takes a map and returns a new one where nested maps are replaced
-// by dot-delimited keys.
-func (tab *Table) flattenMap(level int, prefix string, m map[string]interface{}) {
+// by dot-delimited keys. depth is the number of attributes prefix is nested in, keys of the map are one deeper.
+func (tab *Table) flattenMap(level int, depth int, prefix string, m map[string]interface{}) {
 	for k, v := range m {
-		_, ok := tab.ParsedAttributeConfigMap[getKey(prefix, k)]
+		attrConfig, ok := tab.ParsedAttributeConfigMap[getKey(prefix, k)]
 		if ok || (len(tab.ParsedAttributeConfigMap) == 0) {
 			byteArr, err := json.Marshal(v)
 			if err == nil {
 				tab.addAttribute(getKey(prefix, k), string(byteArr))
 			}
 		}
+		if ok && attrConfig.isJSON() {
+			// Kept as JSON, its elements do not multiply the rows
+			continue
+		}
 		if tab.MaxLevel > 0 && level >= tab.MaxLevel {
 			// Don't flatten further
 			continue
 		}
+		if !tab.allowsDescent(getKey(prefix, k), depth+1, v) {
+			continue
+		}
 		switch child := v.(type) {
 		case map[string]interface{}:
-			tab.flattenMap(level+1, getKey(prefix, k), child)
+			tab.flattenMap(level+1, depth+1, getKey(prefix, k), child)
 		case []interface{}:
-			tab.flattenList(level+1, getKey(prefix, k), child)
+			tab.flattenList(level+1, depth+1, getKey(prefix, k), child)
 		case reflect.Value:
-			tab.flattenValue(level, getKey(prefix, k), child)
+			tab.flattenValue(level, depth+1, getKey(prefix, k), child)
 		default:
 			tab.addAttribute(getKey(prefix, k), v)
 		}
 	}
 }
 
-func (tab *Table) flattenList(level int, prefix string, list []interface{}) {
-	newListTable := Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap}
+func (tab *Table) flattenList(level int, depth int, prefix string, list []interface{}) {
+	newListTable := tab.newTable()
 	for _, value := range list {
-		newTable := Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap}
+		newTable := tab.newTable()
 		_, ok := tab.ParsedAttributeConfigMap[prefix]
 		if ok || (len(tab.ParsedAttributeConfigMap) == 0) {
 			byteArr, err := json.Marshal(value)
@@ -181,16 +256,19 @@ func (tab *Table) flattenList(level int, prefix string, list []interface{}) {
 		}
 		switch child := value.(type) {
 		case map[string]interface{}:
-			mapTab := Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap}
-			mapTab.flattenMap(level+1, prefix, child)
+			if !tab.budget.allowsNesting(prefix, depth) {
+				break
+			}
+			mapTab := tab.newTable()
+			mapTab.flattenMap(level+1, depth, prefix, child)
 			newTable.addRowsAndFlatten(mapTab.Rows)
 		case []interface{}:
-			listTab := Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap}
-			listTab.flattenList(level+1, prefix, child)
+			listTab := tab.newTable()
+			listTab.flattenList(level+1, depth, prefix, child)
 			newTable.addRowsAndFlatten(listTab.Rows)
 		case reflect.Value:
-			valTab := Table{MaxLevel: tab.MaxLevel, ParsedAttributeConfigMap: tab.ParsedAttributeConfigMap}
-			valTab.flattenValue(level, prefix, child)
+			valTab := tab.newTable()
+			valTab.flattenValue(level, depth, prefix, child)
 			newTable.addRowsAndFlatten(valTab.Rows)
 		default:
 			newTable.addAttribute(prefix, value)
@@ -228,7 +306,7 @@ func isUnexportedField(name string, f reflect.Value) bool {
 	return false
 }
 
-func (tab *Table) flattenValue(level int, prefix string, value reflect.Value) {
+func (tab *Table) flattenValue(level int, depth int, prefix string, value reflect.Value) {
 	value = getAdjustedValue(value)
 	tab.addAttributeForPrefix(prefix, value)
 
@@ -253,19 +331,19 @@ func (tab *Table) flattenValue(level int, prefix string, value reflect.Value) {
 			val := value.FieldByName(n)
 			fieldMap[n] = val
 		}
-		tab.flattenMap(level+1, prefix, fieldMap)
+		tab.flattenMap(level+1, depth, prefix, fieldMap)
 	case reflect.Slice:
 		fieldList := make([]interface{}, 0)
 		for i := 0; i < value.Len(); i++ {
 			fieldList = append(fieldList, value.Index(i))
 		}
-		tab.flattenList(level+1, prefix, fieldList)
+		tab.flattenList(level+1, depth, prefix, fieldList)
 	case reflect.Map:
 		fieldMap := make(map[string]interface{}, 0)
 		for _, k := range value.MapKeys() {
 			fieldMap[k.String()] = value.MapIndex(k)
 		}
-		tab.flattenMap(level+1, prefix, fieldMap)
+		tab.flattenMap(level+1, depth, prefix, fieldMap)
 	default:
 		tab.addAttribute(prefix, value.Interface())
 	}
@@ -13,12 +13,29 @@ import (
 	"strings"
 )
 
-// ParsedAttributeConfig represents the attributes for a table
+// Flatten modes of a parsed attribute
+const (
+	// FlattenJSON keeps the attribute as a JSON column, its nested attributes are not flattened
+	FlattenJSON = "json"
+	// FlattenChild keeps the attribute as a hidden JSON column, whose elements are the rows of child tables
+	FlattenChild = "child"
+)
+
+// ParsedAttributeConfig represents the attributes for a table.
+// By default the nested attributes of an attribute are flattened, each element of a nested array
+// adding rows which are multiplied by the elements of the sibling arrays.
+// Flatten set to FlattenJSON or FlattenChild stops the flattening at the attribute.
 type ParsedAttributeConfig struct {
 	SourceName string `json:"sourceName"`
 	TargetName string `json:"targetName"`
 	TargetType string `json:"targetType"`
 	Enabled    bool   `json:"enabled"`
+	Flatten    string `json:"flatten,omitempty"`
+}
+
+// isJSON returns true if the attribute is kept as JSON, without flattening its nested attributes
+func (attr *ParsedAttributeConfig) isJSON() bool {
+	return attr.Flatten == FlattenJSON || attr.Flatten == FlattenChild
 }
 
 // AwsConfig represents the additional attributes for AWS table
@@ -41,7 +58,26 @@ type AzureConfig struct {
 	ResourceGroupAttribute  string `json:"resourceGroupAttribute,omitempty"`
 }
 
-// TableConfig represents the configuration of a table
+// CacheConfig represents the result cache settings of a table, in seconds.
+// Results are reused for TTL seconds, and served for up to MaxStale more seconds
+// while they are refreshed in the background. Caching is disabled if TTL is 0.
+type CacheConfig struct {
+	TTL      int `json:"ttl,omitempty"`
+	MaxStale int `json:"maxStale,omitempty"`
+}
+
+// ParentTableConfig links a child table to a JSON column of its parent table.
+// Each element of the column is a row of the child table, along with the KeyColumns of the parent row.
+type ParentTableConfig struct {
+	Table      string   `json:"table"`
+	Column     string   `json:"column"`
+	KeyColumns []string `json:"keyColumns"`
+}
+
+// TableConfig represents the configuration of a table.
+// Timeout is the number of seconds the generation of the table may take, it is not bounded if 0.
+// Parent is set for the child tables, whose parsed attributes are relative to the elements of the parent column.
+// Limits bound the rows flattened from each object, the limits left to 0 use the global limits.
 type TableConfig struct {
 	Imports          []string                `json:"imports"`
 	MaxLevel         int                     `json:"maxLevel"`
@@ -51,19 +87,36 @@ type TableConfig struct {
 	Aws              AwsConfig               `json:"aws"`
 	Gcp              GcpConfig               `json:"gcp"`
 	Azure            AzureConfig             `json:"azure"`
+	Cache            CacheConfig             `json:"cache"`
+	Timeout          int                     `json:"timeout,omitempty"`
+	Parent           *ParentTableConfig      `json:"parent,omitempty"`
+	Limits           FlattenLimits           `json:"limits"`
 	ParsedAttributes []ParsedAttributeConfig `json:"parsedAttributes"`
 
+	name                     string
 	parsedAttributeConfigMap map[string]ParsedAttributeConfig
+	attributePrefixes        map[string]bool
 }
 
 func (tableConfig *TableConfig) initParsedAttributeConfigMap() {
 	tableConfig.parsedAttributeConfigMap = make(map[string]ParsedAttributeConfig)
+	// The enabled attributes and their ancestors, the other subtrees of the objects are not flattened
+	tableConfig.attributePrefixes = nil
+	if len(tableConfig.ParsedAttributes) > 0 {
+		tableConfig.attributePrefixes = make(map[string]bool)
+	}
 	for _, attr := range tableConfig.ParsedAttributes {
 		if attr.Enabled {
 			level := strings.Count(attr.SourceName, "_")
 			if level > tableConfig.MaxLevel {
 				tableConfig.MaxLevel = level
 			}
+			for idx, char := range attr.SourceName {
+				if char == '_' {
+					tableConfig.attributePrefixes[attr.SourceName[:idx]] = true
+				}
+			}
+			tableConfig.attributePrefixes[attr.SourceName] = true
 		}
 		tableConfig.parsedAttributeConfigMap[attr.SourceName] = attr
 	}
@@ -0,0 +1,172 @@
+/**
+ * Copyright (c) 2020-present, The cloudquery authors
+ *
+ * This source code is licensed as defined by the LICENSE file found in the
+ * root directory of this source tree.
+ *
+ * SPDX-License-Identifier: (Apache-2.0 OR GPL-2.0-only)
+ */
+
+package utilities
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// maxSourceDepth bounds the depth of the attributes collected from a source type
+const maxSourceDepth = 16
+
+// TableSource describes the objects the rows of a table are flattened from
+type TableSource struct {
+	// Type is the type of the objects flattened by the table
+	Type reflect.Type
+	// Extra lists the attributes added to the rows besides those of Type
+	Extra []string
+	// FieldNames is true if the objects are converted with structs.Map before they are flattened,
+	// which nests embedded structs and keeps structs without exported fields as is
+	FieldNames bool
+}
+
+// SourceNames holds the flattened attribute names of a TableSource.
+// Maps and interfaces may hold any key, so any name under an open prefix is reachable.
+type SourceNames struct {
+	names      map[string]bool
+	kinds      map[string]reflect.Kind
+	open       map[string]bool
+	lists      map[string]bool
+	fieldNames bool
+}
+
+// GetSourceNames returns the attribute names the flattener may produce for the objects of the source
+func (source TableSource) GetSourceNames() *SourceNames {
+	sourceNames := &SourceNames{
+		names:      make(map[string]bool),
+		kinds:      make(map[string]reflect.Kind),
+		open:       make(map[string]bool),
+		lists:      make(map[string]bool),
+		fieldNames: source.FieldNames,
+	}
+	for _, name := range source.Extra {
+		sourceNames.names[name] = true
+	}
+	if source.Type == nil {
+		sourceNames.open[""] = true
+		return sourceNames
+	}
+	sourceNames.collect(source.Type, "", 0, make(map[reflect.Type]bool))
+	return sourceNames
+}
+
+// IsReachable returns true if the flattener may produce an attribute with given name
+func (sourceNames *SourceNames) IsReachable(name string) bool {
+	if sourceNames.names[name] || sourceNames.open[""] {
+		return true
+	}
+	for prefix := range sourceNames.open {
+		if strings.HasPrefix(name, prefix+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetKind returns the kind of the values of the attribute with given name.
+// It returns false if the kind is not known, like for attributes nested in maps.
+func (sourceNames *SourceNames) GetKind(name string) (reflect.Kind, bool) {
+	kind, ok := sourceNames.kinds[name]
+	return kind, ok
+}
+
+// IsList returns true if the attribute with given name holds lists, whose elements are flattened into rows
+func (sourceNames *SourceNames) IsList(name string) bool {
+	return sourceNames.lists[name]
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (sourceNames *SourceNames) collect(sourceType reflect.Type, prefix string, depth int, visiting map[reflect.Type]bool) {
+	for sourceType.Kind() == reflect.Ptr {
+		sourceType = sourceType.Elem()
+	}
+	if prefix != "" {
+		sourceNames.names[prefix] = true
+		if _, found := sourceNames.kinds[prefix]; !found {
+			// Lists keep the prefix of their items, the attribute holds the list itself
+			sourceNames.kinds[prefix] = sourceType.Kind()
+		}
+	}
+	if depth > maxSourceDepth {
+		return
+	}
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		if sourceType == timeType {
+			return
+		}
+		if sourceNames.fieldNames && !hasExportedFields(sourceType) {
+			// structs.Map keeps structs without exported fields as is
+			return
+		}
+		if visiting[sourceType] {
+			// Recursive types can be nested to any depth
+			sourceNames.open[prefix] = true
+			return
+		}
+		visiting[sourceType] = true
+		defer delete(visiting, sourceType)
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := sourceType.Field(i)
+			name, ok := sourceNames.getFieldName(field)
+			if !ok {
+				continue
+			}
+			if field.Anonymous && name == field.Name && !sourceNames.fieldNames {
+				// Embedded structs are inlined by encoding/json, and nested by structs.Map
+				sourceNames.collect(field.Type, prefix, depth, visiting)
+				continue
+			}
+			sourceNames.collect(field.Type, getKey(prefix, name), depth+1, visiting)
+		}
+	case reflect.Slice, reflect.Array:
+		if sourceType.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as strings
+			return
+		}
+		// Lists are flattened into rows with the same prefix
+		if prefix != "" {
+			sourceNames.lists[prefix] = true
+		}
+		sourceNames.collect(sourceType.Elem(), prefix, depth, visiting)
+	case reflect.Map, reflect.Interface:
+		sourceNames.open[prefix] = true
+	}
+}
+
+func hasExportedFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldName returns the name of the attribute a struct field is encoded as,
+// from its json tag if any, and false if the field is not encoded.
+// structs.Map reads the json tags too, as the Azure tables set it as the default tag of structs.
+func (sourceNames *SourceNames) getFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" && (!field.Anonymous || sourceNames.fieldNames) {
+		// Unexported field
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
@@ -11,7 +11,11 @@ package utilities
 
 import (
 	"encoding/json"
+	"math"
 	"strconv"
+	"strings"
+
+	"github.com/Uptycs/basequery-go/plugin/table"
 )
 
 // GetStringValue returns the string representation of given interface
@@ -29,8 +33,8 @@ func GetStringValue(value interface{}) string {
 		}
 		return value
 	case json.Number:
-		val, _ := value.Int64()
-		return strconv.FormatInt(val, 10)
+		// Keep the number exactly as it was received
+		return value.String()
 	case bool:
 		return strconv.FormatBool(value)
 	}
@@ -38,35 +42,77 @@ func GetStringValue(value interface{}) string {
 	return getNumericStringValue(value)
 }
 
+// GetTypedStringValue returns the string representation of given interface for a column of given osquery type.
+// Booleans and integral floating point numbers are converted to integers for INTEGER and BIGINT columns.
+func GetTypedStringValue(value interface{}, targetType string) string {
+	switch strings.ToUpper(targetType) {
+	case table.ColumnTypeInteger, table.ColumnTypeBigInt:
+		switch value := value.(type) {
+		case bool:
+			if value {
+				return "1"
+			}
+			return "0"
+		case json.Number:
+			if _, err := value.Int64(); err != nil {
+				if floatValue, err := value.Float64(); err == nil && isIntegral(floatValue) {
+					return strconv.FormatInt(int64(floatValue), 10)
+				}
+			}
+		case float64:
+			if isIntegral(value) {
+				return strconv.FormatInt(int64(value), 10)
+			}
+		case float32:
+			if isIntegral(float64(value)) {
+				return strconv.FormatInt(int64(value), 10)
+			}
+		}
+	}
+	return GetStringValue(value)
+}
+
+func isIntegral(value float64) bool {
+	return value == math.Trunc(value) && math.Abs(value) < (1<<63)
+}
+
+func formatFloat(value float64, bitSize int) string {
+	// Use exponent only for very large or very small numbers, like JSON encoders do
+	if value != 0 && (math.Abs(value) >= 1e21 || math.Abs(value) < 1e-6) {
+		return strconv.FormatFloat(value, 'g', -1, bitSize)
+	}
+	return strconv.FormatFloat(value, 'f', -1, bitSize)
+}
+
 func getNumericStringValue(value interface{}) string {
 	if value == nil {
 		return ""
 	}
 	switch v := value.(type) {
 	case int:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatInt(int64(v), 10)
 	case int8:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatInt(int64(v), 10)
 	case int16:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatInt(int64(v), 10)
 	case int32:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatInt(int64(v), 10)
 	case int64:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatInt(v, 10)
 	case uint:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatUint(uint64(v), 10)
 	case uint8:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatUint(uint64(v), 10)
 	case uint16:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatUint(uint64(v), 10)
 	case uint32:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatUint(uint64(v), 10)
 	case uint64:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return strconv.FormatUint(v, 10)
 	case float32:
-		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		return formatFloat(float64(v), 32)
 	case float64:
-		return strconv.FormatFloat(v, 'g', -1, 64)
+		return formatFloat(v, 64)
 	}
 
 	return ""
@@ -19,23 +19,18 @@ import (
 )
 
 var (
-	// TableConfigurationMap is the map of tableName->TableConfig
-	TableConfigurationMap = map[string]*TableConfig{}
 	// AwsAccountID is read from env variable AWS_ACCOUNT_ID
 	AwsAccountID string
-	// ExtConfiguration holds extension's config data including credential files` path
-	ExtConfiguration ExtensionConfiguration
 	// DefaultGcpProjectID is projectID read from file set in env var GOOGLE_APPLICATION_CREDENTIALS
 	DefaultGcpProjectID string
 )
 
-// ReadTableConfig parses json encoded data to read list TableConfig entries
-// These are available for reading from utilities.TableConfigurationMap[]
-func ReadTableConfig(jsonEncoded []byte) error {
+// ParseTableConfig parses json encoded data to read list TableConfig entries
+func ParseTableConfig(jsonEncoded []byte) (map[string]*TableConfig, error) {
 	var configurations map[string]*TableConfig
 	errUnmarshal := json.Unmarshal(jsonEncoded, &configurations)
 	if errUnmarshal != nil {
-		return errUnmarshal
+		return nil, errUnmarshal
 	}
 	for tableName, config := range configurations {
 		GetLogger().WithFields(log.Fields{
@@ -43,13 +38,30 @@ func ReadTableConfig(jsonEncoded []byte) error {
 		}).Debug("found table configuration")
 		for _, attr := range config.ParsedAttributes {
 			if attr.SourceName == "" || attr.TargetName == "" || attr.TargetType == "" {
-				return fmt.Errorf("invalid parsedAttribute entry: %+v", attr)
+				return nil, fmt.Errorf("invalid parsedAttribute entry: %+v", attr)
 			}
 		}
+		config.name = tableName
 		config.initParsedAttributeConfigMap()
-		TableConfigurationMap[tableName] = config
 	}
-	
+	return configurations, nil
+}
+
+// ReadTableConfig parses json encoded data to read list TableConfig entries
+// These are added to the configurations available from GetTableConfig()
+func ReadTableConfig(jsonEncoded []byte) error {
+	configurations, err := ParseTableConfig(jsonEncoded)
+	if err != nil {
+		return err
+	}
+	tables := make(map[string]*TableConfig)
+	for tableName, config := range GetTableConfigurations() {
+		tables[tableName] = config
+	}
+	for tableName, config := range configurations {
+		tables[tableName] = config
+	}
+	SetTableConfigurations(tables)
 	return nil
 }
 
@@ -57,7 +69,7 @@ func ReadTableConfig(jsonEncoded []byte) error {
 func RowToMap(inMap map[string]string, row map[string]interface{}, tableConfig *TableConfig) map[string]string {
 	for key, value := range tableConfig.getParsedAttributeConfigMap() {
 		if row[key] != nil {
-			inMap[value.TargetName] = GetStringValue(row[key])
+			inMap[value.TargetName] = GetTypedStringValue(row[key], value.TargetType)
 		}
 	}
 	return inMap
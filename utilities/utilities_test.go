@@ -10,10 +10,23 @@
 package utilities
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Uptycs/basequery-go/plugin/table"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,7 +149,7 @@ func TestReadTableConfig(t *testing.T) {
 	readErr := ReadTableConfig([]byte(tableConfigJSON))
 	assert.Nil(t, readErr)
 
-	myTable1, found := TableConfigurationMap["test_table_1"]
+	myTable1, found := GetTableConfig("test_table_1")
 	assert.True(t, found)
 
 	assert.Equal(t, 4, len(myTable1.ParsedAttributes))
@@ -144,18 +157,18 @@ func TestReadTableConfig(t *testing.T) {
 	// Col "Item_Object_Name" is deepest enabled attributes with level 2
 	assert.Equal(t, 2, myTable1.MaxLevel)
 
-	for _, v := range TableConfigurationMap {
+	for _, v := range GetTableConfigurations() {
 		assert.Equal(t, len(v.parsedAttributeConfigMap), len(v.ParsedAttributes))
 	}
 
-	assert.Equal(t, 3, len(TableConfigurationMap))
+	assert.Equal(t, 3, len(GetTableConfigurations()))
 }
 
 func TestRowToMap(t *testing.T) {
 	readErr := ReadTableConfig([]byte(tableConfigJSON))
 	assert.Nil(t, readErr)
 
-	tabConfig, found := TableConfigurationMap["test_table_1"]
+	tabConfig, found := GetTableConfig("test_table_1")
 	assert.True(t, found)
 
 	inRow := make(map[string]interface{})
@@ -244,7 +257,10 @@ type myTest struct {
 func TestGetStringValue(t *testing.T) {
 	list := []myTest{
 		{12, "12"},
-		{1000002, "1.000002e+06"},
+		{1000002, "1000002"},
+		{float64(1234567), "1234567"},
+		{json.Number("12345678901234567890"), "12345678901234567890"},
+		{json.Number("3.14159"), "3.14159"},
 		{10.00002, "10.00002"},
 		{"astring", "astring"},
 		{true, "true"},
@@ -259,6 +275,40 @@ func TestGetStringValue(t *testing.T) {
 	}
 }
 
+func TestGetTypedStringValue(t *testing.T) {
+	assert.Equal(t, "1", GetTypedStringValue(true, "INTEGER"))
+	assert.Equal(t, "true", GetTypedStringValue(true, "TEXT"))
+	assert.Equal(t, "42", GetTypedStringValue(json.Number("42.0"), "BIGINT"))
+	assert.Equal(t, "42.5", GetTypedStringValue(json.Number("42.5"), "BIGINT"))
+	assert.Equal(t, "0.25", GetTypedStringValue(json.Number("0.25"), "DOUBLE"))
+}
+
+func TestGetColumns(t *testing.T) {
+	readErr := ReadTableConfig([]byte(tableConfigJSON))
+	assert.Nil(t, readErr)
+
+	columns := GetTableColumns("test_table_1")
+	// Disabled attribute is skipped
+	assert.Equal(t, 3, len(columns))
+	assert.Equal(t, "description", columns[0].Name)
+	assert.Equal(t, table.ColumnTypeText, columns[0].Type)
+	assert.Equal(t, "id", columns[2].Name)
+	assert.Equal(t, table.ColumnType(table.ColumnTypeInteger), columns[2].Type)
+
+	columns = GetTableColumns("test_table_2")
+	assert.Equal(t, 1, len(columns))
+	assert.Equal(t, "region", columns[0].Name)
+
+	assert.Nil(t, GetTableColumns("unknown_table"))
+}
+
+func TestNewTableNumbers(t *testing.T) {
+	tab := NewTable(context.Background(), []byte(`{"size": 12345678901234567, "ratio": 0.1}`), nil)
+	assert.Equal(t, 1, len(tab.Rows))
+	assert.Equal(t, "12345678901234567", GetStringValue(tab.Rows[0]["size"]))
+	assert.Equal(t, "0.1", GetStringValue(tab.Rows[0]["ratio"]))
+}
+
 func TestGetSnakeCase(t *testing.T) {
 	list := []myTest{
 		{"id", "id"},
@@ -279,12 +329,546 @@ func TestNewTable(t *testing.T) {
 	readErr := ReadTableConfig([]byte(tableConfigJSON))
 	assert.Nil(t, readErr)
 
-	myTable1, found := TableConfigurationMap["table_test_table_1"]
+	myTable1, found := GetTableConfig("table_test_table_1")
 	assert.True(t, found)
 
-	tableWithConfig := NewTable([]byte(tableJSON1), myTable1)
+	tableWithConfig := NewTable(context.Background(), []byte(tableJSON1), myTable1)
 	assert.Equal(t, 2, len(tableWithConfig.Rows))
 
-	table := NewTable([]byte(tableJSON1), nil)
+	table := NewTable(context.Background(), []byte(tableJSON1), nil)
 	assert.Equal(t, 2, len(table.Rows))
 }
+
+func TestMatchConstraints(t *testing.T) {
+	queryContext := table.QueryContext{
+		Constraints: map[string]table.ConstraintList{
+			"account_id": {
+				Affinity: table.ColumnTypeText,
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorEquals, Expression: "111"},
+					{Operator: table.OperatorEquals, Expression: "222"},
+				},
+			},
+			"region_code": {
+				Affinity: table.ColumnTypeText,
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorLike, Expression: "us-%-1"},
+				},
+			},
+			"zone": {
+				Affinity: table.ColumnTypeText,
+				Constraints: []table.Constraint{
+					{Operator: table.OperatorGlob, Expression: "europe-*"},
+					{Operator: table.OperatorGreaterThan, Expression: "a"},
+				},
+			},
+		},
+	}
+	assert.True(t, MatchConstraints(queryContext, "account_id", "111"))
+	assert.True(t, MatchConstraints(queryContext, "account_id", "222"))
+	assert.False(t, MatchConstraints(queryContext, "account_id", "333"))
+	assert.True(t, MatchConstraints(queryContext, "region_code", "us-east-1"))
+	assert.True(t, MatchConstraints(queryContext, "region_code", "US-WEST-1"))
+	assert.False(t, MatchConstraints(queryContext, "region_code", "eu-west-1"))
+	assert.True(t, MatchConstraints(queryContext, "zone", "europe-west1-b"))
+	assert.False(t, MatchConstraints(queryContext, "zone", "us-central1-a"))
+	assert.True(t, MatchConstraints(queryContext, "project_id", "any"))
+	assert.True(t, MatchConstraints(queryContext, "", "any"))
+	assert.True(t, MatchConstraints(table.QueryContext{}, "account_id", "any"))
+}
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, MatchGlob("us-*", "us-east-1"))
+	assert.True(t, MatchGlob("arn:aws:*", "arn:aws:iam::111:role/path/name"))
+	assert.True(t, MatchGlob("us-east-?", "us-east-2"))
+	assert.True(t, MatchGlob("us-[ew]*", "us-west-1"))
+	assert.False(t, MatchGlob("us-*", "US-EAST-1"))
+	assert.False(t, MatchGlob("eu-*", "us-east-1"))
+}
+
+func TestMatchFilterRules(t *testing.T) {
+	rules := []FilterRule{
+		{Tables: []string{"aws_ec2_*"}, Accounts: []string{"111", "222"}},
+		{Tables: []string{"aws_ec2_*"}, Action: FilterActionExclude, Accounts: []string{"222"}, Regions: []string{"eu-*"}},
+		{
+			Tables: []string{"aws_ec2_instance"},
+			Action: FilterActionExclude,
+			Rows: []FilterRowPredicate{
+				{Tag: "env", Values: []string{"dev*"}},
+			},
+		},
+		{
+			Tables: []string{"aws_ec2_instance"},
+			Action: FilterActionExclude,
+			Rows: []FilterRowPredicate{
+				{Attribute: "State_Name", Values: []string{"terminated"}},
+			},
+		},
+	}
+	level := FilterLevelAccount
+	assert.True(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "111", "", nil))
+	assert.True(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "222", "", nil))
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "333", "", nil))
+	// Rules of other tables do not apply
+	assert.True(t, MatchFilterRules(rules, level, "aws_s3_bucket", "333", "", nil))
+
+	level = FilterLevelRegion
+	assert.True(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "222", "us-east-1", nil))
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "222", "eu-west-1", nil))
+	assert.True(t, MatchFilterRules(rules, level, "aws_ec2_vpc", "111", "eu-west-1", nil))
+
+	level = FilterLevelRow
+	row := map[string]interface{}{
+		"InstanceId": "i-1",
+		"State_Name": "running",
+		"Tags":       `[{"Key":"env","Value":"prod"}]`,
+	}
+	assert.True(t, MatchFilterRules(rules, level, "aws_ec2_instance", "111", "us-east-1", InterfaceRow(row)))
+	row["Tags"] = `[{"Key":"name","Value":"x"},{"Key":"env","Value":"dev-1"}]`
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_instance", "111", "us-east-1", InterfaceRow(row)))
+	row["Tags"] = `{"Key":"env","Value":"dev-2"}`
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_instance", "111", "us-east-1", InterfaceRow(row)))
+	delete(row, "Tags")
+	row["State_Name"] = "terminated"
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_instance", "111", "us-east-1", InterfaceRow(row)))
+
+	labels := map[string]string{"labels": `{"env":"dev"}`}
+	assert.False(t, MatchFilterRules(rules, level, "aws_ec2_instance", "111", "", StringRow(labels)))
+	assert.True(t, MatchFilterRules(nil, level, "aws_ec2_instance", "111", "", StringRow(labels)))
+}
+
+type testSourceTag struct {
+	Key   *string
+	Value *string
+}
+
+type testSourceNode struct {
+	Name     string
+	Children []*testSourceNode
+}
+
+type testSourceEmbedded struct {
+	Region string `json:"region"`
+}
+
+type testSourceItem struct {
+	testSourceEmbedded
+	ID         *string           `json:"id"`
+	Size       *int64            `json:"size,omitempty"`
+	Created    *time.Time        `json:"created"`
+	Tags       []testSourceTag   `json:"tags"`
+	Labels     map[string]string `json:"labels"`
+	Tree       testSourceNode    `json:"tree"`
+	Data       []byte            `json:"data"`
+	Ignored    string            `json:"-"`
+	unexported string
+}
+
+func TestGetSourceNames(t *testing.T) {
+	source := TableSource{Type: reflect.TypeOf(testSourceItem{}), Extra: []string{"server_name"}}
+	sourceNames := source.GetSourceNames()
+	for _, name := range []string{"region", "id", "size", "created", "tags", "tags_Key", "tags_Value",
+		"labels", "labels_env", "tree_Name", "tree_Children_Name", "tree_Children_Children_Name", "data", "server_name"} {
+		assert.True(t, sourceNames.IsReachable(name), name)
+	}
+	for _, name := range []string{"testSourceEmbedded", "ID", "created_wall", "Ignored", "unexported", "tags_Key_x", "data_x"} {
+		assert.False(t, sourceNames.IsReachable(name), name)
+	}
+	kind, found := sourceNames.GetKind("size")
+	assert.True(t, found)
+	assert.Equal(t, reflect.Int64, kind)
+	kind, _ = sourceNames.GetKind("tags")
+	assert.Equal(t, reflect.Slice, kind)
+	_, found = sourceNames.GetKind("labels_env")
+	assert.False(t, found)
+	assert.True(t, sourceNames.IsList("tags"))
+	assert.True(t, sourceNames.IsList("tree_Children"))
+	for _, name := range []string{"tags_Key", "labels", "data", "tree"} {
+		assert.False(t, sourceNames.IsList(name), name)
+	}
+
+	// structs.Map reads the json tags too, but does not inline embedded structs
+	source = TableSource{Type: reflect.TypeOf(testSourceItem{}), FieldNames: true}
+	sourceNames = source.GetSourceNames()
+	for _, name := range []string{"id", "created", "tags_Key"} {
+		assert.True(t, sourceNames.IsReachable(name), name)
+	}
+	for _, name := range []string{"ID", "region", "created_wall", "Ignored", "unexported"} {
+		assert.False(t, sourceNames.IsReachable(name), name)
+	}
+
+	assert.True(t, TableSource{}.GetSourceNames().IsReachable("any_name"))
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMetrics(t *testing.T) {
+	statusCodes := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusForbidden}
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		code := statusCodes[0]
+		statusCodes = statusCodes[1:]
+		return &http.Response{StatusCode: code, Body: http.NoBody}, nil
+	})
+	transport := NewMetricsTransport(base, ProviderGcp, func(req *http.Request) string {
+		return "test-project"
+	})
+	ctx := WithTableName(context.Background(), "gcp_test_metrics")
+	for count := 0; count < 3; count++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://compute.googleapis.com/compute/v1/projects/test-project/zones", nil)
+		_, err := transport.RoundTrip(req)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 3.0, testutil.ToFloat64(apiCalls.WithLabelValues("gcp_test_metrics", ProviderGcp, "test-project", "")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(apiErrors.WithLabelValues("gcp_test_metrics", ProviderGcp, "test-project", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(apiThrottles.WithLabelValues("gcp_test_metrics", ProviderGcp, "test-project", "")))
+
+	ObserveGenerate("gcp_test_metrics", time.Second, 5, nil)
+	ObserveGenerate("gcp_test_metrics", time.Second, 0, fmt.Errorf("failed"))
+	assert.Equal(t, 5.0, testutil.ToFloat64(generateRows.WithLabelValues("gcp_test_metrics", ProviderGcp)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(generateErrors.WithLabelValues("gcp_test_metrics", ProviderGcp)))
+	assert.Equal(t, "", GetTableName(context.Background()))
+	assert.Equal(t, ProviderAzure, GetProvider("azure_compute_vm"))
+}
+
+func TestCollectionErrors(t *testing.T) {
+	for count := 0; count < maxCollectionErrors+2; count++ {
+		ReportCollectionError(CollectionError{Table: "aws_test_errors", Account: strconv.Itoa(count)})
+	}
+	collectionErrors := GetCollectionErrors()
+	assert.Equal(t, maxCollectionErrors, len(collectionErrors))
+	assert.Equal(t, "2", collectionErrors[0].Account)
+	assert.Equal(t, strconv.Itoa(maxCollectionErrors+1), collectionErrors[maxCollectionErrors-1].Account)
+	assert.Equal(t, ProviderAws, collectionErrors[0].Provider)
+	assert.False(t, collectionErrors[0].Time.IsZero())
+
+	ReportCollectionError(CollectionError{Table: "aws_test_other_errors", Account: "other"})
+	ReportCollectionError(CollectionError{Table: "aws_test_errors", Account: "last"})
+	lastErrors := GetLastCollectionErrors("aws_test_errors", 2)
+	assert.Equal(t, 2, len(lastErrors))
+	assert.Equal(t, strconv.Itoa(maxCollectionErrors+1), lastErrors[0].Account)
+	assert.Equal(t, "last", lastErrors[1].Account)
+	assert.Equal(t, maxCollectionErrors-1, len(GetLastCollectionErrors("aws_test_errors", maxCollectionErrors+10)))
+	assert.Equal(t, 0, len(GetLastCollectionErrors("aws_test_errors", 0)))
+}
+
+func TestCollectionStatus(t *testing.T) {
+	startTime := time.Now()
+	RecordCollectionSuccess("gcp_test_status", "status-project", startTime)
+	ReportCollectionError(CollectionError{Table: "gcp_test_status", Account: "status-project", Message: "forbidden"})
+	// The failure happened during the collection
+	RecordCollectionSuccess("gcp_test_status", "status-project", startTime)
+	statuses := GetCollectionStatuses(ProviderGcp, "status-project")
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, "forbidden", statuses[0].LastError)
+	assert.True(t, statuses[0].LastSuccess.Before(statuses[0].LastFailure))
+
+	RecordCollectionSuccess("gcp_test_status", "status-project", time.Now())
+	statuses = GetCollectionStatuses(ProviderGcp, "status-project")
+	assert.True(t, statuses[0].LastSuccess.After(statuses[0].LastFailure))
+	assert.Equal(t, 0, len(GetCollectionStatuses(ProviderAws, "status-project")))
+}
+
+func TestGetRetryPolicy(t *testing.T) {
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.Retry = ProviderRetryPolicy{
+		RetryPolicy: RetryPolicy{MaxAttempts: 5, RequestsPerSecond: 10},
+		Services:    map[string]RetryPolicy{"ec2": {MaxBackoffSeconds: 2}},
+	}
+	SetExtConfiguration(&extConfig)
+
+	assert.Equal(t, RetryPolicy{MaxAttempts: 5, MaxBackoffSeconds: 2, RequestsPerSecond: 10}, GetRetryPolicy(ProviderAws, "EC2"))
+	assert.Equal(t, RetryPolicy{MaxAttempts: 5, MaxBackoffSeconds: DefaultMaxBackoff.Seconds(), RequestsPerSecond: 10}, GetRetryPolicy(ProviderAws, "S3"))
+	assert.Equal(t, RetryPolicy{MaxAttempts: DefaultMaxAttempts, MaxBackoffSeconds: DefaultMaxBackoff.Seconds()}, GetRetryPolicy(ProviderGcp, "compute"))
+	for attempt := 1; attempt < 100; attempt++ {
+		assert.LessOrEqual(t, int64(GetRetryPolicy(ProviderAws, "ec2").GetBackoff(attempt)), int64(2*time.Second))
+	}
+
+	extConfig.ExtConfGcp.Retry.Services = map[string]RetryPolicy{"compute": {MaxAttempts: -1}}
+	assert.Error(t, extConfig.Validate())
+}
+
+func TestSendWithRetry(t *testing.T) {
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+	extConfig := *savedConfiguration
+	extConfig.ExtConfGcp.Retry = ProviderRetryPolicy{RetryPolicy: RetryPolicy{MaxAttempts: 3, RequestsPerSecond: 20}}
+	SetExtConfiguration(&extConfig)
+
+	var calls, unavailable int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&unavailable) == 1 {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "request", string(body))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, ProviderGcp, func(req *http.Request) string {
+		return "test"
+	}, func(req *http.Request) string {
+		return "retry-project"
+	})}
+	startTime := time.Now()
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("request"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	// The second attempt waits for the rate limit
+	assert.GreaterOrEqual(t, int64(time.Since(startTime)), int64(40*time.Millisecond))
+
+	atomic.StoreInt32(&unavailable, 1)
+	atomic.StoreInt32(&calls, 0)
+	extConfig.ExtConfGcp.Retry.MaxBackoffSeconds = 0.01
+	SetExtConfiguration(&extConfig)
+	resp, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRunEnrichments(t *testing.T) {
+	readErr := ReadTableConfig([]byte(tableConfigJSON))
+	assert.Nil(t, readErr)
+	readErr = ReadTableConfig([]byte(`{"aws_test_enrichment": {"aws": {}, "gcp": {}, "azure": {}, "parsedAttributes": [
+		{"sourceName": "Name", "targetName": "name", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Policy", "targetName": "policy", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "PolicyStatus_IsPublic", "targetName": "policy_status_is_public", "targetType": "TEXT", "enabled": true}
+	]}}`))
+	assert.Nil(t, readErr)
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+
+	run := func(ctx context.Context, tableName string, attributes ...[]string) []bool {
+		var mutex sync.Mutex
+		ran := make([]bool, len(attributes))
+		enrichments := make([]Enrichment, 0)
+		for idx := range attributes {
+			idx := idx
+			enrichments = append(enrichments, Enrichment{Attributes: attributes[idx], Enrich: func(ctx context.Context) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				ran[idx] = true
+			}})
+		}
+		RunEnrichments(ctx, tableName, enrichments)
+		return ran
+	}
+
+	// All of the enrichments run when the columns used are not known
+	assert.Equal(t, []bool{true, true}, run(context.Background(), "test_table_1", []string{"Item"}, []string{"ID"}))
+	assert.Equal(t, []bool{true, true}, run(WithColumnsUsed(context.Background(), nil), "test_table_1", []string{"Item"}, []string{"ID"}))
+	// Enrichments filling nested attributes of the columns used run
+	ctx := WithColumnsUsed(context.Background(), []string{"name"})
+	assert.Equal(t, []bool{true, false}, run(ctx, "test_table_1", []string{"Item"}, []string{"ID"}))
+	ctx = WithColumnsUsed(context.Background(), []string{"name", "policy_status_is_public"})
+	assert.Equal(t, []bool{false, true}, run(ctx, "aws_test_enrichment", []string{"Policy"}, []string{"PolicyStatus"}))
+
+	// Attributes of the row predicates of the filter rules are used
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.Filters = []FilterRule{
+		{Tables: []string{"aws_test_*"}, Rows: []FilterRowPredicate{{Attribute: "Policy", Values: []string{"*"}}}},
+		{Tables: []string{"aws_other_*"}, Rows: []FilterRowPredicate{{Tag: "env", Values: []string{"prod"}}}},
+	}
+	SetExtConfiguration(&extConfig)
+	ctx = WithColumnsUsed(context.Background(), []string{"name"})
+	assert.Equal(t, []bool{true, false, false}, run(ctx, "aws_test_enrichment", []string{"Policy"}, []string{"PolicyStatus"}, []string{"Tags"}))
+}
+
+func TestNewTableFlattenJSON(t *testing.T) {
+	readErr := ReadTableConfig([]byte(`{"test_table_flatten": {"aws": {}, "gcp": {}, "azure": {}, "maxLevel": 4, "parsedAttributes": [
+		{"sourceName": "Instances_Id", "targetName": "id", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Instances_Interfaces", "targetName": "interfaces", "targetType": "TEXT", "enabled": true, "flatten": "json"},
+		{"sourceName": "Instances_Interfaces_Name", "targetName": "interface_name", "targetType": "TEXT", "enabled": false},
+		{"sourceName": "Instances_Tags", "targetName": "tags", "targetType": "TEXT", "enabled": true}
+	]}}`))
+	assert.Nil(t, readErr)
+	tableConfig, _ := GetTableConfig("test_table_flatten")
+	jsonStr := []byte(`{"Instances": [
+		{"Id": "i-1", "Interfaces": [{"Name": "eth0"}, {"Name": "eth1"}], "Tags": [{"Key": "a"}, {"Key": "b"}]},
+		{"Id": "i-2", "Interfaces": [{"Name": "eth0"}], "Tags": []}
+	]}`)
+
+	// Interfaces are kept as JSON, only the tags of the first instance multiply its rows
+	tab := NewTable(context.Background(), jsonStr, tableConfig)
+	assert.Equal(t, 3, len(tab.Rows))
+	for _, row := range tab.Rows[:2] {
+		assert.Equal(t, "i-1", row["Instances_Id"])
+		assert.Equal(t, `[{"Name":"eth0"},{"Name":"eth1"}]`, row["Instances_Interfaces"])
+	}
+	assert.Equal(t, `[{"Name":"eth0"}]`, tab.Rows[2]["Instances_Interfaces"])
+
+	tableConfig.ParsedAttributes[1].Flatten = ""
+	tableConfig.initParsedAttributeConfigMap()
+	tab = NewTable(context.Background(), jsonStr, tableConfig)
+	assert.Equal(t, 5, len(tab.Rows))
+}
+
+func TestNewTableFlattenLimits(t *testing.T) {
+	readErr := ReadTableConfig([]byte(`{"test_table_limits": {"aws": {}, "gcp": {}, "azure": {}, "limits": {"maxRows": 3}, "parsedAttributes": [
+		{"sourceName": "Id", "targetName": "id", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Tags_Key", "targetName": "tag_key", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Ports", "targetName": "ports", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Network_Interface_Id", "targetName": "network_interface_id", "targetType": "TEXT", "enabled": true},
+		{"sourceName": "Network_Interface_Sub_Id", "targetName": "network_interface_sub_id", "targetType": "TEXT", "enabled": true}
+	]}}`))
+	assert.Nil(t, readErr)
+	tableConfig, _ := GetTableConfig("test_table_limits")
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+	jsonStr := []byte(`{"Id": "i-1", "Tags": [{"Key": "a"}, {"Key": "b"}], "Ports": [1, 2, 3], "Unused": [[1, 2], [3, 4]]}`)
+
+	// The cross product of the tags and the ports is truncated, unconfigured subtrees are not flattened
+	tab := NewTable(context.Background(), jsonStr, tableConfig)
+	assert.Equal(t, 3, len(tab.Rows))
+	assert.Equal(t, "i-1", tab.Rows[0]["Id"])
+	collectionErrors := GetCollectionErrors()
+	assert.Equal(t, flattenLimitErrorCode, collectionErrors[len(collectionErrors)-1].Code)
+
+	// Table limits override the global limits
+	extConfig := *savedConfiguration
+	extConfig.Limits = FlattenLimits{MaxRows: 10, MaxBytes: 40, OnOverflow: "FAIL"}
+	SetExtConfiguration(&extConfig)
+	assert.Equal(t, FlattenLimits{MaxRows: 3, MaxBytes: 40, OnOverflow: OverflowFail}, GetFlattenLimits("test_table_limits"))
+	assert.Equal(t, 0, len(NewTable(context.Background(), jsonStr, tableConfig).Rows))
+
+	extConfig.Limits = FlattenLimits{MaxNesting: 1}
+	SetExtConfiguration(&extConfig)
+	tab = NewTable(context.Background(), jsonStr, tableConfig)
+	assert.Equal(t, 3, len(tab.Rows))
+	assert.Nil(t, tab.Rows[0]["Tags_Key"])
+
+	// The depth of an attribute does not depend on the underscores of its keys
+	extConfig.Limits = FlattenLimits{MaxNesting: 2}
+	SetExtConfiguration(&extConfig)
+	tab = NewTable(context.Background(), []byte(`{"Network_Interface": {"Id": "eni-1", "Sub": {"Id": "sub-1"}}}`), tableConfig)
+	assert.Equal(t, 1, len(tab.Rows))
+	assert.Equal(t, "eni-1", tab.Rows[0]["Network_Interface_Id"])
+	assert.Nil(t, tab.Rows[0]["Network_Interface_Sub_Id"])
+
+	// Without limits, all of the rows are flattened
+	extConfig.Limits = FlattenLimits{}
+	SetExtConfiguration(&extConfig)
+	tableConfig.Limits = FlattenLimits{}
+	assert.Equal(t, 6, len(NewTable(context.Background(), jsonStr, tableConfig).Rows))
+
+	assert.NotNil(t, FlattenLimits{MaxRows: -1}.Validate())
+	assert.NotNil(t, FlattenLimits{OnOverflow: "drop"}.Validate())
+	assert.Nil(t, FlattenLimits{MaxBytes: 1024, OnOverflow: "truncate"}.Validate())
+}
+
+func TestFlattenGeneration(t *testing.T) {
+	readErr := ReadTableConfig([]byte(`{"test_table_generation": {"aws": {}, "gcp": {}, "azure": {}, "limits": {"maxRows": 3}, "parsedAttributes": [
+		{"sourceName": "Ports", "targetName": "ports", "targetType": "TEXT", "enabled": true}
+	]}}`))
+	assert.Nil(t, readErr)
+	tableConfig, _ := GetTableConfig("test_table_generation")
+	generate := func(ctx context.Context, objects ...string) []map[string]string {
+		rows := make([]map[string]string, 0)
+		for _, object := range objects {
+			for _, row := range NewTable(ctx, []byte(object), tableConfig).Rows {
+				rows = append(rows, RowToMap(make(map[string]string), row, tableConfig))
+			}
+		}
+		return rows
+	}
+
+	// The rows limit applies to the whole generation, not to each object
+	ctx, generation := WithFlattenGeneration(context.Background(), "test_table_generation")
+	rows, err := generation.ApplyLimits(generate(ctx, `{"Ports": [1, 2]}`, `{"Ports": [3, 4]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"ports": "1"}, {"ports": "2"}, {"ports": "3"}}, rows)
+
+	// The overflow of a generation does not fail the other generations
+	tableConfig.Limits.OnOverflow = OverflowFail
+	ctx, generation = WithFlattenGeneration(context.Background(), "test_table_generation")
+	other, otherGeneration := WithFlattenGeneration(context.Background(), "test_table_generation")
+	rows, err = generation.ApplyLimits(generate(ctx, `{"Ports": [1, 2, 3, 4]}`))
+	assert.NotNil(t, err)
+	assert.Nil(t, rows)
+	rows, err = otherGeneration.ApplyLimits(generate(other, `{"Ports": [1]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rows))
+}
+
+func TestGetAwsAccounts(t *testing.T) {
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+	defer SetDiscoveredAwsAccounts(nil)
+
+	extConfig := *savedConfiguration
+	extConfig.ExtConfAws.Accounts = []ExtensionConfigurationAwsAccount{{ID: "111", ProfileName: "configured"}}
+	SetExtConfiguration(&extConfig)
+	SetDiscoveredAwsAccounts([]ExtensionConfigurationAwsAccount{{ID: "111", RoleArn: "discovered"}, {ID: "222", RoleArn: "discovered"}})
+
+	// Configured accounts take precedence over the discovered ones
+	assert.Equal(t, []ExtensionConfigurationAwsAccount{
+		{ID: "111", ProfileName: "configured"},
+		{ID: "222", RoleArn: "discovered"},
+	}, GetAwsAccounts())
+
+	extConfig.ExtConfAws.Organization = &ExtensionConfigurationAwsOrganization{RefreshInterval: -1}
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAws.Organization = nil
+	extConfig.ExtConfAws.Accounts = []ExtensionConfigurationAwsAccount{{ID: "111", WebIdentityTokenFile: "/token"}}
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAws.Accounts = []ExtensionConfigurationAwsAccount{{ID: "111", RoleChain: []ExtensionConfigurationAwsRole{{RoleArn: "hub", SessionDuration: 60}}}}
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAws.Accounts = []ExtensionConfigurationAwsAccount{{ID: "111", WebIdentityTokenFile: "/token", WebIdentityRoleArn: "hub",
+		RoleChain: []ExtensionConfigurationAwsRole{{RoleArn: "hub", SessionDuration: 900}}, RoleArn: "spoke"}}
+	assert.Nil(t, extConfig.Validate())
+}
+
+func TestGetGcpAccounts(t *testing.T) {
+	savedConfiguration := GetExtConfiguration()
+	defer SetExtConfiguration(savedConfiguration)
+	defer SetDiscoveredGcpAccounts(nil)
+
+	extConfig := *savedConfiguration
+	extConfig.ExtConfGcp.Accounts = []ExtensionConfigurationGcpAccount{{ProjectID: "project-1", KeyFile: "/configured.json"}}
+	SetExtConfiguration(&extConfig)
+	SetDiscoveredGcpAccounts([]ExtensionConfigurationGcpAccount{{ProjectID: "project-1", KeyFile: "/discovered.json"}, {ProjectID: "project-2", KeyFile: "/discovered.json"}})
+
+	// Configured accounts take precedence over the discovered projects
+	assert.Equal(t, []ExtensionConfigurationGcpAccount{
+		{ProjectID: "project-1", KeyFile: "/configured.json"},
+		{ProjectID: "project-2", KeyFile: "/discovered.json"},
+	}, GetGcpAccounts())
+
+	extConfig.ExtConfGcp.Discovery = []ExtensionConfigurationGcpDiscovery{{Parent: "projects/1"}}
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfGcp.Discovery = []ExtensionConfigurationGcpDiscovery{{Parent: "folders/1"}}
+	assert.Nil(t, extConfig.Validate())
+}
+
+func TestValidateAzureCredentials(t *testing.T) {
+	extConfig := ExtensionConfiguration{}
+	extConfig.ExtConfAzure.Accounts = []ExtensionConfigurationAzureAccount{{SubscriptionID: "sub-1", TenantID: "tenant-1"}}
+	assert.Nil(t, extConfig.Validate())
+
+	extConfig.ExtConfAzure.Accounts[0].ClientSecret = "secret"
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAzure.Accounts[0].ClientID = "client-1"
+	assert.Nil(t, extConfig.Validate())
+
+	extConfig.ExtConfAzure.Accounts[0].AuthType = "password"
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAzure.Accounts[0].AuthType = AzureAuthClientCertificate
+	assert.NotNil(t, extConfig.Validate())
+	extConfig.ExtConfAzure.Accounts[0].CertificatePath = "/cert.pfx"
+	assert.Nil(t, extConfig.Validate())
+}